@@ -0,0 +1,303 @@
+package spannerplan
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan/stats"
+)
+
+// UnbatchedApplies returns the PlanNode indexes of Apply operators that are not
+// fed by a batch, in ascending index order. It returns nil when every Apply is
+// batched or the plan has no Apply operators.
+//
+// The check is a heuristic over the visible operator tree and assumes:
+//
+//   - An Apply operator is any operator whose display name ends with "Apply",
+//     such as Cross Apply, Outer Apply, or Distributed Cross Apply.
+//   - An Apply is batched when its Input subtree contains a Create Batch
+//     operator or a Batch Scan (scan_type BatchScan).
+//   - An Apply below the Map side of a batched Apply is batched too, because it
+//     runs once per batch rather than once per input row. One on its Input side
+//     is not, since it builds the batch.
+//
+// Apply operators failing these conditions usually evaluate their Map side row
+// by row, which often means one remote lookup per input row. The heuristic does
+// not look at execution statistics, so it can also flag cheap local applies.
+func (qp *QueryPlan) UnbatchedApplies() []int32 {
+	var result []int32
+	ancestors := make(map[int32]struct{})
+	var walk func(node *sppb.PlanNode, batched bool)
+	walk = func(node *sppb.PlanNode, batched bool) {
+		if _, ok := ancestors[node.GetIndex()]; ok {
+			return
+		}
+		ancestors[node.GetIndex()] = struct{}{}
+		defer delete(ancestors, node.GetIndex())
+
+		mapBatched := batched
+		if isApply(node) {
+			inputBatched := qp.applyInputBatched(node)
+			if !batched && !inputBatched && !slices.Contains(result, node.GetIndex()) {
+				result = append(result, node.GetIndex())
+			}
+			mapBatched = batched || inputBatched
+		}
+
+		for i, link := range node.GetChildLinks() {
+			if !qp.IsVisible(link) {
+				continue
+			}
+			// The Input side of an Apply runs before any batch exists, so only the Map side
+			// inherits the batch.
+			childBatched := mapBatched
+			if isApply(node) && qp.LinkTypeInParent(node, i) == "Input" {
+				childBatched = batched
+			}
+			walk(qp.GetNodeByChildLink(link), childBatched)
+		}
+	}
+	walk(qp.GetNodeByChildLink(nil), false)
+
+	slices.Sort(result)
+	return result
+}
+
+func isApply(node *sppb.PlanNode) bool {
+	return strings.HasSuffix(node.GetDisplayName(), "Apply")
+}
+
+func isBatchOperator(node *sppb.PlanNode) bool {
+	return node.GetDisplayName() == "Create Batch" ||
+		node.GetMetadata().GetFields()["scan_type"].GetStringValue() == "BatchScan"
+}
+
+// applyInputBatched reports whether the Input subtree of the Apply node contains a batch operator.
+func (qp *QueryPlan) applyInputBatched(node *sppb.PlanNode) bool {
+	for i, link := range node.GetChildLinks() {
+		if qp.LinkTypeInParent(node, i) != "Input" || !qp.IsVisible(link) {
+			continue
+		}
+		if qp.subtreeContains(qp.GetNodeByChildLink(link), isBatchOperator, make(map[int32]struct{})) {
+			return true
+		}
+	}
+	return false
+}
+
+func (qp *QueryPlan) subtreeContains(node *sppb.PlanNode, pred func(*sppb.PlanNode) bool, visited map[int32]struct{}) bool {
+	if _, ok := visited[node.GetIndex()]; ok {
+		return false
+	}
+	visited[node.GetIndex()] = struct{}{}
+
+	if pred(node) {
+		return true
+	}
+	for _, link := range qp.VisibleChildLinks(node) {
+		if qp.subtreeContains(qp.GetNodeByChildLink(link), pred, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// WallClock returns the elapsed time between the earliest execution start and the latest
+// execution end across all nodes. It returns an error when no node has both timestamps,
+// which is the case for PLAN-only output.
+//
+// Comparing it with [QueryPlan.SummedLatency] shows how much operator time overlapped:
+// summed latency well above wall clock indicates parallel execution.
+func (qp *QueryPlan) WallClock() (time.Duration, error) {
+	var start, end time.Time
+	for _, node := range qp.planNodes {
+		executionStats, err := stats.Extract(node, false)
+		if err != nil {
+			return 0, fmt.Errorf("failed to extract execution stats of node %d: %w", node.GetIndex(), err)
+		}
+		summary := executionStats.ExecutionSummary
+		if summary.ExecutionStartTimestamp == "" || summary.ExecutionEndTimestamp == "" {
+			continue
+		}
+		nodeStart, err := summary.StartTime()
+		if err != nil {
+			return 0, fmt.Errorf("node %d: %w", node.GetIndex(), err)
+		}
+		nodeEnd, err := summary.EndTime()
+		if err != nil {
+			return 0, fmt.Errorf("node %d: %w", node.GetIndex(), err)
+		}
+		if start.IsZero() || nodeStart.Before(start) {
+			start = nodeStart
+		}
+		if end.IsZero() || nodeEnd.After(end) {
+			end = nodeEnd
+		}
+	}
+	if start.IsZero() {
+		return 0, errors.New("no execution timestamps in plan")
+	}
+	return end.Sub(start), nil
+}
+
+// SummedLatency returns the sum of every node's latency statistic. Because a node's latency
+// usually includes time spent in its children, this is a measure of total operator time rather
+// than of elapsed time. It returns an error when no node has a latency statistic.
+func (qp *QueryPlan) SummedLatency() (time.Duration, error) {
+	var total time.Duration
+	var found bool
+	for _, node := range qp.planNodes {
+		latency, ok, err := nodeLatency(node)
+		if err != nil {
+			return 0, err
+		}
+		total += latency
+		found = found || ok
+	}
+	if !found {
+		return 0, errors.New("no latency statistics in plan")
+	}
+	return total, nil
+}
+
+// TotalScannedRows returns the sum of the scanned_rows statistic over every node, the rows read
+// by scans before any filtering. With [QueryPlan.TotalFilteredRows], it powers plan-wide summaries
+// and thresholds such as "the plan scans more than 10M rows". Nodes whose statistic is absent or
+// not a number are skipped; it reports false when no node has one, as in PLAN-only output.
+func (qp *QueryPlan) TotalScannedRows() (int64, bool) {
+	return qp.totalRows(func(s *stats.ExecutionStats) stats.ExecutionStatsValue { return s.ScannedRows })
+}
+
+// TotalFilteredRows returns the sum of the filtered_rows statistic over every node, the rows
+// discarded by filters, in the same way as [QueryPlan.TotalScannedRows].
+func (qp *QueryPlan) TotalFilteredRows() (int64, bool) {
+	return qp.totalRows(func(s *stats.ExecutionStats) stats.ExecutionStatsValue { return s.FilteredRows })
+}
+
+func (qp *QueryPlan) totalRows(value func(*stats.ExecutionStats) stats.ExecutionStatsValue) (int64, bool) {
+	var total int64
+	var found bool
+	for _, node := range qp.planNodes {
+		executionStats, err := stats.Extract(node, false)
+		if err != nil {
+			continue
+		}
+		rows, err := value(executionStats).Float64()
+		if err != nil {
+			continue
+		}
+		total += int64(math.Round(rows))
+		found = true
+	}
+	return total, found
+}
+
+// CriticalPath returns the PlanNode indexes of the root-to-leaf chain of visible operators
+// whose latencies add up to the largest total, ordered from the root. Ties go to the
+// earlier child link.
+//
+// Spanner reports an operator's latency including the time spent in its children, and the
+// children of one operator can run in parallel, so the summed latency of the path is not an
+// elapsed time. The path is instead the chain that most operator time flows through, which is
+// usually where optimization pays off first. Operators without a latency statistic count as
+// zero. It returns an error when no node has a latency statistic, which is the case for
+// PLAN-only output.
+func (qp *QueryPlan) CriticalPath() ([]int32, error) {
+	type pathResult struct {
+		total time.Duration
+		path  []int32
+	}
+	memo := make(map[int32]pathResult)
+	ancestors := make(map[int32]struct{})
+	var found bool
+	var walk func(node *sppb.PlanNode) (pathResult, error)
+	walk = func(node *sppb.PlanNode) (pathResult, error) {
+		if result, ok := memo[node.GetIndex()]; ok {
+			return result, nil
+		}
+		ancestors[node.GetIndex()] = struct{}{}
+		defer delete(ancestors, node.GetIndex())
+
+		var best pathResult
+		for _, link := range qp.VisibleChildLinks(node) {
+			child := qp.GetNodeByChildLink(link)
+			if _, ok := ancestors[child.GetIndex()]; ok {
+				continue
+			}
+			result, err := walk(child)
+			if err != nil {
+				return pathResult{}, err
+			}
+			if best.path == nil || result.total > best.total {
+				best = result
+			}
+		}
+
+		latency, ok, err := nodeLatency(node)
+		if err != nil {
+			return pathResult{}, err
+		}
+		found = found || ok
+		result := pathResult{total: latency + best.total, path: append([]int32{node.GetIndex()}, best.path...)}
+		memo[node.GetIndex()] = result
+		return result, nil
+	}
+
+	result, err := walk(qp.GetNodeByChildLink(nil))
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("no latency statistics in plan")
+	}
+	return result.path, nil
+}
+
+// nodeLatency returns the latency statistic of node, reporting false when the node has none.
+func nodeLatency(node *sppb.PlanNode) (time.Duration, bool, error) {
+	executionStats, err := stats.Extract(node, false)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to extract execution stats of node %d: %w", node.GetIndex(), err)
+	}
+	if executionStats.Latency.Total == "" {
+		return 0, false, nil
+	}
+	latency, err := executionStats.Latency.Duration()
+	if err != nil {
+		return 0, false, fmt.Errorf("node %d: %w", node.GetIndex(), err)
+	}
+	return latency, true, nil
+}
+
+// DMLInfo reports whether the plan is a DML plan, that is, whether its root operator is
+// Apply Mutations, and returns the operation_type metadata of the root, such as "INSERT",
+// "UPDATE" or "DELETE". The operation is "" when the root has no operation_type. It returns
+// false and "" for query plans.
+func (qp *QueryPlan) DMLInfo() (isDML bool, operation string) {
+	root := qp.GetNodeByChildLink(nil)
+	if root.GetDisplayName() != "Apply Mutations" {
+		return false, ""
+	}
+	return true, root.GetMetadata().GetFields()["operation_type"].GetStringValue()
+}
+
+// NodesUsingIndex returns the PlanNode indexes of the index scans, scan_type IndexScan, whose
+// scan_target is indexName, in ascending index order. It returns nil when the plan does not scan
+// the index. Names are compared case-insensitively, like identifiers in Spanner, so "singersbyname"
+// matches a scan of SingersByName. Other operators naming the index, such as a Distributed Union
+// whose distribution_table is the index, are not scans and are not returned.
+func (qp *QueryPlan) NodesUsingIndex(indexName string) []int32 {
+	var result []int32
+	for _, target := range qp.ScanTargets() {
+		if target.Kind == ScanIndex && strings.EqualFold(target.Name, indexName) {
+			result = append(result, target.NodeID)
+		}
+	}
+	return result
+}
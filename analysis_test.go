@@ -0,0 +1,370 @@
+package spannerplan
+
+import (
+	_ "embed"
+	"slices"
+	"testing"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+//go:embed plantree/reference/testdata/dca.yaml
+var dcaYAML []byte
+
+func relational(index int32, displayName string, children ...int32) *sppb.PlanNode {
+	node := &sppb.PlanNode{Index: index, Kind: sppb.PlanNode_RELATIONAL, DisplayName: displayName}
+	for _, child := range children {
+		node.ChildLinks = append(node.ChildLinks, &sppb.PlanNode_ChildLink{ChildIndex: child})
+	}
+	return node
+}
+
+func withScanType(node *sppb.PlanNode, scanType string) *sppb.PlanNode {
+	node.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{
+		"scan_type": structpb.NewStringValue(scanType),
+	}}
+	return node
+}
+
+func TestUnbatchedApplies(t *testing.T) {
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		planNodes []*sppb.PlanNode
+		want      []int32
+	}{
+		{
+			name:      "distributed cross apply fixture is batched",
+			planNodes: dca.GetQueryPlan().GetPlanNodes(),
+		},
+		{
+			name: "cross apply over table scan",
+			planNodes: []*sppb.PlanNode{
+				relational(0, "Cross Apply", 1, 2),
+				withScanType(relational(1, "Scan"), "TableScan"),
+				withScanType(relational(2, "Scan"), "IndexScan"),
+			},
+			want: []int32{0},
+		},
+		{
+			name: "cross apply over batch scan",
+			planNodes: []*sppb.PlanNode{
+				relational(0, "Cross Apply", 1, 2),
+				withScanType(relational(1, "Scan"), "BatchScan"),
+				withScanType(relational(2, "Scan"), "TableScan"),
+			},
+		},
+		{
+			name: "outer apply below map side of batched apply",
+			planNodes: []*sppb.PlanNode{
+				relational(0, "Distributed Cross Apply", 1, 2),
+				relational(1, "Create Batch", 3),
+				relational(2, "Outer Apply", 4, 5),
+				withScanType(relational(3, "Scan"), "TableScan"),
+				withScanType(relational(4, "Scan"), "TableScan"),
+				withScanType(relational(5, "Scan"), "TableScan"),
+			},
+		},
+		{
+			name: "unbatched apply on the input side of batched apply",
+			planNodes: []*sppb.PlanNode{
+				relational(0, "Distributed Cross Apply", 1, 2),
+				relational(1, "Create Batch", 3),
+				withScanType(relational(2, "Scan"), "BatchScan"),
+				relational(3, "Cross Apply", 4, 5),
+				withScanType(relational(4, "Scan"), "TableScan"),
+				withScanType(relational(5, "Scan"), "IndexScan"),
+			},
+			want: []int32{3},
+		},
+		{
+			name: "nested unbatched applies are sorted",
+			planNodes: []*sppb.PlanNode{
+				relational(0, "Serialize Result", 2),
+				withScanType(relational(1, "Scan"), "TableScan"),
+				relational(2, "Cross Apply", 3, 4),
+				withScanType(relational(3, "Scan"), "TableScan"),
+				relational(4, "Outer Apply", 1, 5),
+				withScanType(relational(5, "Scan"), "TableScan"),
+			},
+			want: []int32{2, 4},
+		},
+		{
+			name: "no apply",
+			planNodes: []*sppb.PlanNode{
+				relational(0, "Serialize Result", 1),
+				withScanType(relational(1, "Scan"), "TableScan"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.planNodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := qp.UnbatchedApplies(); !slices.Equal(got, tt.want) {
+				t.Fatalf("UnbatchedApplies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+//go:embed cmd/rendertree/impl/testdata/distributed_cross_apply_profile.yaml
+var dcaProfileYAML []byte
+
+func TestWallClockAndSummedLatency(t *testing.T) {
+	profile, _, err := ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	tests := []struct {
+		name          string
+		planNodes     []*sppb.PlanNode
+		wantWallClock time.Duration
+		wantSummed    time.Duration
+		wantErr       bool
+	}{
+		{
+			name:          "profile",
+			planNodes:     profile.GetQueryPlan().GetPlanNodes(),
+			wantWallClock: 1956 * time.Microsecond,
+			wantSummed:    10090 * time.Microsecond,
+		},
+		{
+			name:      "plan without stats",
+			planNodes: []*sppb.PlanNode{relational(0, "Serialize Result", 1), relational(1, "Scan")},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.planNodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			wallClock, err := qp.WallClock()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("WallClock() error = nil, want non-nil")
+				}
+			} else if err != nil {
+				t.Fatalf("WallClock() error = %v", err)
+			} else if wallClock != tt.wantWallClock {
+				t.Fatalf("WallClock() = %v, want %v", wallClock, tt.wantWallClock)
+			}
+
+			summed, err := qp.SummedLatency()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("SummedLatency() error = nil, want non-nil")
+				}
+			} else if err != nil {
+				t.Fatalf("SummedLatency() error = %v", err)
+			} else if summed != tt.wantSummed {
+				t.Fatalf("SummedLatency() = %v, want %v", summed, tt.wantSummed)
+			}
+		})
+	}
+}
+
+func TestTotalRows(t *testing.T) {
+	profile, _, err := ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	tests := []struct {
+		name         string
+		planNodes    []*sppb.PlanNode
+		wantScanned  int64
+		wantFiltered int64
+		wantOK       bool
+	}{
+		{
+			name:         "profile",
+			planNodes:    profile.GetQueryPlan().GetPlanNodes(),
+			wantScanned:  70,
+			wantFiltered: 30,
+			wantOK:       true,
+		},
+		{
+			name:      "plan without stats",
+			planNodes: []*sppb.PlanNode{relational(0, "Serialize Result", 1), relational(1, "Scan")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.planNodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if scanned, ok := qp.TotalScannedRows(); scanned != tt.wantScanned || ok != tt.wantOK {
+				t.Errorf("TotalScannedRows() = (%d, %v), want (%d, %v)", scanned, ok, tt.wantScanned, tt.wantOK)
+			}
+			if filtered, ok := qp.TotalFilteredRows(); filtered != tt.wantFiltered || ok != tt.wantOK {
+				t.Errorf("TotalFilteredRows() = (%d, %v), want (%d, %v)", filtered, ok, tt.wantFiltered, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	profile, _, err := ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	withLatency := func(node *sppb.PlanNode, total string) *sppb.PlanNode {
+		node.ExecutionStats = &structpb.Struct{Fields: map[string]*structpb.Value{
+			"latency": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"total": structpb.NewStringValue(total),
+				"unit":  structpb.NewStringValue("msecs"),
+			}}),
+		}}
+		return node
+	}
+	tests := []struct {
+		name      string
+		planNodes []*sppb.PlanNode
+		want      []int32
+		wantErr   bool
+	}{
+		{
+			name:      "profile",
+			planNodes: profile.GetQueryPlan().GetPlanNodes(),
+			want:      []int32{0, 1, 11, 12, 16, 17, 18},
+		},
+		{
+			name: "sum along the path beats the largest child",
+			planNodes: []*sppb.PlanNode{
+				withLatency(relational(0, "Hash Join", 1, 3), "10"),
+				withLatency(relational(1, "Filter", 2), "4"),
+				withLatency(relational(2, "Scan"), "4"),
+				withLatency(relational(3, "Scan"), "5"),
+			},
+			want: []int32{0, 1, 2},
+		},
+		{
+			name: "tie goes to the earlier child",
+			planNodes: []*sppb.PlanNode{
+				withLatency(relational(0, "Union All", 1, 2), "3"),
+				withLatency(relational(1, "Scan"), "1"),
+				withLatency(relational(2, "Scan"), "1"),
+			},
+			want: []int32{0, 1},
+		},
+		{
+			name:      "plan without stats",
+			planNodes: []*sppb.PlanNode{relational(0, "Serialize Result", 1), relational(1, "Scan")},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.planNodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			got, err := qp.CriticalPath()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CriticalPath() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CriticalPath() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("CriticalPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+//go:embed cmd/rendertree/impl/testdata/delete.yaml
+var deleteYAML []byte
+
+func TestDMLInfo(t *testing.T) {
+	deletePlan, _, err := ExtractQueryPlan(deleteYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	applyMutations := func(operation string) []*sppb.PlanNode {
+		root := relational(0, "Apply Mutations", 1)
+		if operation != "" {
+			root.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{
+				"operation_type": structpb.NewStringValue(operation),
+				"table":          structpb.NewStringValue("Singers"),
+			}}
+		}
+		return []*sppb.PlanNode{root, relational(1, "Scan")}
+	}
+	tests := []struct {
+		name          string
+		planNodes     []*sppb.PlanNode
+		wantDML       bool
+		wantOperation string
+	}{
+		{name: "delete fixture", planNodes: deletePlan.GetQueryPlan().GetPlanNodes(), wantDML: true, wantOperation: "DELETE"},
+		{name: "insert", planNodes: applyMutations("INSERT"), wantDML: true, wantOperation: "INSERT"},
+		{name: "update", planNodes: applyMutations("UPDATE"), wantDML: true, wantOperation: "UPDATE"},
+		{name: "delete", planNodes: applyMutations("DELETE"), wantDML: true, wantOperation: "DELETE"},
+		{name: "missing operation_type", planNodes: applyMutations(""), wantDML: true},
+		{name: "query", planNodes: dca.GetQueryPlan().GetPlanNodes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.planNodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			isDML, operation := qp.DMLInfo()
+			if isDML != tt.wantDML || operation != tt.wantOperation {
+				t.Fatalf("DMLInfo() = (%v, %q), want (%v, %q)", isDML, operation, tt.wantDML, tt.wantOperation)
+			}
+		})
+	}
+}
+
+func TestNodesUsingIndex(t *testing.T) {
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(dca.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		indexName string
+		want      []int32
+	}{
+		{indexName: "AlbumsByAlbumTitle", want: []int32{6}},
+		{indexName: "albumsbyalbumtitle", want: []int32{6}},
+		{indexName: "Albums"},
+		{indexName: "SongsBySongGenre"},
+		{indexName: ""},
+	}
+	for _, tt := range tests {
+		if got := qp.NodesUsingIndex(tt.indexName); !slices.Equal(got, tt.want) {
+			t.Errorf("NodesUsingIndex(%q) = %v, want %v", tt.indexName, got, tt.want)
+		}
+	}
+}
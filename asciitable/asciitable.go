@@ -28,6 +28,31 @@ const (
 	AlignCenter Alignment = "center"
 )
 
+// Border selects the table grid characters used by [RenderTable]. The zero value means [BorderASCII].
+type Border string
+
+const (
+	// BorderASCII draws the grid with "+", "-", and "|". It is also the default when [TableSpec.Border] is empty.
+	BorderASCII Border = "ascii"
+	// BorderLight draws the grid with light Unicode box-drawing characters.
+	BorderLight Border = "light"
+	// BorderRounded is [BorderLight] with rounded corners.
+	BorderRounded Border = "rounded"
+	// BorderHeavy draws the grid with heavy Unicode box-drawing characters.
+	BorderHeavy Border = "heavy"
+	// BorderDouble draws the grid with double Unicode box-drawing characters.
+	BorderDouble Border = "double"
+)
+
+// ParseBorder parses a border name. Valid values are "ascii", "light", "rounded", "heavy", and "double".
+func ParseBorder(s string) (Border, error) {
+	border := Border(strings.ToLower(s))
+	if _, err := mapBorder(border); err != nil {
+		return "", err
+	}
+	return border, nil
+}
+
 // CellFunc returns one table cell for row at index.
 type CellFunc[T any] func(row T, index int) string
 
@@ -39,12 +64,33 @@ type Column[T any] struct {
 	Alignment Alignment
 	// Cell returns the rendered cell text for each row.
 	Cell CellFunc[T]
+	// Width fixes the column to this many display columns in [RenderTable], so output stays
+	// byte-stable when content widths change. Longer header and cell lines are truncated with
+	// "…"; shorter ones are padded. Zero sizes the column to its content.
+	// [RenderTableless] ignores Width.
+	Width int
+	// Style optionally returns ANSI SGR parameters for the cell of row at index, such as a color
+	// that depends on the cell value. They follow the RowStyle parameters, so a cell color
+	// overrides the row color, and are applied after widths are measured like RowStyle. An empty
+	// result leaves the cell with the row style.
+	Style CellFunc[T]
 }
 
 // TableSpec defines the columns of an ASCII table.
 type TableSpec[T any] struct {
 	// Columns is the ordered list of table columns.
 	Columns []Column[T]
+	// Border selects the grid characters. [RenderTableless] ignores Border.
+	Border Border
+	// RowStyle optionally returns ANSI SGR parameters such as "1;36" for row at index.
+	// Every non-empty cell line of the row is wrapped in the escape sequence and a reset,
+	// after widths are measured, so styling never changes the layout. An empty result
+	// leaves the row unstyled.
+	RowStyle func(row T, index int) string
+	// Footer optionally holds one cell per column for a row below the others, such as totals.
+	// [RenderTable] draws it after a separator line, aligned like the rows, and
+	// [RenderTableless] writes it as a last unstyled row. Nil renders no footer.
+	Footer []string
 }
 
 // AppendixSpec defines how appendices read row IDs and item lines.
@@ -74,30 +120,58 @@ func RenderTable[T any](rows []T, spec TableSpec[T]) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	borderStyle, err := mapBorder(spec.Border)
+	if err != nil {
+		return "", err
+	}
 
 	var sb strings.Builder
 	table := tablewriter.NewTable(&sb,
 		tablewriter.WithRenderer(
-			renderer.NewBlueprint(tw.Rendition{Symbols: tw.NewSymbols(tw.StyleASCII)}),
+			renderer.NewBlueprint(tw.Rendition{Symbols: tw.NewSymbols(borderStyle)}),
 		),
 		tablewriter.WithTrimSpace(tw.Off),
 		tablewriter.WithHeaderAutoFormat(tw.Off),
 		tablewriter.WithHeaderAlignment(tw.AlignLeft),
 		tablewriter.WithRowAlignmentConfig(tw.CellAlignment{PerColumn: alignments}),
 		tablewriter.WithRowAutoWrap(tw.WrapNone),
+		tablewriter.WithFooterAutoFormat(tw.Off),
+		tablewriter.WithFooterAlignmentConfig(tw.CellAlignment{PerColumn: alignments}),
+		tablewriter.WithFooterAutoWrap(tw.WrapNone),
 	)
+	for j, col := range spec.Columns {
+		if col.Width > 0 {
+			headers[j] = fitCell(headers[j], col.Width, tw.AlignLeft)
+		}
+	}
 	table.Header(headers)
 
 	for i, row := range rows {
 		rowData := make([]string, len(spec.Columns))
+		style := rowStyle(spec, row, i)
 		for j, col := range spec.Columns {
 			rowData[j] = col.Cell(row, i)
+			if col.Width > 0 {
+				rowData[j] = fitCell(rowData[j], col.Width, alignments[j])
+			}
+			rowData[j] = styleCell(rowData[j], cellStyle(style, col, row, i))
 		}
 		if err := table.Append(rowData); err != nil {
 			return "", fmt.Errorf("failed to append row at index %d: %w", i, err)
 		}
 	}
 
+	if spec.Footer != nil {
+		footer := make([]string, len(spec.Footer))
+		for j, col := range spec.Columns {
+			footer[j] = spec.Footer[j]
+			if col.Width > 0 {
+				footer[j] = fitCell(footer[j], col.Width, alignments[j])
+			}
+		}
+		table.Footer(footer)
+	}
+
 	if err := table.Render(); err != nil {
 		return "", fmt.Errorf("failed to render table: %w", err)
 	}
@@ -123,7 +197,14 @@ func RenderTableless[T any](rows []T, spec TableSpec[T]) (string, error) {
 	resolvedRows, columnWidths := resolveTablelessRows(tableRows, alignments)
 
 	var sb strings.Builder
-	for _, row := range resolvedRows {
+	for rowIndex, row := range resolvedRows {
+		styles := make([]string, len(spec.Columns))
+		if rowIndex < len(rows) {
+			style := rowStyle(spec, rows[rowIndex], rowIndex)
+			for j, col := range spec.Columns {
+				styles[j] = cellStyle(style, col, rows[rowIndex], rowIndex)
+			}
+		}
 		for lineIndex, lastNonEmptyColumn := range row.lastNonEmptyColumns {
 			if lastNonEmptyColumn < 0 {
 				sb.WriteByte('\n')
@@ -137,7 +218,7 @@ func RenderTableless[T any](rows []T, spec TableSpec[T]) (string, error) {
 				if lineIndex < len(row.cells[columnIndex]) {
 					cell = row.cells[columnIndex][lineIndex]
 				}
-				sb.WriteString(alignTablelessCell(cell, columnWidths[columnIndex], alignments[columnIndex]))
+				sb.WriteString(styleCell(alignTablelessCell(cell, columnWidths[columnIndex], alignments[columnIndex]), styles[columnIndex]))
 			}
 			sb.WriteByte('\n')
 		}
@@ -185,6 +266,9 @@ func collectTableRows[T any](rows []T, spec TableSpec[T]) ([][]string, []string,
 		}
 		tableRows = append(tableRows, rowData)
 	}
+	if spec.Footer != nil {
+		tableRows = append(tableRows, spec.Footer)
+	}
 	return tableRows, headers, alignments, nil
 }
 
@@ -199,6 +283,9 @@ func validateTableSpec[T any](spec TableSpec[T]) ([]string, []tw.Align, error) {
 		if col.Cell == nil {
 			return nil, nil, fmt.Errorf("table spec column %d (%q) has nil Cell", i, col.Header)
 		}
+		if col.Width < 0 {
+			return nil, nil, fmt.Errorf("table spec column %d (%q) has negative Width %d", i, col.Header, col.Width)
+		}
 		alignment, err := mapAlignment(col.Alignment)
 		if err != nil {
 			return nil, nil, fmt.Errorf("table spec column %d (%q): %w", i, col.Header, err)
@@ -206,6 +293,9 @@ func validateTableSpec[T any](spec TableSpec[T]) ([]string, []tw.Align, error) {
 		headers = append(headers, col.Header)
 		alignments = append(alignments, alignment)
 	}
+	if spec.Footer != nil && len(spec.Footer) != len(spec.Columns) {
+		return nil, nil, fmt.Errorf("table spec footer has %d cells, want %d", len(spec.Footer), len(spec.Columns))
+	}
 
 	return headers, alignments, nil
 }
@@ -249,6 +339,62 @@ func alignTablelessCell(s string, width int, alignment tw.Align) string {
 	return s
 }
 
+func rowStyle[T any](spec TableSpec[T], row T, index int) string {
+	if spec.RowStyle == nil {
+		return ""
+	}
+	return spec.RowStyle(row, index)
+}
+
+// cellStyle returns the SGR parameters of style, the RowStyle of row, followed by the Style
+// of col.
+func cellStyle[T any](style string, col Column[T], row T, index int) string {
+	if col.Style == nil {
+		return style
+	}
+	if s := col.Style(row, index); s != "" {
+		if style == "" {
+			return s
+		}
+		return style + ";" + s
+	}
+	return style
+}
+
+// styleCell wraps every non-empty line of s in the SGR sequence for style.
+func styleCell(s, style string) string {
+	if style == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "\x1b[" + style + "m" + line + "\x1b[0m"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fitCell truncates or pads every line of s to exactly width display columns.
+func fitCell(s string, width int, alignment tw.Align) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if tabwrap.StringWidth(line) > width {
+			line = tabwrap.Truncate(line, width, "…")
+		}
+		switch alignment {
+		case tw.AlignRight:
+			line = tabwrap.FillLeft(line, width)
+		case tw.AlignCenter:
+			line = tabwrap.FillRight(tabwrap.FillLeft(line, (width+tabwrap.StringWidth(line))/2), width)
+		default:
+			line = tabwrap.FillRight(line, width)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
 func leftPadDisplay(s string, width int) string {
 	currentWidth := tabwrap.StringWidth(s)
 	if currentWidth >= width {
@@ -286,6 +432,23 @@ func collectAppendixRows[T any](rows []T, spec AppendixSpec[T]) (appendixRows, e
 	return resolved, nil
 }
 
+func mapBorder(border Border) (tw.BorderStyle, error) {
+	switch border {
+	case "", BorderASCII:
+		return tw.StyleASCII, nil
+	case BorderLight:
+		return tw.StyleLight, nil
+	case BorderRounded:
+		return tw.StyleRounded, nil
+	case BorderHeavy:
+		return tw.StyleHeavy, nil
+	case BorderDouble:
+		return tw.StyleDouble, nil
+	default:
+		return tw.StyleASCII, fmt.Errorf("unknown border %q, expect ascii, light, rounded, heavy, or double", border)
+	}
+}
+
 func mapAlignment(alignment Alignment) (tw.Align, error) {
 	switch alignment {
 	case "", AlignLeft:
@@ -55,6 +55,162 @@ func TestRenderTable(t *testing.T) {
 	}
 }
 
+func TestRenderTable_FixedWidth(t *testing.T) {
+	rows := []testRow{
+		{id: 1, idText: "1", text: "Root", rows: "10"},
+		{id: 2, idText: "2", text: "+- Child with a long name", rows: "12345"},
+	}
+	operator := operatorColumn()
+	operator.Width = 12
+	spec := asciitable.TableSpec[testRow]{
+		Columns: []asciitable.Column[testRow]{
+			idColumn(),
+			operator,
+			{
+				Header:    "Rows",
+				Alignment: asciitable.AlignRight,
+				Cell: func(row testRow, _ int) string {
+					return row.rows
+				},
+				Width: 6,
+			},
+		},
+	}
+
+	got, err := asciitable.RenderTable(rows, spec)
+	if err != nil {
+		t.Fatalf("RenderTable() error = %v", err)
+	}
+	want := heredoc.Doc(`
+		+----+--------------+--------+
+		| ID | Operator     | Rows   |
+		+----+--------------+--------+
+		|  1 | Root         |     10 |
+		|  2 | +- Child wi… |  12345 |
+		+----+--------------+--------+
+	`)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("RenderTable() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderTable_Border(t *testing.T) {
+	rows := []testRow{{id: 1, idText: "1", text: "Root"}}
+	spec := asciitable.TableSpec[testRow]{
+		Columns: []asciitable.Column[testRow]{idColumn(), operatorColumn()},
+		Border:  asciitable.BorderRounded,
+	}
+
+	got, err := asciitable.RenderTable(rows, spec)
+	if err != nil {
+		t.Fatalf("RenderTable() error = %v", err)
+	}
+	want := heredoc.Doc(`
+		╭────┬──────────╮
+		│ ID │ Operator │
+		├────┼──────────┤
+		│  1 │ Root     │
+		╰────┴──────────╯
+	`)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("RenderTable() mismatch (-want +got):\n%s", diff)
+	}
+
+	spec.Border = "dotted"
+	if _, err := asciitable.RenderTable(rows, spec); err == nil {
+		t.Fatal("RenderTable() unknown border error = nil, want non-nil")
+	}
+	if _, err := asciitable.ParseBorder("dotted"); err == nil {
+		t.Fatal("ParseBorder(dotted) error = nil, want non-nil")
+	}
+	if got, err := asciitable.ParseBorder("Heavy"); err != nil || got != asciitable.BorderHeavy {
+		t.Fatalf("ParseBorder(Heavy) = (%q, %v), want (%q, nil)", got, err, asciitable.BorderHeavy)
+	}
+}
+
+func TestRenderTable_RowStyle(t *testing.T) {
+	rows := []testRow{
+		{id: 1, idText: "1", text: "Root"},
+		{id: 10, idText: "10", text: "Scan"},
+	}
+	spec := asciitable.TableSpec[testRow]{
+		Columns: []asciitable.Column[testRow]{idColumn(), operatorColumn()},
+		RowStyle: func(row testRow, _ int) string {
+			if row.text == "Scan" {
+				return "36"
+			}
+			return ""
+		},
+	}
+
+	got, err := asciitable.RenderTable(rows, spec)
+	if err != nil {
+		t.Fatalf("RenderTable() error = %v", err)
+	}
+	want := "+----+----------+\n" +
+		"| ID | Operator |\n" +
+		"+----+----------+\n" +
+		"|  1 | Root     |\n" +
+		"| \x1b[36m10\x1b[0m | \x1b[36mScan\x1b[0m     |\n" +
+		"+----+----------+\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("RenderTable() mismatch (-want +got):\n%s", diff)
+	}
+
+	gotTableless, err := asciitable.RenderTableless(rows, spec)
+	if err != nil {
+		t.Fatalf("RenderTableless() error = %v", err)
+	}
+	wantTableless := " 1|Root\n\x1b[36m10\x1b[0m|\x1b[36mScan\x1b[0m\n"
+	if diff := cmp.Diff(wantTableless, gotTableless); diff != "" {
+		t.Fatalf("RenderTableless() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderTable_CellStyle(t *testing.T) {
+	rows := []testRow{
+		{id: 1, idText: "1", text: "Root"},
+		{id: 10, idText: "10", text: "Scan"},
+	}
+	operator := operatorColumn()
+	operator.Style = func(row testRow, _ int) string {
+		if row.id == 1 {
+			return "1"
+		}
+		return ""
+	}
+	spec := asciitable.TableSpec[testRow]{
+		Columns: []asciitable.Column[testRow]{idColumn(), operator},
+		RowStyle: func(testRow, int) string {
+			return "36"
+		},
+	}
+
+	got, err := asciitable.RenderTable(rows, spec)
+	if err != nil {
+		t.Fatalf("RenderTable() error = %v", err)
+	}
+	want := "+----+----------+\n" +
+		"| ID | Operator |\n" +
+		"+----+----------+\n" +
+		"|  \x1b[36m1\x1b[0m | \x1b[36;1mRoot\x1b[0m     |\n" +
+		"| \x1b[36m10\x1b[0m | \x1b[36mScan\x1b[0m     |\n" +
+		"+----+----------+\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("RenderTable() mismatch (-want +got):\n%s", diff)
+	}
+
+	spec.RowStyle = nil
+	gotTableless, err := asciitable.RenderTableless(rows, spec)
+	if err != nil {
+		t.Fatalf("RenderTableless() error = %v", err)
+	}
+	wantTableless := " 1|\x1b[1mRoot\x1b[0m\n10|Scan\n"
+	if diff := cmp.Diff(wantTableless, gotTableless); diff != "" {
+		t.Fatalf("RenderTableless() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestRenderTable_RowIndex(t *testing.T) {
 	rows := []testRow{
 		{id: 1, idText: "1", text: "Root"},
@@ -90,6 +246,45 @@ func TestRenderTable_RowIndex(t *testing.T) {
 	}
 }
 
+func TestRenderTable_Footer(t *testing.T) {
+	rows := []testRow{
+		{id: 1, idText: "1", text: "Root", rows: "10"},
+		{id: 2, idText: "2", text: "+- Child", rows: "3"},
+	}
+	spec := asciitable.TableSpec[testRow]{
+		Columns: []asciitable.Column[testRow]{
+			idColumn(),
+			operatorColumn(),
+			{
+				Header:    "Rows",
+				Alignment: asciitable.AlignRight,
+				Cell: func(row testRow, _ int) string {
+					return row.rows
+				},
+			},
+		},
+		Footer: []string{"", "total rows", "13"},
+	}
+
+	got, err := asciitable.RenderTable(rows, spec)
+	if err != nil {
+		t.Fatalf("RenderTable() error = %v", err)
+	}
+	want := heredoc.Doc(`
+		+----+------------+------+
+		| ID | Operator   | Rows |
+		+----+------------+------+
+		|  1 | Root       |   10 |
+		|  2 | +- Child   |    3 |
+		+----+------------+------+
+		|    | total rows |   13 |
+		+----+------------+------+
+	`)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("RenderTable() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestRenderTableless(t *testing.T) {
 	rows := []testRow{
 		{id: 1, idText: "1", text: "Root", rows: "10"},
@@ -122,6 +317,39 @@ func TestRenderTableless(t *testing.T) {
 	}
 }
 
+func TestRenderTableless_Footer(t *testing.T) {
+	rows := []testRow{
+		{id: 1, idText: "1", text: "Root", rows: "10"},
+		{id: 12, idText: "*12", text: "+- Child", rows: "3"},
+	}
+	spec := asciitable.TableSpec[testRow]{
+		Columns: []asciitable.Column[testRow]{
+			idColumn(),
+			operatorColumn(),
+			{
+				Header:    "Rows",
+				Alignment: asciitable.AlignRight,
+				Cell: func(row testRow, _ int) string {
+					return row.rows
+				},
+			},
+		},
+		RowStyle: func(testRow, int) string { return "1" },
+		Footer:   []string{"", "Total", "13"},
+	}
+
+	got, err := asciitable.RenderTableless(rows, spec)
+	if err != nil {
+		t.Fatalf("RenderTableless() error = %v", err)
+	}
+	want := "\x1b[1m  1\x1b[0m|\x1b[1mRoot\x1b[0m|\x1b[1m10\x1b[0m\n" +
+		"\x1b[1m*12\x1b[0m|\x1b[1m+- Child\x1b[0m|\x1b[1m 3\x1b[0m\n" +
+		"   |Total|13\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("RenderTableless() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestRenderTableless_PreservesMultilineCells(t *testing.T) {
 	rows := []testRow{
 		{id: 1, idText: "1", text: "Root\n+- Child"},
@@ -256,6 +484,21 @@ func TestRenderTable_InvalidSpec(t *testing.T) {
 	if err == nil {
 		t.Fatal("RenderTable() nil Cell error = nil, want non-nil")
 	}
+
+	_, err = asciitable.RenderTable(nil, asciitable.TableSpec[testRow]{
+		Columns: []asciitable.Column[testRow]{{Header: "bad", Cell: func(testRow, int) string { return "" }, Width: -1}},
+	})
+	if err == nil {
+		t.Fatal("RenderTable() negative Width error = nil, want non-nil")
+	}
+
+	_, err = asciitable.RenderTable(nil, asciitable.TableSpec[testRow]{
+		Columns: []asciitable.Column[testRow]{{Header: "bad", Cell: func(testRow, int) string { return "" }}},
+		Footer:  []string{"a", "b"},
+	})
+	if err == nil {
+		t.Fatal("RenderTable() footer length error = nil, want non-nil")
+	}
 }
 
 func TestRenderTableless_InvalidSpec(t *testing.T) {
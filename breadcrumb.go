@@ -0,0 +1,71 @@
+package spannerplan
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// breadcrumbSeparator joins the steps of [QueryPlan.Breadcrumb].
+const breadcrumbSeparator = " › "
+
+// Breadcrumb returns the path from the root to the node at index on one line, such as
+// "Distributed Union › Cross Apply › [Map] Local Distributed Union › Filter Scan". Each step is
+// the [NodeTitle] of a node, formatted with opts and prefixed by the type of the link from its
+// parent when the link has one. The path ends at the node itself, so the breadcrumb of the root
+// is its own title.
+//
+// A node with several parents is reached through the first link to it in plan node order (see
+// [QueryPlan.ParentLinks]). For a node unreachable from the root, the path starts at its
+// topmost ancestor. Breadcrumb returns an error when index is out of range or the ancestors
+// of the node form a cycle.
+func (qp *QueryPlan) Breadcrumb(index int32, opts ...Option) (string, error) {
+	links, err := qp.ancestors(index)
+	if err != nil {
+		return "", err
+	}
+
+	node := qp.GetNodeByIndex(index)
+	steps := make([]string, 0, len(links)+1)
+	if len(links) > 0 {
+		steps = append(steps, NodeTitle(links[0].Parent, opts...))
+	} else {
+		steps = append(steps, NodeTitle(node, opts...))
+	}
+	for _, link := range links {
+		child := qp.GetNodeByChildLink(link.ChildLink)
+		linkType := qp.LinkTypeInParent(link.Parent, slices.Index(link.Parent.GetChildLinks(), link.ChildLink))
+		if linkType != "" {
+			steps = append(steps, fmt.Sprintf("[%s] %s", linkType, NodeTitle(child, opts...)))
+		} else {
+			steps = append(steps, NodeTitle(child, opts...))
+		}
+	}
+	return strings.Join(steps, breadcrumbSeparator), nil
+}
+
+// ancestors returns the links from the topmost ancestor of the node at index down to the node,
+// following the first parent link of each node.
+func (qp *QueryPlan) ancestors(index int32) ([]ResolvedParentLink, error) {
+	if index < 0 || int(index) >= len(qp.planNodes) {
+		return nil, fmt.Errorf("node index %d out of range, len(planNodes)=%d", index, len(qp.planNodes))
+	}
+
+	var links []ResolvedParentLink
+	visited := map[int32]struct{}{index: {}}
+	for current := index; ; {
+		parentLinks := qp.parentLinksMap[current]
+		if len(parentLinks) == 0 {
+			break
+		}
+		link := parentLinks[0]
+		current = link.Parent.GetIndex()
+		if _, ok := visited[current]; ok {
+			return nil, fmt.Errorf("ancestors of node %d form a cycle at node %d", index, current)
+		}
+		visited[current] = struct{}{}
+		links = append(links, link)
+	}
+	slices.Reverse(links)
+	return links, nil
+}
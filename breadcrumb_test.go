@@ -0,0 +1,69 @@
+package spannerplan
+
+import (
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+func TestBreadcrumb(t *testing.T) {
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(dca.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		index int32
+		opts  []Option
+		want  string
+	}{
+		{name: "root", index: 0, opts: []Option{HideMetadata()}, want: "Distributed Union"},
+		{
+			name:  "deep node",
+			index: 30,
+			opts:  []Option{HideMetadata()},
+			want:  "Distributed Union › Distributed Cross Apply › [Map] Serialize Result › Cross Apply › [Map] Local Distributed Union › Filter Scan",
+		},
+		{
+			name:  "titles use options",
+			index: 2,
+			opts:  []Option{WithExecutionMethodFormat(ExecutionMethodFormatAngle), WithTargetMetadataFormat(TargetMetadataFormatOn)},
+			want:  "Distributed Union on AlbumsByAlbumTitle <Row> (split_ranges_aligned: false) › Distributed Cross Apply <Row> › [Input] Create Batch <Row>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := qp.Breadcrumb(tt.index, tt.opts...)
+			if err != nil {
+				t.Fatalf("Breadcrumb(%d) error = %v", tt.index, err)
+			}
+			if got != tt.want {
+				t.Errorf("Breadcrumb(%d) = %q, want %q", tt.index, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := qp.Breadcrumb(int32(len(qp.PlanNodes()))); err == nil {
+		t.Error("Breadcrumb(out of range) error = nil, want non-nil")
+	}
+}
+
+func TestBreadcrumbCycle(t *testing.T) {
+	qp, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Root"},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "A", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 2}}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "B", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := qp.Breadcrumb(1); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Breadcrumb(cycle) error = %v, want a cycle error", err)
+	}
+}
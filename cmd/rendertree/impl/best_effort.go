@@ -0,0 +1,44 @@
+package impl
+
+import (
+	"maps"
+	"slices"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan/asciitable"
+)
+
+// statsWarnings collects the per-node execution stats errors that --best-effort renders past.
+// A node is reported once even when the plan is processed more than once.
+type statsWarnings struct {
+	errs map[int32]error
+}
+
+func (w *statsWarnings) add(node *sppb.PlanNode, err error) {
+	if w.errs == nil {
+		w.errs = make(map[int32]error)
+	}
+	if _, ok := w.errs[node.GetIndex()]; !ok {
+		w.errs[node.GetIndex()] = err
+	}
+}
+
+// section formats the warnings as an appendix ordered by node ID and preceded by a blank line,
+// like the sections selected by --print. It returns "" when there are no warnings.
+func (w *statsWarnings) section() (string, error) {
+	if len(w.errs) == 0 {
+		return "", nil
+	}
+	s, err := asciitable.RenderAppendix(slices.Sorted(maps.Keys(w.errs)), asciitable.AppendixSpec[int32]{
+		Title: "Warnings:",
+		ID:    func(id int32) uint { return uint(id) },
+		Items: func(id int32) []string {
+			return []string{"failed to extract execution stats: " + w.errs[id].Error()}
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return "\n" + s, nil
+}
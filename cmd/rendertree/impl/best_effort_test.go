@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRun_BestEffort(t *testing.T) {
+	t.Parallel()
+
+	// Node 0 has a string where Spanner reports a {total, unit} value.
+	malformed := strings.Replace(string(dcaProfileYAML),
+		"                rows:\n                    total: \"33\"\n                    unit: rows\n",
+		"                rows: \"33\"\n", 1)
+	const wantWarnings = "\nWarnings:\n" +
+		" 0: failed to extract execution stats: json: cannot unmarshal string into Go struct field ExecutionStats.rows of type stats.ExecutionStatsValue\n"
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-mode", "profile"}, strings.NewReader(malformed), &stdout, &stderr); err == nil {
+		t.Fatal("run(malformed stats) error = nil, want non-nil")
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-mode", "profile", "-best-effort"}, strings.NewReader(malformed), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-best-effort) error = %v", err)
+	}
+	if !strings.HasSuffix(stdout.String(), wantWarnings) {
+		t.Errorf("run(-best-effort) output does not end with the Warnings section %q:\n%s", wantWarnings, stdout.String())
+	}
+	for _, want := range []string{
+		"|   0 | Distributed Union on AlbumsByAlbumTitle <Row>                                             |      |       |         |",
+		"|  *1 | +- Distributed Cross Apply <Row>                                                          |   33 |     1 |  1.9 ms |",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("run(-best-effort) output does not contain %q:\n%s", want, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"-mode", "profile", "-best-effort", "-format", "json"}, strings.NewReader(malformed), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-best-effort -format=json) error = %v", err)
+	}
+	if !json.Valid(stdout.Bytes()) {
+		t.Errorf("run(-best-effort -format=json) stdout is not valid JSON:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), strings.TrimPrefix(wantWarnings, "\n")) {
+		t.Errorf("run(-best-effort -format=json) stderr = %q, want the Warnings section", stderr.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-mode", "profile", "-best-effort"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-best-effort valid) error = %v", err)
+	}
+	if strings.Contains(stdout.String(), "Warnings:") {
+		t.Errorf("run(-best-effort valid) printed a Warnings section:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,116 @@
+package impl
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan"
+	"github.com/apstndb/spannerplan/asciitable"
+	"github.com/apstndb/spannerplan/plantree"
+)
+
+// criticalPathStyle holds the SGR parameters that highlight --critical-path rows.
+const criticalPathStyle = "1;31"
+
+type criticalPathMode string
+
+const (
+	criticalPathOff       criticalPathMode = ""
+	criticalPathHighlight criticalPathMode = "highlight"
+	criticalPathOnly      criticalPathMode = "only"
+)
+
+// criticalPathFlag is the value of --critical-path. It is a boolean flag, so a bare
+// --critical-path highlights the path, and --critical-path=only selects the isolated view.
+type criticalPathFlag struct {
+	mode criticalPathMode
+}
+
+func (f *criticalPathFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(f.mode)
+}
+
+func (f *criticalPathFlag) Set(s string) error {
+	switch s {
+	case string(criticalPathOnly):
+		f.mode = criticalPathOnly
+		return nil
+	case string(criticalPathHighlight):
+		f.mode = criticalPathHighlight
+		return nil
+	}
+	enabled, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("must be a boolean, %q, or %q", criticalPathHighlight, criticalPathOnly)
+	}
+	f.mode = criticalPathOff
+	if enabled {
+		f.mode = criticalPathHighlight
+	}
+	return nil
+}
+
+func (f *criticalPathFlag) IsBoolFlag() bool { return true }
+
+func criticalPathIDs(planNodes []*sppb.PlanNode) ([]int32, error) {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return nil, err
+	}
+	path, err := qp.CriticalPath()
+	if err != nil {
+		return nil, fmt.Errorf("--critical-path: %w", err)
+	}
+	return path, nil
+}
+
+// runCriticalPathOnly prints one line per critical-path operator, from the root, with its
+// latency and its title without the tree prefix.
+func runCriticalPathOnly(planNodes []*sppb.PlanNode, path []int32, opts []plantree.Option, stdout io.Writer) error {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	rows, err := plantree.ProcessPlan(qp, opts...)
+	if err != nil {
+		return err
+	}
+
+	// A node reused in a DAG renders more than once; list its first occurrence.
+	pathRows := make([]plantree.RowWithPredicates, 0, len(path))
+	for _, id := range path {
+		i := slices.IndexFunc(rows, func(row plantree.RowWithPredicates) bool { return row.ID == id })
+		if i < 0 {
+			return fmt.Errorf("critical path node %d is not rendered", id)
+		}
+		pathRows = append(pathRows, rows[i])
+	}
+
+	s, err := asciitable.RenderTableless(pathRows, asciitable.TableSpec[plantree.RowWithPredicates]{
+		Columns: []asciitable.Column[plantree.RowWithPredicates]{
+			{
+				Alignment: asciitable.AlignRight,
+				Cell:      func(row plantree.RowWithPredicates, _ int) string { return row.FormatID() },
+			},
+			{
+				Alignment: asciitable.AlignRight,
+				Cell:      func(row plantree.RowWithPredicates, _ int) string { return secsToS(row.ExecutionStats.Latency) },
+			},
+			{
+				Cell: func(row plantree.RowWithPredicates, _ int) string { return row.NodeText },
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, s)
+	return err
+}
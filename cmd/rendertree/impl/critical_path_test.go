@@ -0,0 +1,90 @@
+package impl
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	heredoc "github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_CriticalPath(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-print", "none", "-color", "always", "-critical-path"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-critical-path) error = %v", err)
+	}
+	var highlighted []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if strings.Contains(line, "\x1b["+criticalPathStyle+"m") {
+			fields := strings.Fields(line)
+			highlighted = append(highlighted, fields[1])
+		}
+	}
+	want := []string{
+		"\x1b[1;31m0\x1b[0m", "\x1b[1;31m*1\x1b[0m", "\x1b[1;31m11\x1b[0m", "\x1b[1;31m12\x1b[0m",
+		"\x1b[1;31m16\x1b[0m", "\x1b[1;31m*17\x1b[0m", "\x1b[1;31m18\x1b[0m",
+	}
+	if !slices.Equal(highlighted, want) {
+		t.Errorf("highlighted IDs = %q, want %q", highlighted, want)
+	}
+
+	tests := []struct {
+		args  []string
+		input []byte
+	}{
+		{args: []string{"-critical-path"}, input: dcaYAML},
+		{args: []string{"-critical-path=only", "-mode", "plan"}, input: dcaProfileYAML},
+	}
+	for _, tt := range tests {
+		err := run(tt.args, bytes.NewReader(tt.input), &stdout, &stderr)
+		if err == nil || !strings.Contains(err.Error(), "--critical-path is only valid in PROFILE mode") {
+			t.Errorf("run(%q) error = %v, want PROFILE-only error", tt.args, err)
+		}
+	}
+}
+
+func TestRun_CriticalPathOnly(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-critical-path=only"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-critical-path=only) error = %v", err)
+	}
+	want := heredoc.Doc(`
+		  0|1.92 ms|Distributed Union on AlbumsByAlbumTitle <Row>
+		 *1| 1.9 ms|Distributed Cross Apply <Row>
+		 11|0.88 ms|[Map] Serialize Result <Row>
+		 12|0.87 ms|Cross Apply <Row>
+		 16|0.85 ms|[Map] Local Distributed Union <Row>
+		*17|       |Filter Scan <Row> (seekable_key_size: 0)
+		 18|0.84 ms|Index Scan on SongsBySongGenre <Row> (Full scan, scan_method: Row)
+	`)
+	if diff := cmp.Diff(want, stdout.String()); diff != "" {
+		t.Errorf("run(-critical-path=only) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCriticalPathFlag(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    criticalPathMode
+		wantErr bool
+	}{
+		{input: "true", want: criticalPathHighlight},
+		{input: "highlight", want: criticalPathHighlight},
+		{input: "only", want: criticalPathOnly},
+		{input: "false", want: criticalPathOff},
+		{input: "all", wantErr: true},
+	}
+	for _, tt := range tests {
+		var f criticalPathFlag
+		err := f.Set(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if f.mode != tt.want {
+			t.Errorf("Set(%q) mode = %q, want %q", tt.input, f.mode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan/plantree"
+)
+
+// predicatesColumnName is the trailing column of --format=csv and --format=tsv, which holds the
+// predicates the text output prints in its Predicates section.
+const predicatesColumnName = "Predicates"
+
+// runDelimited writes the table of renderOpts as CSV, or as TSV when comma is a tab, with a
+// header row of the column names. The Operator column keeps its tree prefix, and the predicates
+// of each row, formatted like the Predicates section, are written one per line in a trailing
+// Predicates column instead of an appendix. Fields are quoted by [csv.Writer] as needed, so
+// operator text with commas, quotes and newlines reads back unchanged.
+func runDelimited(planNodes []*sppb.PlanNode, renderOpts renderTreeOptions, comma rune, w io.Writer) error {
+	_, rows, renderDef, err := processTableRows(planNodes, renderOpts)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	header := make([]string, 0, len(renderDef.Columns)+1)
+	for _, def := range renderDef.Columns {
+		header = append(header, def.Name)
+	}
+	if err := cw.Write(append(header, predicatesColumnName)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		values, err := renderDef.ColumnMapFunc(row)
+		if err != nil {
+			return err
+		}
+		predicates, err := predicateLines(row, renderOpts.formatPredicate)
+		if err != nil {
+			return err
+		}
+		if err := cw.Write(append(values, strings.Join(predicates, "\n"))); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// predicateLines returns the predicates of row formatted by format, or the default
+// "Type: Description" lines when format is nil.
+func predicateLines(row plantree.RowWithPredicates, format predicateFormatter) ([]string, error) {
+	if format == nil {
+		return row.Predicates, nil
+	}
+	lines := make([]string, 0, len(row.PredicateLinks))
+	for _, link := range row.PredicateLinks {
+		line, err := format(row, link)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format predicate of node %d: %w", row.ID, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
@@ -0,0 +1,237 @@
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/samber/lo"
+
+	"github.com/apstndb/spannerplan"
+)
+
+// diffMarks are the line prefixes of --diff, as in a unified diff, with "~" for a changed operator.
+var diffMarks = map[spannerplan.NodeChange]string{
+	spannerplan.NodeUnchanged: " ",
+	spannerplan.NodeChanged:   "~",
+	spannerplan.NodeAdded:     "+",
+	spannerplan.NodeRemoved:   "-",
+}
+
+// runDiff prints the operator tree of planNodes compared with the baseline plan in the file at
+// path, as returned by [spannerplan.ComparePlans]. After a "---" line naming the baseline and a
+// "+++" line for the compared plan, each operator gets a line with its mark, its link type and
+// title indented by depth, and the PlanNode index in the plan it comes from. A changed operator
+// also shows its baseline title, or the difference when the titles are the same, and an operator
+// with stats in both plans shows the change of its rows and latency when they are not zero.
+//
+// With summary, the tree is followed by the [diffSummary] of the comparison. With
+// format [outputFormatJSON], only the summary is written, as one JSON object.
+func runDiff(planNodes []*sppb.PlanNode, path string, maxInputBytes int64, titleOpts []spannerplan.Option, summary bool, format outputFormat, jsonCompact bool, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := readInput(f, maxInputBytes)
+	if err != nil {
+		return fmt.Errorf("cannot read --diff file %s: %w", path, err)
+	}
+	beforeQS, err := decodeSingleInput(b)
+	if err != nil {
+		return fmt.Errorf("invalid input in --diff file %s: %w", path, err)
+	}
+	before, err := spannerplan.New(beforeQS.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		return fmt.Errorf("invalid plan in --diff file %s: %w", path, err)
+	}
+	after, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	diff, err := spannerplan.ComparePlans(before, after)
+	if err != nil {
+		return err
+	}
+
+	if format == outputFormatJSON {
+		return writeDiffSummaryJSON(newDiffSummary(before, after, diff), jsonCompact, w)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ (stdin)\n", path)
+	for _, node := range diff.Nodes {
+		sb.WriteString(diffLine(node, titleOpts))
+		sb.WriteByte('\n')
+	}
+	if summary {
+		sb.WriteString("\n" + newDiffSummary(before, after, diff).text(titleOpts))
+	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+func diffLine(node spannerplan.NodeDiff, titleOpts []spannerplan.Option) string {
+	planNode := node.After
+	if planNode == nil {
+		planNode = node.Before
+	}
+	line := diffMarks[node.Change] + " " + strings.Repeat("  ", node.Depth)
+	if node.LinkType != "" {
+		line += "[" + node.LinkType + "] "
+	}
+	title := spannerplan.NodeTitle(planNode, titleOpts...)
+	line += fmt.Sprintf("%s #%d", title, planNode.GetIndex())
+
+	if node.Change == spannerplan.NodeChanged {
+		if beforeTitle := spannerplan.NodeTitle(node.Before, titleOpts...); beforeTitle != title {
+			line += fmt.Sprintf(" (was: %s #%d)", beforeTitle, node.Before.GetIndex())
+		} else {
+			line += fmt.Sprintf(" (%s)", node.Reason)
+		}
+	}
+
+	var deltas []string
+	if rows, ok := node.RowsDelta(); ok && rows != 0 {
+		deltas = append(deltas, "rows "+signed(strconv.FormatFloat(rows, 'f', -1, 64)))
+	}
+	if latency, ok := node.LatencyDelta(); ok && latency != 0 {
+		deltas = append(deltas, "latency "+signed(latency.String()))
+	}
+	if len(deltas) > 0 {
+		line += " [" + strings.Join(deltas, ", ") + "]"
+	}
+	return line
+}
+
+// signed prefixes a non-negative number with "+".
+func signed(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return s
+	}
+	return "+" + s
+}
+
+// diffSummary is the --profile-diff-summary of a [spannerplan.PlanDiff]: whether the structure
+// changed, the number of operators of each [spannerplan.NodeChange], and for PROFILE captures
+// the change of the root's rows and latency and the operator whose latency grew the most.
+type diffSummary struct {
+	// StructureChanged reports whether the plans are not [spannerplan.StructurallyEqual].
+	StructureChanged                   bool
+	Unchanged, Changed, Added, Removed int
+	// RowsDelta and LatencyDelta are the changes of the root operator. They are nil unless both
+	// roots have the statistic.
+	RowsDelta    *float64
+	LatencyDelta *time.Duration
+	// Regression is the matched operator with the largest latency increase, or nil when no
+	// operator got slower.
+	Regression *spannerplan.NodeDiff
+}
+
+func newDiffSummary(before, after *spannerplan.QueryPlan, diff *spannerplan.PlanDiff) diffSummary {
+	summary := diffSummary{StructureChanged: !spannerplan.StructurallyEqual(before, after)}
+	var regression time.Duration
+	for i, node := range diff.Nodes {
+		switch node.Change {
+		case spannerplan.NodeUnchanged:
+			summary.Unchanged++
+		case spannerplan.NodeChanged:
+			summary.Changed++
+		case spannerplan.NodeAdded:
+			summary.Added++
+		case spannerplan.NodeRemoved:
+			summary.Removed++
+		}
+		latency, ok := node.LatencyDelta()
+		if node.Depth == 0 && node.Before != nil && node.After != nil {
+			if rows, ok := node.RowsDelta(); ok {
+				summary.RowsDelta = &rows
+			}
+			if ok {
+				summary.LatencyDelta = &latency
+			}
+		}
+		if ok && latency > regression {
+			regression = latency
+			summary.Regression = &diff.Nodes[i]
+		}
+	}
+	return summary
+}
+
+// text returns the summary as a "Diff summary:" block of "key: value" lines, like the header of
+// --query-stats. The rows, latency and regression lines are omitted when they are unknown.
+func (s diffSummary) text(titleOpts []spannerplan.Option) string {
+	var sb strings.Builder
+	sb.WriteString("Diff summary:\n")
+	fmt.Fprintf(&sb, "  structure: %s\n", lo.Ternary(s.StructureChanged, "changed", "unchanged"))
+	fmt.Fprintf(&sb, "  operators: %d unchanged, %d changed, %d added, %d removed\n", s.Unchanged, s.Changed, s.Added, s.Removed)
+	if s.RowsDelta != nil {
+		fmt.Fprintf(&sb, "  rows: %s\n", signed(strconv.FormatFloat(*s.RowsDelta, 'f', -1, 64)))
+	}
+	if s.LatencyDelta != nil {
+		fmt.Fprintf(&sb, "  latency: %s\n", signed(s.LatencyDelta.String()))
+	}
+	if s.Regression != nil {
+		latency, _ := s.Regression.LatencyDelta()
+		fmt.Fprintf(&sb, "  largest regression: %s #%d (latency %s)\n", spannerplan.NodeTitle(s.Regression.After, titleOpts...), s.Regression.After.GetIndex(), signed(latency.String()))
+	}
+	return sb.String()
+}
+
+// diffSummaryJSON is the --format=json encoding of a [diffSummary]. Latencies are milliseconds,
+// the unit of Spanner's execution statistics.
+type diffSummaryJSON struct {
+	StructureChanged  bool            `json:"structureChanged"`
+	Unchanged         int             `json:"unchanged"`
+	Changed           int             `json:"changed"`
+	Added             int             `json:"added"`
+	Removed           int             `json:"removed"`
+	RowsDelta         *float64        `json:"rowsDelta,omitempty"`
+	LatencyDeltaMs    *float64        `json:"latencyDeltaMs,omitempty"`
+	LargestRegression *regressionJSON `json:"largestRegression,omitempty"`
+}
+
+type regressionJSON struct {
+	ID             int32   `json:"id"`
+	DisplayName    string  `json:"displayName"`
+	LatencyDeltaMs float64 `json:"latencyDeltaMs"`
+}
+
+func writeDiffSummaryJSON(s diffSummary, compact bool, w io.Writer) error {
+	out := diffSummaryJSON{
+		StructureChanged: s.StructureChanged,
+		Unchanged:        s.Unchanged,
+		Changed:          s.Changed,
+		Added:            s.Added,
+		Removed:          s.Removed,
+		RowsDelta:        s.RowsDelta,
+	}
+	if s.LatencyDelta != nil {
+		out.LatencyDeltaMs = lo.ToPtr(milliseconds(*s.LatencyDelta))
+	}
+	if s.Regression != nil {
+		latency, _ := s.Regression.LatencyDelta()
+		out.LargestRegression = &regressionJSON{
+			ID:             s.Regression.After.GetIndex(),
+			DisplayName:    s.Regression.After.GetDisplayName(),
+			LatencyDeltaMs: milliseconds(latency),
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(out)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
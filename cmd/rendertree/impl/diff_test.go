@@ -0,0 +1,123 @@
+package impl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	heredoc "github.com/MakeNowJust/heredoc/v2"
+)
+
+func TestRun_Diff(t *testing.T) {
+	t.Parallel()
+
+	baseline := filepath.Join(t.TempDir(), "before.yaml")
+	if err := os.WriteFile(baseline, dcaProfileYAML, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The compared plan reads another index at the root, which also took longer.
+	after := strings.Replace(strings.ReplaceAll(string(dcaProfileYAML), "AlbumsByAlbumTitle", "AlbumsByTitle"), `total: "1.92"`, `total: "2.5"`, 1)
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-diff", baseline}, strings.NewReader(after), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-diff) error = %v", err)
+	}
+	want := "--- " + baseline + "\n" + heredoc.Doc(`
+		+++ (stdin)
+		~ Distributed Union on AlbumsByTitle <Row> #0 (was: Distributed Union on AlbumsByAlbumTitle <Row> #0) [latency +580µs]
+		    Distributed Cross Apply <Row> #1
+		      [Input] Create Batch <Row> #2
+		        Local Distributed Union <Row> #3
+		          Compute Struct <Row> #4
+		~           Index Scan on AlbumsByTitle <Row> (Full scan, scan_method: Automatic) #5 (was: Index Scan on AlbumsByAlbumTitle <Row> (Full scan, scan_method: Automatic) #5)
+		      [Map] Serialize Result <Row> #11
+		        Cross Apply <Row> #12
+		          [Input] Batch Scan on $v2 <Row> (scan_method: Row) #13
+		          [Map] Local Distributed Union <Row> #16
+		            Filter Scan <Row> (seekable_key_size: 0) #17
+		              Index Scan on SongsBySongGenre <Row> (Full scan, scan_method: Row) #18
+	`)
+	if got := stdout.String(); got != want {
+		t.Errorf("run(-diff) = %q, want %q", got, want)
+	}
+
+	err := run([]string{"-diff", filepath.Join(t.TempDir(), "missing.yaml")}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if err == nil {
+		t.Fatal("run(-diff missing file) error = nil, want non-nil")
+	}
+}
+
+func TestRun_ProfileDiffSummary(t *testing.T) {
+	t.Parallel()
+
+	baseline := filepath.Join(t.TempDir(), "before.yaml")
+	if err := os.WriteFile(baseline, dcaProfileYAML, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	after := strings.Replace(strings.ReplaceAll(string(dcaProfileYAML), "AlbumsByAlbumTitle", "AlbumsByTitle"), `total: "1.92"`, `total: "2.5"`, 1)
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-diff", baseline, "-profile-diff-summary"}, strings.NewReader(after), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-profile-diff-summary) error = %v", err)
+	}
+	wantSummary := heredoc.Doc(`
+
+		Diff summary:
+		  structure: changed
+		  operators: 10 unchanged, 2 changed, 0 added, 0 removed
+		  rows: +0
+		  latency: +580µs
+		  largest regression: Distributed Union on AlbumsByTitle <Row> #0 (latency +580µs)
+	`)
+	if got := stdout.String(); !strings.HasPrefix(got, "--- "+baseline+"\n") || !strings.HasSuffix(got, "#18\n"+wantSummary) {
+		t.Errorf("run(-profile-diff-summary) = %q, want the diff followed by %q", got, wantSummary)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		input []byte
+		want  string
+	}{
+		{
+			name:  "json",
+			args:  []string{"-format", "json"},
+			input: []byte(after),
+			want: heredoc.Doc(`
+				{
+				  "structureChanged": true,
+				  "unchanged": 10,
+				  "changed": 2,
+				  "added": 0,
+				  "removed": 0,
+				  "rowsDelta": 0,
+				  "latencyDeltaMs": 0.58,
+				  "largestRegression": {
+				    "id": 0,
+				    "displayName": "Distributed Union",
+				    "latencyDeltaMs": 0.58
+				  }
+				}
+			`),
+		},
+		{
+			name:  "compact json of a plan without stats",
+			args:  []string{"-format", "json", "-json-compact"},
+			input: dcaYAML,
+			want:  `{"structureChanged":false,"unchanged":12,"changed":0,"added":0,"removed":0}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(append([]string{"-diff", baseline, "-profile-diff-summary"}, tt.args...), bytes.NewReader(tt.input), &stdout, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("run() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
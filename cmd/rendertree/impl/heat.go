@@ -0,0 +1,36 @@
+package impl
+
+import (
+	"github.com/apstndb/spannerplan/plantree"
+)
+
+// heatScale holds the 256-color SGR foreground parameters of the --heat Latency cells, from
+// green for 0% of the root latency to red for 100%, one step per 10%.
+var heatScale = []string{
+	"38;5;46", "38;5;82", "38;5;118", "38;5;154", "38;5;190",
+	"38;5;226", "38;5;220", "38;5;214", "38;5;208", "38;5;202", "38;5;196",
+}
+
+// heatBoldStyle holds the SGR parameters of the --heat Operator cells of hot nodes.
+const heatBoldStyle = "1"
+
+// heatCellStyle returns the cell style of --heat. It colors the Latency cell of a row on
+// heatScale by the latency as a percentage of the root latency, and bolds the Operator cell of
+// a row at or above threshold percent. Rows without a latency are left unstyled.
+func heatCellStyle(threshold float64) func(row plantree.RowWithPredicates, column string) string {
+	return func(row plantree.RowWithPredicates, column string) string {
+		pct, ok := row.ExecutionStats.Latency.PercentOf(row.RootLatency)
+		if !ok {
+			return ""
+		}
+		switch column {
+		case "Latency":
+			return heatScale[int(min(max(pct, 0), 100)/10)]
+		case operatorRenderDef.Name:
+			if pct >= threshold {
+				return heatBoldStyle
+			}
+		}
+		return ""
+	}
+}
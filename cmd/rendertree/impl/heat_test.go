@@ -0,0 +1,102 @@
+package impl
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spannerplan/plantree"
+	"github.com/apstndb/spannerplan/stats"
+)
+
+func TestHeatCellStyle(t *testing.T) {
+	root := stats.ExecutionStatsValue{Total: "10", Unit: "msecs"}
+	row := func(latency string) plantree.RowWithPredicates {
+		var row plantree.RowWithPredicates
+		row.RootLatency = root
+		if latency != "" {
+			row.ExecutionStats.Latency = stats.ExecutionStatsValue{Total: latency, Unit: "msecs"}
+		}
+		return row
+	}
+
+	tests := []struct {
+		name         string
+		row          plantree.RowWithPredicates
+		wantLatency  string
+		wantOperator string
+	}{
+		{name: "root", row: row("10"), wantLatency: "38;5;196", wantOperator: heatBoldStyle},
+		{name: "at the threshold", row: row("5"), wantLatency: "38;5;226", wantOperator: heatBoldStyle},
+		{name: "below the threshold", row: row("4.99"), wantLatency: "38;5;190"},
+		{name: "cold", row: row("0.01"), wantLatency: "38;5;46"},
+		{name: "above the root", row: row("12"), wantLatency: "38;5;196", wantOperator: heatBoldStyle},
+		{name: "no latency", row: row("")},
+	}
+
+	style := heatCellStyle(50)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := style(tt.row, "Latency"); got != tt.wantLatency {
+				t.Errorf("Latency style = %q, want %q", got, tt.wantLatency)
+			}
+			if got := style(tt.row, "Operator"); got != tt.wantOperator {
+				t.Errorf("Operator style = %q, want %q", got, tt.wantOperator)
+			}
+			if got := style(tt.row, "Rows"); got != "" {
+				t.Errorf("Rows style = %q, want none", got)
+			}
+		})
+	}
+}
+
+var sgrPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestRun_Heat(t *testing.T) {
+	for _, layout := range []string{"table", "tableless"} {
+		t.Run(layout, func(t *testing.T) {
+			var plain, stderr bytes.Buffer
+			if err := run([]string{"-print", "none", "-layout", layout}, bytes.NewReader(dcaProfileYAML), &plain, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			var stdout bytes.Buffer
+			if err := run([]string{"-print", "none", "-layout", layout, "-heat", "-color", "always"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+				t.Fatalf("run(-heat) error = %v", err)
+			}
+			got := stdout.String()
+			// The escapes must not change the layout.
+			if diff := cmp.Diff(plain.String(), sgrPattern.ReplaceAllString(got, "")); diff != "" {
+				t.Errorf("run(-heat) without escapes mismatch (-plain +heat):\n%s", diff)
+			}
+			for _, want := range []string{
+				"\x1b[38;5;196m1.92 ms\x1b[0m",
+				"\x1b[38;5;46m0.01 ms\x1b[0m",
+				"\x1b[1mDistributed Union on AlbumsByAlbumTitle <Row>\x1b[0m",
+			} {
+				if !strings.Contains(got, want) {
+					t.Errorf("run(-heat) output does not contain %q:\n%s", want, got)
+				}
+			}
+			if strings.Contains(got, "\x1b[1m   |  +- Local Distributed Union") {
+				t.Errorf("run(-heat) bolded a node below the threshold:\n%s", got)
+			}
+
+			stdout.Reset()
+			if err := run([]string{"-print", "none", "-layout", layout, "-heat", "-color", "never"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+				t.Fatalf("run(-heat -color never) error = %v", err)
+			}
+			if diff := cmp.Diff(plain.String(), stdout.String()); diff != "" {
+				t.Errorf("run(-heat -color never) mismatch (-plain +heat):\n%s", diff)
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-heat"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "--heat is only valid in PROFILE mode") {
+		t.Errorf("run(-heat) on a PLAN error = %v, want PROFILE-only error", err)
+	}
+}
@@ -1,6 +1,8 @@
 package impl
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"flag"
 	"fmt"
@@ -10,10 +12,13 @@ import (
 	"os"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/go-tabwrap"
 	"github.com/goccy/go-yaml"
 	"github.com/olekukonko/tablewriter/tw"
 	"github.com/samber/lo"
@@ -112,10 +117,11 @@ const (
 var _ yaml.BytesUnmarshaler = (*inlineType)(nil)
 
 type plainColumnRenderDef struct {
-	Template  string     `json:"template"`
-	Name      string     `json:"name"`
-	Alignment tw.Align   `json:"alignment"`
-	Inline    inlineType `json:"inline"`
+	Template  string      `json:"template"`
+	Name      string      `json:"name"`
+	Alignment tw.Align    `json:"alignment"`
+	Inline    inlineType  `json:"inline"`
+	Summary   summaryType `json:"summary"`
 }
 
 type columnRenderDef struct {
@@ -123,6 +129,13 @@ type columnRenderDef struct {
 	Name      string
 	Alignment tw.Align
 	Inline    inlineType
+	// Summary is how --summary aggregates the column into the footer row.
+	Summary summaryType
+	// Width fixes the table column width when set by --fixed-widths. Zero sizes it to content.
+	Width int
+	// DropIfEmpty omits the column from the table when every row maps to an empty or zero value.
+	// It is set on the default PROFILE stats columns by --drop-empty-columns.
+	DropIfEmpty bool
 }
 
 func (d columnRenderDef) shouldInline(inline bool) bool {
@@ -140,7 +153,9 @@ func (d columnRenderDef) shouldInline(inline bool) bool {
 
 func templateMapFunc(tmplName, tmplText string) (func(row plantree.RowWithPredicates) (string, error), error) {
 	tmpl, err := template.New(tmplName).Funcs(map[string]any{
-		"secsToS": secsToS,
+		"secsToS":   secsToS,
+		"div":       divFloat,
+		"pctOfRoot": latencyPercentOfRoot,
 	}).Parse(tmplText)
 	if err != nil {
 		return nil, err
@@ -148,7 +163,7 @@ func templateMapFunc(tmplName, tmplText string) (func(row plantree.RowWithPredic
 
 	return func(row plantree.RowWithPredicates) (string, error) {
 		var sb strings.Builder
-		if err = tmpl.Execute(&sb, row); err != nil {
+		if err := tmpl.Execute(&sb, row); err != nil {
 			return "", err
 		}
 
@@ -156,6 +171,16 @@ func templateMapFunc(tmplName, tmplText string) (func(row plantree.RowWithPredic
 	}, nil
 }
 
+// latencyPercentOfRoot formats v as a percentage of rootLatency rounded to one decimal, such as
+// "45.3%". It returns "" when v or rootLatency is empty or not a duration, or rootLatency is zero.
+func latencyPercentOfRoot(v, rootLatency stats.ExecutionStatsValue) string {
+	pct, ok := v.PercentOf(rootLatency)
+	if !ok {
+		return ""
+	}
+	return strconv.FormatFloat(pct, 'f', 1, 64) + "%"
+}
+
 var (
 	idRenderDef = columnRenderDef{
 		Name:      "ID",
@@ -175,6 +200,40 @@ var (
 	}
 )
 
+// divFloat is the div template function, so that custom columns can compute ratios such as the
+// latency per row from the numeric accessors of [stats.ExecutionStats]. Integer arguments, such as
+// the result of Executions, are converted to float64, and durations, such as the result of
+// LatencyDuration, to seconds.
+func divFloat(a, b any) (float64, error) {
+	x, err := toFloat64(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := toFloat64(b)
+	if err != nil {
+		return 0, err
+	}
+	if y == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return x / y, nil
+}
+
+func toFloat64(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case time.Duration:
+		return v.Seconds(), nil
+	default:
+		return 0, fmt.Errorf("cannot divide %T", v)
+	}
+}
+
 var secsRe = regexp.MustCompile(`secs$`)
 
 func secsToS(v any) string {
@@ -196,6 +255,7 @@ var (
 					},
 					Name:      "Rows",
 					Alignment: tw.AlignRight,
+					Summary:   summaryTypeRoot,
 				},
 				{
 					MapFunc: func(row plantree.RowWithPredicates) (string, error) {
@@ -203,6 +263,7 @@ var (
 					},
 					Name:      "Exec.",
 					Alignment: tw.AlignRight,
+					Summary:   summaryTypeSum,
 				},
 				{
 					MapFunc: func(row plantree.RowWithPredicates) (string, error) {
@@ -210,12 +271,183 @@ var (
 					},
 					Name:      "Latency",
 					Alignment: tw.AlignRight,
+					Summary:   summaryTypeMax,
 				},
 			},
 		},
 	}
 )
 
+// misestimateFactor is the actual/estimated row ratio, in either direction, marked as a misestimate.
+const misestimateFactor = 10
+
+// estimateRenderDefs are appended to the PROFILE columns when the plan carries row estimates.
+var estimateRenderDefs = []columnRenderDef{
+	{
+		MapFunc: func(row plantree.RowWithPredicates) (string, error) {
+			return row.EstimatedRows, nil
+		},
+		Name:      "Est. Rows",
+		Alignment: tw.AlignRight,
+		Summary:   summaryTypeRoot,
+	},
+	{
+		MapFunc: func(row plantree.RowWithPredicates) (string, error) {
+			ratio, ok := row.RowsEstimateRatio()
+			if !ok {
+				return "", nil
+			}
+			s := strconv.FormatFloat(ratio, 'f', 2, 64)
+			if ratio >= misestimateFactor || ratio <= 1.0/misestimateFactor {
+				s = "!" + s
+			}
+			return s, nil
+		},
+		Name:      "Act/Est",
+		Alignment: tw.AlignRight,
+		Summary:   summaryTypeNone,
+	},
+}
+
+// producedRenderDef is inserted after the PROFILE Rows column by --rows-produced.
+var producedRenderDef = columnRenderDef{
+	MapFunc: func(row plantree.RowWithPredicates) (string, error) {
+		produced, _ := row.ExecutionStats.RowsProduced()
+		return produced.Total, nil
+	},
+	Name:      "Produced",
+	Alignment: tw.AlignRight,
+	Summary:   summaryTypeSum,
+}
+
+// withRowsProduced inserts producedRenderDef after the Rows column of the default PROFILE columns.
+func withRowsProduced(renderDef tableRenderDef) tableRenderDef {
+	i := slices.IndexFunc(renderDef.Columns, func(def columnRenderDef) bool { return def.Name == "Rows" })
+	return tableRenderDef{Columns: slices.Insert(slices.Clone(renderDef.Columns), i+1, producedRenderDef)}
+}
+
+// avgPerExecutionRenderDef is inserted after the PROFILE Latency column by --avg-exec.
+var avgPerExecutionRenderDef = columnRenderDef{
+	MapFunc: func(row plantree.RowWithPredicates) (string, error) {
+		avg, ok := row.ExecutionStats.LatencyPerExecution()
+		if !ok {
+			return "", nil
+		}
+		return secsToS(avg), nil
+	},
+	Name:      "Avg/Exec",
+	Alignment: tw.AlignRight,
+	Summary:   summaryTypeNone,
+}
+
+// withAvgPerExecution inserts avgPerExecutionRenderDef after the Latency column of the default
+// PROFILE columns.
+func withAvgPerExecution(renderDef tableRenderDef) tableRenderDef {
+	i := slices.IndexFunc(renderDef.Columns, func(def columnRenderDef) bool { return def.Name == "Latency" })
+	return tableRenderDef{Columns: slices.Insert(slices.Clone(renderDef.Columns), i+1, avgPerExecutionRenderDef)}
+}
+
+// cpuTimeRenderDef is inserted after the PROFILE Latency column by --cpu-time.
+var cpuTimeRenderDef = columnRenderDef{
+	MapFunc: func(row plantree.RowWithPredicates) (string, error) {
+		return secsToS(row.ExecutionStats.CpuTime), nil
+	},
+	Name:      "CPU Time",
+	Alignment: tw.AlignRight,
+	Summary:   summaryTypeMax,
+}
+
+// withCPUTime inserts cpuTimeRenderDef after the Latency column of the default PROFILE columns.
+func withCPUTime(renderDef tableRenderDef) tableRenderDef {
+	i := slices.IndexFunc(renderDef.Columns, func(def columnRenderDef) bool { return def.Name == "Latency" })
+	return tableRenderDef{Columns: slices.Insert(slices.Clone(renderDef.Columns), i+1, cpuTimeRenderDef)}
+}
+
+// latencyPercentRenderDef is inserted after the PROFILE Latency column by --latency-pct.
+var latencyPercentRenderDef = columnRenderDef{
+	MapFunc: func(row plantree.RowWithPredicates) (string, error) {
+		return latencyPercentOfRoot(row.ExecutionStats.Latency, row.RootLatency), nil
+	},
+	Name:      "Lat%",
+	Alignment: tw.AlignRight,
+	Summary:   summaryTypeNone,
+}
+
+// withLatencyPercent inserts latencyPercentRenderDef after the Latency column of the default
+// PROFILE columns.
+func withLatencyPercent(renderDef tableRenderDef) tableRenderDef {
+	i := slices.IndexFunc(renderDef.Columns, func(def columnRenderDef) bool { return def.Name == "Latency" })
+	return tableRenderDef{Columns: slices.Insert(slices.Clone(renderDef.Columns), i+1, latencyPercentRenderDef)}
+}
+
+// parseIDTemplate parses --id-template into a MapFunc for the ID column. The template executes
+// against the row, and the result keeps the "*" prefix of [plantree.RowWithPredicates.FormatID]
+// for rows with predicates. The template also runs once against an empty row so that references
+// to unknown fields are reported as usage errors instead of failing mid-render.
+func parseIDTemplate(tmplText string) (func(row plantree.RowWithPredicates) (string, error), error) {
+	mapFunc, err := templateMapFunc("id", tmplText)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := mapFunc(plantree.RowWithPredicates{}); err != nil {
+		return nil, err
+	}
+	return func(row plantree.RowWithPredicates) (string, error) {
+		id, err := mapFunc(row)
+		if err != nil {
+			return "", err
+		}
+		return lo.Ternary(len(row.Predicates) != 0, "*", "") + id, nil
+	}, nil
+}
+
+// withIDMapFunc replaces the MapFunc of the ID column of renderDef.
+func withIDMapFunc(renderDef tableRenderDef, mapFunc func(row plantree.RowWithPredicates) (string, error)) tableRenderDef {
+	columns := slices.Clone(renderDef.Columns)
+	for i := range columns {
+		if columns[i].Name == idRenderDef.Name {
+			columns[i].MapFunc = mapFunc
+		}
+	}
+	return tableRenderDef{Columns: columns}
+}
+
+// withDroppableStatsColumns marks every column of renderDef except ID and Operator as DropIfEmpty.
+func withDroppableStatsColumns(renderDef tableRenderDef) tableRenderDef {
+	columns := slices.Clone(renderDef.Columns)
+	for i := range columns {
+		columns[i].DropIfEmpty = !slices.Contains([]string{"ID", "Operator"}, columns[i].Name)
+	}
+	return tableRenderDef{Columns: columns}
+}
+
+// columnIsEmpty reports whether def maps every row to an empty value or to a value whose number is
+// zero, such as "0" or "0 msecs". Rows whose value fails to map count as informative, so the
+// error still surfaces when the table is rendered.
+func columnIsEmpty(def columnRenderDef, rows []plantree.RowWithPredicates) bool {
+	for _, row := range rows {
+		v, err := def.MapFunc(row)
+		if err != nil {
+			return false
+		}
+		fields := strings.Fields(v)
+		if len(fields) == 0 {
+			continue
+		}
+		if f, err := strconv.ParseFloat(fields[0], 64); err != nil || f != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func hasEstimatedRows(planNodes []*sppb.PlanNode) bool {
+	return slices.ContainsFunc(planNodes, func(node *sppb.PlanNode) bool {
+		_, ok := node.GetMetadata().GetFields()["estimated_rows"]
+		return ok
+	})
+}
+
 type repeatableStringList []string
 
 func (s *repeatableStringList) String() string {
@@ -301,178 +533,131 @@ func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
 	flagSet := flag.NewFlagSet("rendertree", flag.ContinueOnError)
 	flagSet.SetOutput(stderr)
 
-	customFile := flagSet.String("custom-file", "", "Read custom table column definitions from a YAML file (mutually exclusive with --custom-column)")
-	mode := flagSet.String("mode", "AUTO", "PROFILE, PLAN, AUTO(ignore case)")
-	printSectionsStr := flagSet.String("print", "basic", printFlagUsage)
-	showScalarVars := flagSet.Bool("show-vars", false, "show scalar variable assignments in semantic appendix sections")
-	resolveScalarVars := flagSet.Bool("resolve-vars", false, "EXPERIMENTAL: resolve scalar variable aliases in semantic appendix sections")
-	resolveScalarVarsRecursive := flagSet.Bool("resolve-vars-recursive", false, "EXPERIMENTAL: recursively resolve scalar variable aliases in semantic appendix sections")
-	disallowUnknownStats := flagSet.Bool("disallow-unknown-stats", false, "error on unknown stats field")
-	layoutStr := flagSet.String("layout", string(layoutTable), "Render layout: 'table' or 'tableless' (default: table)")
-	executionMethod := flagSet.String("execution-method", "angle", "Format execution method metadata: 'angle' or 'raw' (default: angle)")
-	targetMetadata := flagSet.String("target-metadata", "on", "Format target metadata: 'on' or 'raw' (default: on)")
-	knownFlag := flagSet.String("known-flag", "", "Format known flags: 'label' or 'raw' (default: label)")
-	compact := flagSet.Bool("compact", false, "Enable compact format")
-	tableless := flagSet.Bool("tableless", false, "Shortcut for --layout=tableless")
-	inlineStats := flagSet.Bool("inline-stats", false, "Enable inline stats")
-	wrapWidth := flagSet.Int("wrap-width", 0, "Number of characters at which to wrap the Operator column content. 0 means no wrapping.")
-	hangingIndent := flagSet.Bool("hanging-indent", false, "Enable hanging indent for wrapped lines after node-local prefixes such as [Input] and [Map]")
-
-	var customColumn repeatableStringList
-	flagSet.Var(&customColumn, "custom-column", "Add one custom table column definition as a YAML/JSON object (repeatable, mutually exclusive with --custom-file)")
+	cfg := DefaultRenderConfig()
+	cfg.Warnings = stderr
+	flagSet.StringVar(&cfg.CustomFile, "custom-file", cfg.CustomFile, "Read custom table column definitions from a YAML file (mutually exclusive with --custom-column)")
+	flagSet.StringVar(&cfg.Mode, "mode", cfg.Mode, "PROFILE, PLAN, AUTO(ignore case)")
+	flagSet.StringVar(&cfg.StatsFrom, "stats-from", cfg.StatsFrom, "Read execution stats from a PROFILE capture of the same plan and attach them to the plan read from stdin")
+	flagSet.StringVar(&cfg.MaxInputBytes, "max-input-bytes", cfg.MaxInputBytes, "Largest input to read from stdin or --stats-from, such as '512MiB' or '1000000'; 0 disables the limit")
+	flagSet.StringVar(&cfg.InputFormat, "input-format", cfg.InputFormat, "Input decoder: 'auto', 'yaml', 'json', 'proto' (binary ResultSetStats) or 'studio' (console export with a top-level queryPlan or planNodes key)")
+	flagSet.StringVar(&cfg.Print, "print", cfg.Print, printFlagUsage)
+	flagSet.BoolVar(&cfg.ShowVars, "show-vars", false, "show scalar variable assignments in semantic appendix sections")
+	flagSet.BoolVar(&cfg.ResolveVars, "resolve-vars", false, "EXPERIMENTAL: resolve scalar variable aliases in semantic appendix sections")
+	flagSet.BoolVar(&cfg.ResolveVarsRecursive, "resolve-vars-recursive", false, "EXPERIMENTAL: recursively resolve scalar variable aliases in semantic appendix sections")
+	flagSet.BoolVar(&cfg.DisallowUnknownStats, "disallow-unknown-stats", false, "error on unknown stats field")
+	flagSet.BoolVar(&cfg.BestEffort, "best-effort", false, "Render nodes whose execution stats cannot be extracted with empty stats, and list the errors in a trailing Warnings section instead of failing")
+	flagSet.StringVar(&cfg.Layout, "layout", cfg.Layout, "Render layout: 'table' or 'tableless' (default: table)")
+	flagSet.StringVar(&cfg.ExecutionMethod, "execution-method", cfg.ExecutionMethod, "Format execution method metadata: 'angle' or 'raw' (default: angle)")
+	flagSet.StringVar(&cfg.TargetMetadata, "target-metadata", cfg.TargetMetadata, "Format target metadata: 'on' or 'raw' (default: on)")
+	flagSet.StringVar(&cfg.KnownFlag, "known-flag", cfg.KnownFlag, "Format known flags: 'label' or 'raw' (default: label)")
+	flagSet.BoolVar(&cfg.Compact, "compact", false, "Enable compact format")
+	flagSet.BoolVar(&cfg.Tableless, "tableless", false, "Shortcut for --layout=tableless")
+	flagSet.BoolVar(&cfg.InlineStats, "inline-stats", false, "Enable inline stats")
+	flagSet.BoolVar(&cfg.StatBadges, "stat-badges", false, "Append a badge of rows, executions and latency such as '[33r 1x 1.92ms]' to each operator with stats, aligned in a gutter")
+	flagSet.IntVar(&cfg.LatencyBar, "latency-bar", 0, "Append a bar of N cells such as '[▇▇▁▁▁]' showing each operator's self latency relative to the slowest operator. 0 means no bar.")
+	flagSet.BoolVar(&cfg.DropEmptyColumns, "drop-empty-columns", false, "Omit default PROFILE stats columns whose value is empty or zero on every row")
+	flagSet.BoolVar(&cfg.RowsProduced, "rows-produced", false, "Add a Produced column after Rows in the default PROFILE columns, showing the rows an operator read before discarding any")
+	flagSet.BoolVar(&cfg.Summary, "summary", false, "PROFILE only: append a Total row to the table with the root Rows, the summed Exec. and the largest Latency; custom columns choose with their summary key")
+	flagSet.BoolVar(&cfg.QueryStats, "query-stats", false, "Print a header block above the table with the elapsed_time, cpu_time, rows_returned and optimizer_version query stats of the input, when it has any")
+	flagSet.BoolVar(&cfg.CPUTime, "cpu-time", false, "Add a CPU Time column after Latency in the default PROFILE columns, showing the cpu_time statistic")
+	flagSet.BoolVar(&cfg.LatencyPercent, "latency-pct", false, "Add a Lat% column after Latency in the default PROFILE columns, showing each operator's latency as a percentage of the root's latency")
+	flagSet.BoolVar(&cfg.AvgPerExecution, "avg-exec", false, "Add an Avg/Exec column after Latency in the default PROFILE columns, showing the latency of one execution of operators that ran more than once")
+	flagSet.IntVar(&cfg.WrapWidth, "wrap-width", 0, "Number of characters at which to wrap the Operator column content. 0 means no wrapping.")
+	flagSet.BoolVar(&cfg.HangingIndent, "hanging-indent", false, "Enable hanging indent for wrapped lines after node-local prefixes such as [Input] and [Map]")
+	flagSet.StringVar(&cfg.FixedWidths, "fixed-widths", cfg.FixedWidths, "Comma-separated fixed column widths such as 'ID:4,Operator:80'; longer cells are truncated with an ellipsis (table layout only)")
+	flagSet.StringVar(&cfg.ColumnOrder, "column-order", cfg.ColumnOrder, "Comma-separated column names such as 'ID,Operator,Latency,Rows' to move to the front in that order; other columns follow in their original order")
+	flagSet.StringVar(&cfg.Sections, "sections", cfg.Sections, "Comma-separated output sections in output order (table, appendix); appendix prints the sections selected by --print")
+	flagSet.BoolVar(&cfg.Lint, "lint", false, "Print plan anti-pattern findings instead of the rendered plan, and fail when any finding is at or above --lint-severity")
+	flagSet.BoolVar(&cfg.Advisories, "advisories", false, "Append the --lint findings to the rendered plan in an Advisories section, without failing")
+	flagSet.StringVar(&cfg.VerifyIndex, "verify-index", cfg.VerifyIndex, "Fail unless the plan scans the named index, listing the scans it uses instead; the output is otherwise unchanged")
+	flagSet.StringVar(&cfg.Search, "search", cfg.Search, "Print the ID, title and path from the root of each operator whose title matches this regular expression instead of the rendered plan, and fail when none matches")
+	flagSet.BoolVar(&cfg.Interactive, "interactive", false, "Browse the plan in an interactive terminal UI with collapsible subtrees and a node detail panel (requires rendertree from the cmd/rendertree/tui module)")
+	flagSet.StringVar(&cfg.LintSeverity, "lint-severity", cfg.LintSeverity, "Minimum finding severity that makes --lint fail: 'info', 'warning' or 'error'")
+	flagSet.BoolVar(&cfg.Heat, "heat", false, "PROFILE only: color each Latency cell from green to red by its percentage of the root latency, and bold the Operator of nodes at or above --heat-threshold (requires color output)")
+	flagSet.Float64Var(&cfg.HeatThreshold, "heat-threshold", cfg.HeatThreshold, "Percentage of the root latency at or above which --heat bolds the Operator")
+	flagSet.StringVar(&cfg.ThemeFile, "theme-file", cfg.ThemeFile, "Read a YAML theme mapping operator categories and names to colors, and selecting border and edge characters")
+	flagSet.StringVar(&cfg.Color, "color", cfg.Color, "Colorize output: 'auto' (only when stdout is a terminal and NO_COLOR is unset), 'always' or 'never'")
+	flagSet.StringVar(&cfg.Format, "format", cfg.Format, "Output format: 'text' (the rendered table and appendices), 'json' (an array of rendered rows), 'jsonl' (one rendered row per line), 'csv' or 'tsv' (the table columns and a Predicates column), 'markdown' (a Markdown table and the appendix in a code block), 'otlp' (OTLP/JSON trace spans of a PROFILE plan), 'dot' (a Graphviz digraph of the operator tree) or 'mermaid' (a Mermaid flowchart of the operator tree)")
+	flagSet.BoolVar(&cfg.JSONCompact, "json-compact", false, "With --format=json, write the JSON on one line instead of indenting it")
+	flagSet.BoolVar(&cfg.ScalarLinks, "scalar-links", false, "With --format=dot or --format=mermaid, also draw the links to scalar nodes such as predicates as dashed edges")
+	flagSet.BoolVar(&cfg.ShowDataflow, "show-dataflow", false, "With --format=dot, also draw the variable dependencies between operators as dashed blue edges labeled with the variable, such as $v2")
+	flagSet.BoolVar(&cfg.NoTrailingNewline, "no-trailing-newline", false, "Omit the newlines at the end of the output")
+	flagSet.StringVar(&cfg.OutputEncoding, "output-encoding", cfg.OutputEncoding, "Line endings and byte order mark of the output: 'lf', 'crlf', 'lf-bom' or 'crlf-bom'")
+	flagSet.StringVar(&cfg.IDTemplate, "id-template", cfg.IDTemplate, "Go template for the ID column of the default columns, e.g. 'N{{.ID}}'; the '*' predicate marker is kept (default: '{{.DisplayID}}')")
+	flagSet.StringVar(&cfg.PredicateTemplate, "predicate-template", cfg.PredicateTemplate, "Go template for each predicate line, e.g. '{{.Type}} @ node {{.NodeID}}: {{.Description}}' (default: '{{.Type}}: {{.Description}}')")
+	flagSet.IntVar(&cfg.TruncatePredicates, "truncate-predicates", 0, "Truncate each predicate line in the predicates appendix to N display columns with an ellipsis. 0 means no truncation.")
+
+	flagSet.BoolVar(&cfg.DumpRows, "dump-rows", false, "Print the rendered rows (ID, text, predicates and stats) as deterministic YAML instead of the rendered plan, for snapshot tests")
+	flagSet.StringVar(&cfg.Diff, "diff", cfg.Diff, "Compare the plan read from stdin with the baseline plan in this file and print the operators marked as unchanged, changed (~), added (+) or removed (-), with row and latency changes for PROFILE captures, instead of the rendered plan")
+	flagSet.BoolVar(&cfg.ProfileDiffSummary, "profile-diff-summary", false, "With --diff, append a summary: whether the structure changed, the number of unchanged, changed, added and removed operators, and for PROFILE captures the root's row and latency changes and the largest latency regression; with --format=json, print only the summary as JSON")
+	flagSet.BoolVar(&cfg.Shape, "shape", false, "Append a line counting the operators at each depth of the tree, such as 'Shape: depth 0: 1, depth 1: 2'")
+
+	var criticalPath criticalPathFlag
+	flagSet.Var(&criticalPath, "critical-path", "PROFILE only: highlight the root-to-leaf chain of operators with the largest summed latency (requires color output), or print just that chain with --critical-path=only")
+
+	flagSet.Var((*repeatableStringList)(&cfg.CustomColumns), "custom-column", "Add one custom table column definition as a YAML/JSON object (repeatable, mutually exclusive with --custom-file)")
 	if err := flagSet.Parse(args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return nil
 		}
 		return &usageError{err: err}
 	}
+	cfg.CriticalPath = string(criticalPath.mode)
 
-	// These are semantic flag-combination checks that run after Parse succeeds.
-	// flag.ContinueOnError only covers parse-time failures, so we still print usage here.
-	if len(customColumn) > 0 && *customFile != "" {
-		const msg = "--custom-column and --custom-file are mutually exclusive"
-		_, _ = fmt.Fprintln(stderr, msg)
-		flagSet.Usage()
-		return &usageError{err: errors.New(msg)}
-	}
-	printSections, err := parsePrintSections(*printSectionsStr)
-	if err != nil {
-		_, _ = fmt.Fprintf(stderr, "Invalid value for -print flag: %v\n", err)
-		flagSet.Usage()
-		return &usageError{err: err}
-	}
-
-	parsedMode, err := parseExplainMode(*mode)
-	if err != nil {
-		_, _ = fmt.Fprintf(stderr, "Invalid value for -mode flag: %v\n", err)
-		flagSet.Usage()
-		return &usageError{err: err}
-	}
-
+	// --tableless conflicts only with a --layout given explicitly.
 	var layoutExplicit bool
 	flagSet.Visit(func(f *flag.Flag) {
 		if f.Name == "layout" {
 			layoutExplicit = true
 		}
 	})
-	parsedLayout, err := parseLayout(*layoutStr)
-	if err != nil {
-		_, _ = fmt.Fprintf(stderr, "Invalid value for -layout flag: %v\n", err)
-		flagSet.Usage()
-		return &usageError{err: err}
-	}
-	if *tableless {
-		if layoutExplicit && parsedLayout != layoutTableless {
-			const msg = "--tableless and --layout=table are mutually exclusive"
-			_, _ = fmt.Fprintln(stderr, msg)
-			flagSet.Usage()
-			return &usageError{err: errors.New(msg)}
-		}
-		parsedLayout = layoutTableless
-	}
-
-	var opts []plantree.Option
-	if *disallowUnknownStats {
-		opts = append(opts, plantree.DisallowUnknownStats())
-	}
-
-	if *compact {
-		opts = append(opts, plantree.EnableCompact())
-	}
-
-	em := spannerplan.ExecutionMethodFormatAngle
-	if *executionMethod != "" {
-		em, err = spannerplan.ParseExecutionMethodFormat(*executionMethod)
-		if err != nil {
-			_, _ = fmt.Fprintf(stderr, "Invalid value for -execution-method flag: %v.\n", err)
-			flagSet.Usage()
-			return &usageError{err: err}
-		}
+	if cfg.Tableless && !layoutExplicit {
+		cfg.Layout = string(layoutTableless)
 	}
-	opts = append(opts, plantree.WithQueryPlanOptions(spannerplan.WithExecutionMethodFormat(em)))
 
-	tm := spannerplan.TargetMetadataFormatOn
-	if *targetMetadata != "" {
-		tm, err = spannerplan.ParseTargetMetadataFormat(*targetMetadata)
-		if err != nil {
-			_, _ = fmt.Fprintf(stderr, "Invalid value for -target-metadata flag: %v.\n", err)
-			flagSet.Usage()
-			return &usageError{err: err}
-		}
-	}
-	opts = append(opts, plantree.WithQueryPlanOptions(spannerplan.WithTargetMetadataFormat(tm)))
-
-	kf := spannerplan.KnownFlagFormatLabel
-	if *knownFlag != "" {
-		kf, err = spannerplan.ParseKnownFlagFormat(*knownFlag)
-		if err != nil {
-			_, _ = fmt.Fprintf(stderr, "Invalid value for -known-flag: %v.\n", err)
+	r, err := newRenderer(cfg)
+	if err != nil {
+		// flag.ContinueOnError only covers parse-time failures, so we still print usage here.
+		var configErr *configError
+		if errors.As(err, &configErr) {
+			_, _ = fmt.Fprintln(stderr, configErr.message)
 			flagSet.Usage()
-			return &usageError{err: err}
+			return &usageError{err: configErr.err}
 		}
+		return err
 	}
-	opts = append(opts, plantree.WithQueryPlanOptions(spannerplan.WithKnownFlagFormat(kf)))
 
-	if *wrapWidth > 0 {
-		opts = append(opts, plantree.WithWrapWidth(*wrapWidth))
-	}
-	if *hangingIndent {
-		opts = append(opts, plantree.WithHangingIndent())
+	b, err := readLimited(stdin, r.maxInputBytes)
+	if err != nil {
+		return fmt.Errorf("cannot read stdin: %w", err)
 	}
+	return r.render(b, stdout)
+}
 
-	b, err := io.ReadAll(stdin)
+func runLint(planNodes []*sppb.PlanNode, disallowUnknownStats bool, failSeverity spannerplan.Severity, stdout io.Writer) error {
+	qp, err := spannerplan.New(planNodes)
 	if err != nil {
 		return err
 	}
 
-	qs, _, err := spannerplan.ExtractQueryPlan(b)
+	findings, err := lintPlan(qp, disallowUnknownStats)
 	if err != nil {
-		var collapsedStr string
-		if len(b) > jsonSnippetLen {
-			collapsedStr = "(collapsed)"
-		}
-		return fmt.Errorf("invalid input at protoyaml.Unmarshal:\nerror: %w\ninput: %.*s%s", err, jsonSnippetLen, strings.TrimSpace(string(b)), collapsedStr)
+		return err
 	}
 
-	planNodes := qs.GetQueryPlan().GetPlanNodes()
-
-	var renderDef tableRenderDef
-	if len(customColumn) > 0 {
-		renderDef, err = customColumnListToTableRenderDef(customColumn)
-		if err != nil {
+	var failed int
+	for _, finding := range findings {
+		if _, err := fmt.Fprintln(stdout, finding); err != nil {
 			return err
 		}
-	} else if *customFile != "" {
-		b, err := os.ReadFile(*customFile)
-		if err != nil {
-			return err
-		}
-		renderDef, err = customFileToTableRenderDef(b)
-		if err != nil {
-			return err
+		if finding.Severity >= failSeverity {
+			failed++
 		}
-	} else {
-		withStats := shouldRenderWithStats(planNodes, parsedMode)
-		renderDef = withStatsToRenderDefMap[withStats]
 	}
-
-	s, err := renderTreeImpl(planNodes, renderTreeOptions{
-		renderDef:                  renderDef,
-		layout:                     parsedLayout,
-		printSections:              printSections,
-		showScalarVars:             *showScalarVars,
-		resolveScalarVars:          *resolveScalarVars,
-		resolveScalarVarsRecursive: *resolveScalarVarsRecursive,
-		disallowUnknownStats:       *disallowUnknownStats,
-		inlineStats:                *inlineStats,
-		plantreeOptions:            opts,
-	})
-	if err != nil {
-		return err
+	if failed > 0 {
+		return fmt.Errorf("lint: %d finding(s) at or above %s severity", failed, failSeverity)
 	}
-
-	_, err = io.WriteString(stdout, s)
-	return err
+	return nil
 }
 
 type renderTreeOptions struct {
@@ -484,46 +669,84 @@ type renderTreeOptions struct {
 	resolveScalarVarsRecursive bool
 	disallowUnknownStats       bool
 	inlineStats                bool
+	formatPredicate            predicateFormatter
+	outputSections             []outputSection
+	style                      tableStyle
+	markdown                   bool
+	summary                    bool
 	plantreeOptions            []plantree.Option
 }
 
 func renderTreeImpl(planNodes []*sppb.PlanNode, renderOpts renderTreeOptions) (string, error) {
-	plantreeOptions := slices.Clone(renderOpts.plantreeOptions)
-	plantreeOptions = append(plantreeOptions,
-		plantree.WithQueryPlanOptions(
-			spannerplan.WithInlineStatsFunc(inlineStatsFuncFromTableRenderDef(renderOpts.disallowUnknownStats, renderOpts.renderDef, renderOpts.inlineStats)),
-		))
-
-	qp, err := spannerplan.New(planNodes)
-	if err != nil {
+	var b strings.Builder
+	if err := renderTreeTo(&b, planNodes, renderOpts); err != nil {
 		return "", err
 	}
+	return b.String(), nil
+}
 
-	rows, err := plantree.ProcessPlan(qp, plantreeOptions...)
+// renderTreeTo writes the output sections of planNodes to w as printResultTo does.
+func renderTreeTo(w io.Writer, planNodes []*sppb.PlanNode, renderOpts renderTreeOptions) error {
+	qp, rows, renderDef, err := processTableRows(planNodes, renderOpts)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	s, err := printResult(rows, printResultOptions{
-		renderDef: tableRenderDef{
-			Columns: lo.Filter(renderOpts.renderDef.Columns, func(def columnRenderDef, index int) bool {
-				return !def.shouldInline(renderOpts.inlineStats)
-			}),
-		},
+	return printResultTo(w, rows, printResultOptions{
+		header:                     dmlHeader(qp),
+		renderDef:                  renderDef,
 		layout:                     renderOpts.layout,
 		printSections:              renderOpts.printSections,
 		showScalarVars:             renderOpts.showScalarVars,
 		resolveScalarVars:          renderOpts.resolveScalarVars,
 		resolveScalarVarsRecursive: renderOpts.resolveScalarVarsRecursive,
+		formatPredicate:            renderOpts.formatPredicate,
+		outputSections:             renderOpts.outputSections,
+		style:                      renderOpts.style,
+		markdown:                   renderOpts.markdown,
+		summary:                    renderOpts.summary,
 	})
+}
+
+// processTableRows returns the rows of planNodes and the columns of the table, without the
+// columns rendered inline and the DropIfEmpty columns that are empty on every row.
+func processTableRows(planNodes []*sppb.PlanNode, renderOpts renderTreeOptions) (*spannerplan.QueryPlan, []plantree.RowWithPredicates, tableRenderDef, error) {
+	qp, err := spannerplan.New(planNodes)
 	if err != nil {
-		return "", err
+		return nil, nil, tableRenderDef{}, err
 	}
 
-	return s, nil
+	// Inline stats see only their node, so they get the root latency for .RootLatency from here.
+	// An extraction error is reported by ProcessPlan.
+	var rootLatency stats.ExecutionStatsValue
+	if rootStats, err := stats.Extract(qp.GetNodeByChildLink(nil), renderOpts.disallowUnknownStats); err == nil {
+		rootLatency = rootStats.Latency
+	}
+
+	plantreeOptions := slices.Clone(renderOpts.plantreeOptions)
+	plantreeOptions = append(plantreeOptions,
+		plantree.IncludePlanNode(),
+		plantree.WithLatencyRank(),
+		plantree.WithRootLatency(),
+		plantree.WithIndexPaths(),
+		plantree.WithQueryPlanOptions(
+			spannerplan.WithInlineStatsFunc(inlineStatsFuncFromTableRenderDef(renderOpts.disallowUnknownStats, renderOpts.renderDef, renderOpts.inlineStats, rootLatency)),
+		))
+
+	rows, err := plantree.ProcessPlan(qp, plantreeOptions...)
+	if err != nil {
+		return nil, nil, tableRenderDef{}, err
+	}
+
+	renderDef := tableRenderDef{
+		Columns: lo.Filter(renderOpts.renderDef.Columns, func(def columnRenderDef, index int) bool {
+			return !def.shouldInline(renderOpts.inlineStats) && !(def.DropIfEmpty && columnIsEmpty(def, rows))
+		}),
+	}
+	return qp, rows, renderDef, nil
 }
 
-func inlineStatsFuncFromTableRenderDef(disallowUnknownStats bool, renderDef tableRenderDef, inlineStats bool) func(node *sppb.PlanNode) []string {
+func inlineStatsFuncFromTableRenderDef(disallowUnknownStats bool, renderDef tableRenderDef, inlineStats bool, rootLatency stats.ExecutionStatsValue) func(node *sppb.PlanNode) []string {
 	return func(node *sppb.PlanNode) []string {
 		executionStats, err := stats.Extract(node, disallowUnknownStats)
 		if err != nil {
@@ -531,7 +754,7 @@ func inlineStatsFuncFromTableRenderDef(disallowUnknownStats bool, renderDef tabl
 			return nil
 		}
 
-		row := plantree.RowWithPredicates{ExecutionStats: *executionStats}
+		row := plantree.RowWithPredicates{ExecutionStats: *executionStats, RootLatency: rootLatency, Node: node}
 
 		var result []string
 		for _, def := range renderDef.Columns {
@@ -564,6 +787,102 @@ func shouldRenderWithStats(qp []*sppb.PlanNode, parsedMode explainMode) bool {
 	}
 }
 
+// gzipMagic is the first two bytes of a gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// readInput reads all of r, failing once it has read more than limit bytes so that an
+// accidentally huge input is never buffered in full, and returns it through [decompressInput].
+// A limit of 0 reads without a limit.
+func readInput(r io.Reader, limit int64) ([]byte, error) {
+	b, err := readLimited(r, limit)
+	if err != nil {
+		return nil, err
+	}
+	return decompressInput(b, limit)
+}
+
+// decompressInput returns b, decompressed when it starts with the gzip magic bytes. It fails when
+// the result is longer than limit, which applies to the decompressed bytes so that a small
+// archive cannot expand without bound. A limit of 0 accepts any length.
+func decompressInput(b []byte, limit int64) ([]byte, error) {
+	if bytes.HasPrefix(b, gzipMagic) {
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip input: %w", err)
+		}
+		defer zr.Close()
+		if b, err = io.ReadAll(limitReader(zr, limit)); err != nil {
+			return nil, fmt.Errorf("invalid gzip input: %w", err)
+		}
+	}
+	if err := checkInputSize(b, limit); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// readLimited reads all of r, failing once it has read more than limit bytes. A limit of 0
+// reads without a limit.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	b, err := io.ReadAll(limitReader(r, limit))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInputSize(b, limit); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// limitReader returns r limited to one byte past limit, so that [checkInputSize] can tell input
+// longer than limit apart, or r itself for a limit of 0.
+func limitReader(r io.Reader, limit int64) io.Reader {
+	if limit == 0 {
+		return r
+	}
+	return io.LimitReader(r, limit+1)
+}
+
+// checkInputSize fails when b is longer than a limit other than 0.
+func checkInputSize(b []byte, limit int64) error {
+	if limit > 0 && int64(len(b)) > limit {
+		return fmt.Errorf("input is larger than %s; raise the limit with --max-input-bytes", stats.FormatBytes(limit))
+	}
+	return nil
+}
+
+// attachStatsFrom overlays the execution stats of the capture in the file at path onto planNodes.
+// The file is decoded like AUTO --input-format and must hold a structurally identical plan.
+func attachStatsFrom(planNodes []*sppb.PlanNode, path string, maxInputBytes int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := readInput(f, maxInputBytes)
+	if err != nil {
+		return fmt.Errorf("cannot read --stats-from file %s: %w", path, err)
+	}
+	statsQS, err := decodeSingleInput(b)
+	if err != nil {
+		return fmt.Errorf("invalid input in --stats-from file %s: %w", path, err)
+	}
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	if err := qp.AttachStats(statsQS.GetQueryPlan().GetPlanNodes()); err != nil {
+		return fmt.Errorf("cannot attach stats from %s: %w", path, err)
+	}
+	return nil
+}
+
+// autoModeHidesStats reports whether AUTO mode, which only looks at the root node, would render
+// a plan with execution stats on other nodes as PLAN.
+func autoModeHidesStats(planNodes []*sppb.PlanNode, parsedMode explainMode) bool {
+	return parsedMode == explainModeAuto && !spannerplan.HasStats(planNodes) && spannerplan.HasAnyStats(planNodes)
+}
+
 func unmarshalAlign(t *tw.Align, bytes []byte) error {
 	var s string
 	if err := yaml.Unmarshal(bytes, &s); err != nil {
@@ -591,6 +910,7 @@ func plainColumnRenderDefsToTableRenderDef(defs []plainColumnRenderDef) (tableRe
 			Name:      def.Name,
 			Alignment: def.Alignment,
 			Inline:    def.Inline,
+			Summary:   def.Summary,
 		})
 	}
 	return tdef, nil
@@ -618,6 +938,92 @@ func customColumnListToTableRenderDef(customColumns []string) (tableRenderDef, e
 	return plainColumnRenderDefsToTableRenderDef(defs)
 }
 
+// fixedWidth is one NAME:WIDTH entry of --fixed-widths.
+type fixedWidth struct {
+	name  string
+	width int
+}
+
+func parseFixedWidths(s string) ([]fixedWidth, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var widths []fixedWidth
+	for _, entry := range strings.Split(s, ",") {
+		name, widthStr, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid entry %q, expect NAME:WIDTH", entry)
+		}
+		width, err := strconv.Atoi(strings.TrimSpace(widthStr))
+		if err != nil || width < 1 {
+			return nil, fmt.Errorf("invalid width in %q, expect a positive integer", entry)
+		}
+		widths = append(widths, fixedWidth{name: name, width: width})
+	}
+	return widths, nil
+}
+
+func applyFixedWidths(renderDef tableRenderDef, widths []fixedWidth) (tableRenderDef, error) {
+	if len(widths) == 0 {
+		return renderDef, nil
+	}
+
+	columns := slices.Clone(renderDef.Columns)
+	for _, w := range widths {
+		i := slices.IndexFunc(columns, func(def columnRenderDef) bool { return def.Name == w.name })
+		if i < 0 {
+			return tableRenderDef{}, fmt.Errorf("unknown column in --fixed-widths: %q", w.name)
+		}
+		columns[i].Width = w.width
+	}
+	return tableRenderDef{Columns: columns}, nil
+}
+
+// parseColumnOrder parses the comma-separated column names of --column-order.
+func parseColumnOrder(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var names []string
+	for _, entry := range strings.Split(s, ",") {
+		name := strings.TrimSpace(entry)
+		if name == "" {
+			return nil, fmt.Errorf("empty column name in %q", s)
+		}
+		if slices.Contains(names, name) {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// applyColumnOrder moves the named columns to the front in the given order. The unnamed columns
+// keep their relative order after them.
+func applyColumnOrder(renderDef tableRenderDef, names []string) (tableRenderDef, error) {
+	if len(names) == 0 {
+		return renderDef, nil
+	}
+
+	columns := make([]columnRenderDef, 0, len(renderDef.Columns))
+	for _, name := range names {
+		i := slices.IndexFunc(renderDef.Columns, func(def columnRenderDef) bool { return def.Name == name })
+		if i < 0 {
+			return tableRenderDef{}, fmt.Errorf("unknown column in --column-order: %q", name)
+		}
+		columns = append(columns, renderDef.Columns[i])
+	}
+	for _, def := range renderDef.Columns {
+		if !slices.Contains(names, def.Name) {
+			columns = append(columns, def)
+		}
+	}
+	return tableRenderDef{Columns: columns}, nil
+}
+
 func parseInlineType(s string) (inlineType, error) {
 	switch i := inlineType(strings.ToUpper(s)); i {
 	case inlineTypeNever, inlineTypeCan, inlineTypeAlways:
@@ -628,42 +1034,168 @@ func parseInlineType(s string) (inlineType, error) {
 }
 
 type printResultOptions struct {
+	// header is written on its own line directly above the table section. Empty writes nothing.
+	header                     string
 	renderDef                  tableRenderDef
 	layout                     layout
 	printSections              PrintSections
 	showScalarVars             bool
 	resolveScalarVars          bool
 	resolveScalarVarsRecursive bool
+	formatPredicate            predicateFormatter
+	outputSections             []outputSection
+	style                      tableStyle
+	// markdown renders the table as a Markdown table and the appendix as a fenced code block.
+	markdown bool
+	// summary appends the footer row of summaryFooter to the table.
+	summary bool
+}
+
+// tableStyle holds presentation settings that do not change table content.
+type tableStyle struct {
+	border asciitable.Border
+	// rowStyle returns the ANSI SGR parameters for a row. Nil leaves every row unstyled.
+	rowStyle func(row plantree.RowWithPredicates) string
+	// cellStyle returns the ANSI SGR parameters for the cell of a row in the named column, which
+	// follow those of rowStyle. Nil leaves every cell with its row style.
+	cellStyle func(row plantree.RowWithPredicates, column string) string
 }
 
 func printResult(rows []plantree.RowWithPredicates, printOpts printResultOptions) (string, error) {
 	var b strings.Builder
+	if err := printResultTo(&b, rows, printOpts); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// printResultTo writes the output sections of rows to w, separated by blank lines. Each section,
+// such as the table or the predicates appendix, is written as soon as it is rendered, so the
+// output is never held in memory as a whole. A section that fails to render stops the output
+// after the sections before it.
+func printResultTo(w io.Writer, rows []plantree.RowWithPredicates, printOpts printResultOptions) error {
+	sections := printOpts.outputSections
+	if sections == nil {
+		sections = defaultOutputSections
+	}
 
-	if len(rows) > 0 && len(printOpts.renderDef.Columns) > 0 {
-		tablePart, err := renderTablePartForLayout(printOpts.renderDef, rows, printOpts.layout)
+	wrote := false
+	for _, section := range sections {
+		writeSection, ok := outputSectionWriters[section]
+		if !ok {
+			return fmt.Errorf("unsupported output section: %s", section)
+		}
+		part, err := writeSection(rows, printOpts)
 		if err != nil {
-			return "", err
+			return err
 		}
-		b.WriteString(tablePart)
+		if part == "" {
+			continue
+		}
+		if wrote {
+			part = "\n" + part
+		}
+		if _, err := io.WriteString(w, part); err != nil {
+			return err
+		}
+		wrote = true
 	}
+	return nil
+}
+
+// outputSection is one top-level block of the rendered output, selected by --sections.
+type outputSection string
+
+const (
+	outputSectionTable    outputSection = "table"
+	outputSectionAppendix outputSection = "appendix"
+)
+
+var defaultOutputSections = []outputSection{outputSectionTable, outputSectionAppendix}
+
+// outputSectionWriter renders one output section. An empty result omits the section and its separator.
+type outputSectionWriter func(rows []plantree.RowWithPredicates, printOpts printResultOptions) (string, error)
+
+var outputSectionWriters = map[outputSection]outputSectionWriter{
+	outputSectionTable:    writeTableSection,
+	outputSectionAppendix: writeAppendixSection,
+}
+
+func parseOutputSections(s string) ([]outputSection, error) {
+	sections := []outputSection{}
+	if strings.TrimSpace(s) == "" {
+		return sections, nil
+	}
+	for _, name := range strings.Split(s, ",") {
+		section := outputSection(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := outputSectionWriters[section]; !ok {
+			return nil, fmt.Errorf("unknown section: %q. Must be one of %s", name, strings.Join(outputSectionNames(), ", "))
+		}
+		if slices.Contains(sections, section) {
+			return nil, fmt.Errorf("duplicate section: %q", name)
+		}
+		sections = append(sections, section)
+	}
+	return sections, nil
+}
+
+func outputSectionNames() []string {
+	names := make([]string, 0, len(outputSectionWriters))
+	for section := range outputSectionWriters {
+		names = append(names, string(section))
+	}
+	slices.Sort(names)
+	return names
+}
+
+func writeTableSection(rows []plantree.RowWithPredicates, printOpts printResultOptions) (string, error) {
+	if len(rows) == 0 || len(printOpts.renderDef.Columns) == 0 {
+		return "", nil
+	}
+	if printOpts.markdown {
+		table, err := renderMarkdownTable(printOpts.renderDef, rows, printOpts.summary)
+		if err != nil || printOpts.header == "" {
+			return table, err
+		}
+		// A table cannot interrupt a paragraph, so a blank line separates the header.
+		return printOpts.header + "\n\n" + table, nil
+	}
+	table, err := renderTablePartForLayout(printOpts.renderDef, rows, printOpts.layout, printOpts.style, printOpts.summary)
+	if err != nil || printOpts.header == "" {
+		return table, err
+	}
+	return printOpts.header + "\n" + table, nil
+}
 
+// dmlHeader returns a line such as "DELETE on MutationTest" summarizing the mutation of a DML
+// plan, so the operation is visible before the read sub-plan. It returns "" for query plans.
+func dmlHeader(qp *spannerplan.QueryPlan) string {
+	isDML, operation := qp.DMLInfo()
+	if !isDML {
+		return ""
+	}
+	if operation == "" {
+		operation = "Apply Mutations"
+	}
+	if table := qp.GetNodeByChildLink(nil).GetMetadata().GetFields()["table"].GetStringValue(); table != "" {
+		return operation + " on " + table
+	}
+	return operation
+}
+
+func writeAppendixSection(rows []plantree.RowWithPredicates, printOpts printResultOptions) (string, error) {
 	sections := scalarAppendixSections(printOpts.printSections)
-	appendixPart, err := scalarappendix.Render(rows, scalarappendix.Options{
+	appendix, err := scalarappendix.Render(rows, scalarappendix.Options{
 		Sections:                   &sections,
 		ShowScalarVars:             printOpts.showScalarVars,
 		ResolveScalarVars:          printOpts.resolveScalarVars,
 		ResolveScalarVarsRecursive: printOpts.resolveScalarVarsRecursive,
+		FormatPredicate:            printOpts.formatPredicate,
 	})
-	if err != nil {
-		return "", err
+	if err != nil || !printOpts.markdown || appendix == "" {
+		return appendix, err
 	}
-	if appendixPart != "" {
-		if b.Len() > 0 {
-			b.WriteString("\n")
-		}
-		b.WriteString(appendixPart)
-	}
-	return b.String(), nil
+	return markdownCodeBlock(appendix), nil
 }
 
 func scalarAppendixSections(sections PrintSections) scalarappendix.Sections {
@@ -682,42 +1214,109 @@ func printSectionsFromScalarAppendix(sections scalarappendix.Sections) PrintSect
 	return converted
 }
 
+// predicateFormatter formats one predicate line in the predicates appendix.
+// A nil predicateFormatter keeps the default "Type: Description" lines.
+type predicateFormatter func(row plantree.RowWithPredicates, link plantree.ScalarChildLink) (string, error)
+
+// predicateTemplateData is the data passed to --predicate-template.
+type predicateTemplateData struct {
+	// NodeID is the displayed ID of the row owning the predicate.
+	NodeID int32
+	// Type is the predicate child-link type, such as "Residual Condition".
+	Type string
+	// Description is the predicate expression.
+	Description string
+	// Variable is the child-link variable, when Spanner provides one.
+	Variable string
+	// ChildIndex is the PlanNode index of the predicate scalar node.
+	ChildIndex int32
+}
+
+func parsePredicateTemplate(tmplText string) (predicateFormatter, error) {
+	tmpl, err := template.New("predicate").Parse(tmplText)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(row plantree.RowWithPredicates, link plantree.ScalarChildLink) (string, error) {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, predicateTemplateData{
+			NodeID:      row.DisplayID(),
+			Type:        link.Type,
+			Description: link.Description,
+			Variable:    link.Variable,
+			ChildIndex:  link.ChildIndex,
+		}); err != nil {
+			return "", err
+		}
+		return sb.String(), nil
+	}, nil
+}
+
+// truncatePredicates returns a predicateFormatter that cuts each line of the predicates formatted
+// by format to width display columns, ending a cut line with "…". A nil format formats the
+// default "Type: Description" lines.
+func truncatePredicates(format predicateFormatter, width int) predicateFormatter {
+	return func(row plantree.RowWithPredicates, link plantree.ScalarChildLink) (string, error) {
+		line := fmt.Sprintf("%s: %s", link.Type, link.Description)
+		if format != nil {
+			var err error
+			if line, err = format(row, link); err != nil {
+				return "", err
+			}
+		}
+		lines := strings.Split(line, "\n")
+		for i, l := range lines {
+			if tabwrap.StringWidth(l) > width {
+				lines[i] = tabwrap.Truncate(l, width, "…")
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+}
+
 type renderedTableRow []string
 
-func renderTablePartForLayout(renderDef tableRenderDef, rows []plantree.RowWithPredicates, tableLayout layout) (string, error) {
+func renderTablePartForLayout(renderDef tableRenderDef, rows []plantree.RowWithPredicates, tableLayout layout, style tableStyle, summary bool) (string, error) {
 	switch tableLayout {
 	case "", layoutTable:
-		return renderTablePart(renderDef, rows)
+		return renderTablePart(renderDef, rows, style, summary)
 	case layoutTableless:
-		return renderTablelessPart(renderDef, rows)
+		return renderTablelessPart(renderDef, rows, style, summary)
 	default:
 		return "", fmt.Errorf("unsupported layout: %s", tableLayout)
 	}
 }
 
-func renderTablePart(renderDef tableRenderDef, rows []plantree.RowWithPredicates) (string, error) {
+func renderTablePart(renderDef tableRenderDef, rows []plantree.RowWithPredicates, style tableStyle, summary bool) (string, error) {
 	tableRows, err := renderedRows(renderDef, rows)
 	if err != nil {
 		return "", err
 	}
 
-	spec, err := renderedTableSpec(renderDef)
+	spec, err := renderedTableSpec(renderDef, rows, style)
 	if err != nil {
 		return "", err
 	}
+	if summary {
+		spec.Footer = summaryFooter(renderDef, tableRows)
+	}
 	return asciitable.RenderTable(tableRows, spec)
 }
 
-func renderTablelessPart(renderDef tableRenderDef, rows []plantree.RowWithPredicates) (string, error) {
+func renderTablelessPart(renderDef tableRenderDef, rows []plantree.RowWithPredicates, style tableStyle, summary bool) (string, error) {
 	tableRows, err := renderedRows(renderDef, rows)
 	if err != nil {
 		return "", err
 	}
 
-	spec, err := renderedTableSpec(renderDef)
+	spec, err := renderedTableSpec(renderDef, rows, style)
 	if err != nil {
 		return "", err
 	}
+	if summary {
+		spec.Footer = summaryFooter(renderDef, tableRows)
+	}
 	return asciitable.RenderTableless(tableRows, spec)
 }
 
@@ -733,9 +1332,16 @@ func renderedRows(renderDef tableRenderDef, rows []plantree.RowWithPredicates) (
 	return tableRows, nil
 }
 
-func renderedTableSpec(renderDef tableRenderDef) (asciitable.TableSpec[renderedTableRow], error) {
+func renderedTableSpec(renderDef tableRenderDef, rows []plantree.RowWithPredicates, style tableStyle) (asciitable.TableSpec[renderedTableRow], error) {
 	spec := asciitable.TableSpec[renderedTableRow]{
 		Columns: make([]asciitable.Column[renderedTableRow], 0, len(renderDef.Columns)),
+		Border:  style.border,
+	}
+	if style.rowStyle != nil {
+		// renderedRows keeps one rendered row per plan row, so the index identifies the plan row.
+		spec.RowStyle = func(_ renderedTableRow, index int) string {
+			return style.rowStyle(rows[index])
+		}
 	}
 	for i, col := range renderDef.Columns {
 		alignment, err := tableAlignment(col.Alignment)
@@ -743,16 +1349,24 @@ func renderedTableSpec(renderDef tableRenderDef) (asciitable.TableSpec[renderedT
 			return asciitable.TableSpec[renderedTableRow]{}, fmt.Errorf("column %d (%q): %w", i, col.Name, err)
 		}
 		index := i
-		spec.Columns = append(spec.Columns, asciitable.Column[renderedTableRow]{
+		column := asciitable.Column[renderedTableRow]{
 			Header:    col.Name,
 			Alignment: alignment,
+			Width:     col.Width,
 			Cell: func(row renderedTableRow, _ int) string {
 				if index >= len(row) {
 					return ""
 				}
 				return row[index]
 			},
-		})
+		}
+		if style.cellStyle != nil {
+			name := col.Name
+			column.Style = func(_ renderedTableRow, rowIndex int) string {
+				return style.cellStyle(rows[rowIndex], name)
+			}
+		}
+		spec.Columns = append(spec.Columns, column)
 	}
 	return spec, nil
 }
@@ -2,8 +2,12 @@ package impl
 
 import (
 	"bytes"
+	"compress/gzip"
 	_ "embed"
 	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -15,6 +19,7 @@ import (
 	"github.com/apstndb/spannerplan"
 	"github.com/apstndb/spannerplan/asciitable"
 	"github.com/apstndb/spannerplan/plantree"
+	"github.com/apstndb/spannerplan/stats"
 )
 
 func sliceOf[T any](vs ...T) []T {
@@ -64,6 +69,63 @@ func Test_customColumnListToTableRenderDef(t *testing.T) {
 	}
 }
 
+func TestTemplateMapFunc_Div(t *testing.T) {
+	row := plantree.RowWithPredicates{ExecutionStats: stats.ExecutionStats{
+		Rows:             stats.ExecutionStatsValue{Total: "4", Unit: "rows"},
+		Latency:          stats.ExecutionStatsValue{Total: "2", Unit: "msecs"},
+		ExecutionSummary: stats.ExecutionStatsSummary{NumExecutions: "0"},
+	}}
+
+	tests := []struct {
+		desc    string
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{
+			desc: "duration by float",
+			tmpl: `{{printf "%.4f" (div .ExecutionStats.LatencyDuration .ExecutionStats.Rows.Float64)}}`,
+			want: "0.0005",
+		},
+		{
+			desc: "integer by float",
+			tmpl: `{{div .ExecutionStats.LatencyDuration.Microseconds .ExecutionStats.Rows.Float64}}`,
+			want: "500",
+		},
+		{
+			desc:    "division by zero",
+			tmpl:    `{{div .ExecutionStats.Rows.Float64 .ExecutionStats.ExecutionSummary.Executions}}`,
+			wantErr: true,
+		},
+		{
+			desc:    "unparsable value",
+			tmpl:    `{{div .ExecutionStats.CpuTimeDuration 1.0}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mapFunc, err := templateMapFunc("test", tt.tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := mapFunc(row)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("mapFunc() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mapFunc() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("mapFunc() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestTableAlignmentTreatsDefaultAsLeft(t *testing.T) {
 	got, err := tableAlignment(tw.AlignDefault)
 	if err != nil {
@@ -321,6 +383,7 @@ Predicates(identified by ID):
 			input:     deleteYAML,
 			renderDef: withStatsToRenderDefMap[false],
 			want: heredoc.Doc(`
+DELETE on MutationTest
 +----+----------------------------------------------------------------------------------+
 | ID | Operator                                                                         |
 +----+----------------------------------------------------------------------------------+
@@ -342,6 +405,7 @@ Predicates(identified by ID):
 				spannerplan.WithTargetMetadataFormat(spannerplan.TargetMetadataFormatRaw),
 			)),
 			want: heredoc.Doc(`
+DELETE on MutationTest
 +----+--------------------------------------------------------------------------------------------------------------+
 | ID | Operator                                                                                                     |
 +----+--------------------------------------------------------------------------------------------------------------+
@@ -627,6 +691,44 @@ func TestPrintResult_UnsupportedPrintSection(t *testing.T) {
 	}
 }
 
+// recordingWriter keeps each Write call separately.
+type recordingWriter struct {
+	writes []string
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestPrintResultTo(t *testing.T) {
+	stats, _, err := spannerplan.ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderOpts := renderTreeOptions{
+		renderDef:     withStatsToRenderDefMap[false],
+		printSections: PrintSections{PrintPredicates},
+	}
+
+	want, err := renderTreeImpl(stats.GetQueryPlan().GetPlanNodes(), renderOpts)
+	if err != nil {
+		t.Fatalf("renderTreeImpl() error = %v", err)
+	}
+	var w recordingWriter
+	if err := renderTreeTo(&w, stats.GetQueryPlan().GetPlanNodes(), renderOpts); err != nil {
+		t.Fatalf("renderTreeTo() error = %v", err)
+	}
+	if got := strings.Join(w.writes, ""); got != want {
+		t.Errorf("renderTreeTo() output differs from renderTreeImpl():\n%s\nwant:\n%s", got, want)
+	}
+	// The table and the predicates appendix are written separately, the appendix after the blank
+	// line that separates them.
+	if len(w.writes) != 2 || !strings.HasPrefix(w.writes[0], "+-----+") || !strings.HasPrefix(w.writes[1], "\nPredicates(identified by ID):") {
+		t.Errorf("renderTreeTo() writes = %q, want the table and then the appendix", w.writes)
+	}
+}
+
 func TestParsePrintSections(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -830,6 +932,26 @@ func TestRun_UsageErrors(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:        "advisories with lint",
+			args:        []string{"-advisories", "-lint"},
+			wantErrText: "--advisories cannot be combined with --lint, --interactive, --search, --diff or --format",
+		},
+		{
+			name:        "shape with format",
+			args:        []string{"-shape", "-format", "json"},
+			wantErrText: "--shape cannot be combined with --lint, --interactive, --search or --format",
+		},
+		{
+			name:        "negative truncate-predicates",
+			args:        []string{"-truncate-predicates", "-1"},
+			wantErrText: "width cannot be negative: -1",
+		},
+		{
+			name:        "dump-rows with search",
+			args:        []string{"-dump-rows", "-search", "Scan"},
+			wantErrText: "--dump-rows cannot be combined with --lint, --interactive, --search, --shape or --format",
+		},
 		{
 			name:        "custom-column and custom-file are mutually exclusive",
 			args:        []string{"-custom-column", `{"name":"ID","template":"{{.FormatID}}"}`, "-custom-file", "custom.yaml"},
@@ -863,6 +985,185 @@ func TestRun_UsageErrors(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:        "invalid predicate-template",
+			args:        []string{"-predicate-template", "{{.Type"},
+			wantErrText: "unclosed action",
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -predicate-template flag:") {
+					t.Fatalf("stderr = %q, want invalid predicate-template message", stderr)
+				}
+			},
+		},
+		{
+			name:        "invalid format",
+			args:        []string{"-format", "xml"},
+			wantErrText: `unknown output format: "xml"`,
+		},
+		{
+			name:        "diff with lint",
+			args:        []string{"-diff", "before.yaml", "-lint"},
+			wantErrText: "--diff cannot be combined with --lint, --interactive, --search, --shape, --dump-rows or --format, except --format=json with --profile-diff-summary",
+		},
+		{
+			name:        "diff with json format",
+			args:        []string{"-diff", "before.yaml", "-format", "json"},
+			wantErrText: "--diff cannot be combined with --lint, --interactive, --search, --shape, --dump-rows or --format, except --format=json with --profile-diff-summary",
+		},
+		{
+			name:        "profile diff summary without diff",
+			args:        []string{"-profile-diff-summary"},
+			wantErrText: "--profile-diff-summary requires --diff",
+		},
+		{
+			name:        "summary with json format",
+			args:        []string{"-summary", "-format", "json"},
+			wantErrText: "--summary requires --format=text or --format=markdown",
+		},
+		{
+			name:        "query stats with csv format",
+			args:        []string{"-query-stats", "-format", "csv"},
+			wantErrText: "--query-stats requires --format=text or --format=markdown",
+		},
+		{
+			name:        "query stats with search",
+			args:        []string{"-query-stats", "-search", "Scan"},
+			wantErrText: "--query-stats cannot be combined with --lint, --interactive, --search, --dump-rows or --diff",
+		},
+		{
+			name:        "json-compact without json format",
+			args:        []string{"-json-compact"},
+			wantErrText: "--json-compact requires --format=json",
+		},
+		{
+			name:        "scalar-links without a graph format",
+			args:        []string{"-scalar-links"},
+			wantErrText: "--scalar-links requires --format=dot or --format=mermaid",
+		},
+		{
+			name:        "show-dataflow with mermaid",
+			args:        []string{"-show-dataflow", "-format", "mermaid"},
+			wantErrText: "--show-dataflow requires --format=dot",
+		},
+		{
+			name:        "markdown format with tableless layout",
+			args:        []string{"-format", "markdown", "-layout", "tableless"},
+			wantErrText: "--format=markdown cannot be combined with --layout=tableless",
+		},
+		{
+			name:        "json format with lint",
+			args:        []string{"-format", "json", "-lint"},
+			wantErrText: "--format cannot be combined with --lint or --interactive",
+		},
+		{
+			name:        "invalid id-template",
+			args:        []string{"-id-template", "{{.Unknown}}"},
+			wantErrText: "can't evaluate field Unknown",
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -id-template flag:") {
+					t.Fatalf("stderr = %q, want invalid id-template message", stderr)
+				}
+			},
+		},
+		{
+			name:        "invalid search",
+			args:        []string{"-search", "Scan("},
+			wantErrText: "missing closing )",
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -search flag:") {
+					t.Fatalf("stderr = %q, want invalid search message", stderr)
+				}
+			},
+		},
+		{
+			name:        "best-effort with lint",
+			args:        []string{"-best-effort", "-lint"},
+			wantErrText: "--best-effort cannot be combined with --lint",
+		},
+		{
+			name:        "search with lint",
+			args:        []string{"-search", "Scan", "-lint"},
+			wantErrText: "--search cannot be combined with --lint, --interactive or --format",
+		},
+		{
+			name:        "heat-threshold above 100",
+			args:        []string{"-heat", "-heat-threshold", "150"},
+			wantErrText: "must be between 0 and 100, but: 150",
+		},
+		{
+			name:        "invalid max-input-bytes",
+			args:        []string{"-max-input-bytes", "10 parsecs"},
+			wantErrText: `invalid byte quantity "10 parsecs": unknown unit "parsecs"`,
+		},
+		{
+			name:        "invalid fixed-widths",
+			args:        []string{"-fixed-widths", "ID:0"},
+			wantErrText: `invalid width in "ID:0"`,
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -fixed-widths flag:") {
+					t.Fatalf("stderr = %q, want invalid fixed-widths message", stderr)
+				}
+			},
+		},
+		{
+			name:        "invalid color",
+			args:        []string{"-color", "sometimes"},
+			wantErrText: `unknown color mode: "sometimes"`,
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -color flag:") {
+					t.Fatalf("stderr = %q, want invalid color message", stderr)
+				}
+			},
+		},
+		{
+			name:        "invalid input format",
+			args:        []string{"-input-format", "xml"},
+			wantErrText: `unknown input format: "xml"`,
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -input-format flag:") {
+					t.Fatalf("stderr = %q, want invalid input format message", stderr)
+				}
+			},
+		},
+		{
+			name:        "invalid output encoding",
+			args:        []string{"-output-encoding", "utf16"},
+			wantErrText: `unknown output encoding: "utf16"`,
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -output-encoding flag:") {
+					t.Fatalf("stderr = %q, want invalid output encoding message", stderr)
+				}
+			},
+		},
+		{
+			name:        "invalid sections",
+			args:        []string{"-sections", "table,broken"},
+			wantErrText: `unknown section: "broken"`,
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -sections flag:") {
+					t.Fatalf("stderr = %q, want invalid sections message", stderr)
+				}
+			},
+		},
+		{
+			name:        "invalid lint-severity",
+			args:        []string{"-lint", "-lint-severity", "broken"},
+			wantErrText: "invalid input: broken",
+			postCheck: func(t *testing.T, stderr string, err error) {
+				t.Helper()
+				if !strings.Contains(stderr, "Invalid value for -lint-severity flag:") {
+					t.Fatalf("stderr = %q, want invalid lint-severity message", stderr)
+				}
+			},
+		},
 		{
 			name:        "invalid hanging-indent",
 			args:        []string{"-hanging-indent=broken"},
@@ -927,6 +1228,151 @@ func TestRun_TablelessShortcutMatchesLayout(t *testing.T) {
 	}
 }
 
+func TestRun_StatsFrom(t *testing.T) {
+	t.Parallel()
+
+	profilePath := filepath.Join(t.TempDir(), "profile.yaml")
+	if err := os.WriteFile(profilePath, dcaProfileYAML, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mutationPath := filepath.Join(t.TempDir(), "delete.yaml")
+	if err := os.WriteFile(mutationPath, deleteYAML, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var want, stderr bytes.Buffer
+	if err := run(nil, bytes.NewReader(dcaProfileYAML), &want, &stderr); err != nil {
+		t.Fatalf("run(profile) error = %v", err)
+	}
+	var got bytes.Buffer
+	if err := run([]string{"-stats-from", profilePath}, bytes.NewReader(dcaYAML), &got, &stderr); err != nil {
+		t.Fatalf("run(-stats-from) error = %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("run(-stats-from) output differs from rendering the profile:\n%s\nwant:\n%s", got.String(), want.String())
+	}
+
+	err := run([]string{"-stats-from", mutationPath}, bytes.NewReader(dcaYAML), &got, &stderr)
+	if wantErr := `node 0 (Distributed Union) and node 0 (Apply Mutations) differ: display name "Distributed Union" != "Apply Mutations"`; err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("run(-stats-from mismatched) error = %v, want substring %q", err, wantErr)
+	}
+}
+
+func TestRun_MaxInputBytes(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"-max-input-bytes", "1KiB"}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if wantErr := "input is larger than 1 KiB; raise the limit with --max-input-bytes"; err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("run(-max-input-bytes=1KiB) error = %v, want substring %q", err, wantErr)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("run(-max-input-bytes=1KiB) wrote output:\n%s", stdout.String())
+	}
+
+	for _, limit := range []string{strconv.Itoa(len(dcaYAML)), "0"} {
+		stdout.Reset()
+		if err := run([]string{"-max-input-bytes", limit}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+			t.Errorf("run(-max-input-bytes=%s) error = %v", limit, err)
+		}
+	}
+
+	profilePath := filepath.Join(t.TempDir(), "profile.yaml")
+	if err := os.WriteFile(profilePath, dcaProfileYAML, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = run([]string{"-max-input-bytes", strconv.Itoa(len(dcaYAML)), "-stats-from", profilePath}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if wantErr := "cannot read --stats-from file " + profilePath + ": input is larger than"; err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("run(-stats-from over the limit) error = %v, want substring %q", err, wantErr)
+	}
+}
+
+func TestRun_GzipInput(t *testing.T) {
+	t.Parallel()
+
+	gzipped := func(b []byte) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(b); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	var want, stderr bytes.Buffer
+	if err := run(nil, bytes.NewReader(dcaYAML), &want, &stderr); err != nil {
+		t.Fatalf("run(plain) error = %v", err)
+	}
+	var got bytes.Buffer
+	if err := run(nil, bytes.NewReader(gzipped(dcaYAML)), &got, &stderr); err != nil {
+		t.Fatalf("run(gzip) error = %v", err)
+	}
+	if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+		t.Errorf("run(gzip) mismatch (-plain +gzip):\n%s", diff)
+	}
+
+	profilePath := filepath.Join(t.TempDir(), "profile.yaml.gz")
+	if err := os.WriteFile(profilePath, gzipped(dcaProfileYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got.Reset()
+	if err := run([]string{"-stats-from", profilePath}, bytes.NewReader(dcaYAML), &got, &stderr); err != nil {
+		t.Fatalf("run(-stats-from gzip) error = %v", err)
+	}
+	if !strings.Contains(got.String(), "Latency") {
+		t.Errorf("run(-stats-from gzip) did not render PROFILE:\n%s", got.String())
+	}
+
+	// The limit applies to the decompressed input.
+	err := run([]string{"-max-input-bytes", "1KiB"}, bytes.NewReader(gzipped(dcaYAML)), &got, &stderr)
+	if wantErr := "input is larger than 1 KiB"; err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("run(-max-input-bytes=1KiB, gzip) error = %v, want substring %q", err, wantErr)
+	}
+
+	truncated := gzipped(dcaYAML)
+	truncated = truncated[:len(truncated)/2]
+	if err := run(nil, bytes.NewReader(truncated), &got, &stderr); err == nil || !strings.Contains(err.Error(), "invalid gzip input") {
+		t.Errorf("run(truncated gzip) error = %v, want a read error", err)
+	}
+}
+
+func TestRun_DMLHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc      string
+		input     []byte
+		args      []string
+		wantFirst string
+	}{
+		{desc: "DML table", input: deleteYAML, wantFirst: "DELETE on MutationTest"},
+		{desc: "DML tableless", input: deleteYAML, args: []string{"-tableless"}, wantFirst: "DELETE on MutationTest"},
+		{desc: "query", input: dcaYAML, wantFirst: "+-----+"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(append([]string{"-mode", "plan", "-print", "none"}, tt.args...), bytes.NewReader(tt.input), &stdout, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if first, _, _ := strings.Cut(stdout.String(), "\n"); !strings.HasPrefix(first, tt.wantFirst) {
+				t.Fatalf("first line = %q, want prefix %q", first, tt.wantFirst)
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-mode", "plan", "-sections", "appendix"}, bytes.NewReader(deleteYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-sections appendix) error = %v", err)
+	}
+	if strings.Contains(stdout.String(), "DELETE on MutationTest") {
+		t.Fatalf("run(-sections appendix) output contains the table header:\n%s", stdout.String())
+	}
+}
+
 func TestRun_TablelessInlineStatsWrappedProfile(t *testing.T) {
 	t.Parallel()
 
@@ -1058,3 +1504,717 @@ func lineContaining(s, needle string) string {
 	}
 	return ""
 }
+
+func TestRun_CustomColumnLatencyRank(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	err := run([]string{
+		"-print", "none",
+		"-custom-column", `{"name":"ID","template":"{{.FormatID}}"}`,
+		"-custom-column", `{"name":"Rank","template":"{{if .LatencyRank}}#{{.LatencyRank}}{{end}}"}`,
+	}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run(-custom-column .LatencyRank) error = %v", err)
+	}
+
+	for _, want := range []string{"| 5   | #1   |", "| 18  | #2   |", "| 2   |      |"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+}
+
+func TestRun_CustomColumnIndexPath(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	err := run([]string{
+		"-mode", "plan",
+		"-print", "none",
+		"-custom-column", `{"name":"ID","template":"{{.FormatID}}"}`,
+		"-custom-column", `{"name":"Path","template":"{{.IndexPath}}"}`,
+	}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run(-custom-column .IndexPath) error = %v", err)
+	}
+	if want := "| 13  | 0.0.1.0.0     |"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+	}
+}
+
+func TestRun_CustomColumnReadsPlanNode(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	err := run([]string{
+		"-mode", "plan",
+		"-print", "none",
+		"-custom-column", `{"name":"ID","template":"{{.FormatID}}"}`,
+		"-custom-column", `{"name":"Method","template":"{{with .Node}}{{(index .Metadata.Fields \"scan_method\").GetStringValue}}{{end}}"}`,
+	}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run(-custom-column .Node) error = %v", err)
+	}
+
+	if got := stderr.String(); got != "" {
+		t.Fatalf("stderr = %q, want empty", got)
+	}
+	if !strings.Contains(stdout.String(), "| 5   | Automatic |") {
+		t.Fatalf("stdout = %q, want scan_method read from .Node", stdout.String())
+	}
+}
+
+func TestRun_PredicateTemplate(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	err := run([]string{
+		"-mode", "plan",
+		"-predicate-template", "{{.Type}} @ node {{.NodeID}} (scalar {{.ChildIndex}}): {{.Description}}",
+	}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run(-predicate-template) error = %v", err)
+	}
+	if got := stderr.String(); got != "" {
+		t.Fatalf("stderr = %q, want empty", got)
+	}
+
+	want := heredoc.Doc(`
+Predicates(identified by ID):
+  1: Split Range @ node 1 (scalar 25): ($AlbumId = $AlbumId_1)
+ 17: Residual Condition @ node 17 (scalar 23): ($AlbumId = $batched_AlbumId_1)
+`)
+	if !strings.HasSuffix(stdout.String(), want) {
+		t.Fatalf("stdout = %q, want predicates suffix %q", stdout.String(), want)
+	}
+}
+
+func TestRun_TruncatePredicates(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	err := run([]string{
+		"-mode", "plan",
+		"-truncate-predicates", "24",
+		"-predicate-template", "{{.Type}} @ node {{.NodeID}}: {{.Description}}",
+	}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run(-truncate-predicates) error = %v", err)
+	}
+
+	want := heredoc.Doc(`
+Predicates(identified by ID):
+  1: Split Range @ node 1: (…
+ 17: Residual Condition @ no…
+`)
+	if !strings.HasSuffix(stdout.String(), want) {
+		t.Fatalf("stdout = %q, want predicates suffix %q", stdout.String(), want)
+	}
+}
+
+func TestTruncatePredicates(t *testing.T) {
+	format := truncatePredicates(nil, 12)
+	tests := []struct {
+		desc string
+		link plantree.ScalarChildLink
+		want string
+	}{
+		{desc: "long", link: plantree.ScalarChildLink{Type: "Condition", Description: "TRUE"}, want: "Condition: …"},
+		{desc: "fits", link: plantree.ScalarChildLink{Type: "Cond", Description: "TRUE"}, want: "Cond: TRUE"},
+		{desc: "wide characters", link: plantree.ScalarChildLink{Type: "Cond", Description: "'日本語'"}, want: "Cond: '日本…"},
+		{desc: "each line", link: plantree.ScalarChildLink{Type: "Cond", Description: "($a = 1)\nAND ($bbbbbb = 2)"}, want: "Cond: ($a =…\nAND ($bbbbb…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := format(plantree.RowWithPredicates{}, tt.link)
+			if err != nil {
+				t.Fatalf("format() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_IDTemplate(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-mode", "plan", "-print", "none", "-id-template", "N{{.ID}}"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-id-template) error = %v", err)
+	}
+	for _, want := range []string{
+		"|   N0 | Distributed Union on AlbumsByAlbumTitle <Row>",
+		"|  *N1 | +- Distributed Cross Apply <Row>",
+		"|   N2 |    +- [Input] Create Batch <Row>",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, stdout.String())
+		}
+	}
+
+	var defaultOut, emptyTemplateOut bytes.Buffer
+	if err := run([]string{"-mode", "plan"}, bytes.NewReader(dcaYAML), &defaultOut, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if err := run([]string{"-mode", "plan", "-id-template", "{{.DisplayID}}"}, bytes.NewReader(dcaYAML), &emptyTemplateOut, &stderr); err != nil {
+		t.Fatalf("run(-id-template={{.DisplayID}}) error = %v", err)
+	}
+	if defaultOut.String() != emptyTemplateOut.String() {
+		t.Errorf("run(-id-template={{.DisplayID}}) output differs from the default:\n%s\nwant:\n%s", emptyTemplateOut.String(), defaultOut.String())
+	}
+}
+
+func TestRun_StatBadges(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-mode", "profile", "-print", "none", "-stat-badges"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-stat-badges) error = %v", err)
+	}
+	for _, want := range []string{
+		"|   0 | Distributed Union on AlbumsByAlbumTitle <Row>                                             [33r 1x 1.92ms] |",
+		"|  *1 | +- Distributed Cross Apply <Row>                                                          [33r 1x  1.9ms] |",
+		"|   2 |    +- [Input] Create Batch <Row>                                                                          |",
+		"|   3 |    |  +- Local Distributed Union <Row>                                                    [ 7r 1x 0.95ms] |",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRun_LatencyBar(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-mode", "profile", "-print", "none", "-latency-bar", "8"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-latency-bar) error = %v", err)
+	}
+	for _, want := range []string{
+		"|  *1 | +- Distributed Cross Apply <Row>                                                          [▇▁▁▁▁▁▁▁] |",
+		"|   2 |    +- [Input] Create Batch <Row>                                                                     |",
+		"|   5 |    |        +- Index Scan on AlbumsByAlbumTitle <Row> (Full scan, scan_method: Automatic) [▇▇▇▇▇▇▇▇] |",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestParsePredicateTemplate(t *testing.T) {
+	if _, err := parsePredicateTemplate("{{.Type"); err == nil {
+		t.Fatal("parsePredicateTemplate(broken) error = nil, want non-nil")
+	}
+
+	format, err := parsePredicateTemplate("{{.Unknown}}")
+	if err != nil {
+		t.Fatalf("parsePredicateTemplate() error = %v", err)
+	}
+	_, err = printResult([]plantree.RowWithPredicates{{
+		ID:             1,
+		Predicates:     []string{"Condition: TRUE"},
+		PredicateLinks: []plantree.ScalarChildLink{{Type: "Condition", Description: "TRUE"}},
+	}}, printResultOptions{
+		printSections:   PrintSections{PrintPredicates},
+		formatPredicate: format,
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to format predicate of node 1") {
+		t.Fatalf("printResult() error = %v, want predicate formatting error", err)
+	}
+}
+
+func TestRun_EstimatedRowsColumns(t *testing.T) {
+	input := heredoc.Doc(`
+		planNodes:
+		  - index: 0
+		    kind: RELATIONAL
+		    displayName: Serialize Result
+		    childLinks:
+		      - childIndex: 1
+		    metadata:
+		      estimated_rows: "2"
+		    executionStats:
+		      rows: {total: "40", unit: rows}
+		      latency: {total: "1", unit: msecs}
+		      execution_summary: {num_executions: "1"}
+		  - index: 1
+		    kind: RELATIONAL
+		    displayName: Scan
+		    metadata:
+		      estimated_rows: "50"
+		      scan_type: TableScan
+		      scan_target: Singers
+		    executionStats:
+		      rows: {total: "40", unit: rows}
+		      latency: {total: "0.5", unit: msecs}
+		      execution_summary: {num_executions: "1"}
+	`)
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run(nil, strings.NewReader(input), &stdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want := heredoc.Doc(`
+		+----+-----------------------------------------------+------+-------+---------+-----------+---------+
+		| ID | Operator                                      | Rows | Exec. | Latency | Est. Rows | Act/Est |
+		+----+-----------------------------------------------+------+-------+---------+-----------+---------+
+		|  0 | Serialize Result (estimated_rows: 2)          |   40 |     1 |    1 ms |         2 |  !20.00 |
+		|  1 | +- Table Scan on Singers (estimated_rows: 50) |   40 |     1 |  0.5 ms |        50 |    0.80 |
+		+----+-----------------------------------------------+------+-------+---------+-----------+---------+
+	`)
+	if diff := cmp.Diff(want, stdout.String()); diff != "" {
+		t.Errorf("run() mismatch (-want +got):\n%s", diff)
+	}
+
+	stdout.Reset()
+	if err := run(nil, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(profile) error = %v", err)
+	}
+	if strings.Contains(stdout.String(), "Est. Rows") {
+		t.Errorf("run(profile) = %q, want no estimate columns without estimates", stdout.String())
+	}
+}
+
+func TestParseOutputSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []outputSection
+		wantErr string
+	}{
+		{name: "default", input: "table,appendix", want: []outputSection{outputSectionTable, outputSectionAppendix}},
+		{name: "reordered and case-insensitive", input: " Appendix , TABLE", want: []outputSection{outputSectionAppendix, outputSectionTable}},
+		{name: "empty suppresses all sections", input: "", want: []outputSection{}},
+		{name: "unknown", input: "summary", wantErr: `unknown section: "summary"`},
+		{name: "duplicate", input: "table,table", wantErr: `duplicate section: "table"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOutputSections(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseOutputSections(%q) error = %v, want substring %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseOutputSections(%q) error = %v", tt.input, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseOutputSections(%q) mismatch (-want +got):\n%s", tt.input, diff)
+			}
+		})
+	}
+}
+
+func TestRun_SectionsOrder(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-mode", "plan", "-sections", "appendix,table"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-sections) error = %v", err)
+	}
+
+	wantPrefix := heredoc.Doc(`
+		Predicates(identified by ID):
+		  1: Split Range: ($AlbumId = $AlbumId_1)
+		 17: Residual Condition: ($AlbumId = $batched_AlbumId_1)
+
+	`)
+	if got := stdout.String(); !strings.HasPrefix(got, wantPrefix) || !strings.Contains(got, "| ID  | Operator") {
+		t.Fatalf("stdout = %q, want appendix %q followed by the table", got, wantPrefix)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-mode", "plan", "-sections", "table"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-sections=table) error = %v", err)
+	}
+	if got := stdout.String(); strings.Contains(got, "Predicates") {
+		t.Fatalf("stdout = %q, want no appendix", got)
+	}
+}
+
+func TestParseFixedWidths(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []fixedWidth
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "ID:4, Operator : 80", want: []fixedWidth{{name: "ID", width: 4}, {name: "Operator", width: 80}}},
+		{input: "ID", wantErr: true},
+		{input: ":4", wantErr: true},
+		{input: "ID:x", wantErr: true},
+		{input: "ID:-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseFixedWidths(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseFixedWidths(%q) error = nil, want non-nil", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseFixedWidths(%q) error = %v", tt.input, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(fixedWidth{})); diff != "" {
+			t.Errorf("parseFixedWidths(%q) mismatch (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
+
+func TestRun_FixedWidths(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-mode", "plan", "-print", "none", "-fixed-widths", "ID:4,Operator:30"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-fixed-widths) error = %v", err)
+	}
+
+	want := heredoc.Doc(`
+		+------+--------------------------------+
+		| ID   | Operator                       |
+		+------+--------------------------------+
+		|    0 | Distributed Union on AlbumsBy… |
+		|   *1 | +- Distributed Cross Apply <R… |
+		|    2 |    +- [Input] Create Batch <R… |
+		|    3 |    |  +- Local Distributed Un… |
+		|    4 |    |     +- Compute Struct <R… |
+		|    5 |    |        +- Index Scan on … |
+		|   11 |    +- [Map] Serialize Result … |
+		|   12 |       +- Cross Apply <Row>     |
+		|   13 |          +- [Input] Batch Sca… |
+		|   16 |          +- [Map] Local Distr… |
+		|  *17 |             +- Filter Scan <R… |
+		|   18 |                +- Index Scan … |
+		+------+--------------------------------+
+	`)
+	if diff := cmp.Diff(want, stdout.String()); diff != "" {
+		t.Errorf("run(-fixed-widths) mismatch (-want +got):\n%s", diff)
+	}
+
+	err := run([]string{"-fixed-widths", "Rows:4"}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), `unknown column in --fixed-widths: "Rows"`) {
+		t.Fatalf("run(-fixed-widths=Rows:4) error = %v, want unknown column error", err)
+	}
+}
+
+func TestParseColumnOrder(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "Rows, ID", want: []string{"Rows", "ID"}},
+		{input: "ID,,Rows", wantErr: true},
+		{input: "ID,Rows,ID", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseColumnOrder(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseColumnOrder(%q) error = nil, want non-nil", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseColumnOrder(%q) error = %v", tt.input, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("parseColumnOrder(%q) mismatch (-want +got):\n%s", tt.input, diff)
+		}
+	}
+}
+
+func TestRun_ColumnOrder(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-print", "none", "-column-order", "Latency,Rows"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-column-order) error = %v", err)
+	}
+	header := strings.Split(stdout.String(), "\n")[1]
+	if want := "| Latency | Rows | ID  | Operator "; !strings.HasPrefix(header, want) || !strings.HasSuffix(header, "| Exec. |") {
+		t.Errorf("header = %q, want Latency and Rows first and the other columns in their original order", header)
+	}
+
+	err := run([]string{"-mode", "plan", "-column-order", "Rows"}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), `unknown column in --column-order: "Rows"`) {
+		t.Fatalf("run(-column-order=Rows) error = %v, want unknown column error", err)
+	}
+}
+
+func TestRun_RowsProduced(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-print", "none", "-rows-produced"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-rows-produced) error = %v", err)
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if want := "| ID  | Operator "; !strings.HasPrefix(lines[1], want) || !strings.Contains(lines[1], "| Rows | Produced | Exec. |") {
+		t.Fatalf("header = %q, want Produced after Rows", lines[1])
+	}
+	if want := "Index Scan on SongsBySongGenre <Row> (Full scan, scan_method: Row)      |   33 |       63 |"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, stdout.String())
+	}
+}
+
+func TestRun_AvgPerExecution(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-print", "none", "-avg-exec"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-avg-exec) error = %v", err)
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if !strings.HasSuffix(lines[1], "| Exec. | Latency | Avg/Exec |") {
+		t.Fatalf("header = %q, want Avg/Exec after Latency", lines[1])
+	}
+	for _, want := range []string{
+		"[Map] Local Distributed Union <Row>                                           |   33 |     7 | 0.85 ms |  0.12 ms |",
+		"Distributed Union on AlbumsByAlbumTitle <Row>                                             |   33 |     1 | 1.92 ms |          |",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRun_CPUTime(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run([]string{"-print", "none", "-cpu-time"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-cpu-time) error = %v", err)
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if !strings.HasSuffix(lines[1], "| Exec. | Latency | CPU Time |") {
+		t.Fatalf("header = %q, want CPU Time after Latency", lines[1])
+	}
+	if want := "Distributed Union on AlbumsByAlbumTitle <Row>                                             |   33 |     1 | 1.92 ms |  0.59 ms |"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, stdout.String())
+	}
+}
+
+func TestRun_LatencyPercent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		args []string
+		want []string
+	}{
+		{
+			desc: "default columns",
+			args: []string{"-latency-pct"},
+			want: []string{
+				"| Exec. | Latency | Lat%   |",
+				"Distributed Union on AlbumsByAlbumTitle <Row>                                             |   33 |     1 | 1.92 ms | 100.0% |",
+				"[Input] Create Batch <Row>                                                          |      |       |         |        |",
+				"[Map] Local Distributed Union <Row>                                           |   33 |     7 | 0.85 ms |  44.3% |",
+			},
+		},
+		{
+			desc: "custom template",
+			args: []string{
+				"-custom-column", `{name: ID, template: "{{.FormatID}}", alignment: RIGHT}`,
+				"-custom-column", `{name: Operator, template: "{{.Text}}"}`,
+				"-custom-column", `{name: Pct, template: "{{pctOfRoot .ExecutionStats.Latency .RootLatency}}", alignment: RIGHT}`,
+			},
+			want: []string{"[Map] Local Distributed Union <Row>                                           |  44.3% |"},
+		},
+		{
+			desc: "inline stats",
+			args: []string{
+				"-inline-stats",
+				"-custom-column", `{name: ID, template: "{{.FormatID}}", alignment: RIGHT}`,
+				"-custom-column", `{name: Operator, template: "{{.Text}}"}`,
+				"-custom-column", `{name: pct, template: "{{pctOfRoot .ExecutionStats.Latency .RootLatency}}"}`,
+			},
+			want: []string{"[Map] Local Distributed Union <Row> (pct=44.3%)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(append([]string{"-print", "none"}, tt.args...), bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Summary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		args []string
+		want string
+	}{
+		{
+			desc: "default columns",
+			args: []string{"-summary"},
+			want: "|     | Total                                                                                     |   33 |    22 | 1.92 ms |",
+		},
+		{
+			desc: "custom columns",
+			args: []string{
+				"-summary",
+				"-custom-column", `{name: ID, template: "{{.FormatID}}", alignment: RIGHT}`,
+				"-custom-column", `{name: Operator, template: "{{.Text}}"}`,
+				"-custom-column", `{name: Rows, template: "{{.ExecutionStats.Rows.Total}}", alignment: RIGHT, summary: ROOT}`,
+				"-custom-column", `{name: Exec, template: "{{.ExecutionStats.ExecutionSummary.NumExecutions}}", alignment: RIGHT}`,
+				"-custom-column", `{name: Name, template: "{{.DisplayName}}", summary: SUM}`,
+			},
+			want: "|     | Total                                                                                     |   33 |   22 |                         |",
+		},
+		{
+			desc: "tableless",
+			args: []string{"-summary", "-layout", "tableless"},
+			want: "   |Total|33|22|1.92 ms\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(append([]string{"-print", "none"}, tt.args...), bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+
+	t.Run("PLAN mode", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err := run([]string{"-summary"}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+		if err == nil || !strings.Contains(err.Error(), "--summary is only valid in PROFILE mode") {
+			t.Fatalf("run() error = %v, want PROFILE mode error", err)
+		}
+	})
+}
+
+func TestRun_WarnsWhenAutoModeHidesStats(t *testing.T) {
+	t.Parallel()
+
+	const partialProfile = `{"queryPlan": {"planNodes": [
+		{"index": 0, "kind": "RELATIONAL", "displayName": "Serialize Result", "childLinks": [{"childIndex": 1}]},
+		{"index": 1, "kind": "RELATIONAL", "displayName": "Scan", "executionStats": {"rows": {"total": "3", "unit": "rows"}}}
+	]}}`
+	const warning = "warning: the root node has no execution stats but other nodes do"
+
+	tests := []struct {
+		desc        string
+		input       []byte
+		args        []string
+		wantWarning bool
+	}{
+		{desc: "AUTO with stats below the root", input: []byte(partialProfile), wantWarning: true},
+		{desc: "explicit PROFILE", input: []byte(partialProfile), args: []string{"-mode", "profile"}},
+		{desc: "explicit PLAN", input: []byte(partialProfile), args: []string{"-mode", "plan"}},
+		{desc: "AUTO with a full profile", input: dcaProfileYAML},
+		{desc: "AUTO without stats", input: dcaYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(append([]string{"-print", "none"}, tt.args...), bytes.NewReader(tt.input), &stdout, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if got := strings.Contains(stderr.String(), warning); got != tt.wantWarning {
+				t.Fatalf("stderr = %q, want warning: %v", stderr.String(), tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestRun_DropEmptyColumns(t *testing.T) {
+	t.Parallel()
+
+	header := func(out string) string {
+		lines := strings.Split(out, "\n")
+		for _, line := range lines {
+			if strings.HasPrefix(line, "| ") {
+				return line
+			}
+		}
+		t.Fatalf("output has no header row:\n%s", out)
+		return ""
+	}
+	tests := []struct {
+		desc  string
+		input []byte
+		args  []string
+		want  string
+	}{
+		{
+			desc:  "all-zero Rows column is dropped",
+			input: deleteYAML,
+			args:  []string{"-drop-empty-columns"},
+			want:  "| ID | Operator                                                                         | Exec. | Latency |",
+		},
+		{
+			desc:  "off by default",
+			input: deleteYAML,
+			want:  "| ID | Operator                                                                         | Rows | Exec. | Latency |",
+		},
+		{
+			desc:  "informative columns are kept",
+			input: dcaProfileYAML,
+			args:  []string{"-drop-empty-columns"},
+			want:  "| ID  | Operator                                                                                  | Rows | Exec. | Latency |",
+		},
+		{
+			desc:  "custom columns are never dropped",
+			input: deleteYAML,
+			args:  []string{"-drop-empty-columns", "-custom-column", `{name: Rows, template: "{{.ExecutionStats.Rows.Total}}"}`},
+			want:  "| Rows |",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(append([]string{"-mode", "profile", "-print", "none"}, tt.args...), bytes.NewReader(tt.input), &stdout, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if got := header(stdout.String()); got != tt.want {
+				t.Fatalf("header = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_StatsMapTemplate(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-print", "none", "-custom-column", `{name: ID, template: "{{.FormatID}}"}`, "-custom-column", `{name: Scanned, template: "{{index .StatsMap \"scanned_rows\"}}"}`}
+	if err := run(args, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if want := "| 18  | 63 rows |"; !strings.Contains(stdout.String(), want) {
+		t.Fatalf("output does not contain %q:\n%s", want, stdout.String())
+	}
+}
@@ -0,0 +1,91 @@
+package impl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/protoyaml"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apstndb/spannerplan"
+)
+
+// inputFormat is the value of --input-format.
+type inputFormat string
+
+const (
+	inputFormatAuto   inputFormat = "auto"
+	inputFormatYAML   inputFormat = "yaml"
+	inputFormatJSON   inputFormat = "json"
+	inputFormatProto  inputFormat = "proto"
+	inputFormatStudio inputFormat = "studio"
+)
+
+func parseInputFormat(s string) (inputFormat, error) {
+	switch f := inputFormat(strings.ToLower(s)); f {
+	case inputFormatAuto, inputFormatYAML, inputFormatJSON, inputFormatProto, inputFormatStudio:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown input format: %q. Must be one of auto, yaml, json, proto, studio", s)
+	}
+}
+
+// decodeInput decodes b as f. Auto and yaml accept every envelope of [spannerplan.ExtractQueryPlan]
+// and a YAML stream of several plans, decoded by [spannerplan.ExtractQueryPlans]. The other
+// formats decode a single plan: json additionally requires b to be JSON, studio requires a
+// console export with a top-level queryPlan or planNodes key, and proto reads a binary-encoded
+// ResultSetStats.
+func decodeInput(b []byte, f inputFormat) ([]*sppb.ResultSetStats, error) {
+	switch f {
+	case inputFormatJSON:
+		if !json.Valid(b) {
+			return nil, errors.New("input is not valid JSON")
+		}
+	case inputFormatStudio:
+		j, err := protoyaml.YAMLToJSON(b)
+		if err != nil {
+			return nil, err
+		}
+		var topLevel map[string]json.RawMessage
+		if err := json.Unmarshal(j, &topLevel); err != nil {
+			return nil, err
+		}
+		if _, ok := topLevel["queryPlan"]; !ok {
+			if _, ok := topLevel["planNodes"]; !ok {
+				return nil, errors.New("input is not a console export: expected a top-level queryPlan or planNodes key")
+			}
+		}
+	case inputFormatProto:
+		var stats sppb.ResultSetStats
+		if err := proto.Unmarshal(b, &stats); err != nil {
+			return nil, err
+		}
+		if len(stats.GetQueryPlan().GetPlanNodes()) == 0 {
+			return nil, errors.New("protobuf input has no plan nodes")
+		}
+		return []*sppb.ResultSetStats{&stats}, nil
+	case inputFormatAuto, inputFormatYAML:
+		return spannerplan.ExtractQueryPlans(b)
+	}
+	stats, _, err := spannerplan.ExtractQueryPlan(b)
+	if err != nil {
+		return nil, err
+	}
+	return []*sppb.ResultSetStats{stats}, nil
+}
+
+// decodeSingleInput decodes b, the content of a file named by a flag such as --stats-from, as
+// [inputFormatAuto] does, requiring a single plan.
+func decodeSingleInput(b []byte) (*sppb.ResultSetStats, error) {
+	plans, err := decodeInput(b, inputFormatAuto)
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) != 1 {
+		return nil, fmt.Errorf("expected a single plan, but the input has %d", len(plans))
+	}
+	return plans[0], nil
+}
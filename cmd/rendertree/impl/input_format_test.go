@@ -0,0 +1,96 @@
+package impl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func TestRun_InputFormat(t *testing.T) {
+	t.Parallel()
+
+	stats, _, err := spannerplan.ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	protoInput, err := proto.Marshal(stats)
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+	const studioInput = `{"queryPlan": {"planNodes": [{"index": 0, "kind": "RELATIONAL", "displayName": "Scan"}]}, "exportedAt": "2024-01-01T00:00:00Z"}`
+
+	tests := []struct {
+		format  string
+		input   []byte
+		wantErr string
+	}{
+		{format: "auto", input: dcaYAML},
+		{format: "yaml", input: dcaYAML},
+		{format: "json", input: []byte(studioInput)},
+		{format: "json", input: dcaYAML, wantErr: "invalid input for --input-format=json: input is not valid JSON"},
+		{format: "studio", input: []byte(studioInput)},
+		{format: "studio", input: dcaYAML, wantErr: "input is not a console export"},
+		{format: "proto", input: protoInput},
+		{format: "proto", input: []byte(studioInput), wantErr: "invalid input for --input-format=proto"},
+		{format: "auto", input: protoInput, wantErr: "invalid input at protoyaml.Unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run([]string{"-mode", "plan", "-print", "none", "-input-format", tt.format}, bytes.NewReader(tt.input), &stdout, &stderr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("run() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if !strings.Contains(stdout.String(), "Scan") {
+				t.Fatalf("run() output does not contain a Scan row:\n%s", stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_MultiplePlans(t *testing.T) {
+	t.Parallel()
+
+	render := func(args []string, input []byte) (string, error) {
+		var stdout, stderr bytes.Buffer
+		err := run(args, bytes.NewReader(input), &stdout, &stderr)
+		return stdout.String(), err
+	}
+	args := []string{"-print", "none"}
+	first, err := render(args, dcaYAML)
+	if err != nil {
+		t.Fatalf("run(first plan) error = %v", err)
+	}
+	second, err := render(args, deleteYAML)
+	if err != nil {
+		t.Fatalf("run(second plan) error = %v", err)
+	}
+	stream := []byte(string(dcaYAML) + "\n---\n" + string(deleteYAML))
+
+	got, err := render(args, stream)
+	if err != nil {
+		t.Fatalf("run(stream) error = %v", err)
+	}
+	if want := "=== Plan 1 ===\n" + first + "\n=== Plan 2 ===\n" + second; got != want {
+		t.Errorf("run(stream) = %q, want %q", got, want)
+	}
+
+	// A single document, even with a document marker, renders without a separator.
+	if got, err := render(args, []byte("---\n"+string(dcaYAML))); err != nil || got != first {
+		t.Errorf("run(single document) = %q, %v, want %q", got, err, first)
+	}
+
+	if _, err := render([]string{"-format", "json"}, stream); err == nil || !strings.Contains(err.Error(), "input has 2 plans") {
+		t.Errorf("run(-format json, stream) error = %v, want the single plan error", err)
+	}
+}
@@ -0,0 +1,17 @@
+package impl
+
+import (
+	"errors"
+	"io"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan/plantree"
+)
+
+// InteractiveRunner runs the --interactive terminal UI. It is nil in the default rendertree, and
+// the rendertree of the github.com/apstndb/spannerplan/cmd/rendertree/tui module sets it before
+// calling [Main], which keeps the terminal UI dependencies out of the spannerplan module.
+var InteractiveRunner func(planNodes []*sppb.PlanNode, opts []plantree.Option, stdout io.Writer) error
+
+var errInteractiveUnavailable = errors.New("--interactive is not available: install rendertree from the cmd/rendertree/tui module")
@@ -0,0 +1,15 @@
+package impl
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRun_InteractiveRequiresTUIBuild(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"-interactive"}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if !errors.Is(err, errInteractiveUnavailable) {
+		t.Fatalf("run(-interactive) error = %v, want %v", err, errInteractiveUnavailable)
+	}
+}
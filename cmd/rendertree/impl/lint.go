@@ -0,0 +1,75 @@
+package impl
+
+import (
+	"fmt"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan"
+	"github.com/apstndb/spannerplan/asciitable"
+	"github.com/apstndb/spannerplan/stats"
+)
+
+func parseLintSeverity(s string) (spannerplan.Severity, error) {
+	switch strings.ToLower(s) {
+	case "info":
+		return spannerplan.SeverityInfo, nil
+	case "warning":
+		return spannerplan.SeverityWarning, nil
+	case "error":
+		return spannerplan.SeverityError, nil
+	default:
+		return 0, fmt.Errorf("invalid input: %s. Must be one of info, warning, error (case-insensitive)", s)
+	}
+}
+
+// lintPlan returns the advisories of spannerplan.Lint with the default rules. The rules skip
+// nodes whose execution stats cannot be read, so lintPlan reads them first and fails on the
+// first node that has unreadable stats, as rendering the plan would.
+func lintPlan(qp *spannerplan.QueryPlan, disallowUnknownStats bool) ([]spannerplan.Advisory, error) {
+	for _, node := range qp.PlanNodes() {
+		if node.GetKind() != sppb.PlanNode_RELATIONAL {
+			continue
+		}
+		if _, err := stats.Extract(node, disallowUnknownStats); err != nil {
+			return nil, fmt.Errorf("failed to extract execution stats of node %d: %w", node.GetIndex(), err)
+		}
+	}
+	return spannerplan.Lint(qp), nil
+}
+
+// advisoriesSection returns the Advisories section of --advisories: the lintPlan findings of
+// planNodes grouped by node ID, or "" when there are none. With bestEffort, nodes whose
+// execution stats cannot be read are skipped instead of failing, as the table renders them
+// with empty stats.
+func advisoriesSection(planNodes []*sppb.PlanNode, disallowUnknownStats, bestEffort bool) (string, error) {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return "", err
+	}
+	var advisories []spannerplan.Advisory
+	if bestEffort {
+		advisories = spannerplan.Lint(qp)
+	} else if advisories, err = lintPlan(qp, disallowUnknownStats); err != nil {
+		return "", err
+	}
+
+	byNode := make(map[int32][]string)
+	var ids []int32
+	for _, advisory := range advisories {
+		if _, ok := byNode[advisory.NodeID]; !ok {
+			ids = append(ids, advisory.NodeID)
+		}
+		byNode[advisory.NodeID] = append(byNode[advisory.NodeID], fmt.Sprintf("%s: %s: %s", advisory.Severity, advisory.Rule, advisory.Message))
+	}
+	s, err := asciitable.RenderAppendix(ids, asciitable.AppendixSpec[int32]{
+		Title: "Advisories:",
+		ID:    func(id int32) uint { return uint(id) },
+		Items: func(id int32) []string { return byNode[id] },
+	})
+	if err != nil || s == "" {
+		return "", err
+	}
+	return "\n" + s, nil
+}
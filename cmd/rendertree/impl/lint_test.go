@@ -0,0 +1,208 @@
+package impl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	heredoc "github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func lintNode(index int32, displayName string, rows string, children ...int32) *sppb.PlanNode {
+	node := &sppb.PlanNode{Index: index, Kind: sppb.PlanNode_RELATIONAL, DisplayName: displayName}
+	for _, child := range children {
+		node.ChildLinks = append(node.ChildLinks, &sppb.PlanNode_ChildLink{ChildIndex: child})
+	}
+	if rows != "" {
+		node.ExecutionStats = &structpb.Struct{Fields: map[string]*structpb.Value{
+			"rows": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"total": structpb.NewStringValue(rows),
+			}}),
+		}}
+	}
+	return node
+}
+
+func TestLintPlan(t *testing.T) {
+	tests := []struct {
+		name      string
+		planNodes []*sppb.PlanNode
+		// disallowUnknownStats is passed to lintPlan.
+		disallowUnknownStats bool
+		want                 []spannerplan.Advisory
+		wantErr              string
+	}{
+		{
+			name: "filter discarding most rows",
+			planNodes: []*sppb.PlanNode{
+				lintNode(0, "Filter", "10", 1),
+				lintNode(1, "Scan", "5000"),
+			},
+			want: []spannerplan.Advisory{{
+				Severity: spannerplan.SeverityWarning,
+				NodeID:   0,
+				Rule:     "filter-discard",
+				Message:  "Filter discards 99.8% of 5000 input rows; consider a seekable condition or an index",
+			}},
+		},
+		{
+			name: "filter over small input",
+			planNodes: []*sppb.PlanNode{
+				lintNode(0, "Filter", "1", 1),
+				lintNode(1, "Scan", "500"),
+			},
+		},
+		{
+			name: "filter without stats",
+			planNodes: []*sppb.PlanNode{
+				lintNode(0, "Filter", "", 1),
+				lintNode(1, "Scan", ""),
+			},
+		},
+		{
+			name: "large sort and unbatched apply are ordered by node",
+			planNodes: []*sppb.PlanNode{
+				lintNode(0, "Sort", "20000", 1),
+				lintNode(1, "Cross Apply", "20000", 2, 3),
+				lintNode(2, "Scan", "100"),
+				lintNode(3, "Scan", "200"),
+			},
+			want: []spannerplan.Advisory{
+				{
+					Severity: spannerplan.SeverityWarning,
+					NodeID:   0,
+					Rule:     "large-sort",
+					Message:  "Sort sorts 20000 rows; consider an index providing the order",
+				},
+				{
+					Severity: spannerplan.SeverityInfo,
+					NodeID:   1,
+					Rule:     "unbatched-apply",
+					Message:  "Cross Apply has no batched input and may run its Map side once per input row",
+				},
+			},
+		},
+		{
+			name: "unknown stats are rejected as rendering rejects them",
+			planNodes: []*sppb.PlanNode{
+				{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan", ExecutionStats: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"future_stat": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+						"total": structpb.NewStringValue("1"),
+					}}),
+				}}},
+			},
+			disallowUnknownStats: true,
+			wantErr:              "failed to extract execution stats of node 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := spannerplan.New(tt.planNodes)
+			if err != nil {
+				t.Fatalf("spannerplan.New() error = %v", err)
+			}
+			got, err := lintPlan(qp, tt.disallowUnknownStats)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("lintPlan() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("lintPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("lintPlan() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRun_Lint(t *testing.T) {
+	want := heredoc.Doc(`
+		warning: node 5: full-scan: full scan of AlbumsByAlbumTitle
+		info: node 17: unseekable-filter-scan: Filter Scan has no seekable key columns and evaluates its conditions on every row it reads
+		warning: node 18: full-scan: full scan of SongsBySongGenre
+	`)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "default severity fails on warnings",
+			args:    []string{"-lint"},
+			wantErr: "lint: 2 finding(s) at or above warning severity",
+		},
+		{
+			name: "error severity passes warnings",
+			args: []string{"-lint", "-lint-severity", "ERROR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			var stderr bytes.Buffer
+			err := run(tt.args, bytes.NewReader(dcaYAML), &stdout, &stderr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("run() error = %v, want substring %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if got := stdout.String(); got != want {
+				t.Fatalf("stdout = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRun_Advisories(t *testing.T) {
+	var plain bytes.Buffer
+	var stderr bytes.Buffer
+	if err := run(nil, bytes.NewReader(dcaYAML), &plain, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	var stdout bytes.Buffer
+	// Unlike --lint, warnings do not fail the run.
+	if err := run([]string{"-advisories"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-advisories) error = %v", err)
+	}
+	want := plain.String() + heredoc.Doc(`
+
+		Advisories:
+		  5: warning: full-scan: full scan of AlbumsByAlbumTitle
+		 17: info: unseekable-filter-scan: Filter Scan has no seekable key columns and evaluates its conditions on every row it reads
+		 18: warning: full-scan: full scan of SongsBySongGenre
+	`)
+	if diff := cmp.Diff(want, stdout.String()); diff != "" {
+		t.Errorf("run(-advisories) mismatch (-want +got):\n%s", diff)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-advisories"}, bytes.NewReader(deleteYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-advisories) error = %v", err)
+	}
+	if want := "Advisories:\n 4: warning: full-scan: full scan of table MutationTest; consider an index on the filtered columns\n"; !strings.HasSuffix(stdout.String(), want) {
+		t.Errorf("run(-advisories) = %q, want suffix %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	unit := `{"queryPlan": {"planNodes": [{"index": 0, "kind": "RELATIONAL", "displayName": "Unit Relation"}]}}`
+	if err := run([]string{"-advisories"}, strings.NewReader(unit), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-advisories) error = %v", err)
+	}
+	if strings.Contains(stdout.String(), "Advisories:") {
+		t.Errorf("run(-advisories) printed an empty Advisories section:\n%s", stdout.String())
+	}
+}
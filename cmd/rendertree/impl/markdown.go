@@ -0,0 +1,112 @@
+package impl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olekukonko/tablewriter/tw"
+
+	"github.com/apstndb/spannerplan/plantree"
+)
+
+// renderMarkdownTable renders rows as a GitHub Flavored Markdown table for --format=markdown,
+// with the columns of renderDef and an alignment row following their Alignment. Cells escape "|",
+// write "<" and ">" as entities so that "<Row>" is not read as an HTML tag, and join wrapped lines
+// with "<br>". Leading spaces and runs of spaces, such as the indentation
+// of the tree prefix, become no-break spaces, since Markdown would otherwise collapse them. With
+// summary, the footer row of summaryFooter is the last row, as Markdown has no table footer.
+func renderMarkdownTable(renderDef tableRenderDef, rows []plantree.RowWithPredicates, summary bool) (string, error) {
+	var b strings.Builder
+	headers := make([]string, 0, len(renderDef.Columns))
+	delimiters := make([]string, 0, len(renderDef.Columns))
+	for i, col := range renderDef.Columns {
+		delimiter, err := markdownDelimiter(col.Alignment)
+		if err != nil {
+			return "", fmt.Errorf("column %d (%q): %w", i, col.Name, err)
+		}
+		headers = append(headers, markdownCell(col.Name))
+		delimiters = append(delimiters, delimiter)
+	}
+	writeMarkdownRow(&b, headers)
+	writeMarkdownRow(&b, delimiters)
+
+	tableRows, err := renderedRows(renderDef, rows)
+	if err != nil {
+		return "", err
+	}
+	if summary {
+		tableRows = append(tableRows, summaryFooter(renderDef, tableRows))
+	}
+	for _, row := range tableRows {
+		cells := make([]string, 0, len(row))
+		for _, value := range row {
+			cells = append(cells, markdownCell(value))
+		}
+		writeMarkdownRow(&b, cells)
+	}
+	return b.String(), nil
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+// markdownDelimiter returns the delimiter row cell for alignment.
+func markdownDelimiter(alignment tw.Align) (string, error) {
+	switch alignment {
+	case tw.AlignRight:
+		return "---:", nil
+	case tw.AlignCenter:
+		return ":---:", nil
+	case "", tw.AlignLeft, tw.AlignNone:
+		return ":---", nil
+	default:
+		return "", fmt.Errorf("unsupported alignment %v", alignment)
+	}
+}
+
+// markdownCellEscaper escapes the characters of a cell that Markdown would read as a column
+// separator or as HTML.
+var markdownCellEscaper = strings.NewReplacer("|", `\|`, "&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// markdownCell escapes value for a Markdown table cell.
+func markdownCell(value string) string {
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		lines[i] = preserveSpaces(markdownCellEscaper.Replace(line))
+	}
+	return strings.Join(lines, "<br>")
+}
+
+// preserveSpaces replaces the leading spaces of s and every space next to another space with
+// U+00A0 NO-BREAK SPACE, keeping single spaces between words as they are.
+func preserveSpaces(s string) string {
+	const noBreakSpace = '\u00a0'
+	runes := []rune(s)
+	for i, r := range runes {
+		if r != ' ' {
+			continue
+		}
+		// A replaced space before i means i continues a leading run or a run of spaces.
+		if i == 0 || runes[i-1] == noBreakSpace || i+1 < len(runes) && runes[i+1] == ' ' {
+			runes[i] = noBreakSpace
+		}
+	}
+	return string(runes)
+}
+
+// markdownCodeBlock returns text as a fenced code block, with a fence longer than any run of
+// backticks in text so that the block cannot end early.
+func markdownCodeBlock(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + "\n" + strings.TrimSuffix(text, "\n") + "\n" + fence + "\n"
+}
@@ -0,0 +1,39 @@
+package impl
+
+import (
+	"testing"
+)
+
+func TestMarkdownCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "Scan <Row>", want: "Scan &lt;Row&gt;"},
+		{value: "   |  +- Filter", want: "   \\|  +- Filter"},
+		{value: "a | b", want: `a \| b`},
+		{value: "AT&T", want: "AT&amp;T"},
+		{value: "first\n  second", want: "first<br>  second"},
+		{value: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := markdownCell(tt.value); got != tt.want {
+			t.Errorf("markdownCell(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownCodeBlock(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "a\n", want: "```\na\n```\n"},
+		{text: "a ``` b", want: "````\na ``` b\n````\n"},
+	}
+	for _, tt := range tests {
+		if got := markdownCodeBlock(tt.text); got != tt.want {
+			t.Errorf("markdownCodeBlock(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan"
+	"github.com/apstndb/spannerplan/plantree"
+	"github.com/apstndb/spannerplan/stats"
+)
+
+// otlpScopeName is the instrumentation scope of the --format=otlp spans.
+const otlpScopeName = "github.com/apstndb/spannerplan"
+
+// otlpSpanKindInternal is SPAN_KIND_INTERNAL, the kind of every operator span.
+const otlpSpanKindInternal = 1
+
+// otlpTraces is the OTLP/JSON encoding of ExportTraceServiceRequest, trimmed to the fields
+// --format=otlp sets.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId,omitempty"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	// The nanosecond timestamps are strings, as the protobuf JSON mapping encodes 64-bit integers.
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// runOTLP writes the spans of planNodes, as returned by [spannerplan.QueryPlan.ToSpans], as
+// OTLP/JSON trace data for import into a tracing backend. The trace ID is derived from the plan
+// (see otlpTraceID), so the same capture always exports the same trace.
+func runOTLP(planNodes []*sppb.PlanNode, w io.Writer) error {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	traceID, err := otlpTraceID(qp)
+	if err != nil {
+		return err
+	}
+	spans, err := qp.ToSpans(traceID)
+	if err != nil {
+		return err
+	}
+
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		otlpSpans = append(otlpSpans, otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.Start.Add(span.Duration).UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+		})
+	}
+	traces := otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(map[string]string{"service.name": "spanner"})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpScopeName}, Spans: otlpSpans}},
+	}}}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(traces)
+}
+
+// otlpAttributes converts attributes to OTLP key-values sorted by key.
+func otlpAttributes(attributes map[string]string) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attributes))
+	for key, value := range attributes {
+		kvs = append(kvs, otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}})
+	}
+	slices.SortFunc(kvs, func(a, b otlpKeyValue) int { return cmp.Compare(a.Key, b.Key) })
+	return kvs
+}
+
+// otlpTraceID returns the first 16 bytes, in hex, of the SHA-256 of the structural signature of
+// qp and the execution start timestamp of its root. Two captures of the same query get
+// different trace IDs as long as the root reports when it started, which PROFILE captures do.
+func otlpTraceID(qp *spannerplan.QueryPlan) (string, error) {
+	signature, err := plantree.StructuralSignature(qp)
+	if err != nil {
+		return "", err
+	}
+	rootStats, err := stats.Extract(qp.GetNodeByChildLink(nil), false)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(signature + "\n" + rootStats.ExecutionSummary.ExecutionStartTimestamp))
+	return hex.EncodeToString(sum[:16]), nil
+}
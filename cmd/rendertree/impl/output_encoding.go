@@ -0,0 +1,91 @@
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// outputEncoding is the value of --output-encoding.
+type outputEncoding string
+
+const (
+	outputEncodingLF      outputEncoding = "lf"
+	outputEncodingCRLF    outputEncoding = "crlf"
+	outputEncodingLFBOM   outputEncoding = "lf-bom"
+	outputEncodingCRLFBOM outputEncoding = "crlf-bom"
+)
+
+const utf8BOM = "\xef\xbb\xbf"
+
+func parseOutputEncoding(s string) (outputEncoding, error) {
+	switch e := outputEncoding(strings.ToLower(s)); e {
+	case outputEncodingLF, outputEncodingCRLF, outputEncodingLFBOM, outputEncodingCRLFBOM:
+		return e, nil
+	default:
+		return "", fmt.Errorf("unknown output encoding: %q. Must be one of lf, crlf, lf-bom, crlf-bom", s)
+	}
+}
+
+// writer wraps w so that output uses the line endings of e and, for the -bom variants, starts
+// with a UTF-8 byte order mark. The renderer only emits "\n", which is written unchanged for lf.
+func (e outputEncoding) writer(w io.Writer) io.Writer {
+	if e == outputEncodingLF {
+		return w
+	}
+	return &encodingWriter{
+		w:    w,
+		crlf: e == outputEncodingCRLF || e == outputEncodingCRLFBOM,
+		bom:  e == outputEncodingLFBOM || e == outputEncodingCRLFBOM,
+	}
+}
+
+type encodingWriter struct {
+	w    io.Writer
+	crlf bool
+	// bom is cleared once the byte order mark has been written.
+	bom bool
+}
+
+func (ew *encodingWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	out := p
+	if ew.crlf {
+		out = bytes.ReplaceAll(out, []byte("\n"), []byte("\r\n"))
+	}
+	if ew.bom {
+		out = append([]byte(utf8BOM), out...)
+		ew.bom = false
+	}
+	if _, err := ew.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// trailingNewlineTrimmer drops the newlines at the end of everything written through it, for
+// --no-trailing-newline. It holds newlines back until more text follows, so output written in
+// several calls, such as lint findings, is trimmed the same way as a single rendered string.
+// Wrap it around the encoding writer so that CRLF and the BOM apply to what is kept.
+type trailingNewlineTrimmer struct {
+	w io.Writer
+	// pending counts the newlines written since the last other byte.
+	pending int
+}
+
+func (t *trailingNewlineTrimmer) Write(p []byte) (int, error) {
+	kept := bytes.TrimRight(p, "\n")
+	if len(kept) == 0 {
+		t.pending += len(p)
+		return len(p), nil
+	}
+	out := append(bytes.Repeat([]byte("\n"), t.pending), kept...)
+	if _, err := t.w.Write(out); err != nil {
+		return 0, err
+	}
+	t.pending = len(p) - len(kept)
+	return len(p), nil
+}
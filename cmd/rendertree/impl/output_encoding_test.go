@@ -0,0 +1,96 @@
+package impl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun_OutputEncoding(t *testing.T) {
+	t.Parallel()
+
+	var lfStdout, lfStderr bytes.Buffer
+	if err := run([]string{"-mode", "plan"}, bytes.NewReader(dcaYAML), &lfStdout, &lfStderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	lf := lfStdout.String()
+	if strings.Contains(lf, "\r") || strings.HasPrefix(lf, utf8BOM) {
+		t.Fatalf("default output contains CR or a BOM:\n%q", lf)
+	}
+
+	tests := []struct {
+		encoding string
+		want     string
+	}{
+		{"lf", lf},
+		{"crlf", strings.ReplaceAll(lf, "\n", "\r\n")},
+		{"LF-BOM", utf8BOM + lf},
+		{"crlf-bom", utf8BOM + strings.ReplaceAll(lf, "\n", "\r\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run([]string{"-mode", "plan", "-output-encoding", tt.encoding}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Fatalf("run(-output-encoding=%s) = %q, want %q", tt.encoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodingWriter_WritesBOMOnce(t *testing.T) {
+	var buf bytes.Buffer
+	w := outputEncodingCRLFBOM.writer(&buf)
+	for _, s := range []string{"", "a\n", "b\n"} {
+		n, err := w.Write([]byte(s))
+		if err != nil || n != len(s) {
+			t.Fatalf("Write(%q) = (%d, %v), want (%d, nil)", s, n, err, len(s))
+		}
+	}
+	if got, want := buf.String(), utf8BOM+"a\r\nb\r\n"; got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}
+
+func TestRun_NoTrailingNewline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "plan", args: []string{"-mode", "plan"}},
+		{name: "crlf", args: []string{"-mode", "plan", "-output-encoding", "crlf-bom"}},
+		{name: "lint", args: []string{"-lint", "-lint-severity", "error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want, got, stderr bytes.Buffer
+			if err := run(tt.args, bytes.NewReader(dcaYAML), &want, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if err := run(append(tt.args, "-no-trailing-newline"), bytes.NewReader(dcaYAML), &got, &stderr); err != nil {
+				t.Fatalf("run(-no-trailing-newline) error = %v", err)
+			}
+			if wantTrimmed := strings.TrimRight(want.String(), "\r\n"); got.String() != wantTrimmed {
+				t.Fatalf("run(-no-trailing-newline) = %q, want %q", got.String(), wantTrimmed)
+			}
+		})
+	}
+}
+
+func TestTrailingNewlineTrimmer(t *testing.T) {
+	var buf bytes.Buffer
+	w := &trailingNewlineTrimmer{w: &buf}
+	for _, s := range []string{"a\n", "\n", "", "b\n\n", "\n"} {
+		n, err := w.Write([]byte(s))
+		if err != nil || n != len(s) {
+			t.Fatalf("Write(%q) = (%d, %v), want (%d, nil)", s, n, err, len(s))
+		}
+	}
+	if got, want := buf.String(), "a\n\nb"; got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}
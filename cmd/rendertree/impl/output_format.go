@@ -0,0 +1,185 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/goccy/go-yaml"
+	"github.com/samber/lo"
+
+	"github.com/apstndb/spannerplan"
+	"github.com/apstndb/spannerplan/plantree"
+)
+
+// outputFormat is the value of --format.
+type outputFormat string
+
+const (
+	outputFormatText     outputFormat = "text"
+	outputFormatJSON     outputFormat = "json"
+	outputFormatJSONL    outputFormat = "jsonl"
+	outputFormatCSV      outputFormat = "csv"
+	outputFormatTSV      outputFormat = "tsv"
+	outputFormatMarkdown outputFormat = "markdown"
+	outputFormatOTLP     outputFormat = "otlp"
+	outputFormatDOT      outputFormat = "dot"
+	outputFormatMermaid  outputFormat = "mermaid"
+)
+
+func parseOutputFormat(s string) (outputFormat, error) {
+	switch f := outputFormat(strings.ToLower(s)); f {
+	case outputFormatText, outputFormatJSON, outputFormatJSONL, outputFormatCSV, outputFormatTSV, outputFormatMarkdown, outputFormatOTLP, outputFormatDOT, outputFormatMermaid:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format: %q. Must be one of text, json, jsonl, csv, tsv, markdown, otlp, dot, mermaid", s)
+	}
+}
+
+// jsonRow is the --format=json, --format=jsonl and --dump-rows form of a [plantree.RowWithPredicates].
+type jsonRow struct {
+	// ID is the PlanNode index, without the "*" predicate marker of FormatID.
+	ID            int32    `json:"id"`
+	DisplayID     int32    `json:"displayId"`
+	Text          string   `json:"text"`
+	HasPredicates bool     `json:"hasPredicates"`
+	Predicates    []string `json:"predicates,omitempty"`
+	// Stats is the execution stats of the PlanNode as in the plan, keyed by their Spanner names,
+	// such as {"rows": {"total": "33", "unit": "rows"}}; execution_summary is an object too.
+	Stats map[string]any `json:"stats,omitempty"`
+}
+
+// newJSONRow returns the jsonRow of row, which is rendered from the PlanNode node.
+func newJSONRow(row plantree.RowWithPredicates, node *sppb.PlanNode) jsonRow {
+	return jsonRow{
+		ID:            row.ID,
+		DisplayID:     row.DisplayID(),
+		Text:          row.Text(),
+		HasPredicates: len(row.Predicates) != 0,
+		Predicates:    row.Predicates,
+		Stats:         node.GetExecutionStats().AsMap(),
+	}
+}
+
+// runJSON writes the rendered rows of planNodes as a JSON array. The rows are all rendered
+// first, and then encoded and written one at a time, so the output is never held in memory as a
+// whole. The array is indented unless compact is set, in which case it is written on one line for
+// tools such as jq.
+func runJSON(planNodes []*sppb.PlanNode, opts []plantree.Option, compact bool, w io.Writer) error {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	rows, err := plantree.ProcessPlan(qp, opts...)
+	if err != nil {
+		return err
+	}
+
+	open, sep, end := "[\n  ", ",\n  ", "\n]\n"
+	if compact {
+		open, sep, end = "[", ",", "]\n"
+	}
+	if len(rows) == 0 {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if !compact {
+		enc.SetIndent("  ", "  ")
+	}
+	for i, row := range rows {
+		buf.Reset()
+		buf.WriteString(lo.Ternary(i == 0, open, sep))
+		if err := enc.Encode(newJSONRow(row, qp.GetNodeByIndex(row.ID))); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode appends a newline.
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, end)
+	return err
+}
+
+// runJSONLines writes the rendered rows of planNodes as JSON Lines, one compact [jsonRow] object
+// per line, for jq and log pipelines that read a stream of records rather than one array.
+func runJSONLines(planNodes []*sppb.PlanNode, opts []plantree.Option, w io.Writer) error {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	rows, err := plantree.ProcessPlan(qp, opts...)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, row := range rows {
+		if err := enc.Encode(newJSONRow(row, qp.GetNodeByIndex(row.ID))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runDumpRows writes the rendered rows of planNodes as a YAML sequence of the --format=json
+// rows, for snapshot tests that should not depend on table widths. Rows keep the tree order
+// and the keys of Stats are sorted at every level, so the same plan always dumps to the same bytes.
+func runDumpRows(planNodes []*sppb.PlanNode, opts []plantree.Option, w io.Writer) error {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	rows, err := plantree.ProcessPlan(qp, opts...)
+	if err != nil {
+		return err
+	}
+
+	dump := make([]jsonRow, 0, len(rows))
+	for _, row := range rows {
+		dump = append(dump, newJSONRow(row, qp.GetNodeByIndex(row.ID)))
+	}
+	b, err := yaml.MarshalWithOptions(dump, yaml.UseLiteralStyleIfMultiline(true))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// runGraph writes the operator tree of planNodes as a Graphviz digraph for --format=dot or a
+// Mermaid flowchart for --format=mermaid, with node labels rendered with titleOpts and, when
+// scalarLinks is set, dashed edges to the hidden scalar nodes. With dataFlow, the digraph also
+// has the data-flow edges of [spannerplan.ShowDataFlow].
+func runGraph(planNodes []*sppb.PlanNode, format outputFormat, titleOpts []spannerplan.Option, scalarLinks, dataFlow bool, w io.Writer) error {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	titleOpts = slices.Clip(titleOpts)
+	if scalarLinks {
+		titleOpts = append(titleOpts, spannerplan.ShowScalarLinks())
+	}
+	if dataFlow {
+		titleOpts = append(titleOpts, spannerplan.ShowDataFlow())
+	}
+	render := spannerplan.RenderDOT
+	if format == outputFormatMermaid {
+		render = spannerplan.RenderMermaid
+	}
+	graph, err := render(qp, titleOpts...)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, graph)
+	return err
+}
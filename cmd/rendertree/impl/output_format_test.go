@@ -0,0 +1,390 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/goccy/go-yaml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun_FormatJSON(t *testing.T) {
+	t.Parallel()
+
+	var pretty, compact, stderr bytes.Buffer
+	if err := run([]string{"-format", "json"}, bytes.NewReader(dcaProfileYAML), &pretty, &stderr); err != nil {
+		t.Fatalf("run(-format=json) error = %v", err)
+	}
+	if err := run([]string{"-format", "json", "-json-compact"}, bytes.NewReader(dcaProfileYAML), &compact, &stderr); err != nil {
+		t.Fatalf("run(-format=json -json-compact) error = %v", err)
+	}
+
+	if !strings.HasPrefix(pretty.String(), "[\n  {\n    \"id\": 0,\n") {
+		t.Errorf("pretty output is not indented:\n%s", pretty.String())
+	}
+	if got := strings.Count(compact.String(), "\n"); got != 1 || !strings.HasSuffix(compact.String(), "]\n") {
+		t.Errorf("compact output has %d newlines, want a single line:\n%s", got, compact.String())
+	}
+
+	var prettyRows, compactRows []jsonRow
+	if err := json.Unmarshal(pretty.Bytes(), &prettyRows); err != nil {
+		t.Fatalf("json.Unmarshal(pretty) error = %v", err)
+	}
+	if err := json.Unmarshal(compact.Bytes(), &compactRows); err != nil {
+		t.Fatalf("json.Unmarshal(compact) error = %v", err)
+	}
+	if diff := cmp.Diff(prettyRows, compactRows); diff != "" {
+		t.Errorf("pretty and compact rows differ (-pretty +compact):\n%s", diff)
+	}
+
+	want := jsonRow{
+		ID:            1,
+		DisplayID:     1,
+		Text:          "+- Distributed Cross Apply <Row>",
+		HasPredicates: true,
+		Predicates:    []string{"Split Range: ($AlbumId = $AlbumId_1)"},
+	}
+	got := prettyRows[1]
+	if diff := cmp.Diff(map[string]any{"total": "33", "unit": "rows"}, got.Stats["rows"]); diff != "" {
+		t.Errorf("rows[1].Stats[rows] mismatch (-want +got):\n%s", diff)
+	}
+	summary, ok := got.Stats["execution_summary"].(map[string]any)
+	if !ok || summary["num_executions"] != "1" {
+		t.Errorf("rows[1].Stats[execution_summary] = %#v, want an object with num_executions", got.Stats["execution_summary"])
+	}
+	got.Stats = nil
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("rows[1] mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun_DumpRows(t *testing.T) {
+	t.Parallel()
+
+	var first, second, stderr bytes.Buffer
+	if err := run([]string{"-dump-rows", "-mode", "plan"}, bytes.NewReader(dcaYAML), &first, &stderr); err != nil {
+		t.Fatalf("run(-dump-rows) error = %v", err)
+	}
+	if err := run([]string{"-dump-rows", "-mode", "plan"}, bytes.NewReader(dcaYAML), &second, &stderr); err != nil {
+		t.Fatalf("run(-dump-rows) error = %v", err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("run(-dump-rows) is not deterministic:\n%s\n---\n%s", first.String(), second.String())
+	}
+
+	want := heredoc.Doc(`
+		- id: 0
+		  displayId: 0
+		  text: Distributed Union on AlbumsByAlbumTitle <Row>
+		  hasPredicates: false
+		- id: 1
+		  displayId: 1
+		  text: "+- Distributed Cross Apply <Row>"
+		  hasPredicates: true
+		  predicates:
+		  - "Split Range: ($AlbumId = $AlbumId_1)"
+	`)
+	if got := first.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("run(-dump-rows) output does not start with:\n%s\ngot:\n%s", want, got)
+	}
+
+	var rows []jsonRow
+	if err := yaml.Unmarshal(first.Bytes(), &rows); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	if len(rows) != 12 || rows[11].ID != 18 {
+		t.Errorf("run(-dump-rows) rows = %+v, want the 12 rows of the plan", rows)
+	}
+}
+
+func TestRun_FormatJSONL(t *testing.T) {
+	t.Parallel()
+
+	var jsonl, array, stderr bytes.Buffer
+	if err := run([]string{"-format", "jsonl"}, bytes.NewReader(dcaProfileYAML), &jsonl, &stderr); err != nil {
+		t.Fatalf("run(-format=jsonl) error = %v", err)
+	}
+	if err := run([]string{"-format", "json"}, bytes.NewReader(dcaProfileYAML), &array, &stderr); err != nil {
+		t.Fatalf("run(-format=json) error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(jsonl.String(), "\n"), "\n")
+	var rows []jsonRow
+	for i, line := range lines {
+		var row jsonRow
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatalf("json.Unmarshal(line %d) error = %v", i, err)
+		}
+		rows = append(rows, row)
+	}
+	var wantRows []jsonRow
+	if err := json.Unmarshal(array.Bytes(), &wantRows); err != nil {
+		t.Fatalf("json.Unmarshal(array) error = %v", err)
+	}
+	if diff := cmp.Diff(wantRows, rows); diff != "" {
+		t.Errorf("JSON Lines rows differ from the JSON array (-json +jsonl):\n%s", diff)
+	}
+
+	// Create Batch has no execution stats, so its line has no stats key at all.
+	if want := `{"id":2,"displayId":2,"text":"   +- [Input] Create Batch <Row>","hasPredicates":false}`; lines[2] != want {
+		t.Errorf("lines[2] = %s, want %s", lines[2], want)
+	}
+}
+
+func TestRun_FormatCSV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		comma      rune
+		wantHeader []string
+		wantRow    []string
+	}{
+		{
+			name:       "csv",
+			args:       []string{"-format", "csv"},
+			comma:      ',',
+			wantHeader: []string{"ID", "Operator", "Rows", "Exec.", "Latency", "Predicates"},
+			wantRow:    []string{"*17", "            +- Filter Scan <Row> (seekable_key_size: 0)", "", "", "", "Residual Condition: ($AlbumId = $batched_AlbumId_1)"},
+		},
+		{
+			name:       "tsv",
+			args:       []string{"-format", "tsv"},
+			comma:      '\t',
+			wantHeader: []string{"ID", "Operator", "Rows", "Exec.", "Latency", "Predicates"},
+			wantRow:    []string{"*17", "            +- Filter Scan <Row> (seekable_key_size: 0)", "", "", "", "Residual Condition: ($AlbumId = $batched_AlbumId_1)"},
+		},
+		{
+			name: "custom columns",
+			args: []string{
+				"-format", "csv",
+				"-custom-column", `{"name":"Node, quoted","template":"\"{{.NodeText}}\""}`,
+				"-custom-column", `{"name":"Rows","template":"{{.ExecutionStats.Rows.Total}}"}`,
+			},
+			comma:      ',',
+			wantHeader: []string{"Node, quoted", "Rows", "Predicates"},
+			wantRow:    []string{`"Filter Scan <Row> (seekable_key_size: 0)"`, "", "Residual Condition: ($AlbumId = $batched_AlbumId_1)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(tt.args, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+				t.Fatalf("run(%v) error = %v", tt.args, err)
+			}
+			r := csv.NewReader(&stdout)
+			r.Comma = tt.comma
+			records, err := r.ReadAll()
+			if err != nil {
+				t.Fatalf("csv.Reader.ReadAll() error = %v", err)
+			}
+			if len(records) != 13 {
+				t.Fatalf("len(records) = %d, want a header and 12 rows", len(records))
+			}
+			if diff := cmp.Diff(tt.wantHeader, records[0]); diff != "" {
+				t.Errorf("header mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRow, records[11]); diff != "" {
+				t.Errorf("row of node 17 mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRun_FormatMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-format", "markdown"}, bytes.NewReader(deleteYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-format=markdown) error = %v", err)
+	}
+	// Show the no-break spaces that keep the tree indentation as "·".
+	got := strings.ReplaceAll(stdout.String(), "\u00a0", "·")
+	want := heredoc.Doc(`
+		DELETE on MutationTest
+
+		| ID | Operator | Rows | Exec. | Latency |
+		| ---: | :--- | ---: | ---: | ---: |
+		| 0 | Apply Mutations on MutationTest &lt;Row&gt; (operation_type: DELETE) | 0 | 1 | 0.04 ms |
+		| 1 | +- Distributed Union on MutationTest &lt;Row&gt; |  |  |  |
+		| 2 | ···+- Local Distributed Union &lt;Row&gt; |  |  |  |
+		| 3 | ······+- Serialize Result &lt;Row&gt; |  |  |  |
+		| 4 | ·········+- Table Scan on MutationTest &lt;Row&gt; (Full scan, scan_method: Automatic) |  |  |  |
+	`)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("run(-format=markdown) mismatch (-want +got):\n%s", diff)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-format", "markdown"}, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-format=markdown) error = %v", err)
+	}
+	wantAppendix := heredoc.Doc(`
+		| 18 | ···············+- Index Scan on SongsBySongGenre &lt;Row&gt; (Full scan, scan_method: Row) | 33 | 7 | 0.84 ms |
+
+		` + "```" + `
+		Predicates(identified by ID):
+		  1: Split Range: ($AlbumId = $AlbumId_1)
+		 17: Residual Condition: ($AlbumId = $batched_AlbumId_1)
+		` + "```" + `
+	`)
+	if got := strings.ReplaceAll(stdout.String(), "\u00a0", "·"); !strings.HasSuffix(got, wantAppendix) {
+		t.Errorf("run(-format=markdown) does not end with the predicates code block %q:\n%s", wantAppendix, got)
+	}
+}
+
+func TestRun_FormatOTLP(t *testing.T) {
+	t.Parallel()
+
+	var first, second, stderr bytes.Buffer
+	if err := run([]string{"-format", "otlp"}, bytes.NewReader(dcaProfileYAML), &first, &stderr); err != nil {
+		t.Fatalf("run(-format=otlp) error = %v", err)
+	}
+	if err := run([]string{"-format", "otlp"}, bytes.NewReader(dcaProfileYAML), &second, &stderr); err != nil {
+		t.Fatalf("run(-format=otlp) error = %v", err)
+	}
+	if first.String() != second.String() {
+		t.Error("run(-format=otlp) is not deterministic")
+	}
+
+	var traces otlpTraces
+	if err := json.Unmarshal(first.Bytes(), &traces); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 12 {
+		t.Fatalf("len(spans) = %d, want 12", len(spans))
+	}
+	traceID := spans[0].TraceID
+	if len(traceID) != 32 {
+		t.Errorf("traceId = %q, want 32 hex digits", traceID)
+	}
+	for _, span := range spans {
+		if span.TraceID != traceID {
+			t.Errorf("span %s traceId = %q, want %q", span.SpanID, span.TraceID, traceID)
+		}
+	}
+	want := otlpSpan{
+		TraceID:           traceID,
+		SpanID:            "0000000000000002",
+		ParentSpanID:      "0000000000000001",
+		Name:              "Distributed Cross Apply",
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: "1745245143426959000",
+		EndTimeUnixNano:   "1745245143428876000",
+	}
+	got := spans[1]
+	if i := slices.IndexFunc(got.Attributes, func(kv otlpKeyValue) bool { return kv.Key == "spanner.stats.rows" }); i < 0 || got.Attributes[i].Value.StringValue != "33 rows" {
+		t.Errorf("spans[1].Attributes = %v, want spanner.stats.rows of 33 rows", got.Attributes)
+	}
+	got.Attributes = nil
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("spans[1] mismatch (-want +got):\n%s", diff)
+	}
+
+	err := run([]string{"-format", "otlp"}, bytes.NewReader(dcaYAML), &first, &stderr)
+	if err == nil || err.Error() != "--format=otlp is only valid in PROFILE mode" {
+		t.Errorf("run(-format=otlp) on a PLAN error = %v, want PROFILE-only error", err)
+	}
+}
+
+func TestRun_FormatGraph(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "operators",
+			args: []string{"-format", "dot"},
+			want: heredoc.Doc(`
+				digraph plan {
+				  node [shape=box];
+				  0 [label="Apply Mutations on MutationTest <Row> (operation_type: DELETE)"];
+				  1 [label="Distributed Union on MutationTest <Row>"];
+				  2 [label="Local Distributed Union <Row>"];
+				  3 [label="Serialize Result <Row>"];
+				  4 [label="Table Scan on MutationTest <Row> (Full scan, scan_method: Automatic)"];
+				  0 -> 1;
+				  1 -> 2;
+				  2 -> 3;
+				  3 -> 4;
+				}
+			`),
+		},
+		{
+			name: "scalar links",
+			args: []string{"-format", "dot", "-scalar-links", "-execution-method", "raw"},
+			want: heredoc.Doc(`
+				digraph plan {
+				  node [shape=box];
+				  0 [label="Apply Mutations on MutationTest (execution_method: Row, operation_type: DELETE)"];
+				  1 [label="Distributed Union on MutationTest (execution_method: Row)"];
+				  2 [label="Local Distributed Union (execution_method: Row)"];
+				  3 [label="Serialize Result (execution_method: Row)"];
+				  4 [label="Table Scan on MutationTest (Full scan, execution_method: Row, scan_method: Automatic)"];
+				  5 [label="PK", shape=ellipse];
+				  6 [label="$PK", shape=ellipse];
+				  7 [label="true", shape=ellipse];
+				  0 -> 1;
+				  1 -> 2;
+				  2 -> 3;
+				  3 -> 4;
+				  4 -> 5 [style=dashed];
+				  3 -> 6 [style=dashed];
+				  1 -> 7 [label="[Split Range]", style=dashed];
+				}
+			`),
+		},
+		{
+			name: "mermaid",
+			args: []string{"-format", "mermaid", "-scalar-links"},
+			want: heredoc.Doc(`
+				flowchart TD
+				  n0["Apply Mutations on MutationTest #lt;Row#gt; #40;operation_type: DELETE#41;"]
+				  n1["Distributed Union on MutationTest #lt;Row#gt;"]
+				  n2["Local Distributed Union #lt;Row#gt;"]
+				  n3["Serialize Result #lt;Row#gt;"]
+				  n4["Table Scan on MutationTest #lt;Row#gt; #40;Full scan, scan_method: Automatic#41;"]
+				  n5("PK")
+				  n6("$PK")
+				  n7("true")
+				  n0 --> n1
+				  n1 --> n2
+				  n2 --> n3
+				  n3 --> n4
+				  n4 -.-> n5
+				  n3 -.-> n6
+				  n1 -.->|"#91;Split Range#93;"| n7
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(tt.args, bytes.NewReader(deleteYAML), &stdout, &stderr); err != nil {
+				t.Fatalf("run(%v) error = %v", tt.args, err)
+			}
+			if diff := cmp.Diff(tt.want, stdout.String()); diff != "" {
+				t.Errorf("run(%v) mismatch (-want +got):\n%s", tt.args, diff)
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-format", "dot", "-show-dataflow"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-show-dataflow) error = %v", err)
+	}
+	// Create Batch defines $v2, the scan target of the Batch Scan.
+	if want := "  2 -> 13 [label=\"$v2\", style=dashed, color=blue, fontcolor=blue, constraint=false];\n"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("run(-show-dataflow) = %q, want it to contain %q", stdout.String(), want)
+	}
+}
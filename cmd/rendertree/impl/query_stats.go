@@ -0,0 +1,32 @@
+package impl
+
+import (
+	"strings"
+)
+
+// queryStatsHeaderKeys are the query stats printed by --query-stats, in output order.
+var queryStatsHeaderKeys = []string{"elapsed_time", "cpu_time", "rows_returned", "optimizer_version"}
+
+// queryStatsHeader returns the header block of --query-stats: one "key: value" line for each of
+// queryStatsHeaderKeys in queryStats, followed by a blank line. With markdown, the lines are list
+// items. It returns "" when queryStats has none of the keys, as for PLAN captures, so that the
+// header is omitted rather than printed empty.
+func queryStatsHeader(queryStats map[string]string, markdown bool) string {
+	var b strings.Builder
+	for _, key := range queryStatsHeaderKeys {
+		value, ok := queryStats[key]
+		if !ok {
+			continue
+		}
+		if markdown {
+			b.WriteString("- ")
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(key + ": " + value + "\n")
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "Query stats:\n" + b.String() + "\n"
+}
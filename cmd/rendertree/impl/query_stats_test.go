@@ -0,0 +1,66 @@
+package impl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	heredoc "github.com/MakeNowJust/heredoc/v2"
+)
+
+func TestRun_QueryStats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		input      []byte
+		wantHeader string
+	}{
+		{
+			name:  "profile",
+			args:  []string{"-query-stats"},
+			input: dcaProfileYAML,
+			wantHeader: heredoc.Doc(`
+				Query stats:
+				  elapsed_time: 7.54 msecs
+				  cpu_time: 5.34 msecs
+				  rows_returned: 33
+				  optimizer_version: 7
+
+				+-----+`),
+		},
+		{
+			name:  "markdown",
+			args:  []string{"-query-stats", "-format", "markdown"},
+			input: dcaProfileYAML,
+			wantHeader: heredoc.Doc(`
+				Query stats:
+				- elapsed_time: 7.54 msecs
+				- cpu_time: 5.34 msecs
+				- rows_returned: 33
+				- optimizer_version: 7
+
+				| ID |`),
+		},
+		{
+			name:       "plan without query stats",
+			args:       []string{"-query-stats"},
+			input:      dcaYAML,
+			wantHeader: "+-----+",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			if err := run(tt.args, bytes.NewReader(tt.input), &stdout, &stderr); err != nil {
+				t.Fatalf("run(%q) error = %v", tt.args, err)
+			}
+			if got := stdout.String(); !strings.HasPrefix(got, tt.wantHeader) {
+				t.Errorf("run(%q) output does not start with %q:\n%s", tt.args, tt.wantHeader, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,725 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apstndb/spannerplan"
+	"github.com/apstndb/spannerplan/plantree"
+	"github.com/apstndb/spannerplan/stats"
+)
+
+// RenderConfig is the programmatic form of the rendertree flags, for [Render]. Each field holds
+// the value of the flag with the matching name, such as Mode for --mode and CustomColumns for
+// the repeated --custom-column, and accepts the same strings. Start from [DefaultRenderConfig],
+// since the zero value of a string field is an empty flag value rather than its default.
+//
+// RenderConfig encodes as a JSON object keyed by the field names in lower camel case, such as
+// "customColumns" and "wrapWidth", except for Warnings, which is not encoded. Decode config files
+// into [DefaultRenderConfig] so that omitted keys keep their defaults.
+type RenderConfig struct {
+	CustomFile    string   `json:"customFile"`
+	CustomColumns []string `json:"customColumns"`
+	Mode          string   `json:"mode"`
+	StatsFrom     string   `json:"statsFrom"`
+	MaxInputBytes string   `json:"maxInputBytes"`
+	InputFormat   string   `json:"inputFormat"`
+
+	Print                string `json:"print"`
+	ShowVars             bool   `json:"showVars"`
+	ResolveVars          bool   `json:"resolveVars"`
+	ResolveVarsRecursive bool   `json:"resolveVarsRecursive"`
+	DisallowUnknownStats bool   `json:"disallowUnknownStats"`
+	BestEffort           bool   `json:"bestEffort"`
+
+	Layout           string `json:"layout"`
+	Tableless        bool   `json:"tableless"`
+	ExecutionMethod  string `json:"executionMethod"`
+	TargetMetadata   string `json:"targetMetadata"`
+	KnownFlag        string `json:"knownFlag"`
+	Compact          bool   `json:"compact"`
+	InlineStats      bool   `json:"inlineStats"`
+	StatBadges       bool   `json:"statBadges"`
+	LatencyBar       int    `json:"latencyBar"`
+	DropEmptyColumns bool   `json:"dropEmptyColumns"`
+	RowsProduced     bool   `json:"rowsProduced"`
+	AvgPerExecution  bool   `json:"avgPerExecution"`
+	LatencyPercent   bool   `json:"latencyPercent"`
+	CPUTime          bool   `json:"cpuTime"`
+	Summary          bool   `json:"summary"`
+	QueryStats       bool   `json:"queryStats"`
+	WrapWidth        int    `json:"wrapWidth"`
+	HangingIndent    bool   `json:"hangingIndent"`
+	FixedWidths      string `json:"fixedWidths"`
+	ColumnOrder      string `json:"columnOrder"`
+	Sections         string `json:"sections"`
+
+	Lint               bool   `json:"lint"`
+	LintSeverity       string `json:"lintSeverity"`
+	Advisories         bool   `json:"advisories"`
+	VerifyIndex        string `json:"verifyIndex"`
+	Search             string `json:"search"`
+	Interactive        bool   `json:"interactive"`
+	CriticalPath       string `json:"criticalPath"`
+	Shape              bool   `json:"shape"`
+	DumpRows           bool   `json:"dumpRows"`
+	Diff               string `json:"diff"`
+	ProfileDiffSummary bool   `json:"profileDiffSummary"`
+
+	ThemeFile          string  `json:"themeFile"`
+	Color              string  `json:"color"`
+	Heat               bool    `json:"heat"`
+	HeatThreshold      float64 `json:"heatThreshold"`
+	Format             string  `json:"format"`
+	JSONCompact        bool    `json:"jsonCompact"`
+	ScalarLinks        bool    `json:"scalarLinks"`
+	ShowDataflow       bool    `json:"showDataflow"`
+	NoTrailingNewline  bool    `json:"noTrailingNewline"`
+	OutputEncoding     string  `json:"outputEncoding"`
+	IDTemplate         string  `json:"idTemplate"`
+	PredicateTemplate  string  `json:"predicateTemplate"`
+	TruncatePredicates int     `json:"truncatePredicates"`
+
+	// Warnings receives what rendertree writes to stderr: warnings about the input and, with
+	// Format "json", the Warnings section of BestEffort. Nil discards them.
+	Warnings io.Writer `json:"-"`
+}
+
+// DefaultRenderConfig returns the configuration of rendertree run without flags.
+func DefaultRenderConfig() RenderConfig {
+	return RenderConfig{
+		Mode:            "AUTO",
+		MaxInputBytes:   stats.FormatBytes(spannerplan.MaxQueryPlanBytes),
+		InputFormat:     string(inputFormatAuto),
+		Print:           "basic",
+		Layout:          string(layoutTable),
+		ExecutionMethod: "angle",
+		TargetMetadata:  "on",
+		Sections:        "table,appendix",
+		LintSeverity:    "warning",
+		Color:           "auto",
+		HeatThreshold:   50,
+		Format:          string(outputFormatText),
+		OutputEncoding:  string(outputEncodingLF),
+	}
+}
+
+// Render renders input, a query plan in any format rendertree reads, gzip-compressed or not, to
+// w as rendertree does with the flags in cfg. It returns an error for an invalid cfg before
+// reading input, and the same errors as rendertree otherwise, such as when a --lint finding
+// fails the run or the decompressed input is larger than cfg.MaxInputBytes.
+func Render(w io.Writer, input []byte, cfg RenderConfig) error {
+	r, err := newRenderer(cfg)
+	if err != nil {
+		return err
+	}
+	return r.render(input, w)
+}
+
+// RenderTreeTable renders planNodes, such as the plan nodes of a decoded ResultSetStats, as
+// [Render] renders input holding them, and returns the output. It is a wrapper around
+// [RenderTreeTableTo] for callers that want a string.
+func RenderTreeTable(planNodes []*sppb.PlanNode, cfg RenderConfig) (string, error) {
+	var sb strings.Builder
+	if err := RenderTreeTableTo(&sb, planNodes, cfg); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// RenderTreeTableTo renders planNodes to w as [RenderTreeTable] does. The table and the appendix
+// are each written as soon as they are rendered, so large output can stream to stdout or an HTTP
+// response, and an error can follow the sections already written. It cannot show query stats,
+// which planNodes do not carry, and returns an error when cfg.Interactive is set, since the
+// terminal UI needs a terminal rather than a writer. planNodes are not modified: with
+// cfg.StatsFrom, the execution stats are attached to a copy.
+func RenderTreeTableTo(w io.Writer, planNodes []*sppb.PlanNode, cfg RenderConfig) error {
+	r, err := newRenderer(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.Interactive {
+		return errors.New("RenderTreeTable cannot render Interactive")
+	}
+	if cfg.StatsFrom != "" {
+		// Attaching the stats overwrites ExecutionStats, so work on a copy of the caller's nodes.
+		planNodes = slices.Clone(planNodes)
+		for i, node := range planNodes {
+			planNodes[i] = proto.Clone(node).(*sppb.PlanNode)
+		}
+	}
+	return r.renderStats(&sppb.ResultSetStats{QueryPlan: &sppb.QueryPlan{PlanNodes: planNodes}}, w, r.output(w))
+}
+
+// configError is an invalid [RenderConfig]. rendertree reports it as a usage error: it prints
+// message and the usage, and exits with err.
+type configError struct {
+	message string
+	err     error
+}
+
+func (e *configError) Error() string { return e.message }
+
+func (e *configError) Unwrap() error { return e.err }
+
+func invalidFlag(name string, err error) *configError {
+	return &configError{message: fmt.Sprintf("Invalid value for -%s flag: %v", name, err), err: err}
+}
+
+func invalidCombination(msg string) *configError {
+	return &configError{message: msg, err: errors.New(msg)}
+}
+
+// renderer is a validated [RenderConfig]. It renders a single input, since it collects the
+// Warnings section of BestEffort.
+type renderer struct {
+	cfg RenderConfig
+
+	maxInputBytes   int64
+	printSections   PrintSections
+	formatPredicate predicateFormatter
+	idMapFunc       func(row plantree.RowWithPredicates) (string, error)
+	fixedWidths     []fixedWidth
+	columnOrder     []string
+	outputSections  []outputSection
+	failSeverity    spannerplan.Severity
+	encoding        outputEncoding
+	format          outputFormat
+	searchRegexp    *regexp.Regexp
+	color           colorMode
+	theme           *theme
+	mode            explainMode
+	inputFormat     inputFormat
+	layout          layout
+	criticalPath    criticalPathMode
+	titleOpts       []spannerplan.Option
+	opts            []plantree.Option
+	warnings        statsWarnings
+}
+
+// newRenderer validates cfg, returning a *configError for a bad value or combination. It
+// checks the values in the order rendertree reports them.
+func newRenderer(cfg RenderConfig) (*renderer, error) {
+	r := &renderer{cfg: cfg}
+	if r.cfg.Warnings == nil {
+		r.cfg.Warnings = io.Discard
+	}
+
+	if len(cfg.CustomColumns) > 0 && cfg.CustomFile != "" {
+		return nil, invalidCombination("--custom-column and --custom-file are mutually exclusive")
+	}
+
+	var err error
+	if r.maxInputBytes, err = stats.ParseBytes(cfg.MaxInputBytes); err != nil {
+		return nil, invalidFlag("max-input-bytes", err)
+	}
+	if r.printSections, err = parsePrintSections(cfg.Print); err != nil {
+		return nil, invalidFlag("print", err)
+	}
+	if cfg.PredicateTemplate != "" {
+		if r.formatPredicate, err = parsePredicateTemplate(cfg.PredicateTemplate); err != nil {
+			return nil, invalidFlag("predicate-template", err)
+		}
+	}
+	if cfg.TruncatePredicates < 0 {
+		return nil, invalidFlag("truncate-predicates", fmt.Errorf("width cannot be negative: %d", cfg.TruncatePredicates))
+	}
+	if cfg.TruncatePredicates > 0 {
+		r.formatPredicate = truncatePredicates(r.formatPredicate, cfg.TruncatePredicates)
+	}
+	if cfg.IDTemplate != "" {
+		if r.idMapFunc, err = parseIDTemplate(cfg.IDTemplate); err != nil {
+			return nil, invalidFlag("id-template", err)
+		}
+	}
+	if r.fixedWidths, err = parseFixedWidths(cfg.FixedWidths); err != nil {
+		return nil, invalidFlag("fixed-widths", err)
+	}
+	if r.columnOrder, err = parseColumnOrder(cfg.ColumnOrder); err != nil {
+		return nil, invalidFlag("column-order", err)
+	}
+	if r.outputSections, err = parseOutputSections(cfg.Sections); err != nil {
+		return nil, invalidFlag("sections", err)
+	}
+	if r.failSeverity, err = parseLintSeverity(cfg.LintSeverity); err != nil {
+		return nil, invalidFlag("lint-severity", err)
+	}
+	if r.encoding, err = parseOutputEncoding(cfg.OutputEncoding); err != nil {
+		return nil, invalidFlag("output-encoding", err)
+	}
+	if r.format, err = parseOutputFormat(cfg.Format); err != nil {
+		return nil, invalidFlag("format", err)
+	}
+	if cfg.JSONCompact && r.format != outputFormatJSON {
+		return nil, invalidCombination("--json-compact requires --format=json")
+	}
+	if cfg.Summary && r.format != outputFormatText && r.format != outputFormatMarkdown {
+		return nil, invalidCombination("--summary requires --format=text or --format=markdown")
+	}
+	if cfg.QueryStats && r.format != outputFormatText && r.format != outputFormatMarkdown {
+		return nil, invalidCombination("--query-stats requires --format=text or --format=markdown")
+	}
+	if cfg.ScalarLinks && r.format != outputFormatDOT && r.format != outputFormatMermaid {
+		return nil, invalidCombination("--scalar-links requires --format=dot or --format=mermaid")
+	}
+	if cfg.ShowDataflow && r.format != outputFormatDOT {
+		return nil, invalidCombination("--show-dataflow requires --format=dot")
+	}
+	if cfg.BestEffort && cfg.Lint {
+		return nil, invalidCombination("--best-effort cannot be combined with --lint")
+	}
+	if r.format != outputFormatText && (cfg.Lint || cfg.Interactive) {
+		return nil, invalidCombination("--format cannot be combined with --lint or --interactive")
+	}
+	if cfg.Search != "" {
+		if r.searchRegexp, err = regexp.Compile(cfg.Search); err != nil {
+			return nil, invalidFlag("search", err)
+		}
+		if cfg.Lint || cfg.Interactive || r.format != outputFormatText {
+			return nil, invalidCombination("--search cannot be combined with --lint, --interactive or --format")
+		}
+	}
+	if cfg.Shape && (cfg.Lint || cfg.Interactive || cfg.Search != "" || r.format != outputFormatText) {
+		return nil, invalidCombination("--shape cannot be combined with --lint, --interactive, --search or --format")
+	}
+	if cfg.Advisories && (cfg.Lint || cfg.Interactive || cfg.Search != "" || cfg.Diff != "" || r.format != outputFormatText) {
+		return nil, invalidCombination("--advisories cannot be combined with --lint, --interactive, --search, --diff or --format")
+	}
+	if cfg.DumpRows && (cfg.Lint || cfg.Interactive || cfg.Search != "" || cfg.Shape || r.format != outputFormatText) {
+		return nil, invalidCombination("--dump-rows cannot be combined with --lint, --interactive, --search, --shape or --format")
+	}
+	if cfg.ProfileDiffSummary && cfg.Diff == "" {
+		return nil, invalidCombination("--profile-diff-summary requires --diff")
+	}
+	// The summary alone can be written as JSON.
+	diffFormat := r.format != outputFormatText && !(cfg.ProfileDiffSummary && r.format == outputFormatJSON)
+	if cfg.Diff != "" && (cfg.Lint || cfg.Interactive || cfg.Search != "" || cfg.Shape || cfg.DumpRows || diffFormat) {
+		return nil, invalidCombination("--diff cannot be combined with --lint, --interactive, --search, --shape, --dump-rows or --format, except --format=json with --profile-diff-summary")
+	}
+	if cfg.QueryStats && (cfg.Lint || cfg.Interactive || cfg.Search != "" || cfg.DumpRows || cfg.Diff != "") {
+		return nil, invalidCombination("--query-stats cannot be combined with --lint, --interactive, --search, --dump-rows or --diff")
+	}
+	if cfg.CriticalPath != "" {
+		var f criticalPathFlag
+		if err := f.Set(cfg.CriticalPath); err != nil {
+			return nil, invalidFlag("critical-path", err)
+		}
+		r.criticalPath = f.mode
+	}
+
+	if r.color, err = parseColorMode(cfg.Color); err != nil {
+		return nil, invalidFlag("color", err)
+	}
+	if cfg.HeatThreshold < 0 || cfg.HeatThreshold > 100 {
+		return nil, invalidFlag("heat-threshold", fmt.Errorf("must be between 0 and 100, but: %v", cfg.HeatThreshold))
+	}
+	if cfg.ThemeFile != "" {
+		b, err := os.ReadFile(cfg.ThemeFile)
+		if err != nil {
+			return nil, err
+		}
+		if r.theme, err = parseThemeFile(b); err != nil {
+			return nil, invalidFlag("theme-file", err)
+		}
+		r.opts = append(r.opts, r.theme.plantreeOptions()...)
+	}
+
+	if r.mode, err = parseExplainMode(cfg.Mode); err != nil {
+		return nil, invalidFlag("mode", err)
+	}
+	if r.inputFormat, err = parseInputFormat(cfg.InputFormat); err != nil {
+		return nil, invalidFlag("input-format", err)
+	}
+	if r.layout, err = parseLayout(cfg.Layout); err != nil {
+		return nil, invalidFlag("layout", err)
+	}
+	if cfg.Tableless {
+		if r.layout != layoutTableless {
+			return nil, invalidCombination("--tableless and --layout=table are mutually exclusive")
+		}
+	}
+	if r.format == outputFormatMarkdown && r.layout == layoutTableless {
+		return nil, invalidCombination("--format=markdown cannot be combined with --layout=tableless")
+	}
+
+	if cfg.DisallowUnknownStats {
+		r.opts = append(r.opts, plantree.DisallowUnknownStats())
+	}
+	if cfg.BestEffort {
+		r.opts = append(r.opts, plantree.WithStatsErrorHandler(r.warnings.add))
+	}
+	if cfg.Compact {
+		r.opts = append(r.opts, plantree.EnableCompact())
+	}
+
+	em := spannerplan.ExecutionMethodFormatAngle
+	if cfg.ExecutionMethod != "" {
+		if em, err = spannerplan.ParseExecutionMethodFormat(cfg.ExecutionMethod); err != nil {
+			return nil, invalidFlag("execution-method", err)
+		}
+	}
+	tm := spannerplan.TargetMetadataFormatOn
+	if cfg.TargetMetadata != "" {
+		if tm, err = spannerplan.ParseTargetMetadataFormat(cfg.TargetMetadata); err != nil {
+			return nil, invalidFlag("target-metadata", err)
+		}
+	}
+	kf := spannerplan.KnownFlagFormatLabel
+	if cfg.KnownFlag != "" {
+		if kf, err = spannerplan.ParseKnownFlagFormat(cfg.KnownFlag); err != nil {
+			return nil, invalidFlag("known-flag", err)
+		}
+	}
+	r.titleOpts = []spannerplan.Option{
+		spannerplan.WithExecutionMethodFormat(em),
+		spannerplan.WithTargetMetadataFormat(tm),
+		spannerplan.WithKnownFlagFormat(kf),
+	}
+	r.opts = append(r.opts, plantree.WithQueryPlanOptions(r.titleOpts...))
+	if cfg.Compact {
+		r.titleOpts = append(r.titleOpts, spannerplan.EnableCompact())
+	}
+
+	if cfg.WrapWidth > 0 {
+		r.opts = append(r.opts, plantree.WithWrapWidth(cfg.WrapWidth))
+	}
+	if cfg.HangingIndent {
+		r.opts = append(r.opts, plantree.WithHangingIndent())
+	}
+	if cfg.StatBadges {
+		r.opts = append(r.opts, plantree.WithStatBadges())
+	}
+	if cfg.LatencyBar != 0 {
+		r.opts = append(r.opts, plantree.WithLatencyBar(cfg.LatencyBar))
+	}
+	return r, nil
+}
+
+// render renders input to stdout.
+func (r *renderer) render(input []byte, stdout io.Writer) error {
+	input, err := decompressInput(input, r.maxInputBytes)
+	if err != nil {
+		return err
+	}
+	plans, err := decodeInput(input, r.inputFormat)
+	if err != nil {
+		if r.inputFormat != inputFormatAuto {
+			return fmt.Errorf("invalid input for --input-format=%s: %w", r.inputFormat, err)
+		}
+		var collapsedStr string
+		if len(input) > jsonSnippetLen {
+			collapsedStr = "(collapsed)"
+		}
+		return fmt.Errorf("invalid input at protoyaml.Unmarshal:\nerror: %w\ninput: %.*s%s", err, jsonSnippetLen, strings.TrimSpace(string(input)), collapsedStr)
+	}
+	out := r.output(stdout)
+	if len(plans) == 1 {
+		return r.renderStats(plans[0], stdout, out)
+	}
+	return r.renderPlans(plans, stdout, out)
+}
+
+// renderPlans renders plans, the documents of a YAML stream, one after another, each preceded by
+// a "=== Plan N ===" line and separated by a blank line. A plan that fails does not stop the
+// plans after it, so --lint reports the findings of every plan; the errors are returned joined,
+// each naming its plan.
+func (r *renderer) renderPlans(plans []*sppb.ResultSetStats, stdout, out io.Writer) error {
+	if r.format != outputFormatText && r.format != outputFormatMarkdown || r.cfg.Interactive || r.cfg.DumpRows {
+		return fmt.Errorf("input has %d plans, but --format=%s, --interactive and --dump-rows render a single plan", len(plans), r.format)
+	}
+	var errs []error
+	for i, qs := range plans {
+		separator := fmt.Sprintf("=== Plan %d ===\n", i+1)
+		if i > 0 {
+			separator = "\n" + separator
+		}
+		if _, err := io.WriteString(out, separator); err != nil {
+			return err
+		}
+		// Each plan gets its own Warnings section.
+		r.warnings = statsWarnings{}
+		if err := r.renderStats(qs, stdout, out); err != nil {
+			errs = append(errs, fmt.Errorf("plan %d: %w", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// output returns the writer of every plain-text result written to stdout, which applies
+// --output-encoding and --no-trailing-newline.
+func (r *renderer) output(stdout io.Writer) io.Writer {
+	out := r.encoding.writer(stdout)
+	if r.cfg.NoTrailingNewline {
+		out = &trailingNewlineTrimmer{w: out}
+	}
+	return out
+}
+
+// renderStats renders qs, decoded from the input, to out, the [renderer.output] of stdout. The
+// terminal UI draws to stdout directly.
+func (r *renderer) renderStats(qs *sppb.ResultSetStats, stdout, out io.Writer) error {
+	cfg := r.cfg
+
+	planNodes := qs.GetQueryPlan().GetPlanNodes()
+	var header string
+	if cfg.QueryStats {
+		header = queryStatsHeader(spannerplan.QueryStats(qs), r.format == outputFormatMarkdown)
+	}
+
+	if cfg.StatsFrom != "" {
+		if err := attachStatsFrom(planNodes, cfg.StatsFrom, r.maxInputBytes); err != nil {
+			return err
+		}
+	}
+
+	if cfg.VerifyIndex != "" {
+		if err := verifyIndex(planNodes, cfg.VerifyIndex); err != nil {
+			return err
+		}
+	}
+
+	if autoModeHidesStats(planNodes, r.mode) {
+		_, _ = fmt.Fprintln(cfg.Warnings, "warning: the root node has no execution stats but other nodes do; AUTO mode renders them as PLAN. Use --mode=PROFILE to show them.")
+	}
+
+	if cfg.Lint {
+		return runLint(planNodes, cfg.DisallowUnknownStats, r.failSeverity, out)
+	}
+
+	if r.searchRegexp != nil {
+		return runSearch(planNodes, r.searchRegexp, r.titleOpts, out)
+	}
+
+	if cfg.Diff != "" {
+		return runDiff(planNodes, cfg.Diff, r.maxInputBytes, r.titleOpts, cfg.ProfileDiffSummary, r.format, cfg.JSONCompact, out)
+	}
+
+	if cfg.Interactive {
+		if InteractiveRunner == nil {
+			return errInteractiveUnavailable
+		}
+		return InteractiveRunner(planNodes, r.opts, stdout)
+	}
+
+	if r.format == outputFormatOTLP {
+		if !shouldRenderWithStats(planNodes, r.mode) {
+			return errors.New("--format=otlp is only valid in PROFILE mode")
+		}
+		return runOTLP(planNodes, out)
+	}
+
+	if r.format == outputFormatDOT || r.format == outputFormatMermaid {
+		return runGraph(planNodes, r.format, r.titleOpts, cfg.ScalarLinks, cfg.ShowDataflow, out)
+	}
+
+	if r.format != outputFormatText && r.format != outputFormatMarkdown || cfg.DumpRows {
+		var err error
+		switch {
+		case cfg.DumpRows:
+			err = runDumpRows(planNodes, r.opts, out)
+		case r.format == outputFormatJSONL:
+			err = runJSONLines(planNodes, r.opts, out)
+		case r.format == outputFormatCSV || r.format == outputFormatTSV:
+			err = r.runDelimited(planNodes, out)
+		default:
+			err = runJSON(planNodes, r.opts, cfg.JSONCompact, out)
+		}
+		if err != nil {
+			return err
+		}
+		// The Warnings section would make the output invalid JSON, JSON Lines, CSV or YAML.
+		section, err := r.warnings.section()
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(cfg.Warnings, strings.TrimPrefix(section, "\n"))
+		return err
+	}
+
+	// ANSI escapes would show up as text in Markdown.
+	colorEnabled := r.format != outputFormatMarkdown && r.color.enabled(stdout)
+	var style tableStyle
+	if r.theme != nil {
+		style.border = r.theme.border
+		if colorEnabled {
+			style.rowStyle = r.theme.rowStyle
+		}
+	}
+
+	if r.criticalPath != criticalPathOff {
+		if !shouldRenderWithStats(planNodes, r.mode) {
+			return errors.New("--critical-path is only valid in PROFILE mode")
+		}
+		path, err := criticalPathIDs(planNodes)
+		if err != nil {
+			return err
+		}
+		if r.criticalPath == criticalPathOnly {
+			if _, err := io.WriteString(out, header); err != nil {
+				return err
+			}
+			if err := runCriticalPathOnly(planNodes, path, r.opts, out); err != nil {
+				return err
+			}
+			sections, err := r.trailingSections(planNodes)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(out, sections)
+			return err
+		}
+		if colorEnabled {
+			style.rowStyle = highlightRows(path, criticalPathStyle, style.rowStyle)
+		}
+	}
+
+	if cfg.Summary && !shouldRenderWithStats(planNodes, r.mode) {
+		return errors.New("--summary is only valid in PROFILE mode")
+	}
+
+	if cfg.Heat {
+		if !shouldRenderWithStats(planNodes, r.mode) {
+			return errors.New("--heat is only valid in PROFILE mode")
+		}
+		if colorEnabled {
+			style.cellStyle = heatCellStyle(cfg.HeatThreshold)
+		}
+	}
+
+	renderDef, err := r.renderDef(planNodes)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(out, header); err != nil {
+		return err
+	}
+	err = renderTreeTo(out, planNodes, renderTreeOptions{
+		renderDef:                  renderDef,
+		layout:                     r.layout,
+		printSections:              r.printSections,
+		showScalarVars:             cfg.ShowVars,
+		resolveScalarVars:          cfg.ResolveVars,
+		resolveScalarVarsRecursive: cfg.ResolveVarsRecursive,
+		disallowUnknownStats:       cfg.DisallowUnknownStats,
+		inlineStats:                cfg.InlineStats,
+		formatPredicate:            r.formatPredicate,
+		outputSections:             r.outputSections,
+		style:                      style,
+		markdown:                   r.format == outputFormatMarkdown,
+		summary:                    cfg.Summary,
+		plantreeOptions:            r.opts,
+	})
+	if err != nil {
+		return err
+	}
+
+	sections, err := r.trailingSections(planNodes)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, sections)
+	return err
+}
+
+// runDelimited writes the table of planNodes for --format=csv or --format=tsv.
+func (r *renderer) runDelimited(planNodes []*sppb.PlanNode, out io.Writer) error {
+	renderDef, err := r.renderDef(planNodes)
+	if err != nil {
+		return err
+	}
+	comma := ','
+	if r.format == outputFormatTSV {
+		comma = '\t'
+	}
+	return runDelimited(planNodes, renderTreeOptions{
+		renderDef:            renderDef,
+		disallowUnknownStats: r.cfg.DisallowUnknownStats,
+		inlineStats:          r.cfg.InlineStats,
+		formatPredicate:      r.formatPredicate,
+		plantreeOptions:      r.opts,
+	}, comma, out)
+}
+
+// trailingSections returns the sections printed after the text output: the Shape line of
+// Shape, the Advisories section of Advisories and the Warnings section of BestEffort, each
+// when there is one.
+func (r *renderer) trailingSections(planNodes []*sppb.PlanNode) (string, error) {
+	var shape, advisories string
+	var err error
+	if r.cfg.Shape {
+		if shape, err = shapeSection(planNodes); err != nil {
+			return "", err
+		}
+	}
+	if r.cfg.Advisories {
+		if advisories, err = advisoriesSection(planNodes, r.cfg.DisallowUnknownStats, r.cfg.BestEffort); err != nil {
+			return "", err
+		}
+	}
+	warnings, err := r.warnings.section()
+	if err != nil {
+		return "", err
+	}
+	return shape + advisories + warnings, nil
+}
+
+// renderDef returns the table columns: the custom columns when there are any, and otherwise the
+// default columns for the mode and stats of planNodes.
+func (r *renderer) renderDef(planNodes []*sppb.PlanNode) (tableRenderDef, error) {
+	cfg := r.cfg
+
+	var renderDef tableRenderDef
+	var err error
+	if len(cfg.CustomColumns) > 0 {
+		renderDef, err = customColumnListToTableRenderDef(cfg.CustomColumns)
+		if err != nil {
+			return tableRenderDef{}, err
+		}
+	} else if cfg.CustomFile != "" {
+		b, err := os.ReadFile(cfg.CustomFile)
+		if err != nil {
+			return tableRenderDef{}, err
+		}
+		renderDef, err = customFileToTableRenderDef(b)
+		if err != nil {
+			return tableRenderDef{}, err
+		}
+	} else {
+		withStats := shouldRenderWithStats(planNodes, r.mode)
+		renderDef = withStatsToRenderDefMap[withStats]
+		if withStats && cfg.RowsProduced {
+			renderDef = withRowsProduced(renderDef)
+		}
+		if withStats && cfg.AvgPerExecution {
+			renderDef = withAvgPerExecution(renderDef)
+		}
+		if withStats && cfg.LatencyPercent {
+			renderDef = withLatencyPercent(renderDef)
+		}
+		if withStats && cfg.CPUTime {
+			renderDef = withCPUTime(renderDef)
+		}
+		if withStats && hasEstimatedRows(planNodes) {
+			renderDef.Columns = slices.Concat(renderDef.Columns, estimateRenderDefs)
+		}
+		if cfg.DropEmptyColumns {
+			renderDef = withDroppableStatsColumns(renderDef)
+		}
+		if r.idMapFunc != nil {
+			renderDef = withIDMapFunc(renderDef, r.idMapFunc)
+		}
+	}
+	if renderDef, err = applyColumnOrder(renderDef, r.columnOrder); err != nil {
+		return tableRenderDef{}, err
+	}
+	return applyFixedWidths(renderDef, r.fixedWidths)
+}
@@ -0,0 +1,332 @@
+package impl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spannerplan"
+	"github.com/apstndb/spannerplan/stats"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		args   []string
+		config func(*RenderConfig)
+	}{
+		{name: "default"},
+		{
+			name:   "plan mode",
+			args:   []string{"-mode", "plan"},
+			config: func(cfg *RenderConfig) { cfg.Mode = "plan" },
+		},
+		{
+			name: "compact tableless with badges",
+			args: []string{"-compact", "-tableless", "-stat-badges"},
+			config: func(cfg *RenderConfig) {
+				cfg.Compact = true
+				cfg.Layout = "tableless"
+				cfg.StatBadges = true
+			},
+		},
+		{
+			name:   "json",
+			args:   []string{"-format", "json", "-json-compact"},
+			config: func(cfg *RenderConfig) { cfg.Format, cfg.JSONCompact = "json", true },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var want, stderr bytes.Buffer
+			if err := run(tt.args, bytes.NewReader(dcaProfileYAML), &want, &stderr); err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			cfg := DefaultRenderConfig()
+			if tt.config != nil {
+				tt.config(&cfg)
+			}
+			var got bytes.Buffer
+			if err := Render(&got, dcaProfileYAML, cfg); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("Render() output differs from run(%q):\n%s\nwant:\n%s", tt.args, got.String(), want.String())
+			}
+		})
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  func(*RenderConfig)
+		wantErr string
+	}{
+		{
+			name:    "invalid value",
+			config:  func(cfg *RenderConfig) { cfg.Layout = "broken" },
+			wantErr: "Invalid value for -layout flag:",
+		},
+		{
+			name:    "invalid combination",
+			config:  func(cfg *RenderConfig) { cfg.BestEffort, cfg.Lint = true, true },
+			wantErr: "--best-effort cannot be combined with --lint",
+		},
+		{
+			name:    "zero value",
+			config:  func(cfg *RenderConfig) { *cfg = RenderConfig{} },
+			wantErr: "Invalid value for -max-input-bytes flag:",
+		},
+		{
+			name:    "input too large",
+			config:  func(cfg *RenderConfig) { cfg.MaxInputBytes = "1KiB" },
+			wantErr: "input is larger than 1 KiB; raise the limit with --max-input-bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultRenderConfig()
+			tt.config(&cfg)
+			var got bytes.Buffer
+			err := Render(&got, dcaProfileYAML, cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Render() error = %v, want %q", err, tt.wantErr)
+			}
+			if got.Len() != 0 {
+				t.Errorf("Render() wrote output:\n%s", got.String())
+			}
+		})
+	}
+}
+
+func TestRender_Gzip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(dcaProfileYAML); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultRenderConfig()
+	var want, got bytes.Buffer
+	if err := Render(&want, dcaProfileYAML, cfg); err != nil {
+		t.Fatalf("Render(plain) error = %v", err)
+	}
+	if err := Render(&got, buf.Bytes(), cfg); err != nil {
+		t.Fatalf("Render(gzip) error = %v", err)
+	}
+	if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+		t.Errorf("Render(gzip) mismatch (-plain +gzip):\n%s", diff)
+	}
+
+	// The limit applies to the decompressed input, as on stdin.
+	cfg.MaxInputBytes = stats.FormatBytes(int64(buf.Len()))
+	got.Reset()
+	err := Render(&got, buf.Bytes(), cfg)
+	if wantErr := "raise the limit with --max-input-bytes"; err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("Render(gzip, MaxInputBytes=%s) error = %v, want substring %q", cfg.MaxInputBytes, err, wantErr)
+	}
+}
+
+func TestRender_Warnings(t *testing.T) {
+	t.Parallel()
+
+	input := []byte(strings.Replace(string(dcaProfileYAML), "executionStats:", "executionStatsIgnored:", 1))
+	cfg := DefaultRenderConfig()
+	var out bytes.Buffer
+	if err := Render(&out, dcaProfileYAML, cfg); err != nil {
+		t.Fatalf("Render(nil Warnings) error = %v", err)
+	}
+
+	var warnings bytes.Buffer
+	cfg.Warnings = &warnings
+	if err := Render(&out, input, cfg); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(warnings.String(), "AUTO mode renders them as PLAN") {
+		t.Errorf("Warnings = %q, want the AUTO mode warning", warnings.String())
+	}
+}
+
+func TestRenderTreeTable(t *testing.T) {
+	t.Parallel()
+
+	rss, _, err := spannerplan.ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planNodes := rss.GetQueryPlan().GetPlanNodes()
+
+	tests := []struct {
+		name   string
+		config func(*RenderConfig)
+	}{
+		{name: "default"},
+		{
+			name: "compact wrapped plan with custom columns",
+			config: func(cfg *RenderConfig) {
+				cfg.Mode, cfg.Compact, cfg.WrapWidth = "plan", true, 40
+				cfg.ExecutionMethod, cfg.TargetMetadata, cfg.KnownFlag = "raw", "raw", "raw"
+				cfg.CustomColumns = []string{`{name: ID, template: "{{.FormatID}}"}`, `{name: Operator, template: "{{.Text}}"}`}
+			},
+		},
+		{
+			name:   "markdown",
+			config: func(cfg *RenderConfig) { cfg.Format = "markdown" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := DefaultRenderConfig()
+			if tt.config != nil {
+				tt.config(&cfg)
+			}
+			var want bytes.Buffer
+			if err := Render(&want, dcaProfileYAML, cfg); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			got, err := RenderTreeTable(planNodes, cfg)
+			if err != nil {
+				t.Fatalf("RenderTreeTable() error = %v", err)
+			}
+			if got != want.String() {
+				t.Errorf("RenderTreeTable() output differs from Render():\n%s\nwant:\n%s", got, want.String())
+			}
+		})
+	}
+
+	cfg := DefaultRenderConfig()
+	cfg.Interactive = true
+	if _, err := RenderTreeTable(planNodes, cfg); err == nil {
+		t.Error("RenderTreeTable(Interactive) error = nil, want non-nil")
+	}
+}
+
+func TestRenderConfig_JSON(t *testing.T) {
+	t.Parallel()
+
+	// Set every encoded field to a non-default value, so that a field added without a JSON key
+	// round-trips to its zero value and fails the test.
+	var cfg RenderConfig
+	v := reflect.ValueOf(&cfg).Elem()
+	for i := range v.NumField() {
+		switch f := v.Field(i); f.Kind() {
+		case reflect.String:
+			f.SetString(v.Type().Field(i).Name)
+		case reflect.Bool:
+			f.SetBool(true)
+		case reflect.Int:
+			f.SetInt(int64(i + 1))
+		case reflect.Slice:
+			f.Set(reflect.ValueOf([]string{"a", "b"}))
+		}
+	}
+	cfg.Print = ""
+	cfg.Warnings = &bytes.Buffer{}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	got := DefaultRenderConfig()
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := cfg
+	want.Warnings = nil
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RenderConfig JSON round trip mismatch (-want +got):\n%s", diff)
+	}
+
+	got = DefaultRenderConfig()
+	if err := json.Unmarshal([]byte(`{"mode": "PLAN", "wrapWidth": 80}`), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want = DefaultRenderConfig()
+	want.Mode, want.WrapWidth = "PLAN", 80
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("json.Unmarshal(partial) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderTreeTableTo(t *testing.T) {
+	t.Parallel()
+
+	rss, _, err := spannerplan.ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planNodes := rss.GetQueryPlan().GetPlanNodes()
+	cfg := DefaultRenderConfig()
+	cfg.Shape = true
+
+	want, err := RenderTreeTable(planNodes, cfg)
+	if err != nil {
+		t.Fatalf("RenderTreeTable() error = %v", err)
+	}
+	var got bytes.Buffer
+	if err := RenderTreeTableTo(&got, planNodes, cfg); err != nil {
+		t.Fatalf("RenderTreeTableTo() error = %v", err)
+	}
+	if got.String() != want {
+		t.Errorf("RenderTreeTableTo() output differs from RenderTreeTable():\n%s\nwant:\n%s", got.String(), want)
+	}
+
+	errWrite := errors.New("write failed")
+	if err := RenderTreeTableTo(failingWriter{err: errWrite}, planNodes, cfg); !errors.Is(err, errWrite) {
+		t.Errorf("RenderTreeTableTo(failing writer) error = %v, want %v", err, errWrite)
+	}
+}
+
+func TestRenderTreeTable_StatsFromDoesNotModifyNodes(t *testing.T) {
+	t.Parallel()
+
+	profilePath := filepath.Join(t.TempDir(), "profile.yaml")
+	if err := os.WriteFile(profilePath, dcaProfileYAML, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rss, _, err := spannerplan.ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planNodes := rss.GetQueryPlan().GetPlanNodes()
+	cfg := DefaultRenderConfig()
+	cfg.StatsFrom = profilePath
+
+	if _, err := RenderTreeTable(planNodes, cfg); err != nil {
+		t.Fatalf("RenderTreeTable(StatsFrom) error = %v", err)
+	}
+	for _, node := range planNodes {
+		if node.GetExecutionStats() != nil {
+			t.Fatalf("RenderTreeTable(StatsFrom) set the execution stats of node %d", node.GetIndex())
+		}
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
@@ -0,0 +1,43 @@
+package impl
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan"
+)
+
+// runSearch prints one line for each visible node whose title matches re, in tree order: its ID,
+// its title and its breadcrumb, separated by tabs. It fails when no node matches.
+func runSearch(planNodes []*sppb.PlanNode, re *regexp.Regexp, titleOpts []spannerplan.Option, stdout io.Writer) error {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+
+	// A node reused in a DAG is visited more than once; list it once.
+	seen := make(map[int32]bool)
+	err = qp.Walk(func(node *sppb.PlanNode, _ *sppb.PlanNode_ChildLink, _ int) error {
+		title := spannerplan.NodeTitle(node, titleOpts...)
+		if seen[node.GetIndex()] || !re.MatchString(title) {
+			return nil
+		}
+		seen[node.GetIndex()] = true
+		breadcrumb, err := qp.Breadcrumb(node.GetIndex(), titleOpts...)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "%d\t%s\t%s\n", node.GetIndex(), title, breadcrumb)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	if len(seen) == 0 {
+		return fmt.Errorf("search: no operator matches %q", re)
+	}
+	return nil
+}
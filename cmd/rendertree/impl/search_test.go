@@ -0,0 +1,56 @@
+package impl
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	heredoc "github.com/MakeNowJust/heredoc/v2"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func TestRun_Search(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-search", "^(Filter|Batch) Scan"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-search) error = %v", err)
+	}
+	want := heredoc.Doc(`
+		13	Batch Scan on $v2 <Row> (scan_method: Row)	Distributed Union on AlbumsByAlbumTitle <Row> › Distributed Cross Apply <Row> › [Map] Serialize Result <Row> › Cross Apply <Row> › [Input] Batch Scan on $v2 <Row> (scan_method: Row)
+		17	Filter Scan <Row> (seekable_key_size: 0)	Distributed Union on AlbumsByAlbumTitle <Row> › Distributed Cross Apply <Row> › [Map] Serialize Result <Row> › Cross Apply <Row> › [Map] Local Distributed Union <Row> › Filter Scan <Row> (seekable_key_size: 0)
+	`)
+	if got := stdout.String(); got != want {
+		t.Errorf("run(-search) = %q, want %q", got, want)
+	}
+
+	stdout.Reset()
+	err := run([]string{"-search", "Hash Join"}, bytes.NewReader(dcaYAML), &stdout, &stderr)
+	if wantErr := `search: no operator matches "Hash Join"`; err == nil || err.Error() != wantErr {
+		t.Fatalf("run(-search no match) error = %v, want %q", err, wantErr)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("run(-search no match) wrote output:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-search", "^Cross Apply", "-execution-method", "raw"}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-search -execution-method=raw) error = %v", err)
+	}
+	if want := "12\tCross Apply (execution_method: Row)\t"; !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("run(-search -execution-method=raw) = %q, want prefix %q", stdout.String(), want)
+	}
+}
+
+func TestRunSearch_TraversalLimit(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	err := runSearch(sharedChildChain(40), regexp.MustCompile("^Union 39$"), nil, &stdout)
+	if !errors.Is(err, spannerplan.ErrTraversalLimitExceeded) {
+		t.Errorf("runSearch() error = %v, want %v", err, spannerplan.ErrTraversalLimitExceeded)
+	}
+}
@@ -0,0 +1,28 @@
+package impl
+
+import (
+	"fmt"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan"
+)
+
+// shapeSection returns the --shape line, such as "\nShape: depth 0: 1, depth 1: 2\n", which
+// counts the visible operators at each depth of the tree.
+func shapeSection(planNodes []*sppb.PlanNode) (string, error) {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return "", err
+	}
+	counts, err := qp.NodesPerDepth()
+	if err != nil {
+		return "", fmt.Errorf("shape: %w", err)
+	}
+	levels := make([]string, 0, len(counts))
+	for depth, count := range counts {
+		levels = append(levels, fmt.Sprintf("depth %d: %d", depth, count))
+	}
+	return "\nShape: " + strings.Join(levels, ", ") + "\n", nil
+}
@@ -0,0 +1,53 @@
+package impl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func TestRun_Shape(t *testing.T) {
+	t.Parallel()
+
+	const wantShape = "\nShape: depth 0: 1, depth 1: 1, depth 2: 2, depth 3: 2, depth 4: 3, depth 5: 2, depth 6: 1\n"
+	for _, args := range [][]string{
+		{"-shape"},
+		{"-shape", "-mode", "profile", "-critical-path=only"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if err := run(args, bytes.NewReader(dcaProfileYAML), &stdout, &stderr); err != nil {
+			t.Fatalf("run(%q) error = %v", args, err)
+		}
+		if !strings.HasSuffix(stdout.String(), wantShape) {
+			t.Errorf("run(%q) output does not end with %q:\n%s", args, wantShape, stdout.String())
+		}
+	}
+}
+
+func TestShapeSection_TraversalLimit(t *testing.T) {
+	t.Parallel()
+
+	_, err := shapeSection(sharedChildChain(40))
+	if !errors.Is(err, spannerplan.ErrTraversalLimitExceeded) {
+		t.Errorf("shapeSection() error = %v, want %v", err, spannerplan.ErrTraversalLimitExceeded)
+	}
+}
+
+// sharedChildChain returns a plan of n nodes in which every node links twice to the next, so its
+// visible tree has 2^n-1 node occurrences.
+func sharedChildChain(n int) []*sppb.PlanNode {
+	nodes := make([]*sppb.PlanNode, n)
+	for i := range nodes {
+		nodes[i] = &sppb.PlanNode{Index: int32(i), Kind: sppb.PlanNode_RELATIONAL, DisplayName: fmt.Sprintf("Union %d", i)}
+		if i+1 < n {
+			nodes[i].ChildLinks = []*sppb.PlanNode_ChildLink{{ChildIndex: int32(i + 1)}, {ChildIndex: int32(i + 1)}}
+		}
+	}
+	return nodes
+}
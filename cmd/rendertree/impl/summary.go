@@ -0,0 +1,154 @@
+package impl
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// summaryType is how --summary aggregates a column into the footer row.
+type summaryType string
+
+func (s *summaryType) UnmarshalYAML(b []byte) error {
+	var str string
+	if err := yaml.Unmarshal(b, &str); err != nil {
+		return err
+	}
+
+	summary, err := parseSummaryType(str)
+	if err != nil {
+		return err
+	}
+
+	*s = summary
+	return nil
+}
+
+const (
+	// summaryTypeUnspecified sums the column when every non-empty value is a number, except for
+	// the ID and Operator columns.
+	summaryTypeUnspecified summaryType = ""
+	// summaryTypeSum sums the values.
+	summaryTypeSum summaryType = "SUM"
+	// summaryTypeMax takes the largest value.
+	summaryTypeMax summaryType = "MAX"
+	// summaryTypeRoot takes the value of the root row.
+	summaryTypeRoot summaryType = "ROOT"
+	// summaryTypeNone leaves the footer cell empty.
+	summaryTypeNone summaryType = "NONE"
+)
+
+var _ yaml.BytesUnmarshaler = (*summaryType)(nil)
+
+func parseSummaryType(s string) (summaryType, error) {
+	switch summary := summaryType(strings.ToUpper(s)); summary {
+	case summaryTypeSum, summaryTypeMax, summaryTypeRoot, summaryTypeNone:
+		return summary, nil
+	default:
+		return "", fmt.Errorf("summary must be one of SUM, MAX, ROOT, NONE, but: %v", s)
+	}
+}
+
+// summaryLabel is the Operator cell of the footer row.
+const summaryLabel = "Total"
+
+// summaryFooter returns the footer row of --summary for the rendered rows of renderDef, whose
+// first row is the root. The Operator column holds summaryLabel, and every other column holds
+// the aggregate chosen by its Summary. A value is aggregated only when it is a number with an
+// optional unit, such as "3" or "1.92 ms", and a column whose numbers have different units, or a
+// value that is not a number, gets an empty cell.
+func summaryFooter(renderDef tableRenderDef, tableRows []renderedTableRow) []string {
+	footer := make([]string, len(renderDef.Columns))
+	for i, col := range renderDef.Columns {
+		if col.Name == operatorRenderDef.Name {
+			footer[i] = summaryLabel
+			continue
+		}
+		values := make([]string, 0, len(tableRows))
+		for _, row := range tableRows {
+			if i < len(row) {
+				values = append(values, row[i])
+			} else {
+				values = append(values, "")
+			}
+		}
+		footer[i] = summarizeColumn(col, values)
+	}
+	return footer
+}
+
+func summarizeColumn(col columnRenderDef, values []string) string {
+	summary := col.Summary
+	if summary == summaryTypeUnspecified {
+		if slices.Contains([]string{"ID", "Operator"}, col.Name) {
+			return ""
+		}
+		summary = summaryTypeSum
+	}
+
+	switch summary {
+	case summaryTypeRoot:
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	case summaryTypeSum, summaryTypeMax:
+		var (
+			total     float64
+			unit      string
+			precision int
+			found     bool
+		)
+		for _, v := range values {
+			if strings.TrimSpace(v) == "" {
+				continue
+			}
+			n, u, p, ok := parseSummaryValue(v)
+			if !ok || found && u != unit {
+				return ""
+			}
+			switch {
+			case !found:
+				total = n
+			case summary == summaryTypeSum:
+				total += n
+			default:
+				total = max(total, n)
+			}
+			unit, precision, found = u, max(precision, p), true
+		}
+		if !found {
+			return ""
+		}
+		s := strconv.FormatFloat(total, 'f', precision, 64)
+		if unit != "" {
+			s += " " + unit
+		}
+		return s
+	default:
+		return ""
+	}
+}
+
+// parseSummaryValue parses v as a number and an optional unit separated by spaces. precision is
+// the number of digits after the decimal point of the number.
+func parseSummaryValue(v string) (n float64, unit string, precision int, ok bool) {
+	fields := strings.Fields(v)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, "", 0, false
+	}
+	n, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, "", 0, false
+	}
+	if _, frac, found := strings.Cut(fields[0], "."); found {
+		precision = len(frac)
+	}
+	if len(fields) == 2 {
+		unit = fields[1]
+	}
+	return n, unit, precision, true
+}
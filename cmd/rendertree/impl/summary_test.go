@@ -0,0 +1,101 @@
+package impl
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSummaryFooter(t *testing.T) {
+	columns := func(summaries ...summaryType) tableRenderDef {
+		def := tableRenderDef{Columns: []columnRenderDef{idRenderDef, operatorRenderDef}}
+		for _, summary := range summaries {
+			def.Columns = append(def.Columns, columnRenderDef{Name: "Value", Summary: summary})
+		}
+		return def
+	}
+	row := func(cells ...string) renderedTableRow {
+		return append(renderedTableRow{"1", "Scan"}, cells...)
+	}
+
+	tests := []struct {
+		desc      string
+		renderDef tableRenderDef
+		rows      []renderedTableRow
+		want      []string
+	}{
+		{
+			desc:      "sum",
+			renderDef: columns(summaryTypeSum),
+			rows:      []renderedTableRow{row("3"), row(""), row("4")},
+			want:      []string{"", "Total", "7"},
+		},
+		{
+			desc:      "sum keeps the unit and the precision",
+			renderDef: columns(summaryTypeSum),
+			rows:      []renderedTableRow{row("0.1 ms"), row("0.25 ms"), row("1 ms")},
+			want:      []string{"", "Total", "1.35 ms"},
+		},
+		{
+			desc:      "max",
+			renderDef: columns(summaryTypeMax),
+			rows:      []renderedTableRow{row("1.9 ms"), row("1.92 ms"), row("0.01 ms")},
+			want:      []string{"", "Total", "1.92 ms"},
+		},
+		{
+			desc:      "root",
+			renderDef: columns(summaryTypeRoot),
+			rows:      []renderedTableRow{row("33"), row("7")},
+			want:      []string{"", "Total", "33"},
+		},
+		{
+			desc:      "none",
+			renderDef: columns(summaryTypeNone),
+			rows:      []renderedTableRow{row("33"), row("7")},
+			want:      []string{"", "Total", ""},
+		},
+		{
+			desc:      "unspecified sums",
+			renderDef: columns(summaryTypeUnspecified),
+			rows:      []renderedTableRow{row("33"), row("7")},
+			want:      []string{"", "Total", "40"},
+		},
+		{
+			desc:      "mixed units",
+			renderDef: columns(summaryTypeSum),
+			rows:      []renderedTableRow{row("1 ms"), row("1 s")},
+			want:      []string{"", "Total", ""},
+		},
+		{
+			desc:      "not a number",
+			renderDef: columns(summaryTypeSum),
+			rows:      []renderedTableRow{row("3"), row("!0.05")},
+			want:      []string{"", "Total", ""},
+		},
+		{
+			desc:      "no values",
+			renderDef: columns(summaryTypeSum),
+			rows:      []renderedTableRow{row(""), row("")},
+			want:      []string{"", "Total", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := summaryFooter(tt.renderDef, tt.rows)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("summaryFooter() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseSummaryType(t *testing.T) {
+	for _, s := range []string{"sum", "MAX", "Root", "none"} {
+		if _, err := parseSummaryType(s); err != nil {
+			t.Errorf("parseSummaryType(%q) error = %v", s, err)
+		}
+	}
+	if _, err := parseSummaryType("avg"); err == nil {
+		t.Error("parseSummaryType(\"avg\") error = nil, want non-nil")
+	}
+}
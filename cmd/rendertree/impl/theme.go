@@ -0,0 +1,225 @@
+package impl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/apstndb/spannerplan/asciitable"
+	"github.com/apstndb/spannerplan/plantree"
+)
+
+// colorMode is the value of --color.
+type colorMode string
+
+const (
+	colorModeAuto   colorMode = "auto"
+	colorModeAlways colorMode = "always"
+	colorModeNever  colorMode = "never"
+)
+
+func parseColorMode(s string) (colorMode, error) {
+	switch m := colorMode(strings.ToLower(s)); m {
+	case colorModeAuto, colorModeAlways, colorModeNever:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown color mode: %q. Must be one of auto, always, never", s)
+	}
+}
+
+// enabled reports whether output written to w should contain ANSI escape sequences.
+// In auto mode, color requires w to be a terminal and NO_COLOR to be unset.
+func (m colorMode) enabled(w io.Writer) bool {
+	switch m {
+	case colorModeAlways:
+		return true
+	case colorModeNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// highlightRows returns a row style that applies sgr to rows whose ID is in ids and defers
+// to base, which may be nil, for other rows.
+func highlightRows(ids []int32, sgr string, base func(row plantree.RowWithPredicates) string) func(row plantree.RowWithPredicates) string {
+	return func(row plantree.RowWithPredicates) string {
+		if slices.Contains(ids, row.ID) {
+			return sgr
+		}
+		if base == nil {
+			return ""
+		}
+		return base(row)
+	}
+}
+
+// operatorCategory groups operators for theming.
+type operatorCategory string
+
+const (
+	categoryScan      operatorCategory = "scan"
+	categoryJoin      operatorCategory = "join"
+	categoryApply     operatorCategory = "apply"
+	categoryUnion     operatorCategory = "union"
+	categoryAggregate operatorCategory = "aggregate"
+	categorySort      operatorCategory = "sort"
+	categoryFilter    operatorCategory = "filter"
+	categoryOther     operatorCategory = "other"
+)
+
+var operatorCategories = []operatorCategory{
+	categoryScan, categoryJoin, categoryApply, categoryUnion,
+	categoryAggregate, categorySort, categoryFilter, categoryOther,
+}
+
+// categorizeOperator returns the category of an operator display name.
+// Filter Scan is a filter rather than a scan, and Cross Apply is an apply rather than a join.
+func categorizeOperator(displayName string) operatorCategory {
+	switch {
+	case displayName == "Filter" || displayName == "Filter Scan":
+		return categoryFilter
+	case strings.HasSuffix(displayName, "Apply"):
+		return categoryApply
+	case strings.HasSuffix(displayName, "Join"):
+		return categoryJoin
+	case strings.HasSuffix(displayName, "Union"), strings.HasSuffix(displayName, "Union All"):
+		return categoryUnion
+	case strings.HasSuffix(displayName, "Aggregate"):
+		return categoryAggregate
+	case strings.HasSuffix(displayName, "Sort"), strings.HasSuffix(displayName, "Sort Limit"):
+		return categorySort
+	case strings.HasSuffix(displayName, "Scan"):
+		return categoryScan
+	default:
+		return categoryOther
+	}
+}
+
+// sgrColors maps theme color names to SGR foreground parameters.
+var sgrColors = map[string]string{
+	"black":          "30",
+	"red":            "31",
+	"green":          "32",
+	"yellow":         "33",
+	"blue":           "34",
+	"magenta":        "35",
+	"cyan":           "36",
+	"white":          "37",
+	"bright-black":   "90",
+	"bright-red":     "91",
+	"bright-green":   "92",
+	"bright-yellow":  "93",
+	"bright-blue":    "94",
+	"bright-magenta": "95",
+	"bright-cyan":    "96",
+	"bright-white":   "97",
+}
+
+type themeStyle struct {
+	Color string `json:"color"`
+	Bold  bool   `json:"bold"`
+}
+
+func (s themeStyle) sgr() (string, error) {
+	var params []string
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if s.Color != "" {
+		code, ok := sgrColors[strings.ToLower(s.Color)]
+		if !ok {
+			return "", fmt.Errorf("unknown color: %q", s.Color)
+		}
+		params = append(params, code)
+	}
+	return strings.Join(params, ";"), nil
+}
+
+type themeFile struct {
+	Edges      string                `json:"edges"`
+	Border     string                `json:"border"`
+	Categories map[string]themeStyle `json:"categories"`
+	Operators  map[string]themeStyle `json:"operators"`
+}
+
+// theme is a validated --theme-file.
+type theme struct {
+	unicodeEdges bool
+	border       asciitable.Border
+	// categories and operators hold SGR parameters. Operator entries take precedence.
+	categories map[operatorCategory]string
+	operators  map[string]string
+}
+
+func parseThemeFile(b []byte) (*theme, error) {
+	var f themeFile
+	if err := yaml.UnmarshalWithOptions(b, &f, yaml.DisallowUnknownField()); err != nil {
+		return nil, err
+	}
+
+	t := &theme{
+		categories: make(map[operatorCategory]string, len(f.Categories)),
+		operators:  make(map[string]string, len(f.Operators)),
+	}
+	switch strings.ToLower(f.Edges) {
+	case "", "ascii":
+	case "unicode":
+		t.unicodeEdges = true
+	default:
+		return nil, fmt.Errorf("unknown edges: %q. Must be one of ascii, unicode", f.Edges)
+	}
+	if f.Border != "" {
+		border, err := asciitable.ParseBorder(f.Border)
+		if err != nil {
+			return nil, err
+		}
+		t.border = border
+	}
+	for name, style := range f.Categories {
+		category := operatorCategory(strings.ToLower(name))
+		if !slices.Contains(operatorCategories, category) {
+			return nil, fmt.Errorf("unknown category: %q", name)
+		}
+		sgr, err := style.sgr()
+		if err != nil {
+			return nil, fmt.Errorf("category %q: %w", name, err)
+		}
+		t.categories[category] = sgr
+	}
+	for name, style := range f.Operators {
+		sgr, err := style.sgr()
+		if err != nil {
+			return nil, fmt.Errorf("operator %q: %w", name, err)
+		}
+		t.operators[name] = sgr
+	}
+	return t, nil
+}
+
+// plantreeOptions returns the tree rendering options selected by the theme.
+func (t *theme) plantreeOptions() []plantree.Option {
+	if t.unicodeEdges {
+		return []plantree.Option{plantree.WithUnicodeEdges()}
+	}
+	return nil
+}
+
+// rowStyle returns the SGR parameters for row, or "" when the theme does not style it.
+func (t *theme) rowStyle(row plantree.RowWithPredicates) string {
+	if sgr, ok := t.operators[row.DisplayName]; ok {
+		return sgr
+	}
+	return t.categories[categorizeOperator(row.DisplayName)]
+}
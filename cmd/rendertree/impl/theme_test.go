@@ -0,0 +1,139 @@
+package impl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	heredoc "github.com/MakeNowJust/heredoc/v2"
+)
+
+func TestCategorizeOperator(t *testing.T) {
+	tests := []struct {
+		displayName string
+		want        operatorCategory
+	}{
+		{"Scan", categoryScan},
+		{"Batch Scan", categoryScan},
+		{"Filter Scan", categoryFilter},
+		{"Filter", categoryFilter},
+		{"Distributed Cross Apply", categoryApply},
+		{"Hash Join", categoryJoin},
+		{"Distributed Union", categoryUnion},
+		{"Union All", categoryUnion},
+		{"Stream Aggregate", categoryAggregate},
+		{"Sort Limit", categorySort},
+		{"Serialize Result", categoryOther},
+	}
+	for _, tt := range tests {
+		if got := categorizeOperator(tt.displayName); got != tt.want {
+			t.Errorf("categorizeOperator(%q) = %q, want %q", tt.displayName, got, tt.want)
+		}
+	}
+}
+
+func TestParseThemeFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			input: heredoc.Doc(`
+				edges: unicode
+				border: rounded
+				categories:
+				  scan: {color: cyan}
+				  apply: {color: bright-yellow, bold: true}
+				operators:
+				  Distributed Union: {bold: true}
+			`),
+		},
+		{name: "empty", input: ""},
+		{name: "unknown color", input: "categories: {scan: {color: teal}}", wantErr: `category "scan": unknown color: "teal"`},
+		{name: "unknown category", input: "categories: {window: {color: red}}", wantErr: `unknown category: "window"`},
+		{name: "unknown border", input: "border: dotted", wantErr: `unknown border "dotted"`},
+		{name: "unknown edges", input: "edges: fancy", wantErr: `unknown edges: "fancy"`},
+		{name: "unknown field", input: "colour: red", wantErr: "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseThemeFile([]byte(tt.input))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("parseThemeFile() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parseThemeFile() error = %v, want substring %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRun_ThemeFile(t *testing.T) {
+	themePath := filepath.Join(t.TempDir(), "theme.yaml")
+	if err := os.WriteFile(themePath, []byte(heredoc.Doc(`
+		edges: unicode
+		border: light
+		categories:
+		  scan: {color: cyan}
+		operators:
+		  Filter Scan: {color: red, bold: true}
+	`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-mode", "plan", "-print", "none", "-color", "always", "-theme-file", themePath}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-theme-file) error = %v", err)
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if want := "┌─────┬"; !strings.HasPrefix(lines[0], want) {
+		t.Errorf("first line = %q, want prefix %q", lines[0], want)
+	}
+	if want := "│  \x1b[36m13\x1b[0m │ \x1b[36m         ├─ [Input] Batch Scan on $v2 <Row> (scan_method: Row)\x1b[0m"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("output does not contain category-styled row %q:\n%s", want, stdout.String())
+	}
+	if want := "\x1b[1;31m*17\x1b[0m"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("output does not contain operator-styled ID %q:\n%s", want, stdout.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-mode", "plan", "-print", "none", "-color", "never", "-theme-file", themePath}, bytes.NewReader(dcaYAML), &stdout, &stderr); err != nil {
+		t.Fatalf("run(-color=never) error = %v", err)
+	}
+	if strings.Contains(stdout.String(), "\x1b[") {
+		t.Errorf("run(-color=never) output contains escape sequences:\n%q", stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "├─ [Input] Batch Scan on $v2 <Row>") {
+		t.Errorf("run(-color=never) output lost theme edges:\n%s", stdout.String())
+	}
+}
+
+func TestColorModeEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	tests := []struct {
+		mode colorMode
+		want bool
+	}{
+		{colorModeAlways, true},
+		{colorModeNever, false},
+		{colorModeAuto, false},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.enabled(&buf); got != tt.want {
+			t.Errorf("%s.enabled(buffer) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+	if got, err := parseColorMode("Never"); err != nil || got != colorModeNever {
+		t.Errorf("parseColorMode(Never) = (%q, %v), want (%q, nil)", got, err, colorModeNever)
+	}
+	if _, err := parseColorMode("sometimes"); err == nil {
+		t.Error("parseColorMode(sometimes) error = nil, want non-nil")
+	}
+}
@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"fmt"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan"
+)
+
+// verifyIndex returns an error naming the table and index scans of the plan when none of them
+// scans indexName.
+func verifyIndex(planNodes []*sppb.PlanNode, indexName string) error {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return err
+	}
+	if len(qp.NodesUsingIndex(indexName)) > 0 {
+		return nil
+	}
+
+	var scans []string
+	for _, target := range qp.ScanTargets() {
+		switch target.Kind {
+		case spannerplan.ScanIndex, spannerplan.ScanTable:
+			scans = append(scans, fmt.Sprintf("%sScan %s (node %d)", target.Kind, target.Name, target.NodeID))
+		}
+	}
+	if len(scans) == 0 {
+		return fmt.Errorf("verify-index: the plan does not use index %s and scans no table or index", indexName)
+	}
+	return fmt.Errorf("verify-index: the plan does not use index %s; it scans %s", indexName, strings.Join(scans, ", "))
+}
@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRun_VerifyIndex(t *testing.T) {
+	t.Parallel()
+
+	var want, stderr bytes.Buffer
+	if err := run([]string{"-mode", "plan"}, bytes.NewReader(dcaYAML), &want, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	for _, name := range []string{"SongsBySongGenre", "songsbysonggenre"} {
+		var got bytes.Buffer
+		if err := run([]string{"-mode", "plan", "-verify-index", name}, bytes.NewReader(dcaYAML), &got, &stderr); err != nil {
+			t.Fatalf("run(-verify-index=%s) error = %v", name, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("run(-verify-index=%s) changed the output:\n%s\nwant:\n%s", name, got.String(), want.String())
+		}
+	}
+
+	var got bytes.Buffer
+	err := run([]string{"-verify-index", "SongsByTitle"}, bytes.NewReader(dcaYAML), &got, &stderr)
+	wantErr := "verify-index: the plan does not use index SongsByTitle; it scans IndexScan AlbumsByAlbumTitle (node 5), IndexScan SongsBySongGenre (node 18)"
+	if err == nil || err.Error() != wantErr {
+		t.Fatalf("run(-verify-index=SongsByTitle) error = %v, want %q", err, wantErr)
+	}
+	if got.Len() != 0 {
+		t.Errorf("run(-verify-index=SongsByTitle) wrote output:\n%s", got.String())
+	}
+}
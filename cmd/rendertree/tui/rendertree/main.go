@@ -0,0 +1,12 @@
+// Command rendertree is rendertree with the --interactive terminal UI.
+package main
+
+import (
+	"github.com/apstndb/spannerplan/cmd/rendertree/impl"
+	"github.com/apstndb/spannerplan/cmd/rendertree/tui"
+)
+
+func main() {
+	impl.InteractiveRunner = tui.Run
+	impl.Main()
+}
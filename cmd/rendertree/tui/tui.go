@@ -0,0 +1,304 @@
+// Package tui is the terminal UI of rendertree --interactive. It lives in its own module so
+// that the spannerplan module does not depend on bubbletea; the rendertree command of this
+// module sets impl.InteractiveRunner to [Run].
+package tui
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/apstndb/go-tabwrap"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/apstndb/spannerplan"
+	"github.com/apstndb/spannerplan/plantree"
+)
+
+// Run browses planNodes, rendered with opts, in the terminal UI drawn to stdout until the user
+// quits. It has the signature of impl.InteractiveRunner.
+func Run(planNodes []*sppb.PlanNode, opts []plantree.Option, stdout io.Writer) error {
+	m, err := newPlanViewModel(planNodes, opts)
+	if err != nil {
+		return err
+	}
+	// The plan is read from stdin, so keyboard input comes from the controlling terminal.
+	_, err = tea.NewProgram(m, tea.WithInputTTY(), tea.WithOutput(stdout), tea.WithAltScreen()).Run()
+	return err
+}
+
+// detailStatNames lists the statistics shown first in the detail panel, in display order.
+// Statistics in ExecutionStats.Extra follow in key order.
+var detailStatNames = []string{
+	"rows", "scanned_rows", "filtered_rows", "deleted_rows", "latency", "cpu_time",
+	"remote_calls", "filesystem_delay_seconds", "Number of Batches", "Rows Spooled",
+	"Peak Memory Usage (KBytes)", "Peak Buffering Memory Usage (KBytes)",
+	"Disk Usage (KBytes)", "Disk Write Latency (msecs)",
+}
+
+const (
+	defaultViewWidth  = 80
+	defaultViewHeight = 24
+	// minDetailWidth is the narrowest terminal that shows the detail panel beside the tree.
+	minDetailWidth = 60
+)
+
+// planViewModel is the bubbletea model of --interactive.
+type planViewModel struct {
+	rows []plantree.RowWithPredicates
+	// depths holds the tree depth of each row, with the root at zero.
+	depths []int
+	// collapsed holds the indexes of rows whose descendants are hidden.
+	collapsed  map[int]bool
+	cursor     int // index into visibleRows
+	offset     int // first visible row shown
+	width      int
+	height     int
+	hideDetail bool
+}
+
+func newPlanViewModel(planNodes []*sppb.PlanNode, opts []plantree.Option) (*planViewModel, error) {
+	qp, err := spannerplan.New(planNodes)
+	if err != nil {
+		return nil, err
+	}
+	// Each row must stay on one line for navigation, so wrapping is always off.
+	rows, err := plantree.ProcessPlan(qp, append(slices.Clone(opts), plantree.WithWrapWidth(0))...)
+	if err != nil {
+		return nil, err
+	}
+	depths := rowDepths(qp)
+	if len(depths) != len(rows) {
+		return nil, fmt.Errorf("unexpected row count: got=%d want=%d", len(rows), len(depths))
+	}
+	return &planViewModel{
+		rows:      rows,
+		depths:    depths,
+		collapsed: make(map[int]bool),
+		width:     defaultViewWidth,
+		height:    defaultViewHeight,
+	}, nil
+}
+
+// rowDepths returns the depth of every visible node occurrence in the preorder used by [plantree.ProcessPlan].
+func rowDepths(qp *spannerplan.QueryPlan) []int {
+	var depths []int
+	var walk func(node *sppb.PlanNode, depth int)
+	walk = func(node *sppb.PlanNode, depth int) {
+		depths = append(depths, depth)
+		for _, link := range qp.VisibleChildLinks(node) {
+			walk(qp.GetNodeByChildLink(link), depth+1)
+		}
+	}
+	walk(qp.GetNodeByChildLink(nil), 0)
+	return depths
+}
+
+func (m *planViewModel) Init() tea.Cmd { return nil }
+
+func (m *planViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		visible := m.visibleRows()
+		current := visible[m.cursor]
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.cursor--
+		case "down", "j":
+			m.cursor++
+		case "pgup":
+			m.cursor -= m.listHeight()
+		case "pgdown":
+			m.cursor += m.listHeight()
+		case "home", "g":
+			m.cursor = 0
+		case "end", "G":
+			m.cursor = len(visible) - 1
+		case "enter", " ":
+			if m.hasChildren(current) {
+				m.collapsed[current] = !m.collapsed[current]
+			}
+		case "right", "l":
+			delete(m.collapsed, current)
+		case "left", "h":
+			if m.hasChildren(current) && !m.collapsed[current] {
+				m.collapsed[current] = true
+			} else if parent := m.parent(current); parent >= 0 {
+				m.cursor = slices.Index(visible, parent)
+			}
+		case "tab":
+			m.hideDetail = !m.hideDetail
+		}
+		m.clampCursor()
+	}
+	return m, nil
+}
+
+func (m *planViewModel) View() string {
+	visible := m.visibleRows()
+	listHeight := m.listHeight()
+
+	treeWidth := m.width
+	var detail []string
+	if !m.hideDetail && m.width >= minDetailWidth {
+		treeWidth = m.width * 3 / 5
+		detail = m.detailLines(visible[m.cursor])
+	}
+
+	lines := make([]string, 0, listHeight)
+	for i := m.offset; i < len(visible) && i < m.offset+listHeight; i++ {
+		lines = append(lines, m.rowLine(visible[i], i == m.cursor))
+	}
+
+	var b strings.Builder
+	for i := range listHeight {
+		var left string
+		if i < len(lines) {
+			left = lines[i]
+		}
+		if detail == nil {
+			b.WriteString(fitLine(left, treeWidth))
+		} else {
+			var right string
+			if i < len(detail) {
+				right = detail[i]
+			}
+			b.WriteString(fitLine(left, treeWidth-3))
+			b.WriteString(" │ ")
+			b.WriteString(fitLine(right, m.width-treeWidth))
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(fitLine("↑/↓ move  enter toggle  ←/→ collapse/expand  tab detail  q quit", m.width))
+	return b.String()
+}
+
+func (m *planViewModel) rowLine(index int, selected bool) string {
+	row := m.rows[index]
+	marker := "  "
+	if selected {
+		marker = "> "
+	}
+	line := fmt.Sprintf("%s%4s %s", marker, row.FormatID(), row.Text())
+	if m.collapsed[index] {
+		line += fmt.Sprintf(" [+%d]", m.descendantCount(index))
+	}
+	return line
+}
+
+func (m *planViewModel) detailLines(index int) []string {
+	row := m.rows[index]
+	lines := []string{
+		fmt.Sprintf("Node %d: %s", row.DisplayID(), row.DisplayName),
+		strings.TrimSpace(row.NodeText),
+	}
+
+	var statLines []string
+	for _, name := range detailStatNames {
+		if v, ok := row.ExecutionStats.Value(name); ok {
+			statLines = append(statLines, fmt.Sprintf("  %s: %s", name, v))
+		}
+	}
+	extraNames := make([]string, 0, len(row.ExecutionStats.Extra))
+	for name := range row.ExecutionStats.Extra {
+		extraNames = append(extraNames, name)
+	}
+	slices.Sort(extraNames)
+	for _, name := range extraNames {
+		statLines = append(statLines, fmt.Sprintf("  %s: %s", name, row.ExecutionStats.Extra[name]))
+	}
+	if n := row.ExecutionStats.ExecutionSummary.NumExecutions; n != "" {
+		statLines = append(statLines, "  num_executions: "+n)
+	}
+	if row.EstimatedRows != "" {
+		statLines = append(statLines, "  estimated_rows: "+row.EstimatedRows)
+	}
+	if len(statLines) > 0 {
+		lines = append(lines, "", "Stats")
+		lines = append(lines, statLines...)
+	}
+
+	if len(row.PredicateLinks) > 0 {
+		lines = append(lines, "", "Predicates")
+		for _, link := range row.PredicateLinks {
+			lines = append(lines, fmt.Sprintf("  %s: %s", link.Type, link.Description))
+		}
+	}
+	if len(row.ScalarChildLinks) > 0 {
+		lines = append(lines, "", "Scalar child links")
+		for _, link := range row.ScalarChildLinks {
+			name := link.Type
+			if link.Variable != "" {
+				name = strings.TrimSpace(name + " $" + link.Variable)
+			}
+			lines = append(lines, fmt.Sprintf("  %s: %s", name, link.Description))
+		}
+	}
+	return lines
+}
+
+// visibleRows returns the indexes of the rows outside collapsed subtrees.
+func (m *planViewModel) visibleRows() []int {
+	visible := make([]int, 0, len(m.rows))
+	for i := 0; i < len(m.rows); i++ {
+		visible = append(visible, i)
+		if m.collapsed[i] {
+			i += m.descendantCount(i)
+		}
+	}
+	return visible
+}
+
+func (m *planViewModel) descendantCount(index int) int {
+	n := 0
+	for j := index + 1; j < len(m.depths) && m.depths[j] > m.depths[index]; j++ {
+		n++
+	}
+	return n
+}
+
+func (m *planViewModel) hasChildren(index int) bool {
+	return m.descendantCount(index) > 0
+}
+
+// parent returns the row index of the parent of the row at index, or -1 for the root.
+func (m *planViewModel) parent(index int) int {
+	for j := index - 1; j >= 0; j-- {
+		if m.depths[j] < m.depths[index] {
+			return j
+		}
+	}
+	return -1
+}
+
+func (m *planViewModel) listHeight() int {
+	return max(m.height-1, 1)
+}
+
+func (m *planViewModel) clampCursor() {
+	m.cursor = max(min(m.cursor, len(m.visibleRows())-1), 0)
+	listHeight := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+listHeight {
+		m.offset = m.cursor - listHeight + 1
+	}
+}
+
+// fitLine truncates or pads line to exactly width display columns.
+func fitLine(line string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if tabwrap.StringWidth(line) > width {
+		line = tabwrap.Truncate(line, width, "…")
+	}
+	return tabwrap.FillRight(line, width)
+}
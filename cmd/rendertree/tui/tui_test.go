@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-cmp/cmp"
+	"github.com/samber/lo"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func TestPlanViewModel(t *testing.T) {
+	b, err := os.ReadFile("../impl/testdata/distributed_cross_apply_profile.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs, _, err := spannerplan.ExtractQueryPlan(b)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	m, err := newPlanViewModel(qs.GetQueryPlan().GetPlanNodes(), nil)
+	if err != nil {
+		t.Fatalf("newPlanViewModel() error = %v", err)
+	}
+	press := func(keys ...string) {
+		t.Helper()
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			switch key {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "tab":
+				msg = tea.KeyMsg{Type: tea.KeyTab}
+			}
+			m.Update(msg)
+		}
+	}
+	visibleIDs := func() []int32 {
+		return lo.Map(m.visibleRows(), func(i int, _ int) int32 { return m.rows[i].ID })
+	}
+
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	press("j", "j", "enter")
+	if diff := cmp.Diff([]int32{0, 1, 2, 11, 12, 13, 16, 17, 18}, visibleIDs()); diff != "" {
+		t.Fatalf("visible rows after collapsing node 2 mismatch (-want +got):\n%s", diff)
+	}
+	view := m.View()
+	if !strings.Contains(view, "> ") || !strings.Contains(view, "[Input] Create Batch (execution_method: Row) [+3]") {
+		t.Errorf("View() does not mark the collapsed row:\n%s", view)
+	}
+
+	press("G")
+	view = m.View()
+	for _, want := range []string{"Node 18: Scan", "scanned_rows: 63 rows", "latency: 0.84 msecs", "num_executions: 7"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() detail does not contain %q:\n%s", want, view)
+		}
+	}
+
+	press("h")
+	if got := m.rows[m.visibleRows()[m.cursor]].ID; got != 17 {
+		t.Errorf("cursor after moving to parent = node %d, want 17", got)
+	}
+
+	press("tab")
+	if view := m.View(); strings.Contains(view, "Node 17:") {
+		t.Errorf("View() still shows the detail panel after tab:\n%s", view)
+	}
+
+	press("g", "j", "j", "l")
+	if got := len(m.visibleRows()); got != len(m.rows) {
+		t.Errorf("visible rows after expanding = %d, want %d", got, len(m.rows))
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("q did not return a quit command")
+	}
+}
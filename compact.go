@@ -0,0 +1,90 @@
+package spannerplan
+
+import (
+	"strconv"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Compact returns a copy of qp that keeps only the nodes reachable from the root through child
+// links, numbered contiguously in their original relative order, and a map from the index of
+// each kept node in qp to its index in the copy. Nodes become unreachable when an edit of the
+// PlanNodes removes the child links to them, such as pruning a subtree, and Compact drops them
+// so the edited plan can be serialized and rendered again without stray nodes.
+//
+// Child links, short representation subqueries, and subquery_cluster_node metadata values are
+// rewritten to the new indexes, and a subquery or subquery_cluster_node value naming a dropped
+// node is removed. The PlanNodes of the copy are
+// deep copies, so qp is not modified. The copy keeps the options qp was created with, so a
+// [WithVisibilityOverride] function sees the new indexes.
+func (qp *QueryPlan) Compact() (*QueryPlan, map[int32]int32) {
+	reachable := make([]bool, len(qp.planNodes))
+	stack := []int32{0}
+	reachable[0] = true
+	for len(stack) > 0 {
+		index := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, link := range qp.planNodes[index].GetChildLinks() {
+			if child := link.GetChildIndex(); !reachable[child] {
+				reachable[child] = true
+				stack = append(stack, child)
+			}
+		}
+	}
+
+	indexes := make(map[int32]int32)
+	var planNodes []*sppb.PlanNode
+	for i, node := range qp.planNodes {
+		if !reachable[i] {
+			continue
+		}
+		indexes[int32(i)] = int32(len(planNodes))
+		planNodes = append(planNodes, proto.Clone(node).(*sppb.PlanNode))
+	}
+	for _, node := range planNodes {
+		node.Index = indexes[node.GetIndex()]
+		for _, link := range node.GetChildLinks() {
+			link.ChildIndex = indexes[link.GetChildIndex()]
+		}
+		renumberSubqueryClusterNodes(node.GetMetadata(), indexes)
+		for name, old := range node.GetShortRepresentation().GetSubqueries() {
+			if index, ok := indexes[old]; ok {
+				node.ShortRepresentation.Subqueries[name] = index
+			} else {
+				delete(node.ShortRepresentation.Subqueries, name)
+			}
+		}
+	}
+
+	compacted, err := New(planNodes, WithVisibilityOverride(qp.visibilityOverride))
+	if err != nil {
+		// The copy keeps the root and every link target, so it is valid whenever qp is.
+		panic(err)
+	}
+	return compacted, indexes
+}
+
+// renumberSubqueryClusterNodes rewrites the subquery_cluster_node values of s and its nested
+// structs to the indexes in indexes, and removes the values naming a node not in indexes.
+func renumberSubqueryClusterNodes(s *structpb.Struct, indexes map[int32]int32) {
+	for key, value := range s.GetFields() {
+		if nested := value.GetStructValue(); nested != nil {
+			renumberSubqueryClusterNodes(nested, indexes)
+			continue
+		}
+		if key != "subquery_cluster_node" {
+			continue
+		}
+		old, err := strconv.ParseInt(value.GetStringValue(), 10, 32)
+		if err != nil {
+			continue
+		}
+		if index, ok := indexes[int32(old)]; ok {
+			s.GetFields()[key] = structpb.NewStringValue(strconv.Itoa(int(index)))
+		} else {
+			delete(s.GetFields(), key)
+		}
+	}
+}
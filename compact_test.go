@@ -0,0 +1,97 @@
+package spannerplan
+
+import (
+	"maps"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCompact(t *testing.T) {
+	clusterNode := func(index string) *structpb.Struct {
+		return &structpb.Struct{Fields: map[string]*structpb.Value{"subquery_cluster_node": structpb.NewStringValue(index)}}
+	}
+	planNodes := []*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Serialize Result", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 3}}},
+		// Node 1 and its child 2 are no longer linked from the root.
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 2}}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Table Scan", Metadata: clusterNode("1")},
+		{Index: 3, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Distributed Union", Metadata: clusterNode("3"), ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 5},
+			{ChildIndex: 4, Type: "Split Range"},
+		}},
+		{Index: 4, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{
+			Description: "$sq_1",
+			// The subquery follows the dropped nodes 1 and 2.
+			Subqueries: map[string]int32{"sq_1": 5},
+		}},
+		{Index: 5, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Index Scan", Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"scan_target":           structpb.NewStringValue("SongsBySongName"),
+			"subquery_cluster_node": structpb.NewStringValue("1"),
+		}}},
+	}
+	qp, err := New(planNodes)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	compacted, indexes := qp.Compact()
+
+	if diff := cmp.Diff(map[int32]int32{0: 0, 3: 1, 4: 2, 5: 3}, indexes); diff != "" {
+		t.Errorf("Compact() indexes mismatch (-want +got):\n%s", diff)
+	}
+	var names []string
+	for i, node := range compacted.PlanNodes() {
+		if node.GetIndex() != int32(i) {
+			t.Errorf("PlanNodes()[%d].Index = %d, want %d", i, node.GetIndex(), i)
+		}
+		names = append(names, node.GetDisplayName())
+	}
+	if diff := cmp.Diff([]string{"Serialize Result", "Distributed Union", "Function", "Index Scan"}, names); diff != "" {
+		t.Errorf("Compact() nodes mismatch (-want +got):\n%s", diff)
+	}
+	for old, index := range indexes {
+		got := compacted.GetNodeByIndex(index).GetChildLinks()
+		want := qp.GetNodeByIndex(old).GetChildLinks()
+		for j := range want {
+			if gotChild, wantChild := compacted.GetNodeByChildLink(got[j]), qp.GetNodeByChildLink(want[j]); gotChild.GetDisplayName() != wantChild.GetDisplayName() || got[j].GetType() != want[j].GetType() {
+				t.Errorf("node %d childLinks[%d] = %v, want a link to %v", old, j, got[j], want[j])
+			}
+		}
+	}
+	if got := compacted.GetNodeByIndex(1).GetMetadata().GetFields()["subquery_cluster_node"].GetStringValue(); got != "1" {
+		t.Errorf("renumbered subquery_cluster_node = %q, want %q", got, "1")
+	}
+	if _, ok := compacted.GetNodeByIndex(3).GetMetadata().GetFields()["subquery_cluster_node"]; ok {
+		t.Error("subquery_cluster_node naming a dropped node was kept")
+	}
+	if got := compacted.GetNodeByIndex(2).GetShortRepresentation().GetSubqueries(); !maps.Equal(got, map[string]int32{"sq_1": 3}) {
+		t.Errorf("renumbered subqueries = %v, want %v", got, map[string]int32{"sq_1": 3})
+	}
+	if !Equal(qp, compacted) {
+		t.Error("Equal(qp, compacted) = false, want true")
+	}
+
+	if planNodes[3].GetChildLinks()[0].GetChildIndex() != 5 || planNodes[3].GetMetadata().GetFields()["subquery_cluster_node"].GetStringValue() != "3" {
+		t.Error("Compact() modified the original PlanNodes")
+	}
+
+	pruned, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 2, Type: "Condition"}}},
+		// Node 1 was the subquery of the condition before it was pruned.
+		{Index: 1, Kind: sppb.PlanNode_SCALAR, DisplayName: "Scalar Subquery"},
+		{Index: 2, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{
+			Description: "$sq_1",
+			Subqueries:  map[string]int32{"sq_1": 1},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	compacted, _ = pruned.Compact()
+	if got := compacted.GetNodeByIndex(1).GetShortRepresentation().GetSubqueries(); len(got) != 0 {
+		t.Errorf("subqueries naming a dropped node = %v, want none", got)
+	}
+}
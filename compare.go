@@ -0,0 +1,286 @@
+package spannerplan
+
+import (
+	"errors"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan/stats"
+)
+
+// NodeChange is how an operator differs between the plans compared by [ComparePlans].
+type NodeChange string
+
+const (
+	// NodeUnchanged is an operator found in both plans with the same title and short representation.
+	NodeUnchanged NodeChange = "unchanged"
+	// NodeChanged is an operator found in both plans whose kind, short representation or
+	// metadata differs, such as an Index Scan that became a full scan.
+	NodeChanged NodeChange = "changed"
+	// NodeAdded is an operator of the after plan without a counterpart in the before plan.
+	NodeAdded NodeChange = "added"
+	// NodeRemoved is an operator of the before plan without a counterpart in the after plan.
+	NodeRemoved NodeChange = "removed"
+)
+
+// NodeDiff is one operator of a [PlanDiff].
+type NodeDiff struct {
+	Change NodeChange
+	// Before and After are the operator in each plan. Before is nil for [NodeAdded], and After
+	// is nil for [NodeRemoved].
+	Before, After *sppb.PlanNode
+	// LinkType is the type of the child link from the parent, such as "Input", or "" for the
+	// root and for links without a type.
+	LinkType string
+	// Depth is the number of operators above this one, 0 for the root.
+	Depth int
+	// Reason describes the first difference of a [NodeChanged] operator, such as
+	// "metadata differs". It is empty otherwise.
+	Reason string
+}
+
+// RowsDelta returns the rows statistic of After minus that of Before. It reports false unless
+// both operators have a numeric rows statistic, which is the case only for PROFILE captures.
+func (d NodeDiff) RowsDelta() (float64, bool) {
+	before, after, ok := d.stats()
+	if !ok {
+		return 0, false
+	}
+	b, err := before.Rows.Float64()
+	if err != nil {
+		return 0, false
+	}
+	a, err := after.Rows.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return a - b, true
+}
+
+// LatencyDelta returns the latency statistic of After minus that of Before. It reports false
+// unless both operators have a latency statistic, which is the case only for PROFILE captures.
+func (d NodeDiff) LatencyDelta() (time.Duration, bool) {
+	before, after, ok := d.stats()
+	if !ok {
+		return 0, false
+	}
+	b, err := before.LatencyDuration()
+	if err != nil {
+		return 0, false
+	}
+	a, err := after.LatencyDuration()
+	if err != nil {
+		return 0, false
+	}
+	return a - b, true
+}
+
+func (d NodeDiff) stats() (before, after *stats.ExecutionStats, ok bool) {
+	if d.Before == nil || d.After == nil {
+		return nil, nil, false
+	}
+	before, err := stats.Extract(d.Before, false)
+	if err != nil {
+		return nil, nil, false
+	}
+	after, err = stats.Extract(d.After, false)
+	if err != nil {
+		return nil, nil, false
+	}
+	return before, after, true
+}
+
+// PlanDiff is the result of [ComparePlans].
+type PlanDiff struct {
+	// Nodes holds every visible operator of both plans once, in pre-order of the merged operator
+	// tree: a matched operator is followed by its children, and the children of a matched pair
+	// keep their order, with removed children before the added child that replaced them.
+	Nodes []NodeDiff
+}
+
+// HasChanges reports whether any operator of d was added, removed or changed.
+func (d *PlanDiff) HasChanges() bool {
+	for _, node := range d.Nodes {
+		if node.Change != NodeUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangedAfter returns the PlanNode indexes of the after plan whose operators were added or
+// changed, for rendering the after plan as a review view with plantree.WithChangeSet.
+func (d *PlanDiff) ChangedAfter() map[int32]bool {
+	changed := make(map[int32]bool)
+	for _, node := range d.Nodes {
+		if node.After != nil && node.Change != NodeUnchanged {
+			changed[node.After.GetIndex()] = true
+		}
+	}
+	return changed
+}
+
+// ComparePlans compares the visible operator trees of before and after, such as the plans of a
+// query before and after adding an index, whose PlanNode indexes need not agree.
+//
+// Operators are matched by their position in the tree rather than by index: the roots match, and
+// the children of a matched pair are aligned in order as the longest common subsequence of their
+// display names and child link types, so an operator inserted between two others leaves the
+// others matched. A matched operator is [NodeChanged] when its kind, short representation or
+// metadata differs, compared as [StructurallyEqual] does, and [NodeUnchanged] otherwise.
+//
+// An operator without a counterpart starts an unmatched subtree: it and every operator below it
+// are [NodeAdded] or [NodeRemoved], each as its own [NodeDiff], even when a descendant resembles
+// an operator of the other plan. So a changed join order shows up as the old subtree removed and
+// the new one added, rather than as a few matched leaves. Execution statistics never make an
+// operator changed; compare them with [NodeDiff.RowsDelta] and [NodeDiff.LatencyDelta].
+//
+// An operator reachable through several parents is compared once, under the first. ComparePlans
+// returns an error when either plan is nil.
+func ComparePlans(before, after *QueryPlan) (*PlanDiff, error) {
+	if before == nil || after == nil {
+		return nil, errors.New("cannot compare a nil plan")
+	}
+	c := &planComparison{
+		before:        before,
+		after:         after,
+		visitedBefore: make(map[int32]bool),
+		visitedAfter:  make(map[int32]bool),
+	}
+	diff := &PlanDiff{}
+	if !before.IsVisible(nil) || !after.IsVisible(nil) {
+		return diff, nil
+	}
+	c.match(before.GetNodeByChildLink(nil), after.GetNodeByChildLink(nil), "", 0)
+	diff.Nodes = c.nodes
+	return diff, nil
+}
+
+// planComparison is the state of one [ComparePlans] run.
+type planComparison struct {
+	before, after               *QueryPlan
+	visitedBefore, visitedAfter map[int32]bool
+	nodes                       []NodeDiff
+}
+
+// visibleChild is a visible child of an operator and the type of its link.
+type visibleChild struct {
+	node     *sppb.PlanNode
+	linkType string
+}
+
+func (c *planComparison) visibleChildren(qp *QueryPlan, node *sppb.PlanNode, visited map[int32]bool) []visibleChild {
+	var children []visibleChild
+	for i, link := range node.GetChildLinks() {
+		if !qp.IsVisible(link) {
+			continue
+		}
+		child := qp.GetNodeByChildLink(link)
+		if visited[child.GetIndex()] {
+			continue
+		}
+		children = append(children, visibleChild{node: child, linkType: qp.LinkTypeInParent(node, i)})
+	}
+	return children
+}
+
+func (c *planComparison) match(before, after *sppb.PlanNode, linkType string, depth int) {
+	c.visitedBefore[before.GetIndex()] = true
+	c.visitedAfter[after.GetIndex()] = true
+
+	diff := NodeDiff{Change: NodeUnchanged, Before: before, After: after, LinkType: linkType, Depth: depth}
+	if reason := nodeDifference(before, after, false); reason != "" {
+		diff.Change, diff.Reason = NodeChanged, reason
+	}
+	c.nodes = append(c.nodes, diff)
+
+	beforeChildren := c.visibleChildren(c.before, before, c.visitedBefore)
+	afterChildren := c.visibleChildren(c.after, after, c.visitedAfter)
+	i, j := 0, 0
+	for _, pair := range alignChildren(beforeChildren, afterChildren) {
+		for ; i < pair.before; i++ {
+			c.unmatched(c.before, beforeChildren[i], NodeRemoved, depth+1)
+		}
+		for ; j < pair.after; j++ {
+			c.unmatched(c.after, afterChildren[j], NodeAdded, depth+1)
+		}
+		// A shared operator may have been visited below an earlier sibling, which leaves its
+		// counterpart unmatched.
+		if !c.visitedBefore[beforeChildren[i].node.GetIndex()] && !c.visitedAfter[afterChildren[j].node.GetIndex()] {
+			c.match(beforeChildren[i].node, afterChildren[j].node, afterChildren[j].linkType, depth+1)
+		} else {
+			c.unmatched(c.before, beforeChildren[i], NodeRemoved, depth+1)
+			c.unmatched(c.after, afterChildren[j], NodeAdded, depth+1)
+		}
+		i, j = i+1, j+1
+	}
+	for ; i < len(beforeChildren); i++ {
+		c.unmatched(c.before, beforeChildren[i], NodeRemoved, depth+1)
+	}
+	for ; j < len(afterChildren); j++ {
+		c.unmatched(c.after, afterChildren[j], NodeAdded, depth+1)
+	}
+}
+
+// unmatched adds child and its visible descendants in qp as change.
+func (c *planComparison) unmatched(qp *QueryPlan, child visibleChild, change NodeChange, depth int) {
+	visited := c.visitedBefore
+	if change == NodeAdded {
+		visited = c.visitedAfter
+	}
+	if visited[child.node.GetIndex()] {
+		return
+	}
+	visited[child.node.GetIndex()] = true
+
+	diff := NodeDiff{Change: change, LinkType: child.linkType, Depth: depth}
+	if change == NodeAdded {
+		diff.After = child.node
+	} else {
+		diff.Before = child.node
+	}
+	c.nodes = append(c.nodes, diff)
+	for _, grandchild := range c.visibleChildren(qp, child.node, visited) {
+		c.unmatched(qp, grandchild, change, depth+1)
+	}
+}
+
+// childPair is a pair of positions of matched children.
+type childPair struct{ before, after int }
+
+// alignChildren returns the longest common subsequence of before and after, comparing display
+// names and link types, as ascending pairs of positions.
+func alignChildren(before, after []visibleChild) []childPair {
+	same := func(i, j int) bool {
+		return before[i].node.GetDisplayName() == after[j].node.GetDisplayName() && before[i].linkType == after[j].linkType
+	}
+	// lengths[i][j] is the length of the longest common subsequence of before[i:] and after[j:].
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if same(i, j) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var pairs []childPair
+	for i, j := 0, 0; i < len(before) && j < len(after); {
+		switch {
+		case same(i, j):
+			pairs = append(pairs, childPair{before: i, after: j})
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,261 @@
+package spannerplan
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestComparePlans(t *testing.T) {
+	withTable := func(node *sppb.PlanNode, table string) *sppb.PlanNode {
+		node.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{"table": structpb.NewStringValue(table)}}
+		return node
+	}
+	withLinkTypes := func(node *sppb.PlanNode, linkTypes ...string) *sppb.PlanNode {
+		for i, linkType := range linkTypes {
+			node.ChildLinks[i].Type = linkType
+		}
+		return node
+	}
+
+	// subquery is a Scalar Subquery at index whose query is a Scan of B at scan.
+	subquery := func(index, scan int32) []*sppb.PlanNode {
+		return []*sppb.PlanNode{
+			{Index: index, Kind: sppb.PlanNode_SCALAR, DisplayName: "Scalar Subquery", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: scan}}},
+			withTable(relational(scan, "Scan"), "B"),
+		}
+	}
+	// withScalar is a Serialize Result of a Scan of A and of a scalar that refers to the
+	// subquery at index 3 or 4 as $sq_1.
+	withScalar := func(subqueryIndex int32, subquery []*sppb.PlanNode) []*sppb.PlanNode {
+		nodes := []*sppb.PlanNode{
+			withLinkTypes(relational(0, "Serialize Result", 1, 2), "", "Scalar"),
+			withTable(relational(1, "Scan"), "A"),
+			{Index: 2, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{
+				Description: "$sq_1",
+				Subqueries:  map[string]int32{"sq_1": subqueryIndex},
+			}, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: subqueryIndex}}},
+			nil,
+			nil,
+		}
+		for _, node := range subquery {
+			nodes[node.GetIndex()] = node
+		}
+		return nodes
+	}
+
+	tests := []struct {
+		name          string
+		before, after []*sppb.PlanNode
+		want          []string
+	}{
+		{
+			name: "renumbered nodes",
+			before: []*sppb.PlanNode{
+				relational(0, "Union", 1, 2),
+				withTable(relational(1, "Scan"), "A"),
+				withTable(relational(2, "Scan"), "B"),
+			},
+			after: []*sppb.PlanNode{
+				relational(0, "Union", 2, 1),
+				withTable(relational(1, "Scan"), "B"),
+				withTable(relational(2, "Scan"), "A"),
+			},
+			want: []string{"unchanged 0->0", "unchanged 1->2 depth 1", "unchanged 2->1 depth 1"},
+		},
+		{
+			name: "inserted operator",
+			before: []*sppb.PlanNode{
+				relational(0, "Union", 1, 2),
+				relational(1, "Scan"),
+				relational(2, "Sort"),
+			},
+			after: []*sppb.PlanNode{
+				relational(0, "Union", 1, 3, 2),
+				relational(1, "Scan"),
+				relational(2, "Sort"),
+				relational(3, "Filter", 4),
+				relational(4, "Scan"),
+			},
+			want: []string{
+				"unchanged 0->0",
+				"unchanged 1->1 depth 1",
+				"added ->3 depth 1",
+				"added ->4 depth 2",
+				"unchanged 2->2 depth 1",
+			},
+		},
+		{
+			name: "changed metadata",
+			before: []*sppb.PlanNode{
+				relational(0, "Serialize Result", 1),
+				withTable(relational(1, "Scan"), "A"),
+			},
+			after: []*sppb.PlanNode{
+				relational(0, "Serialize Result", 1),
+				withTable(relational(1, "Scan"), "AByName"),
+			},
+			want: []string{"unchanged 0->0", "changed 1->1 depth 1: metadata differs"},
+		},
+		{
+			name: "replaced subtree",
+			before: []*sppb.PlanNode{
+				relational(0, "Serialize Result", 1),
+				withLinkTypes(relational(1, "Hash Join", 2, 3), "Build", "Probe"),
+				relational(2, "Scan"),
+				relational(3, "Scan"),
+			},
+			after: []*sppb.PlanNode{
+				relational(0, "Serialize Result", 1),
+				withLinkTypes(relational(1, "Cross Apply", 2, 3), "Input", "Map"),
+				relational(2, "Scan"),
+				relational(3, "Scan"),
+			},
+			want: []string{
+				"unchanged 0->0",
+				"removed 1-> depth 1",
+				"removed 2-> depth 2",
+				"removed 3-> depth 2",
+				"added ->1 depth 1",
+				"added ->2 depth 2",
+				"added ->3 depth 2",
+			},
+		},
+		{
+			name:   "renumbered scalar subqueries",
+			before: withScalar(3, subquery(3, 4)),
+			after:  withScalar(4, subquery(4, 3)),
+			want:   []string{"unchanged 0->0", "unchanged 1->1 depth 1", "unchanged 2->2 depth 1"},
+		},
+		{
+			name: "shared operator",
+			before: []*sppb.PlanNode{
+				relational(0, "Union", 1, 1),
+				relational(1, "Scan"),
+			},
+			after: []*sppb.PlanNode{
+				relational(0, "Union", 1, 2),
+				relational(1, "Scan"),
+				relational(2, "Scan"),
+			},
+			want: []string{"unchanged 0->0", "unchanged 1->1 depth 1", "added ->2 depth 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := New(tt.before)
+			if err != nil {
+				t.Fatalf("New(before) error = %v", err)
+			}
+			after, err := New(tt.after)
+			if err != nil {
+				t.Fatalf("New(after) error = %v", err)
+			}
+			diff, err := ComparePlans(before, after)
+			if err != nil {
+				t.Fatalf("ComparePlans() error = %v", err)
+			}
+			var got []string
+			for _, node := range diff.Nodes {
+				s := fmt.Sprintf("%s %s->%s", node.Change, nodeIndex(node.Before), nodeIndex(node.After))
+				if node.Depth > 0 {
+					s += fmt.Sprintf(" depth %d", node.Depth)
+				}
+				if node.Reason != "" {
+					s += ": " + node.Reason
+				}
+				got = append(got, s)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ComparePlans() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func nodeIndex(node *sppb.PlanNode) string {
+	if node == nil {
+		return ""
+	}
+	return fmt.Sprint(node.GetIndex())
+}
+
+func TestComparePlans_StatsDeltas(t *testing.T) {
+	withStats := func(node *sppb.PlanNode, rows, latency string) *sppb.PlanNode {
+		s, err := structpb.NewStruct(map[string]any{
+			"rows":    map[string]any{"total": rows, "unit": "rows"},
+			"latency": map[string]any{"total": latency, "unit": "msecs"},
+		})
+		if err != nil {
+			t.Fatalf("structpb.NewStruct() error = %v", err)
+		}
+		node.ExecutionStats = s
+		return node
+	}
+	before, err := New([]*sppb.PlanNode{withStats(relational(0, "Scan"), "33", "1.5")})
+	if err != nil {
+		t.Fatalf("New(before) error = %v", err)
+	}
+	after, err := New([]*sppb.PlanNode{withStats(relational(0, "Scan"), "40", "1.25")})
+	if err != nil {
+		t.Fatalf("New(after) error = %v", err)
+	}
+	diff, err := ComparePlans(before, after)
+	if err != nil {
+		t.Fatalf("ComparePlans() error = %v", err)
+	}
+	if diff.HasChanges() {
+		t.Error("HasChanges() = true for different stats only, want false")
+	}
+	node := diff.Nodes[0]
+	if got, ok := node.RowsDelta(); !ok || got != 7 {
+		t.Errorf("RowsDelta() = (%v, %v), want (7, true)", got, ok)
+	}
+	if got, ok := node.LatencyDelta(); !ok || got != -250*time.Microsecond {
+		t.Errorf("LatencyDelta() = (%v, %v), want (-250µs, true)", got, ok)
+	}
+
+	plan, err := New([]*sppb.PlanNode{relational(0, "Scan")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	diff, err = ComparePlans(plan, after)
+	if err != nil {
+		t.Fatalf("ComparePlans() error = %v", err)
+	}
+	if _, ok := diff.Nodes[0].RowsDelta(); ok {
+		t.Error("RowsDelta() without before stats reported true")
+	}
+	if _, ok := diff.Nodes[0].LatencyDelta(); ok {
+		t.Error("LatencyDelta() without before stats reported true")
+	}
+}
+
+func TestPlanDiff_ChangedAfter(t *testing.T) {
+	before, err := New([]*sppb.PlanNode{relational(0, "Union", 1), relational(1, "Scan")})
+	if err != nil {
+		t.Fatalf("New(before) error = %v", err)
+	}
+	after, err := New([]*sppb.PlanNode{relational(0, "Union", 1, 2), relational(1, "Filter"), relational(2, "Scan")})
+	if err != nil {
+		t.Fatalf("New(after) error = %v", err)
+	}
+	diff, err := ComparePlans(before, after)
+	if err != nil {
+		t.Fatalf("ComparePlans() error = %v", err)
+	}
+	if !diff.HasChanges() {
+		t.Error("HasChanges() = false, want true")
+	}
+	if diff := cmp.Diff(map[int32]bool{1: true}, diff.ChangedAfter()); diff != "" {
+		t.Errorf("ChangedAfter() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ComparePlans(nil, after); err == nil {
+		t.Error("ComparePlans(nil) error = nil, want non-nil")
+	}
+}
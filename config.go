@@ -0,0 +1,180 @@
+package spannerplan
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RenderConfig is a serialization-friendly form of the node title [Option] values.
+//
+// It lets callers that start from JSON, such as WebAssembly or JavaScript embeddings,
+// pass one object instead of composing functional options. Enum fields are encoded as
+// the strings accepted by [ParseExecutionMethodFormat], [ParseTargetMetadataFormat], and
+// [ParseKnownFlagFormat]; unknown strings are rejected when decoding. Omitted enum fields and
+// empty strings decode to their zero (RAW) value, matching [NodeTitle] without options.
+//
+// Wrapping is a plantree concern and is configured there.
+type RenderConfig struct {
+	// ExecutionMethodFormat controls how execution_method metadata is rendered.
+	ExecutionMethodFormat ExecutionMethodFormat
+	// TargetMetadataFormat controls how scan_target, distribution_table, and table metadata are rendered.
+	TargetMetadataFormat TargetMetadataFormat
+	// KnownFlagFormat controls how known boolean flag metadata is rendered.
+	KnownFlagFormat KnownFlagFormat
+	// Compact enables the compact format. See [EnableCompact].
+	Compact bool
+	// HideMetadata hides all metadata and labels. See [HideMetadata].
+	HideMetadata bool
+	// TypeMetadata also renders call_type and iterator_type as metadata. See [ShowTypeMetadata].
+	TypeMetadata bool
+	// ScanEstimates renders the row estimates of scans after their targets. See [ShowScanEstimates].
+	ScanEstimates bool
+}
+
+type renderConfigJSON struct {
+	ExecutionMethodFormat ExecutionMethodFormat `json:"executionMethodFormat"`
+	TargetMetadataFormat  TargetMetadataFormat  `json:"targetMetadataFormat"`
+	KnownFlagFormat       KnownFlagFormat       `json:"knownFlagFormat"`
+	Compact               bool                  `json:"compact,omitempty"`
+	HideMetadata          bool                  `json:"hideMetadata,omitempty"`
+	TypeMetadata          bool                  `json:"typeMetadata,omitempty"`
+	ScanEstimates         bool                  `json:"scanEstimates,omitempty"`
+}
+
+// Options returns the functional options equivalent to c.
+func (c RenderConfig) Options() []Option {
+	opts := []Option{
+		WithExecutionMethodFormat(c.ExecutionMethodFormat),
+		WithTargetMetadataFormat(c.TargetMetadataFormat),
+		WithKnownFlagFormat(c.KnownFlagFormat),
+	}
+	if c.Compact {
+		opts = append(opts, EnableCompact())
+	}
+	if c.HideMetadata {
+		opts = append(opts, HideMetadata())
+	}
+	if c.TypeMetadata {
+		opts = append(opts, ShowTypeMetadata())
+	}
+	if c.ScanEstimates {
+		opts = append(opts, ShowScanEstimates())
+	}
+	return opts
+}
+
+// Validate reports an error when an enum field holds an undefined value.
+func (c RenderConfig) Validate() error {
+	if c.ExecutionMethodFormat != ExecutionMethodFormatRaw && c.ExecutionMethodFormat != ExecutionMethodFormatAngle {
+		return fmt.Errorf("invalid ExecutionMethodFormat: %d", c.ExecutionMethodFormat)
+	}
+	if c.TargetMetadataFormat != TargetMetadataFormatRaw && c.TargetMetadataFormat != TargetMetadataFormatOn {
+		return fmt.Errorf("invalid TargetMetadataFormat: %d", c.TargetMetadataFormat)
+	}
+	if c.KnownFlagFormat != KnownFlagFormatRaw && c.KnownFlagFormat != KnownFlagFormatLabel {
+		return fmt.Errorf("invalid KnownFlagFormat: %d", c.KnownFlagFormat)
+	}
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (c RenderConfig) MarshalJSON() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(renderConfigJSON(c))
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (c *RenderConfig) UnmarshalJSON(b []byte) error {
+	var v renderConfigJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*c = RenderConfig(v)
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler], encoding f as its String form. It
+// returns an error for an undefined value.
+func (f ExecutionMethodFormat) MarshalJSON() ([]byte, error) {
+	if _, err := ParseExecutionMethodFormat(f.String()); err != nil {
+		return nil, err
+	}
+	return json.Marshal(f.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], decoding a string accepted by [ParseExecutionMethodFormat],
+// or "" as ExecutionMethodFormatRaw.
+func (f *ExecutionMethodFormat) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*f = ExecutionMethodFormatRaw
+		return nil
+	}
+	parsed, err := ParseExecutionMethodFormat(s)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler], encoding f as its String form. It
+// returns an error for an undefined value.
+func (f TargetMetadataFormat) MarshalJSON() ([]byte, error) {
+	if _, err := ParseTargetMetadataFormat(f.String()); err != nil {
+		return nil, err
+	}
+	return json.Marshal(f.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], decoding a string accepted by [ParseTargetMetadataFormat],
+// or "" as TargetMetadataFormatRaw.
+func (f *TargetMetadataFormat) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*f = TargetMetadataFormatRaw
+		return nil
+	}
+	parsed, err := ParseTargetMetadataFormat(s)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler], encoding f as its String form. It
+// returns an error for an undefined value.
+func (f KnownFlagFormat) MarshalJSON() ([]byte, error) {
+	if _, err := ParseKnownFlagFormat(f.String()); err != nil {
+		return nil, err
+	}
+	return json.Marshal(f.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], decoding a string accepted by [ParseKnownFlagFormat],
+// or "" as KnownFlagFormatRaw.
+func (f *KnownFlagFormat) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*f = KnownFlagFormatRaw
+		return nil
+	}
+	parsed, err := ParseKnownFlagFormat(s)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
@@ -0,0 +1,189 @@
+package spannerplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestRenderConfigJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     RenderConfig
+		wantJSON string
+		wantErr  string
+	}{
+		{
+			name:     "all fields",
+			input:    `{"executionMethodFormat":"angle","targetMetadataFormat":"ON","knownFlagFormat":"label","compact":true,"hideMetadata":true,"typeMetadata":true,"scanEstimates":true}`,
+			want:     RenderConfig{ExecutionMethodFormat: ExecutionMethodFormatAngle, TargetMetadataFormat: TargetMetadataFormatOn, KnownFlagFormat: KnownFlagFormatLabel, Compact: true, HideMetadata: true, TypeMetadata: true, ScanEstimates: true},
+			wantJSON: `{"executionMethodFormat":"ANGLE","targetMetadataFormat":"ON","knownFlagFormat":"LABEL","compact":true,"hideMetadata":true,"typeMetadata":true,"scanEstimates":true}`,
+		},
+		{
+			name:     "empty object uses raw formats",
+			input:    `{}`,
+			want:     RenderConfig{},
+			wantJSON: `{"executionMethodFormat":"RAW","targetMetadataFormat":"RAW","knownFlagFormat":"RAW"}`,
+		},
+		{
+			name:     "empty enum strings use raw formats",
+			input:    `{"executionMethodFormat":"","targetMetadataFormat":"","knownFlagFormat":""}`,
+			want:     RenderConfig{},
+			wantJSON: `{"executionMethodFormat":"RAW","targetMetadataFormat":"RAW","knownFlagFormat":"RAW"}`,
+		},
+		{
+			name:    "unknown enum string",
+			input:   `{"targetMetadataFormat":"OFF"}`,
+			wantErr: "invalid TargetMetadataFormat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got RenderConfig
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("json.Unmarshal() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("json.Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(b) != tt.wantJSON {
+				t.Fatalf("json.Marshal() = %s, want %s", b, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestRenderConfigValidate(t *testing.T) {
+	if err := (RenderConfig{}).Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if err := (RenderConfig{KnownFlagFormat: 7}).Validate(); err == nil {
+		t.Fatal("Validate() error = nil, want non-nil")
+	}
+	if _, err := json.Marshal(RenderConfig{ExecutionMethodFormat: -1}); err == nil {
+		t.Fatal("json.Marshal() error = nil, want non-nil")
+	}
+}
+
+func TestFormatEnumJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		wantJSON string
+		wantErr  bool
+	}{
+		{name: "execution method", value: ExecutionMethodFormatAngle, wantJSON: `"ANGLE"`},
+		{name: "target metadata", value: TargetMetadataFormatOn, wantJSON: `"ON"`},
+		{name: "known flag", value: KnownFlagFormatLabel, wantJSON: `"LABEL"`},
+		{name: "raw", value: KnownFlagFormatRaw, wantJSON: `"RAW"`},
+		{name: "undefined", value: TargetMetadataFormat(5), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("json.Marshal(%v) error = nil, want non-nil", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("json.Marshal(%v) error = %v", tt.value, err)
+			}
+			if string(b) != tt.wantJSON {
+				t.Fatalf("json.Marshal(%v) = %s, want %s", tt.value, b, tt.wantJSON)
+			}
+
+			got := reflect.New(reflect.TypeOf(tt.value))
+			if err := json.Unmarshal(b, got.Interface()); err != nil {
+				t.Fatalf("json.Unmarshal(%s) error = %v", b, err)
+			}
+			if got.Elem().Interface() != tt.value {
+				t.Errorf("json.Unmarshal(%s) = %v, want %v", b, got.Elem().Interface(), tt.value)
+			}
+		})
+	}
+
+	var f ExecutionMethodFormat
+	if err := json.Unmarshal([]byte(`1`), &f); err == nil {
+		t.Error("json.Unmarshal(1) error = nil, want non-nil for an integer value")
+	}
+}
+
+func TestFormatEnumString(t *testing.T) {
+	for _, tt := range []struct {
+		value fmt.Stringer
+		want  string
+	}{
+		{ExecutionMethodFormatRaw, "RAW"},
+		{ExecutionMethodFormatAngle, "ANGLE"},
+		{TargetMetadataFormatOn, "ON"},
+		{KnownFlagFormatLabel, "LABEL"},
+		{KnownFlagFormat(7), "KnownFlagFormat(7)"},
+	} {
+		if got := tt.value.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestRenderConfigOptions(t *testing.T) {
+	node := &sppb.PlanNode{
+		DisplayName: "Scan",
+		Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"execution_method": structpb.NewStringValue("Row"),
+			"scan_target":      structpb.NewStringValue("Singers"),
+			"scan_type":        structpb.NewStringValue("TableScan"),
+			"Full scan":        structpb.NewStringValue("true"),
+		}},
+	}
+
+	tests := []struct {
+		name string
+		cfg  RenderConfig
+		want string
+	}{
+		{
+			name: "zero value",
+			cfg:  RenderConfig{},
+			want: NodeTitle(node),
+		},
+		{
+			name: "cli defaults",
+			cfg:  RenderConfig{ExecutionMethodFormat: ExecutionMethodFormatAngle, TargetMetadataFormat: TargetMetadataFormatOn, KnownFlagFormat: KnownFlagFormatLabel},
+			want: "Table Scan on Singers <Row> (Full scan)",
+		},
+		{
+			name: "hide metadata",
+			cfg:  RenderConfig{TargetMetadataFormat: TargetMetadataFormatOn, HideMetadata: true},
+			want: NodeTitle(node, WithTargetMetadataFormat(TargetMetadataFormatOn), HideMetadata()),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NodeTitle(node, tt.cfg.Options()...); got != tt.want {
+				t.Fatalf("NodeTitle(cfg.Options()) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package spannerplan
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// EdgeKind tells the edges returned by [QueryPlan.DataFlowEdges] apart.
+type EdgeKind int
+
+const (
+	// EdgeTree is a link of the operator tree: the child operator produces rows for its parent.
+	EdgeTree EdgeKind = iota
+
+	// EdgeData is a variable dependency: an operator reads a variable that another one defines.
+	EdgeData
+)
+
+// String returns "tree" or "data".
+func (k EdgeKind) String() string {
+	switch k {
+	case EdgeTree:
+		return "tree"
+	case EdgeData:
+		return "data"
+	default:
+		return "unknown"
+	}
+}
+
+// Edge is a producer-to-consumer edge between two visible operators, identified by their
+// PlanNode indexes.
+type Edge struct {
+	From int32
+	To   int32
+	Kind EdgeKind
+	// Variable is the variable an [EdgeData] edge carries, as the consumer references it without
+	// the leading $, such as "v2" for $v2. It is empty for an [EdgeTree] edge.
+	Variable string
+}
+
+// variableReference matches a variable reference such as $AlbumId, $v2 or $SingerId' in a
+// description or metadata value.
+var variableReference = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*'*)`)
+
+// DataFlowEdges returns the flow of data between the visible operators of the plan, for graph
+// renderers that draw more than the operator tree.
+//
+// Every visible child link gives an [EdgeTree] edge from the child to its parent. A child link
+// with a variable defines that variable on its parent, and an operator that references it, such
+// as $AlbumTitle in a predicate or $v2 as a scan target, gets an [EdgeData] edge from the
+// defining operator. A reference to a struct variable such as $v1 matches its fields, like
+// v1.AlbumId. References are collected from the description and metadata of the operator and
+// of the scalar nodes under it that are not visible themselves. Variables are matched by name
+// alone, so when several operators define a name, each of them gets an edge, and an operator
+// reading several variables of one producer gets an edge for each.
+//
+// Edges are returned once each: tree edges in the order of [QueryPlan.VisibleNodes], then data
+// edges ordered by To, From and Variable. Self edges and data edges between operators already joined by a
+// tree edge in the same direction are omitted. Nodes unreachable from the root are ignored. The
+// tree is traversed with [QueryPlan.Walk], whose error is returned for a cycle or a plan beyond
+// the traversal limits.
+func (qp *QueryPlan) DataFlowEdges() ([]Edge, error) {
+	var edges []Edge
+	seen := make(map[Edge]bool)
+	add := func(e Edge) {
+		if e.From == e.To || seen[e] || e.Kind == EdgeData && seen[Edge{From: e.From, To: e.To, Kind: EdgeTree}] {
+			return
+		}
+		seen[e] = true
+		edges = append(edges, e)
+	}
+
+	// owner maps every reachable node to the visible operator it belongs to: visible nodes to
+	// themselves, and hidden scalar nodes to their nearest visible ancestor.
+	owner := make(map[int32]int32)
+	var order []int32
+	var claim func(node *sppb.PlanNode, operator int32)
+	claim = func(node *sppb.PlanNode, operator int32) {
+		if _, ok := owner[node.GetIndex()]; ok {
+			return
+		}
+		owner[node.GetIndex()] = operator
+		order = append(order, node.GetIndex())
+		for _, link := range node.GetChildLinks() {
+			if !qp.IsVisible(link) {
+				claim(qp.GetNodeByChildLink(link), operator)
+			}
+		}
+	}
+	err := qp.Walk(func(node *sppb.PlanNode, _ *sppb.PlanNode_ChildLink, _ int) error {
+		claim(node, node.GetIndex())
+		for _, link := range qp.VisibleChildLinks(node) {
+			add(Edge{From: link.GetChildIndex(), To: node.GetIndex(), Kind: EdgeTree})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string][]int32)
+	for _, index := range order {
+		for _, link := range qp.GetNodeByIndex(index).GetChildLinks() {
+			if v := link.GetVariable(); v != "" {
+				definitions[v] = append(definitions[v], owner[index])
+			}
+		}
+	}
+
+	var dataEdges []Edge
+	for _, index := range order {
+		node := qp.GetNodeByIndex(index)
+		for _, name := range nodeVariableReferences(node) {
+			for v, producers := range definitions {
+				if v != name && !strings.HasPrefix(v, name+".") {
+					continue
+				}
+				for _, producer := range producers {
+					dataEdges = append(dataEdges, Edge{From: producer, To: owner[index], Kind: EdgeData, Variable: name})
+				}
+			}
+		}
+	}
+	slices.SortFunc(dataEdges, func(a, b Edge) int {
+		return cmp.Or(cmp.Compare(a.To, b.To), cmp.Compare(a.From, b.From), strings.Compare(a.Variable, b.Variable))
+	})
+	for _, e := range dataEdges {
+		add(e)
+	}
+	return edges, nil
+}
+
+// nodeVariableReferences returns the names of the variables referenced by the description and
+// the string metadata values of node, without the leading $.
+func nodeVariableReferences(node *sppb.PlanNode) []string {
+	texts := []string{node.GetShortRepresentation().GetDescription()}
+	for _, value := range node.GetMetadata().GetFields() {
+		if s, ok := value.GetKind().(*structpb.Value_StringValue); ok {
+			texts = append(texts, s.StringValue)
+		}
+	}
+
+	var names []string
+	for _, text := range texts {
+		for _, match := range variableReference.FindAllStringSubmatch(text, -1) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
@@ -0,0 +1,109 @@
+package spannerplan
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDataFlowEdges(t *testing.T) {
+	metadata := func(fields map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatalf("structpb.NewStruct() error = %v", err)
+		}
+		return s
+	}
+	qp, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Cross Apply", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 1, Type: "Input"},
+			{ChildIndex: 4, Type: "Map"},
+		}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Create Batch", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 2},
+			{ChildIndex: 3, Variable: "v1.Batch"},
+		}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 7, Variable: "Key"},
+		}},
+		{Index: 3, Kind: sppb.PlanNode_SCALAR, DisplayName: "Reference", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{Description: "$Key"}},
+		{Index: 4, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter Scan", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 5},
+			{ChildIndex: 6, Type: "Seek Condition"},
+		}},
+		{Index: 5, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Batch Scan", Metadata: metadata(map[string]any{"scan_target": "$v1"})},
+		{Index: 6, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{Description: "($Key = $Key)"}},
+		{Index: 7, Kind: sppb.PlanNode_SCALAR, DisplayName: "Reference", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{Description: "Key"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	want := []Edge{
+		{From: 1, To: 0, Kind: EdgeTree},
+		{From: 4, To: 0, Kind: EdgeTree},
+		{From: 2, To: 1, Kind: EdgeTree},
+		{From: 5, To: 4, Kind: EdgeTree},
+		// $Key in the hidden Reference under Create Batch; the tree edge from Scan already covers it.
+		// $Key twice in the Seek Condition of Filter Scan gives one edge.
+		{From: 2, To: 4, Kind: EdgeData, Variable: "Key"},
+		// $v1 as the scan target of Batch Scan matches v1.Batch.
+		{From: 1, To: 5, Kind: EdgeData, Variable: "v1"},
+	}
+	got, err := qp.DataFlowEdges()
+	if err != nil {
+		t.Fatalf("DataFlowEdges() error = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DataFlowEdges() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDataFlowEdgesDCA(t *testing.T) {
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(dca.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	edges, err := qp.DataFlowEdges()
+	if err != nil {
+		t.Fatalf("DataFlowEdges() error = %v", err)
+	}
+	var treeEdges int
+	for _, e := range edges {
+		if e.Kind == EdgeTree {
+			treeEdges++
+		}
+	}
+	if treeEdges != 13 {
+		t.Errorf("DataFlowEdges() has %d tree edges, want 13", treeEdges)
+	}
+	for _, want := range []Edge{
+		// Create Batch defines $v2, the scan target of Batch Scan.
+		{From: 2, To: 25, Kind: EdgeData, Variable: "v2"},
+		// Batch Scan defines $batched_AlbumId, read by the seek condition of Table Scan.
+		{From: 25, To: 31, Kind: EdgeData, Variable: "batched_AlbumId"},
+	} {
+		if !slices.Contains(edges, want) {
+			t.Errorf("DataFlowEdges() does not contain %+v", want)
+		}
+	}
+}
+
+func TestDataFlowEdges_TraversalLimit(t *testing.T) {
+	qp, err := New(sharedChildChain(40))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := qp.DataFlowEdges(); !errors.Is(err, ErrTraversalLimitExceeded) {
+		t.Errorf("DataFlowEdges() error = %v, want %v", err, ErrTraversalLimitExceeded)
+	}
+}
@@ -0,0 +1,75 @@
+package spannerplan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShowDataFlow makes [RenderDOT] also draw the [EdgeData] edges of [QueryPlan.DataFlowEdges] as
+// dashed blue edges from the operator defining a variable to the one reading it, labeled with
+// the variable, such as "$v2". They do not constrain the layout, so the operator tree keeps its
+// shape. [RenderMermaid] and [NodeTitle] ignore it.
+func ShowDataFlow() Option {
+	return func(o *option) {
+		o.dataFlow = true
+	}
+}
+
+// RenderDOT returns the visible operator tree of qp as a Graphviz digraph. Each operator is a
+// node whose ID is its PlanNode index and whose label is its [NodeTitle] with opts, so the output
+// of a plan is stable and diffs well. Each visible child link is an edge from the parent to the
+// child, labeled with its [QueryPlan.LinkTypeInParent] in brackets, such as "[Input]", when it
+// has one. A node reachable through several parents is drawn once with an edge from each.
+// [ShowScalarLinks] and [ShowDataFlow] add dashed edges.
+//
+// RenderDOT returns an error when the visible child links form a cycle.
+func RenderDOT(qp *QueryPlan, opts ...Option) (string, error) {
+	nodes, edges, err := planGraph(qp, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph plan {\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, node := range nodes {
+		if node.scalar {
+			fmt.Fprintf(&sb, "  %d [label=%s, shape=ellipse];\n", node.index, dotQuote(node.label))
+		} else {
+			fmt.Fprintf(&sb, "  %d [label=%s];\n", node.index, dotQuote(node.label))
+		}
+	}
+	for _, edge := range edges {
+		var attrs []string
+		if edge.linkType != "" {
+			attrs = append(attrs, "label="+dotQuote("["+edge.linkType+"]"))
+		}
+		if edge.scalar {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(&sb, "  %d -> %d;\n", edge.from, edge.to)
+		} else {
+			fmt.Fprintf(&sb, "  %d -> %d [%s];\n", edge.from, edge.to, strings.Join(attrs, ", "))
+		}
+	}
+	if newOption(opts).dataFlow {
+		dataFlow, err := qp.DataFlowEdges()
+		if err != nil {
+			return "", err
+		}
+		for _, edge := range dataFlow {
+			if edge.Kind != EdgeData {
+				continue
+			}
+			fmt.Fprintf(&sb, "  %d -> %d [label=%s, style=dashed, color=blue, fontcolor=blue, constraint=false];\n", edge.From, edge.To, dotQuote("$"+edge.Variable))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// dotQuote returns s as a DOT double-quoted string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,176 @@
+package spannerplan
+
+import (
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestRenderDOT(t *testing.T) {
+	qp, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Cross Apply", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 1},
+			{ChildIndex: 2, Type: "Map"},
+		}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan", Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"scan_target": structpb.NewStringValue(`Singers "S"`),
+			"scan_type":   structpb.NewStringValue("TableScan"),
+		}}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 3},
+			{ChildIndex: 4, Type: "Condition"},
+		}},
+		{Index: 3, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Unit Relation"},
+		{Index: 4, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{Description: "($x > 1)"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "operators",
+			opts: []Option{WithTargetMetadataFormat(TargetMetadataFormatOn)},
+			want: `digraph plan {
+  node [shape=box];
+  0 [label="Cross Apply"];
+  1 [label="Table Scan on Singers \"S\""];
+  2 [label="Filter"];
+  3 [label="Unit Relation"];
+  0 -> 1 [label="[Input]"];
+  0 -> 2 [label="[Map]"];
+  2 -> 3;
+}
+`,
+		},
+		{
+			name: "scalar links",
+			opts: []Option{ShowScalarLinks()},
+			want: `digraph plan {
+  node [shape=box];
+  0 [label="Cross Apply"];
+  1 [label="Table Scan (Table: Singers \"S\")"];
+  2 [label="Filter"];
+  3 [label="Unit Relation"];
+  4 [label="($x > 1)", shape=ellipse];
+  0 -> 1 [label="[Input]"];
+  0 -> 2 [label="[Map]"];
+  2 -> 3;
+  2 -> 4 [label="[Condition]", style=dashed];
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderDOT(qp, tt.opts...)
+			if err != nil {
+				t.Fatalf("RenderDOT() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("RenderDOT() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderDOT_Plan(t *testing.T) {
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(dca.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got, err := RenderDOT(qp)
+	if err != nil {
+		t.Fatalf("RenderDOT() error = %v", err)
+	}
+	for _, want := range []string{
+		"  1 -> 2 [label=\"[Input]\"];\n",
+		"  1 -> 22 [label=\"[Map]\"];\n",
+		"  31 [label=\"Table Scan (Table: Albums, execution_method: Row, scan_method: Row)\"];\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderDOT() does not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "dashed") {
+		t.Errorf("RenderDOT() without ShowScalarLinks has dashed edges:\n%s", got)
+	}
+}
+
+func TestRenderDOT_DataFlow(t *testing.T) {
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(dca.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	plain, err := RenderDOT(qp)
+	if err != nil {
+		t.Fatalf("RenderDOT() error = %v", err)
+	}
+	got, err := RenderDOT(qp, ShowDataFlow())
+	if err != nil {
+		t.Fatalf("RenderDOT(ShowDataFlow()) error = %v", err)
+	}
+	if !strings.HasPrefix(got, strings.TrimSuffix(plain, "}\n")) {
+		t.Errorf("RenderDOT(ShowDataFlow()) does not start with the operator tree:\n%s", got)
+	}
+	for _, want := range []string{
+		"  2 -> 25 [label=\"$v2\", style=dashed, color=blue, fontcolor=blue, constraint=false];\n",
+		"  25 -> 31 [label=\"$batched_AlbumId\", style=dashed, color=blue, fontcolor=blue, constraint=false];\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderDOT(ShowDataFlow()) does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderDOT_Cycle(t *testing.T) {
+	qp, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Union", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 0}}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := RenderDOT(qp); err == nil || !strings.Contains(err.Error(), "cycle at node 0") {
+		t.Errorf("RenderDOT() error = %v, want a cycle error", err)
+	}
+}
+
+func TestRenderDOT_SharedChild(t *testing.T) {
+	qp, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Union All", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}, {ChildIndex: 2}}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 3}}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 3}}},
+		{Index: 3, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got, err := RenderDOT(qp)
+	if err != nil {
+		t.Fatalf("RenderDOT() error = %v", err)
+	}
+	if n := strings.Count(got, "  3 [label="); n != 1 {
+		t.Errorf("RenderDOT() draws node 3 %d times, want once:\n%s", n, got)
+	}
+	for _, want := range []string{"  1 -> 3;\n", "  2 -> 3;\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderDOT() does not contain %q:\n%s", want, got)
+		}
+	}
+}
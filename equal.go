@@ -0,0 +1,228 @@
+package spannerplan
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Equal reports whether a and b describe the same plan, including execution statistics.
+//
+// Plans are compared by a canonical traversal from the root node rather than by PlanNodes
+// position, so plans whose nodes are numbered differently compare equal when every node
+// reached through child links matches. For each pair of nodes, the traversal compares the
+// kind, display name, short representation, metadata, and execution statistics, and then
+// the child links in order, including their type and variable. Each short representation
+// subquery must refer to the node paired with the one its counterpart refers to. Child link order is
+// significant because it carries meaning, such as the Input and Map sides of an apply, so
+// plans that differ only in child link order are not equal.
+//
+// Node indexes are never compared, and neither are subquery_cluster_node metadata keys at
+// any depth, because their values are node indexes. Nodes unreachable from the root are
+// ignored. Two nil plans are equal; a nil plan is not equal to a non-nil one.
+func Equal(a, b *QueryPlan) bool {
+	return plansEqual(a, b, true)
+}
+
+// StructurallyEqual is like [Equal] but ignores execution statistics, so two PROFILE captures
+// of the same plan, or a PLAN and a PROFILE capture of it, compare equal.
+func StructurallyEqual(a, b *QueryPlan) bool {
+	return plansEqual(a, b, false)
+}
+
+// AttachStats overlays the execution statistics of statsNodes, a capture of the same plan such as a
+// PROFILE run of a query whose structure came from a PLAN capture, onto the nodes of qp.
+//
+// statsNodes must be a valid plan (see [New]) that is [StructurallyEqual] to qp. Nodes are matched
+// by the canonical traversal that comparison uses, which pairs nodes by index whenever both plans
+// number them the same way, as captures of one query do. On a match, each reachable node of qp
+// gets a copy of the statistics of its counterpart, replacing any it had, including with none when
+// the counterpart has none. Otherwise AttachStats returns an error naming the first pair of nodes
+// that differ and leaves qp unchanged.
+//
+// The PlanNodes of qp are modified in place.
+func (qp *QueryPlan) AttachStats(statsNodes []*sppb.PlanNode) error {
+	statsPlan, err := New(statsNodes)
+	if err != nil {
+		return fmt.Errorf("invalid stats plan: %w", err)
+	}
+
+	matched := make(map[*sppb.PlanNode]*sppb.PlanNode)
+	if d := firstDivergence(qp, statsPlan, false, func(x, y *sppb.PlanNode) {
+		if _, ok := matched[x]; !ok {
+			matched[x] = y
+		}
+	}); d != nil {
+		return fmt.Errorf("stats plan does not match the plan: %s", d)
+	}
+	for node, statsNode := range matched {
+		if statsNode.GetExecutionStats() == nil {
+			node.ExecutionStats = nil
+			continue
+		}
+		node.ExecutionStats = proto.Clone(statsNode.GetExecutionStats()).(*structpb.Struct)
+	}
+	return nil
+}
+
+func plansEqual(a, b *QueryPlan, withStats bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return firstDivergence(a, b, withStats, nil) == nil
+}
+
+// planDivergence is the first pair of nodes at which two plans differ.
+type planDivergence struct {
+	a, b   *sppb.PlanNode
+	reason string
+}
+
+func (d *planDivergence) String() string {
+	return fmt.Sprintf("node %d (%s) and node %d (%s) differ: %s",
+		d.a.GetIndex(), d.a.GetDisplayName(), d.b.GetIndex(), d.b.GetDisplayName(), d.reason)
+}
+
+// firstDivergence walks a and b in the canonical order described in [Equal] and returns the first
+// pair of nodes that differ, or nil when the plans are equal. visit, when non-nil, is called for
+// every matched pair before its child links are compared.
+func firstDivergence(a, b *QueryPlan, withStats bool, visit func(x, y *sppb.PlanNode)) *planDivergence {
+	// Pairs already compared, or being compared further up the traversal, count as equal.
+	// A mismatch anywhere ends the traversal, so memoizing only successes is enough, and it
+	// keeps shared subtrees and cycles from being walked more than once.
+	type nodePair struct{ a, b int32 }
+	compared := make(map[nodePair]struct{})
+	// paired maps each node of a to the node of b it was first paired with.
+	paired := make(map[int32]int32)
+	var walk func(x, y *sppb.PlanNode) *planDivergence
+	walk = func(x, y *sppb.PlanNode) *planDivergence {
+		pair := nodePair{x.GetIndex(), y.GetIndex()}
+		if _, ok := compared[pair]; ok {
+			return nil
+		}
+		compared[pair] = struct{}{}
+		if _, ok := paired[x.GetIndex()]; !ok {
+			paired[x.GetIndex()] = y.GetIndex()
+		}
+
+		if reason := nodeDifference(x, y, withStats); reason != "" {
+			return &planDivergence{a: x, b: y, reason: reason}
+		}
+		if visit != nil {
+			visit(x, y)
+		}
+		xLinks, yLinks := x.GetChildLinks(), y.GetChildLinks()
+		if len(xLinks) != len(yLinks) {
+			return &planDivergence{a: x, b: y, reason: fmt.Sprintf("%d child links != %d", len(xLinks), len(yLinks))}
+		}
+		for i := range xLinks {
+			if xLinks[i].GetType() != yLinks[i].GetType() {
+				return &planDivergence{a: x, b: y, reason: fmt.Sprintf("child link %d type %q != %q", i, xLinks[i].GetType(), yLinks[i].GetType())}
+			}
+			if xLinks[i].GetVariable() != yLinks[i].GetVariable() {
+				return &planDivergence{a: x, b: y, reason: fmt.Sprintf("child link %d variable %q != %q", i, xLinks[i].GetVariable(), yLinks[i].GetVariable())}
+			}
+			if d := walk(a.GetNodeByChildLink(xLinks[i]), b.GetNodeByChildLink(yLinks[i])); d != nil {
+				return d
+			}
+		}
+		// Subqueries refer to nodes by index, so each name must refer to the node paired with
+		// its counterpart, which is usually a scalar child compared above.
+		xSubqueries, ySubqueries := a.Subqueries(x), b.Subqueries(y)
+		for _, name := range slices.Sorted(maps.Keys(xSubqueries)) {
+			yIndex, ok := ySubqueries[name]
+			if !ok {
+				return &planDivergence{a: x, b: y, reason: fmt.Sprintf("subquery %s is not a node of both plans", name)}
+			}
+			xIndex := xSubqueries[name]
+			if d := walk(a.GetNodeByIndex(xIndex), b.GetNodeByIndex(yIndex)); d != nil {
+				return d
+			}
+			if paired[xIndex] != yIndex {
+				return &planDivergence{a: x, b: y, reason: fmt.Sprintf("subquery %s refers to node %d and node %d, which are not paired", name, xIndex, yIndex)}
+			}
+		}
+		return nil
+	}
+	return walk(a.GetNodeByChildLink(nil), b.GetNodeByChildLink(nil))
+}
+
+// nodeDifference describes the first field of x and y that differs, without comparing child links
+// or anything that depends on node indexes: of the short representation subqueries, only the
+// names are compared, since they map to node indexes. It returns "" when the nodes match.
+func nodeDifference(x, y *sppb.PlanNode, withStats bool) string {
+	switch {
+	case x.GetKind() != y.GetKind():
+		return fmt.Sprintf("kind %s != %s", x.GetKind(), y.GetKind())
+	case x.GetDisplayName() != y.GetDisplayName():
+		return fmt.Sprintf("display name %q != %q", x.GetDisplayName(), y.GetDisplayName())
+	case x.GetShortRepresentation().GetDescription() != y.GetShortRepresentation().GetDescription():
+		return fmt.Sprintf("short representation %q != %q", x.GetShortRepresentation().GetDescription(), y.GetShortRepresentation().GetDescription())
+	case !subqueryNamesEqual(x.GetShortRepresentation().GetSubqueries(), y.GetShortRepresentation().GetSubqueries()):
+		return "short representation subqueries differ"
+	case !metadataStructsEqual(x.GetMetadata(), y.GetMetadata()):
+		return "metadata differs"
+	case withStats && !proto.Equal(x.GetExecutionStats(), y.GetExecutionStats()):
+		return "execution stats differ"
+	default:
+		return ""
+	}
+}
+
+// subqueryNamesEqual reports whether x and y, short representation subqueries, have the same names.
+func subqueryNamesEqual(x, y map[string]int32) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for name := range x {
+		if _, ok := y[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// metadataStructsEqual compares metadata structs, skipping subquery_cluster_node keys at any depth.
+func metadataStructsEqual(x, y *structpb.Struct) bool {
+	xFields, yFields := x.GetFields(), y.GetFields()
+	for key, xValue := range xFields {
+		if key == "subquery_cluster_node" {
+			continue
+		}
+		yValue, ok := yFields[key]
+		if !ok || !metadataValuesEqual(xValue, yValue) {
+			return false
+		}
+	}
+	for key := range yFields {
+		if _, ok := xFields[key]; !ok && key != "subquery_cluster_node" {
+			return false
+		}
+	}
+	return true
+}
+
+func metadataValuesEqual(x, y *structpb.Value) bool {
+	switch xKind := x.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		yKind, ok := y.GetKind().(*structpb.Value_StructValue)
+		return ok && metadataStructsEqual(xKind.StructValue, yKind.StructValue)
+	case *structpb.Value_ListValue:
+		yKind, ok := y.GetKind().(*structpb.Value_ListValue)
+		if !ok || len(xKind.ListValue.GetValues()) != len(yKind.ListValue.GetValues()) {
+			return false
+		}
+		for i, xElem := range xKind.ListValue.GetValues() {
+			if !metadataValuesEqual(xElem, yKind.ListValue.GetValues()[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return proto.Equal(x, y)
+	}
+}
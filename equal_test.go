@@ -0,0 +1,251 @@
+package spannerplan
+
+import (
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// withoutStats returns copies of planNodes without execution stats.
+func withoutStats(planNodes []*sppb.PlanNode) []*sppb.PlanNode {
+	var result []*sppb.PlanNode
+	for _, node := range planNodes {
+		node = proto.Clone(node).(*sppb.PlanNode)
+		node.ExecutionStats = nil
+		result = append(result, node)
+	}
+	return result
+}
+
+func TestEqual(t *testing.T) {
+	profile, _, err := ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	withMetadata := func(node *sppb.PlanNode, key, value string) *sppb.PlanNode {
+		node.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{key: structpb.NewStringValue(value)}}
+		return node
+	}
+	withLinkType := func(node *sppb.PlanNode, linkType string) *sppb.PlanNode {
+		node.ChildLinks[0].Type = linkType
+		return node
+	}
+	// condition is a Filter over Singers whose condition refers to scalar subqueries over Albums
+	// and Songs, in that child order. The Albums subquery is node albums, which is 3 or 4, and the
+	// Songs subquery is the other.
+	condition := func(albums int32, subqueries map[string]int32) []*sppb.PlanNode {
+		songs := 7 - albums
+		nodes := []*sppb.PlanNode{
+			relational(0, "Filter", 1, 2),
+			withMetadata(relational(1, "Scan"), "table", "Singers"),
+			{Index: 2, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{
+				Description: "($sq_1 > $sq_2)",
+				Subqueries:  subqueries,
+			}, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: albums}, {ChildIndex: songs}}},
+			nil,
+			nil,
+			withMetadata(relational(5, "Scan"), "table", "Albums"),
+			withMetadata(relational(6, "Scan"), "table", "Songs"),
+		}
+		nodes[0].ChildLinks[1].Type = "Condition"
+		nodes[albums] = &sppb.PlanNode{Index: albums, Kind: sppb.PlanNode_SCALAR, DisplayName: "Scalar Subquery", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 5}}}
+		nodes[songs] = &sppb.PlanNode{Index: songs, Kind: sppb.PlanNode_SCALAR, DisplayName: "Scalar Subquery", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 6}}}
+		return nodes
+	}
+
+	tests := []struct {
+		name                  string
+		a, b                  []*sppb.PlanNode
+		wantEqual             bool
+		wantStructurallyEqual bool
+	}{
+		{
+			name:                  "same plan",
+			a:                     profile.GetQueryPlan().GetPlanNodes(),
+			b:                     withoutStats(profile.GetQueryPlan().GetPlanNodes()),
+			wantStructurallyEqual: true,
+		},
+		{
+			name:                  "identical",
+			a:                     profile.GetQueryPlan().GetPlanNodes(),
+			b:                     profile.GetQueryPlan().GetPlanNodes(),
+			wantEqual:             true,
+			wantStructurallyEqual: true,
+		},
+		{
+			name: "renumbered nodes with the same child order",
+			a: []*sppb.PlanNode{
+				relational(0, "Hash Join", 1, 2),
+				withMetadata(relational(1, "Scan"), "table", "Singers"),
+				withMetadata(relational(2, "Scan"), "table", "Albums"),
+			},
+			b: []*sppb.PlanNode{
+				relational(0, "Hash Join", 2, 1),
+				withMetadata(relational(1, "Scan"), "table", "Albums"),
+				withMetadata(relational(2, "Scan"), "table", "Singers"),
+			},
+			wantEqual:             true,
+			wantStructurallyEqual: true,
+		},
+		{
+			name: "swapped child order",
+			a: []*sppb.PlanNode{
+				relational(0, "Hash Join", 1, 2),
+				withMetadata(relational(1, "Scan"), "table", "Singers"),
+				withMetadata(relational(2, "Scan"), "table", "Albums"),
+			},
+			b: []*sppb.PlanNode{
+				relational(0, "Hash Join", 2, 1),
+				withMetadata(relational(1, "Scan"), "table", "Singers"),
+				withMetadata(relational(2, "Scan"), "table", "Albums"),
+			},
+		},
+		{
+			name: "different link type",
+			a:    []*sppb.PlanNode{withLinkType(relational(0, "Cross Apply", 1), "Input"), relational(1, "Scan")},
+			b:    []*sppb.PlanNode{withLinkType(relational(0, "Cross Apply", 1), "Map"), relational(1, "Scan")},
+		},
+		{
+			name: "different metadata",
+			a:    []*sppb.PlanNode{withMetadata(relational(0, "Scan"), "table", "Singers")},
+			b:    []*sppb.PlanNode{withMetadata(relational(0, "Scan"), "table", "Albums")},
+		},
+		{
+			name:                  "subquery_cluster_node is ignored",
+			a:                     []*sppb.PlanNode{withMetadata(relational(0, "Distributed Union"), "subquery_cluster_node", "1")},
+			b:                     []*sppb.PlanNode{withMetadata(relational(0, "Distributed Union"), "subquery_cluster_node", "7")},
+			wantEqual:             true,
+			wantStructurallyEqual: true,
+		},
+		{
+			name:                  "shared child expands like separate children",
+			a:                     []*sppb.PlanNode{relational(0, "Union All", 1, 1), relational(1, "Scan")},
+			b:                     []*sppb.PlanNode{relational(0, "Union All", 1, 2), relational(1, "Scan"), relational(2, "Scan")},
+			wantEqual:             true,
+			wantStructurallyEqual: true,
+		},
+		{
+			name:                  "renumbered scalar subqueries",
+			a:                     condition(3, map[string]int32{"sq_1": 3, "sq_2": 4}),
+			b:                     condition(4, map[string]int32{"sq_1": 4, "sq_2": 3}),
+			wantEqual:             true,
+			wantStructurallyEqual: true,
+		},
+		{
+			name: "swapped scalar subqueries",
+			a:    condition(3, map[string]int32{"sq_1": 3, "sq_2": 4}),
+			b:    condition(3, map[string]int32{"sq_1": 4, "sq_2": 3}),
+		},
+		{
+			name: "different scalar subquery names",
+			a:    condition(3, map[string]int32{"sq_1": 3, "sq_2": 4}),
+			b:    condition(3, map[string]int32{"sq_1": 3, "sq_3": 4}),
+		},
+		{
+			name: "different child count",
+			a:    []*sppb.PlanNode{relational(0, "Union All", 1), relational(1, "Scan")},
+			b:    []*sppb.PlanNode{relational(0, "Union All", 1, 2), relational(1, "Scan"), relational(2, "Scan")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(tt.a)
+			if err != nil {
+				t.Fatalf("New(a) error = %v", err)
+			}
+			b, err := New(tt.b)
+			if err != nil {
+				t.Fatalf("New(b) error = %v", err)
+			}
+			if got := Equal(a, b); got != tt.wantEqual {
+				t.Errorf("Equal() = %v, want %v", got, tt.wantEqual)
+			}
+			if got := Equal(b, a); got != tt.wantEqual {
+				t.Errorf("Equal() with swapped arguments = %v, want %v", got, tt.wantEqual)
+			}
+			if got := StructurallyEqual(a, b); got != tt.wantStructurallyEqual {
+				t.Errorf("StructurallyEqual() = %v, want %v", got, tt.wantStructurallyEqual)
+			}
+		})
+	}
+
+	qp, err := New(profile.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !Equal(nil, nil) || Equal(qp, nil) || StructurallyEqual(nil, qp) {
+		t.Error("Equal and StructurallyEqual must treat only two nil plans as equal")
+	}
+}
+
+func TestAttachStats(t *testing.T) {
+	profile, _, err := ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	profileQP, err := New(profile.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	qp, err := New(withoutStats(profile.GetQueryPlan().GetPlanNodes()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if qp.HasStats() {
+		t.Fatal("HasStats() = true before AttachStats, want false")
+	}
+	if err := qp.AttachStats(profile.GetQueryPlan().GetPlanNodes()); err != nil {
+		t.Fatalf("AttachStats() error = %v", err)
+	}
+	if !Equal(qp, profileQP) {
+		t.Error("Equal(plan with attached stats, profile) = false, want true")
+	}
+	if qp.GetNodeByIndex(0).GetExecutionStats() == profileQP.GetNodeByIndex(0).GetExecutionStats() {
+		t.Error("AttachStats() shares execution stats with statsNodes, want a copy")
+	}
+
+	tests := []struct {
+		name       string
+		statsNodes []*sppb.PlanNode
+		wantErr    string
+	}{
+		{
+			name:       "different display name",
+			statsNodes: []*sppb.PlanNode{relational(0, "Union All", 1), relational(1, "Table Scan")},
+			wantErr:    `stats plan does not match the plan: node 1 (Scan) and node 1 (Table Scan) differ: display name "Scan" != "Table Scan"`,
+		},
+		{
+			name:       "different child count",
+			statsNodes: []*sppb.PlanNode{relational(0, "Union All", 1, 1), relational(1, "Scan")},
+			wantErr:    "stats plan does not match the plan: node 0 (Union All) and node 0 (Union All) differ: 1 child links != 2",
+		},
+		{
+			name:       "invalid stats plan",
+			statsNodes: []*sppb.PlanNode{relational(0, "Union All", 5)},
+			wantErr:    "invalid stats plan: spannerplan: childLink childIndex out of range",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := []*sppb.PlanNode{relational(0, "Union All", 1), relational(1, "Scan")}
+			qp, err := New(nodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for _, node := range tt.statsNodes {
+				node.ExecutionStats = &structpb.Struct{Fields: map[string]*structpb.Value{"rows": structpb.NewStringValue("1")}}
+			}
+			err = qp.AttachStats(tt.statsNodes)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("AttachStats() error = %v, want prefix %q", err, tt.wantErr)
+			}
+			if HasAnyStats(nodes) {
+				t.Error("AttachStats() modified the plan despite the error")
+			}
+		})
+	}
+}
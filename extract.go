@@ -1,20 +1,83 @@
 package spannerplan
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/goccy/go-yaml"
 
 	"github.com/apstndb/protoyaml"
 )
 
+// ExtractQueryPlan decodes a query plan from YAML or JSON input and returns it as ResultSetStats,
+// together with the row type when the input carries one. The envelope is detected from the
+// top-level keys, checked in this order:
+//
+//   - "queryPlan": a ResultSetStats, as returned by the API and by the DOWNLOAD JSON button of the
+//     query plan visualizer in the Google Cloud console.
+//   - "planNodes": a bare QueryPlan, as logged by client libraries or copied from a console export.
+//   - "stats": a ResultSet, as printed by gcloud spanner databases execute-sql and execspansql.
+//     Only this envelope returns a row type.
+//
+// Unknown keys are ignored in every envelope, so exports that add their own fields next to
+// "queryPlan" or "planNodes" are accepted.
 func ExtractQueryPlan(b []byte) (*sppb.ResultSetStats, *sppb.StructType, error) {
+	return extractQueryPlan(context.Background(), b)
+}
+
+// MaxQueryPlanBytes is the largest input [ParseQueryPlanContext] accepts. It is far above the size
+// of real plans, which rarely exceed a few megabytes even with execution statistics.
+const MaxQueryPlanBytes = 64 << 20
+
+// ErrInputTooLarge is returned, wrapped, by [ParseQueryPlanContext] for input longer than
+// [MaxQueryPlanBytes].
+var ErrInputTooLarge = errors.New("spannerplan: input too large")
+
+// ParseQueryPlanContext decodes a query plan from YAML or JSON input in any envelope accepted by
+// [ExtractQueryPlan] and constructs a [QueryPlan] from it with [New].
+//
+// It is meant for services that parse untrusted input. Input longer than [MaxQueryPlanBytes] is
+// rejected with an error wrapping [ErrInputTooLarge] before any decoding, and ctx is checked
+// between the decode stages (YAML to JSON, envelope detection, protobuf unmarshaling, and plan
+// validation), returning ctx.Err() once it is done. A stage that has started runs to completion,
+// so the size bound is what limits the time spent in any one of them.
+func ParseQueryPlanContext(ctx context.Context, b []byte) (*QueryPlan, error) {
+	if len(b) > MaxQueryPlanBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrInputTooLarge, len(b), MaxQueryPlanBytes)
+	}
+	rss, _, err := extractQueryPlan(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return New(rss.GetQueryPlan().GetPlanNodes())
+}
+
+// extractQueryPlan implements [ExtractQueryPlan], returning ctx.Err() when ctx is done before
+// any of its stages.
+func extractQueryPlan(ctx context.Context, b []byte) (*sppb.ResultSetStats, *sppb.StructType, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	j, err := protoyaml.YAMLToJSON(b)
 	if err != nil {
 		return nil, nil, err
 	}
+	return decodeQueryPlanJSON(ctx, j)
+}
 
+// decodeQueryPlanJSON decodes j, the JSON form of one input document, in the envelopes of
+// [ExtractQueryPlan].
+func decodeQueryPlanJSON(ctx context.Context, j []byte) (*sppb.ResultSetStats, *sppb.StructType, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	var topLevel struct {
 		QueryPlan json.RawMessage `json:"queryPlan"`
 		PlanNodes json.RawMessage `json:"planNodes"`
@@ -24,6 +87,10 @@ func ExtractQueryPlan(b []byte) (*sppb.ResultSetStats, *sppb.StructType, error)
 		return nil, nil, err
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
 	if len(topLevel.QueryPlan) != 0 {
 		var rss sppb.ResultSetStats
 		if err := protoyaml.UnmarshalJSON(j, &rss); err != nil {
@@ -45,3 +112,100 @@ func ExtractQueryPlan(b []byte) (*sppb.ResultSetStats, *sppb.StructType, error)
 	}
 	return nil, nil, errors.New("unknown input format")
 }
+
+// ExtractQueryPlans decodes a YAML stream of query plans, such as captures concatenated as
+// documents separated by "---", and returns one ResultSetStats per document in stream order.
+// Each document may use any envelope accepted by [ExtractQueryPlan], and empty documents,
+// including those with only comments or null, are skipped, so a single document, including JSON
+// input, returns a slice of one with the same errors as ExtractQueryPlan. An error in a later
+// document names it, counted from 1 without the skipped documents, such as "document 2: unknown
+// input format", and a stream without documents is an error.
+func ExtractQueryPlans(b []byte) ([]*sppb.ResultSetStats, error) {
+	var plans []*sppb.ResultSetStats
+	for _, document := range splitYAMLDocuments(b) {
+		var doc any
+		err := yaml.Unmarshal(document, &doc)
+		if err == nil && doc == nil {
+			continue
+		}
+		var rss *sppb.ResultSetStats
+		if err == nil {
+			rss, err = decodeQueryPlanDocument(doc)
+		}
+		if err != nil {
+			if len(plans) == 0 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("document %d: %w", len(plans)+1, err)
+		}
+		plans = append(plans, rss)
+	}
+	if len(plans) == 0 {
+		return nil, errors.New("no query plan in input")
+	}
+	return plans, nil
+}
+
+// splitYAMLDocuments splits a YAML stream at its document markers, the lines starting with "---"
+// or "..." followed by a space, a tab or the end of the line. YAML forbids such lines inside a
+// document, even in block and quoted scalars, so no parsing is needed. Text after the marker on
+// the same line, such as a tag, begins the next document. The stream is split here rather than by
+// yaml.Decoder, which reports io.EOF at an empty document and so hides every document after it.
+func splitYAMLDocuments(b []byte) [][]byte {
+	var docs [][]byte
+	start := 0
+	for offset := 0; offset < len(b); {
+		next := len(b)
+		if i := bytes.IndexByte(b[offset:], '\n'); i >= 0 {
+			next = offset + i + 1
+		}
+		if isYAMLDocumentMarker(b[offset:next]) {
+			docs = append(docs, b[start:offset])
+			start = offset + len("---")
+		}
+		offset = next
+	}
+	return append(docs, b[start:])
+}
+
+// isYAMLDocumentMarker reports whether line starts with a "---" or "..." document marker.
+func isYAMLDocumentMarker(line []byte) bool {
+	if !bytes.HasPrefix(line, []byte("---")) && !bytes.HasPrefix(line, []byte("...")) {
+		return false
+	}
+	if len(line) == 3 {
+		return true
+	}
+	switch line[3] {
+	case ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+// decodeQueryPlanDocument decodes one document of a stream, as decoded from YAML.
+func decodeQueryPlanDocument(doc any) (*sppb.ResultSetStats, error) {
+	j, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	rss, _, err := decodeQueryPlanJSON(context.Background(), j)
+	return rss, err
+}
+
+// QueryStats returns the query-level statistics of rss keyed by name, such as "elapsed_time",
+// "cpu_time", "rows_returned" and "optimizer_version". Spanner reports them as strings, such as
+// "7.54 msecs", and other values are formatted as [NodeMetadataSorted] formats metadata. It
+// returns nil when rss has no query stats, as for PLAN captures and the bare "planNodes"
+// envelope of [ExtractQueryPlan].
+func QueryStats(rss *sppb.ResultSetStats) map[string]string {
+	fields := rss.GetQueryStats().GetFields()
+	if len(fields) == 0 {
+		return nil
+	}
+	queryStats := make(map[string]string, len(fields))
+	for name, v := range fields {
+		queryStats[name] = metadataValueString(v)
+	}
+	return queryStats
+}
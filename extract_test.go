@@ -1,11 +1,14 @@
 package spannerplan
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"testing"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
 	"github.com/goccy/go-yaml"
+	"github.com/google/go-cmp/cmp"
 
 	"github.com/apstndb/protoyaml"
 )
@@ -87,6 +90,55 @@ stats:
 			wantNodeLen: 1,
 			wantRowType: true,
 		},
+		{
+			name: "console export with ResultSetStats envelope",
+			input: []byte(`{
+  "queryPlan": {
+    "planNodes": [
+      {"index": 0, "kind": "RELATIONAL", "displayName": "Serialize Result", "childLinks": [{"childIndex": 1}]},
+      {"index": 1, "kind": "RELATIONAL", "displayName": "Scan", "executionStats": {"rows": {"total": "3", "unit": "rows"}}}
+    ]
+  },
+  "queryStats": {"elapsed_time": "1.2 msecs", "rows_returned": "3"},
+  "exportedAt": "2024-01-01T00:00:00Z"
+}`),
+			wantNodeLen: 2,
+		},
+		{
+			name: "console export with bare planNodes envelope",
+			input: []byte(`{
+  "planNodes": [
+    {"index": 0, "kind": "RELATIONAL", "displayName": "Serialize Result", "childLinks": [{"childIndex": 1}]},
+    {"index": 1, "kind": "RELATIONAL", "displayName": "Scan"}
+  ],
+  "queryText": "SELECT 1"
+}`),
+			wantNodeLen: 2,
+		},
+		{
+			name: "queryPlan takes precedence over stats",
+			input: []byte(`
+queryPlan:
+  planNodes:
+    - index: 0
+      kind: RELATIONAL
+      displayName: Root
+stats:
+  queryPlan:
+    planNodes:
+      - index: 0
+      - index: 1
+`),
+			wantNodeLen: 1,
+		},
+		{
+			name: "malformed queryPlan",
+			input: []byte(`
+queryPlan:
+  planNodes: {index: 0}
+`),
+			wantErr: true,
+		},
 		{
 			name: "unknown format",
 			input: []byte(`
@@ -121,6 +173,224 @@ foo: bar
 	}
 }
 
+func TestParseQueryPlanContext(t *testing.T) {
+	input := []byte(`
+queryPlan:
+  planNodes:
+    - index: 0
+      kind: RELATIONAL
+      displayName: Root
+`)
+
+	qp, err := ParseQueryPlanContext(context.Background(), input)
+	if err != nil {
+		t.Fatalf("ParseQueryPlanContext() error = %v", err)
+	}
+	if got := qp.GetNodeByIndex(0).GetDisplayName(); got != "Root" {
+		t.Errorf("root display name = %q, want %q", got, "Root")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseQueryPlanContext(ctx, input); !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseQueryPlanContext(canceled) error = %v, want %v", err, context.Canceled)
+	}
+
+	tooLarge := append(bytes.Repeat([]byte(" "), MaxQueryPlanBytes), input...)
+	if _, err := ParseQueryPlanContext(context.Background(), tooLarge); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("ParseQueryPlanContext(too large) error = %v, want %v", err, ErrInputTooLarge)
+	}
+
+	if _, err := ParseQueryPlanContext(context.Background(), []byte("queryPlan: {}")); !errors.Is(err, ErrInvalidPlan) {
+		t.Errorf("ParseQueryPlanContext(empty plan) error = %v, want %v", err, ErrInvalidPlan)
+	}
+}
+
+func TestExtractQueryPlans(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantNodeLens []int
+		wantErr      string
+	}{
+		{
+			name: "single document",
+			input: `
+queryPlan:
+  planNodes:
+    - {index: 0, kind: RELATIONAL, displayName: Root}
+`,
+			wantNodeLens: []int{1},
+		},
+		{
+			name:         "single JSON document",
+			input:        `{"planNodes": [{"index": 0, "kind": "RELATIONAL", "displayName": "Root"}]}`,
+			wantNodeLens: []int{1},
+		},
+		{
+			name: "stream with mixed envelopes",
+			input: `---
+queryPlan:
+  planNodes:
+    - {index: 0, kind: RELATIONAL, displayName: Root}
+---
+planNodes:
+  - {index: 0, kind: RELATIONAL, displayName: Root, childLinks: [{childIndex: 1}]}
+  - {index: 1, kind: RELATIONAL, displayName: Scan}
+---
+`,
+			wantNodeLens: []int{1, 2},
+		},
+		{
+			name: "invalid second document",
+			input: `
+planNodes:
+  - {index: 0, kind: RELATIONAL, displayName: Root}
+---
+unknown: true
+`,
+			wantErr: "document 2: unknown input format",
+		},
+		{
+			name: "empty document between plans",
+			input: `planNodes:
+  - {index: 0, kind: RELATIONAL, displayName: Root}
+---
+---
+planNodes:
+  - {index: 0, kind: RELATIONAL, displayName: Root, childLinks: [{childIndex: 1}]}
+  - {index: 1, kind: RELATIONAL, displayName: Scan}
+`,
+			wantNodeLens: []int{1, 2},
+		},
+		{
+			name: "comment-only document between plans",
+			input: `planNodes:
+  - {index: 0, kind: RELATIONAL, displayName: Root}
+---
+# note
+---
+planNodes:
+  - {index: 0, kind: RELATIONAL, displayName: Root, childLinks: [{childIndex: 1}]}
+  - {index: 1, kind: RELATIONAL, displayName: Scan}
+`,
+			wantNodeLens: []int{1, 2},
+		},
+		{
+			name: "document end marker and block scalar",
+			input: `planNodes:
+  - index: 0
+    kind: RELATIONAL
+    displayName: Root
+    shortRepresentation:
+      description: |
+        --- not a marker when indented
+...
+--- # comment after the marker
+planNodes:
+  - {index: 0, kind: RELATIONAL, displayName: Root}
+`,
+			wantNodeLens: []int{1, 1},
+		},
+		{
+			name:    "invalid document after an empty one",
+			input:   "planNodes:\n  - {index: 0, kind: RELATIONAL, displayName: Root}\n---\n---\nunknown: true\n",
+			wantErr: "document 2: unknown input format",
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: "no query plan in input",
+		},
+		{
+			name:    "only empty documents",
+			input:   "---\n# note\n---\n",
+			wantErr: "no query plan in input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractQueryPlans([]byte(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ExtractQueryPlans() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractQueryPlans() error = %v", err)
+			}
+			gotNodeLens := make([]int, len(got))
+			for i, rss := range got {
+				gotNodeLens[i] = len(rss.GetQueryPlan().GetPlanNodes())
+			}
+			if diff := cmp.Diff(tt.wantNodeLens, gotNodeLens); diff != "" {
+				t.Errorf("ExtractQueryPlans() node counts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{
+			name: "profile",
+			input: `
+queryPlan:
+  planNodes:
+    - index: 0
+      kind: RELATIONAL
+      displayName: Root
+queryStats:
+  elapsed_time: 7.54 msecs
+  rows_returned: "33"
+  optimizer_version: 7
+  is_graph_query: false
+`,
+			want: map[string]string{
+				"elapsed_time":      "7.54 msecs",
+				"rows_returned":     "33",
+				"optimizer_version": "7",
+				"is_graph_query":    "false",
+			},
+		},
+		{
+			name: "plan",
+			input: `
+queryPlan:
+  planNodes:
+    - index: 0
+      kind: RELATIONAL
+      displayName: Root
+`,
+		},
+		{
+			name: "bare planNodes",
+			input: `
+planNodes:
+  - index: 0
+    kind: RELATIONAL
+    displayName: Root
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rss, _, err := ExtractQueryPlan([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("ExtractQueryPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, QueryStats(rss)); diff != "" {
+				t.Errorf("QueryStats() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func BenchmarkExtractQueryPlan(b *testing.B) {
 	inputs := []struct {
 		name  string
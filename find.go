@@ -0,0 +1,28 @@
+package spannerplan
+
+import (
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// FindNodes returns every PlanNode of the plan for which pred returns true, in ascending PlanNode
+// index order, or nil when none matches. It searches all nodes, including scalar nodes and nodes
+// hidden from the operator tree by [QueryPlan.IsVisible], so a linter can find every operator of
+// a kind, such as every node with "Full scan" metadata, without walking the tree.
+func (qp *QueryPlan) FindNodes(pred func(*sppb.PlanNode) bool) []*sppb.PlanNode {
+	var nodes []*sppb.PlanNode
+	for _, node := range qp.planNodes {
+		if pred(node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// FindByDisplayName returns the nodes of [QueryPlan.FindNodes] whose display name is name, such
+// as "Distributed Union". The comparison is exact; a table scan is a "Scan" node with scan_type
+// metadata TableScan, which [QueryPlan.ScanTargets] reports.
+func (qp *QueryPlan) FindByDisplayName(name string) []*sppb.PlanNode {
+	return qp.FindNodes(func(node *sppb.PlanNode) bool {
+		return node.GetDisplayName() == name
+	})
+}
@@ -0,0 +1,70 @@
+package spannerplan
+
+import (
+	"slices"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+func TestFindNodes(t *testing.T) {
+	stats, _, err := ExtractQueryPlan(deleteYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(stats.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	indexes := func(nodes []*sppb.PlanNode) []int32 {
+		var ids []int32
+		for _, node := range nodes {
+			ids = append(ids, node.GetIndex())
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name string
+		got  []*sppb.PlanNode
+		want []int32
+	}{
+		{
+			name: "display name with several nodes",
+			got:  qp.FindByDisplayName("Distributed Union"),
+			want: []int32{1, 2},
+		},
+		{
+			name: "hidden scalar nodes",
+			got:  qp.FindByDisplayName("Reference"),
+			want: []int32{5, 6},
+		},
+		{
+			name: "display name is exact",
+			got:  qp.FindByDisplayName("distributed union"),
+		},
+		{
+			name: "predicate on metadata",
+			got: qp.FindNodes(func(node *sppb.PlanNode) bool {
+				return node.GetMetadata().GetFields()["scan_type"].GetStringValue() == "TableScan"
+			}),
+			want: []int32{4},
+		},
+		{
+			name: "predicate on kind",
+			got: qp.FindNodes(func(node *sppb.PlanNode) bool {
+				return node.GetKind() == sppb.PlanNode_SCALAR
+			}),
+			want: []int32{5, 6, 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indexes(tt.got); !slices.Equal(got, tt.want) {
+				t.Errorf("indexes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package spannerplan
+
+import (
+	"fmt"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// ShowScalarLinks makes [RenderDOT] and [RenderMermaid] also draw the child links to the scalar
+// nodes that the operator tree hides, such as the Condition of a Filter Scan or the Split Range
+// of a Distributed Union, as dashed edges to nodes labeled with their description. [NodeTitle]
+// ignores it.
+func ShowScalarLinks() Option {
+	return func(o *option) {
+		o.scalarLinks = true
+	}
+}
+
+// graphNode is a node of the diagram drawn by [RenderDOT] and [RenderMermaid].
+type graphNode struct {
+	index  int32
+	label  string
+	scalar bool
+}
+
+// graphEdge is a child link of the diagram, with the link type from its parent or "".
+type graphEdge struct {
+	from, to int32
+	linkType string
+	scalar   bool
+}
+
+// planGraph returns the nodes and edges of the visible operator tree of qp, in pre-order of
+// the tree with every node once, and the hidden scalar links when opts select
+// [ShowScalarLinks]. It returns an error when the visible child links form a cycle.
+func planGraph(qp *QueryPlan, opts []Option) ([]graphNode, []graphEdge, error) {
+	o := newOption(opts)
+
+	var nodes []graphNode
+	var edges []graphEdge
+	drawn := make(map[int32]bool)
+	ancestors := make(map[int32]bool)
+	var walk func(node *sppb.PlanNode) error
+	walk = func(node *sppb.PlanNode) error {
+		if ancestors[node.GetIndex()] {
+			return fmt.Errorf("child links form a cycle at node %d", node.GetIndex())
+		}
+		if drawn[node.GetIndex()] {
+			return nil
+		}
+		drawn[node.GetIndex()] = true
+		nodes = append(nodes, graphNode{index: node.GetIndex(), label: NodeTitle(node, opts...)})
+
+		ancestors[node.GetIndex()] = true
+		defer delete(ancestors, node.GetIndex())
+		for i, link := range node.GetChildLinks() {
+			child := qp.GetNodeByChildLink(link)
+			edge := graphEdge{from: node.GetIndex(), to: child.GetIndex(), linkType: qp.LinkTypeInParent(node, i)}
+
+			if !qp.IsVisible(link) {
+				if !o.scalarLinks {
+					continue
+				}
+				if !drawn[child.GetIndex()] {
+					drawn[child.GetIndex()] = true
+					nodes = append(nodes, graphNode{index: child.GetIndex(), label: scalarLabel(child, opts), scalar: true})
+				}
+				edge.scalar = true
+				edges = append(edges, edge)
+				continue
+			}
+
+			edges = append(edges, edge)
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(qp.GetNodeByChildLink(nil)); err != nil {
+		return nil, nil, err
+	}
+	return nodes, edges, nil
+}
+
+// scalarLabel returns the description of a scalar node, such as "($AlbumTitle = 'Go')", or its
+// [NodeTitle] when it has none.
+func scalarLabel(node *sppb.PlanNode, opts []Option) string {
+	if description := node.GetShortRepresentation().GetDescription(); description != "" {
+		return description
+	}
+	return NodeTitle(node, opts...)
+}
@@ -63,6 +63,10 @@ type Options struct {
 
 	// ResolveScalarVarsRecursive recursively resolves scalar variable aliases in semantic appendix sections.
 	ResolveScalarVarsRecursive bool
+
+	// FormatPredicate formats one predicate line of SectionPredicates.
+	// A nil value prints RowWithPredicates.Predicates as is.
+	FormatPredicate func(row plantree.RowWithPredicates, link plantree.ScalarChildLink) (string, error)
 }
 
 // ParsePreset parses one print preset name.
@@ -222,12 +226,7 @@ func Render(rows []plantree.RowWithPredicates, opts Options) (string, error) {
 				},
 			))
 		case SectionPredicates:
-			part, err = asciitable.RenderAppendix(rows, scalarAppendixSpec(
-				"Predicates(identified by ID):",
-				func(row plantree.RowWithPredicates) []string {
-					return row.Predicates
-				},
-			))
+			part, err = renderPredicates(rows, opts.FormatPredicate)
 		case SectionOrdering:
 			format := semanticScalarLinkFormatter(opts.ShowScalarVars, keyScalarLinkDescription)
 			if resolveVars {
@@ -270,6 +269,47 @@ func Render(rows []plantree.RowWithPredicates, opts Options) (string, error) {
 	return b.String(), nil
 }
 
+func renderPredicates(
+	rows []plantree.RowWithPredicates,
+	formatPredicate func(plantree.RowWithPredicates, plantree.ScalarChildLink) (string, error),
+) (string, error) {
+	if formatPredicate == nil {
+		return asciitable.RenderAppendix(rows, scalarAppendixSpec(
+			"Predicates(identified by ID):",
+			func(row plantree.RowWithPredicates) []string {
+				return row.Predicates
+			},
+		))
+	}
+
+	// AppendixSpec.Items cannot fail, so keep the first formatting error and report it after rendering.
+	var formatErr error
+	part, err := asciitable.RenderAppendix(rows, scalarAppendixSpec(
+		"Predicates(identified by ID):",
+		func(row plantree.RowWithPredicates) []string {
+			lines := make([]string, 0, len(row.PredicateLinks))
+			for _, link := range row.PredicateLinks {
+				line, err := formatPredicate(row, link)
+				if err != nil {
+					if formatErr == nil {
+						formatErr = fmt.Errorf("failed to format predicate of node %d: %w", row.ID, err)
+					}
+					continue
+				}
+				lines = append(lines, line)
+			}
+			return lines
+		},
+	))
+	if err != nil {
+		return "", err
+	}
+	if formatErr != nil {
+		return "", formatErr
+	}
+	return part, nil
+}
+
 func resolvedSections(sections *Sections) (Sections, error) {
 	if sections == nil {
 		return Sections{SectionPredicates}, nil
@@ -289,9 +329,11 @@ func scalarAppendixSpec(
 	return asciitable.AppendixSpec[plantree.RowWithPredicates]{
 		Title: title,
 		ID: func(row plantree.RowWithPredicates) uint {
-			// Spanner PlanNode indexes are zero-based node positions, so they are
-			// non-negative when used as appendix display IDs.
-			return uint(row.ID)
+			// Spanner PlanNode indexes are zero-based node positions and display ID
+			// offsets are non-negative, so display IDs are non-negative as well.
+			// The appendix pads IDs to the widest one returned here, so footers align
+			// on the same displayed IDs as the table's ID column.
+			return uint(row.DisplayID())
 		},
 		Items: items,
 	}
@@ -209,6 +209,79 @@ Predicates(identified by ID):
 	}
 }
 
+func TestRenderDisplayIDOffset(t *testing.T) {
+	rows := scalarAppendixRows()
+	for i := range rows {
+		rows[i].DisplayIDOffset = 1
+	}
+
+	got, err := Render(rows, Options{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := heredoc.Doc(`
+Predicates(identified by ID):
+ 3: Condition: ($SingerId = $SingerId_1)
+`)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Render() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderAlignsDisplayedIDs(t *testing.T) {
+	filter := func(id, offset int32) plantree.RowWithPredicates {
+		return plantree.RowWithPredicates{
+			ID:              id,
+			DisplayIDOffset: offset,
+			DisplayName:     "Filter",
+			Predicates:      []string{"Condition: ($SingerId = 1)"},
+			ScalarChildLinks: []plantree.ScalarChildLink{
+				{Type: "Condition", Description: "($SingerId = 1)"},
+			},
+		}
+	}
+	scan := func(id, offset int32) plantree.RowWithPredicates {
+		return plantree.RowWithPredicates{ID: id, DisplayIDOffset: offset, DisplayName: "Scan"}
+	}
+
+	tests := []struct {
+		name string
+		rows []plantree.RowWithPredicates
+		want string
+	}{
+		{
+			// A subtree starting below node 9 has no single-digit IDs to pad for.
+			name: "subtree with multi-digit IDs only",
+			rows: []plantree.RowWithPredicates{filter(10, 0), scan(11, 0), filter(12, 0)},
+			want: heredoc.Doc(`
+Predicates(identified by ID):
+ 10: Condition: ($SingerId = 1)
+ 12: Condition: ($SingerId = 1)
+`),
+		},
+		{
+			// Raw IDs are single-digit, but the offset makes the last displayed ID 10.
+			name: "offset crosses a digit boundary",
+			rows: []plantree.RowWithPredicates{filter(8, 1), scan(9, 1)},
+			want: heredoc.Doc(`
+Predicates(identified by ID):
+  9: Condition: ($SingerId = 1)
+`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.rows, Options{})
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestRenderResolveScalarVars(t *testing.T) {
 	rows := scalarAppendixRows()
 	sections := Sections{SectionOrdering, SectionAggregate}
@@ -0,0 +1,48 @@
+package spannerplan
+
+import (
+	"iter"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// VisibleNodes returns an iterator over the operator tree, yielding each visible node with its
+// depth, counting the root as depth zero. Nodes are visited in pre-order following child link
+// order, with the same visibility as [QueryPlan.IsVisible], so the sequence matches the rows of
+// plantree.ProcessPlan without building them. A node reachable through several parents is
+// yielded once per occurrence.
+//
+// The traversal is the one of [QueryPlan.Walk], so it ends early where Walk returns an error: at
+// a child link back to a node on the current path, and at [MaxTraversalDepth] or
+// [MaxTraversalOccurrences]. The iterator has no way to report that; use Walk when the plan may
+// not be complete.
+func (qp *QueryPlan) VisibleNodes() iter.Seq2[int, *sppb.PlanNode] {
+	return func(yield func(int, *sppb.PlanNode) bool) {
+		_ = qp.walk(func(node *sppb.PlanNode, _ *sppb.PlanNode_ChildLink, depth int) error {
+			if !yield(depth, node) {
+				return ErrStopWalk
+			}
+			return nil
+		})
+	}
+}
+
+// NodesPerDepth returns the number of visible nodes at each depth of the operator tree, indexed
+// by depth, counting the nodes of [QueryPlan.Walk] in one traversal. The first element is 1 for
+// the root, and the length is the depth of the deepest node plus one, so a bushy plan has a short
+// slice of large counts and a deep one a long slice of small counts. It returns the error of
+// Walk for a cycle or a plan beyond the traversal limits.
+func (qp *QueryPlan) NodesPerDepth() ([]int, error) {
+	var counts []int
+	err := qp.Walk(func(_ *sppb.PlanNode, _ *sppb.PlanNode_ChildLink, depth int) error {
+		if depth == len(counts) {
+			counts = append(counts, 0)
+		}
+		counts[depth]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,148 @@
+package spannerplan
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+func TestVisibleNodes(t *testing.T) {
+	relational := func(index int32, children ...int32) *sppb.PlanNode {
+		node := &sppb.PlanNode{Index: index, Kind: sppb.PlanNode_RELATIONAL}
+		for _, child := range children {
+			node.ChildLinks = append(node.ChildLinks, &sppb.PlanNode_ChildLink{ChildIndex: child})
+		}
+		return node
+	}
+
+	tests := []struct {
+		name      string
+		nodes     []*sppb.PlanNode
+		limit     int
+		wantIDs   []int32
+		wantDepth []int
+	}{
+		{
+			name: "scalars are hidden unless linked as Scalar",
+			nodes: []*sppb.PlanNode{
+				{Index: 0, Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{
+					{ChildIndex: 1},
+					{ChildIndex: 3, Type: "Condition"},
+					{ChildIndex: 4, Type: "Scalar"},
+				}},
+				relational(1, 2),
+				relational(2),
+				{Index: 3, Kind: sppb.PlanNode_SCALAR},
+				{Index: 4, Kind: sppb.PlanNode_SCALAR},
+			},
+			wantIDs:   []int32{0, 1, 2, 4},
+			wantDepth: []int{0, 1, 2, 1},
+		},
+		{
+			name:      "shared child is yielded per occurrence",
+			nodes:     []*sppb.PlanNode{relational(0, 1, 2), relational(1, 2), relational(2)},
+			wantIDs:   []int32{0, 1, 2, 2},
+			wantDepth: []int{0, 1, 2, 1},
+		},
+		{
+			name:      "cycle ends the iteration",
+			nodes:     []*sppb.PlanNode{relational(0, 1), relational(1, 0)},
+			wantIDs:   []int32{0, 1},
+			wantDepth: []int{0, 1},
+		},
+		{
+			name:      "early break",
+			nodes:     []*sppb.PlanNode{relational(0, 1, 2), relational(1), relational(2)},
+			limit:     2,
+			wantIDs:   []int32{0, 1},
+			wantDepth: []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.nodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var ids []int32
+			var depths []int
+			for depth, node := range qp.VisibleNodes() {
+				ids = append(ids, node.GetIndex())
+				depths = append(depths, depth)
+				if len(ids) == tt.limit {
+					break
+				}
+			}
+			if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(depths, tt.wantDepth) {
+				t.Fatalf("VisibleNodes() = ids %v depths %v, want ids %v depths %v", ids, depths, tt.wantIDs, tt.wantDepth)
+			}
+		})
+	}
+}
+
+func TestVisibleNodes_SharedChildren(t *testing.T) {
+	qp, err := New(sharedChildChain(40))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	done := make(chan int)
+	go func() {
+		var n int
+		for range qp.VisibleNodes() {
+			n++
+		}
+		done <- n
+	}()
+	select {
+	case n := <-done:
+		if n != MaxTraversalOccurrences {
+			t.Errorf("VisibleNodes() yielded %d nodes, want %d", n, MaxTraversalOccurrences)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("VisibleNodes() did not stop at the occurrence limit")
+	}
+}
+
+// sharedChildChain returns a plan of n nodes in which every node links twice to the next, so its
+// visible tree has 2^n-1 node occurrences.
+func sharedChildChain(n int) []*sppb.PlanNode {
+	nodes := make([]*sppb.PlanNode, n)
+	for i := range nodes {
+		nodes[i] = &sppb.PlanNode{Index: int32(i), Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Union"}
+		if i+1 < n {
+			nodes[i].ChildLinks = []*sppb.PlanNode_ChildLink{{ChildIndex: int32(i + 1)}, {ChildIndex: int32(i + 1)}}
+		}
+	}
+	return nodes
+}
+
+func TestNodesPerDepth(t *testing.T) {
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(dca.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got, err := qp.NodesPerDepth()
+	if err != nil {
+		t.Fatalf("NodesPerDepth() error = %v", err)
+	}
+	if want := []int{1, 1, 2, 2, 3, 3, 2}; !slices.Equal(got, want) {
+		t.Errorf("NodesPerDepth() = %v, want %v", got, want)
+	}
+
+	shared, err := New(sharedChildChain(40))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := shared.NodesPerDepth(); !errors.Is(err, ErrTraversalLimitExceeded) {
+		t.Errorf("NodesPerDepth() error = %v, want %v", err, ErrTraversalLimitExceeded)
+	}
+}
@@ -0,0 +1,73 @@
+package spannerplan
+
+import (
+	"strconv"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// LimitValue is the row count of a limit or an offset: a literal, or a query parameter that
+// sets it at execution time.
+type LimitValue struct {
+	// Literal is the row count when Param is empty.
+	Literal int64
+	// Param is the query parameter, such as "@page_size", when the count is parameterized.
+	Param string
+}
+
+// String returns Param when the count is parameterized, and Literal otherwise.
+func (v LimitValue) String() string {
+	if v.Param != "" {
+		return v.Param
+	}
+	return strconv.FormatInt(v.Literal, 10)
+}
+
+// LimitInfo returns the limit and the offset of node, such as a Limit or Sort Limit operator.
+// They are read from the scalar child links of type "Limit" and "Offset", whose description is
+// a literal or a query parameter, and otherwise from the limit and offset metadata keys. Each
+// boolean reports whether the value was found, so hasLimit is false for an operator without a
+// limit, and for a limit given by an expression that is neither a literal nor a parameter.
+func (qp *QueryPlan) LimitInfo(node *sppb.PlanNode) (limit LimitValue, hasLimit bool, offset LimitValue, hasOffset bool) {
+	limit, hasLimit = qp.limitValue(node, "Limit", "limit")
+	offset, hasOffset = qp.limitValue(node, "Offset", "offset")
+	return limit, hasLimit, offset, hasOffset
+}
+
+// limitValue returns the value of the child link of node with linkType, or else of the
+// metadata key.
+func (qp *QueryPlan) limitValue(node *sppb.PlanNode, linkType, metadataKey string) (LimitValue, bool) {
+	for _, link := range node.GetChildLinks() {
+		if link.GetType() == linkType {
+			return parseLimitValue(qp.GetNodeByChildLink(link).GetShortRepresentation().GetDescription())
+		}
+	}
+
+	v, ok := node.GetMetadata().GetFields()[metadataKey]
+	if !ok {
+		return LimitValue{}, false
+	}
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return parseLimitValue(kind.StringValue)
+	case *structpb.Value_NumberValue:
+		return LimitValue{Literal: int64(kind.NumberValue)}, true
+	default:
+		return LimitValue{}, false
+	}
+}
+
+// parseLimitValue parses s as a literal row count or a query parameter reference.
+func parseLimitValue(s string) (LimitValue, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "@") || strings.HasPrefix(s, "$") {
+		return LimitValue{Param: s}, len(s) > 1
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return LimitValue{}, false
+	}
+	return LimitValue{Literal: n}, true
+}
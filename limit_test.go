@@ -0,0 +1,66 @@
+package spannerplan
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestLimitInfo(t *testing.T) {
+	scalar := func(index int32, description string) *sppb.PlanNode {
+		return &sppb.PlanNode{Index: index, Kind: sppb.PlanNode_SCALAR, DisplayName: "Constant", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{Description: description}}
+	}
+	qp, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Global Limit", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 1},
+			{ChildIndex: 5, Type: "Limit"},
+			{ChildIndex: 6, Type: "Offset"},
+		}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Sort Limit", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 2},
+			{ChildIndex: 7, Type: "Limit"},
+		}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Limit", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 3}}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"limit":  structpb.NewNumberValue(100),
+			"offset": structpb.NewStringValue("20"),
+		}}},
+		{Index: 3, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Limit", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 4},
+			{ChildIndex: 8, Type: "Limit"},
+		}},
+		{Index: 4, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Table Scan"},
+		scalar(5, "10"),
+		scalar(6, "@page_offset"),
+		scalar(7, "$limit"),
+		scalar(8, "($n + 1)"),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		index      int32
+		wantLimit  string
+		wantOffset string
+	}{
+		{index: 0, wantLimit: "10", wantOffset: "@page_offset"},
+		{index: 1, wantLimit: "$limit"},
+		{index: 2, wantLimit: "100", wantOffset: "20"},
+		{index: 3},
+		{index: 4},
+	}
+	for _, tt := range tests {
+		limit, hasLimit, offset, hasOffset := qp.LimitInfo(qp.GetNodeByIndex(tt.index))
+		if hasLimit != (tt.wantLimit != "") || hasLimit && limit.String() != tt.wantLimit {
+			t.Errorf("LimitInfo(%d) limit = %v, %v, want %q", tt.index, limit, hasLimit, tt.wantLimit)
+		}
+		if hasOffset != (tt.wantOffset != "") || hasOffset && offset.String() != tt.wantOffset {
+			t.Errorf("LimitInfo(%d) offset = %v, %v, want %q", tt.index, offset, hasOffset, tt.wantOffset)
+		}
+	}
+
+	if limit, _, _, _ := qp.LimitInfo(qp.GetNodeByIndex(0)); limit.Literal != 10 || limit.Param != "" {
+		t.Errorf("LimitInfo(0) limit = %+v, want the literal 10", limit)
+	}
+}
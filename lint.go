@@ -0,0 +1,260 @@
+package spannerplan
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan/stats"
+)
+
+// Severity is how serious an [Advisory] is.
+type Severity int
+
+const (
+	// SeverityInfo is a hint that may be harmless, such as an Apply that is not batched.
+	SeverityInfo Severity = iota
+	// SeverityWarning is an anti-pattern that is usually worth fixing, such as a full scan.
+	SeverityWarning
+	// SeverityError is reserved for rules that find a plan certain to perform badly.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Advisory is one plan anti-pattern reported by [Lint].
+type Advisory struct {
+	// NodeID is the PlanNode index of the operator the advisory is about.
+	NodeID   int32
+	Severity Severity
+	// Rule is the name of the rule that reported the advisory, such as "full-scan".
+	Rule string
+	// Message describes the anti-pattern for a human, such as "full scan of Albums".
+	Message string
+}
+
+// String returns the advisory as one line, such as
+// "warning: node 5: full-scan: full scan of AlbumsByAlbumTitle".
+func (a Advisory) String() string {
+	return fmt.Sprintf("%s: node %d: %s: %s", a.Severity, a.NodeID, a.Rule, a.Message)
+}
+
+// LintRule reports the advisories of one anti-pattern in qp. A rule that needs execution
+// statistics skips nodes without them, so it reports nothing for PLAN-only output.
+type LintRule func(qp *QueryPlan) []Advisory
+
+// DefaultLintRules are the rules [Lint] runs when it is given none:
+//
+//   - unbatched-apply (info): an Apply operator listed by [QueryPlan.UnbatchedApplies].
+//   - full-scan (warning): a scan with the "Full scan" metadata set to "true".
+//   - unseekable-filter-scan (info): a Filter Scan whose seekable_key_size metadata is 0, so
+//     none of its conditions narrows the rows it reads.
+//   - filter-discard (warning): a Filter or Filter Scan discarding most of at least 1000 input
+//     rows, from PROFILE statistics.
+//   - scan-ratio (warning): a scan returning a small fraction of at least 1000 scanned rows,
+//     from PROFILE statistics.
+//   - large-sort (warning): a sort of at least 10000 rows, from PROFILE statistics.
+//
+// Add a rule by passing a copy with the rule appended to [Lint].
+var DefaultLintRules = []LintRule{
+	lintUnbatchedApply,
+	lintFullScan,
+	lintUnseekableFilterScan,
+	lintFilterDiscard,
+	lintScanRatio,
+	lintLargeSort,
+}
+
+const (
+	// lintDiscardRatio is the fraction of rows a filter or scan must discard to be reported.
+	lintDiscardRatio = 0.9
+	// lintMinInputRows avoids reporting filters and scans over tiny inputs.
+	lintMinInputRows = 1000
+	// lintLargeSortRows is the number of sorted rows at which a sort is reported.
+	lintLargeSortRows = 10000
+)
+
+// Lint runs rules, or [DefaultLintRules] when rules is empty, over qp and returns the advisories
+// ordered by node ID and then rule name, or nil when there are none.
+func Lint(qp *QueryPlan, rules ...LintRule) []Advisory {
+	if len(rules) == 0 {
+		rules = DefaultLintRules
+	}
+	var advisories []Advisory
+	for _, rule := range rules {
+		advisories = append(advisories, rule(qp)...)
+	}
+	slices.SortStableFunc(advisories, func(a, b Advisory) int {
+		return cmp.Or(cmp.Compare(a.NodeID, b.NodeID), strings.Compare(a.Rule, b.Rule))
+	})
+	return advisories
+}
+
+func lintUnbatchedApply(qp *QueryPlan) []Advisory {
+	var advisories []Advisory
+	for _, index := range qp.UnbatchedApplies() {
+		advisories = append(advisories, Advisory{
+			NodeID:   index,
+			Severity: SeverityInfo,
+			Rule:     "unbatched-apply",
+			Message:  fmt.Sprintf("%s has no batched input and may run its Map side once per input row", qp.GetNodeByIndex(index).GetDisplayName()),
+		})
+	}
+	return advisories
+}
+
+func lintFullScan(qp *QueryPlan) []Advisory {
+	var advisories []Advisory
+	for _, target := range qp.ScanTargets() {
+		if !target.FullScan {
+			continue
+		}
+		message := fmt.Sprintf("full scan of %s", cmp.Or(target.Name, "unknown target"))
+		if target.Kind == ScanTable {
+			message = fmt.Sprintf("full scan of table %s; consider an index on the filtered columns", cmp.Or(target.Name, "unknown target"))
+		}
+		advisories = append(advisories, Advisory{
+			NodeID:   target.NodeID,
+			Severity: SeverityWarning,
+			Rule:     "full-scan",
+			Message:  message,
+		})
+	}
+	return advisories
+}
+
+func lintUnseekableFilterScan(qp *QueryPlan) []Advisory {
+	return lintEachRelational(qp, func(node *sppb.PlanNode) []Advisory {
+		if node.GetDisplayName() != "Filter Scan" || node.GetMetadata().GetFields()["seekable_key_size"].GetStringValue() != "0" {
+			return nil
+		}
+		return []Advisory{{
+			NodeID:   node.GetIndex(),
+			Severity: SeverityInfo,
+			Rule:     "unseekable-filter-scan",
+			Message:  "Filter Scan has no seekable key columns and evaluates its conditions on every row it reads",
+		}}
+	})
+}
+
+func lintFilterDiscard(qp *QueryPlan) []Advisory {
+	return lintEachRelational(qp, func(node *sppb.PlanNode) []Advisory {
+		if node.GetDisplayName() != "Filter" && node.GetDisplayName() != "Filter Scan" {
+			return nil
+		}
+		rows, ok := lintRows(node)
+		if !ok {
+			return nil
+		}
+		input, ok := lintInputRows(qp, node)
+		if !ok || input < lintMinInputRows {
+			return nil
+		}
+		discarded := (input - rows) / input
+		if discarded < lintDiscardRatio {
+			return nil
+		}
+		return []Advisory{{
+			NodeID:   node.GetIndex(),
+			Severity: SeverityWarning,
+			Rule:     "filter-discard",
+			Message:  fmt.Sprintf("%s discards %.1f%% of %v input rows; consider a seekable condition or an index", node.GetDisplayName(), discarded*100, input),
+		}}
+	})
+}
+
+func lintScanRatio(qp *QueryPlan) []Advisory {
+	return lintEachRelational(qp, func(node *sppb.PlanNode) []Advisory {
+		nodeStats, err := stats.Extract(node, false)
+		if err != nil {
+			return nil
+		}
+		scanned, err := nodeStats.ScannedRows.Float64()
+		if err != nil || scanned < lintMinInputRows {
+			return nil
+		}
+		rows, err := nodeStats.Rows.Float64()
+		if err != nil {
+			return nil
+		}
+		if discarded := (scanned - rows) / scanned; discarded < lintDiscardRatio {
+			return nil
+		}
+		return []Advisory{{
+			NodeID:   node.GetIndex(),
+			Severity: SeverityWarning,
+			Rule:     "scan-ratio",
+			Message:  fmt.Sprintf("%s returns %v of %v scanned rows; consider a seekable condition or an index", node.GetDisplayName(), rows, scanned),
+		}}
+	})
+}
+
+func lintLargeSort(qp *QueryPlan) []Advisory {
+	return lintEachRelational(qp, func(node *sppb.PlanNode) []Advisory {
+		if !strings.HasSuffix(node.GetDisplayName(), "Sort") {
+			return nil
+		}
+		rows, ok := lintRows(node)
+		if !ok || rows < lintLargeSortRows {
+			return nil
+		}
+		return []Advisory{{
+			NodeID:   node.GetIndex(),
+			Severity: SeverityWarning,
+			Rule:     "large-sort",
+			Message:  fmt.Sprintf("%s sorts %v rows; consider an index providing the order", node.GetDisplayName(), rows),
+		}}
+	})
+}
+
+// lintEachRelational returns the advisories of check for every relational node of qp.
+func lintEachRelational(qp *QueryPlan, check func(node *sppb.PlanNode) []Advisory) []Advisory {
+	var advisories []Advisory
+	for _, node := range qp.planNodes {
+		if node.GetKind() != sppb.PlanNode_RELATIONAL {
+			continue
+		}
+		advisories = append(advisories, check(node)...)
+	}
+	return advisories
+}
+
+// lintRows returns the actual rows produced by node. It reports false when node has no numeric
+// rows statistic, such as in PLAN-only output.
+func lintRows(node *sppb.PlanNode) (float64, bool) {
+	nodeStats, err := stats.Extract(node, false)
+	if err != nil {
+		return 0, false
+	}
+	rows, err := nodeStats.Rows.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return rows, true
+}
+
+// lintInputRows returns the actual rows produced by the first visible relational child of node.
+func lintInputRows(qp *QueryPlan, node *sppb.PlanNode) (float64, bool) {
+	for _, link := range qp.VisibleChildLinks(node) {
+		child := qp.GetNodeByChildLink(link)
+		if child.GetKind() != sppb.PlanNode_RELATIONAL {
+			continue
+		}
+		return lintRows(child)
+	}
+	return 0, false
+}
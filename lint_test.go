@@ -0,0 +1,169 @@
+package spannerplan
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// withMetadata sets the string metadata of node from alternating keys and values.
+func withMetadata(node *sppb.PlanNode, kv ...string) *sppb.PlanNode {
+	node.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for i := 0; i+1 < len(kv); i += 2 {
+		node.Metadata.Fields[kv[i]] = structpb.NewStringValue(kv[i+1])
+	}
+	return node
+}
+
+// withRowStats sets the totals of the execution stats of node from alternating names and values.
+func withRowStats(node *sppb.PlanNode, kv ...string) *sppb.PlanNode {
+	node.ExecutionStats = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for i := 0; i+1 < len(kv); i += 2 {
+		node.ExecutionStats.Fields[kv[i]] = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"total": structpb.NewStringValue(kv[i+1]),
+		}})
+	}
+	return node
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name      string
+		planNodes []*sppb.PlanNode
+		want      []Advisory
+	}{
+		{
+			name: "full scans of a table and an index",
+			planNodes: []*sppb.PlanNode{
+				relational(0, "Union All", 1, 2),
+				withMetadata(relational(1, "Scan"), "scan_type", "TableScan", "scan_target", "Albums", "Full scan", "true"),
+				withMetadata(relational(2, "Scan"), "scan_type", "IndexScan", "scan_target", "AlbumsByTitle", "Full scan", "true"),
+			},
+			want: []Advisory{
+				{NodeID: 1, Severity: SeverityWarning, Rule: "full-scan", Message: "full scan of table Albums; consider an index on the filtered columns"},
+				{NodeID: 2, Severity: SeverityWarning, Rule: "full-scan", Message: "full scan of AlbumsByTitle"},
+			},
+		},
+		{
+			name: "unseekable filter scan",
+			planNodes: []*sppb.PlanNode{
+				withMetadata(relational(0, "Filter Scan", 1), "seekable_key_size", "0"),
+				withMetadata(relational(1, "Scan"), "scan_type", "TableScan", "scan_target", "Albums"),
+			},
+			want: []Advisory{{
+				NodeID:   0,
+				Severity: SeverityInfo,
+				Rule:     "unseekable-filter-scan",
+				Message:  "Filter Scan has no seekable key columns and evaluates its conditions on every row it reads",
+			}},
+		},
+		{
+			name: "seekable filter scan",
+			planNodes: []*sppb.PlanNode{
+				withMetadata(relational(0, "Filter Scan", 1), "seekable_key_size", "1"),
+				relational(1, "Scan"),
+			},
+		},
+		{
+			name: "filter discarding most rows",
+			planNodes: []*sppb.PlanNode{
+				withRowStats(relational(0, "Filter", 1), "rows", "10"),
+				withRowStats(relational(1, "Scan"), "rows", "5000"),
+			},
+			want: []Advisory{{
+				NodeID:   0,
+				Severity: SeverityWarning,
+				Rule:     "filter-discard",
+				Message:  "Filter discards 99.8% of 5000 input rows; consider a seekable condition or an index",
+			}},
+		},
+		{
+			name: "scan returning few of its scanned rows",
+			planNodes: []*sppb.PlanNode{
+				withRowStats(relational(0, "Scan"), "rows", "20", "scanned_rows", "40000"),
+			},
+			want: []Advisory{{
+				NodeID:   0,
+				Severity: SeverityWarning,
+				Rule:     "scan-ratio",
+				Message:  "Scan returns 20 of 40000 scanned rows; consider a seekable condition or an index",
+			}},
+		},
+		{
+			name: "scan over a small input",
+			planNodes: []*sppb.PlanNode{
+				withRowStats(relational(0, "Scan"), "rows", "1", "scanned_rows", "500"),
+			},
+		},
+		{
+			name: "large sort and unbatched apply are ordered by node",
+			planNodes: []*sppb.PlanNode{
+				withRowStats(relational(0, "Sort", 1), "rows", "20000"),
+				withRowStats(relational(1, "Cross Apply", 2, 3), "rows", "20000"),
+				relational(2, "Scan"),
+				relational(3, "Scan"),
+			},
+			want: []Advisory{
+				{NodeID: 0, Severity: SeverityWarning, Rule: "large-sort", Message: "Sort sorts 20000 rows; consider an index providing the order"},
+				{NodeID: 1, Severity: SeverityInfo, Rule: "unbatched-apply", Message: "Cross Apply has no batched input and may run its Map side once per input row"},
+			},
+		},
+		{
+			name: "unreadable stats are skipped",
+			planNodes: []*sppb.PlanNode{
+				{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Sort", ExecutionStats: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"rows": structpb.NewStringValue("many"),
+				}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.planNodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, Lint(qp)); diff != "" {
+				t.Errorf("Lint() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLint_CustomRules(t *testing.T) {
+	qp, err := New([]*sppb.PlanNode{
+		relational(0, "Hash Join", 1, 2),
+		withMetadata(relational(1, "Scan"), "scan_type", "TableScan", "scan_target", "Albums", "Full scan", "true"),
+		relational(2, "Scan"),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	hashJoin := func(qp *QueryPlan) []Advisory {
+		var advisories []Advisory
+		for _, node := range qp.FindByDisplayName("Hash Join") {
+			advisories = append(advisories, Advisory{NodeID: node.GetIndex(), Severity: SeverityError, Rule: "hash-join", Message: "hash join"})
+		}
+		return advisories
+	}
+
+	got := Lint(qp, append(DefaultLintRules[:len(DefaultLintRules):len(DefaultLintRules)], hashJoin)...)
+	want := []Advisory{
+		{NodeID: 0, Severity: SeverityError, Rule: "hash-join", Message: "hash join"},
+		{NodeID: 1, Severity: SeverityWarning, Rule: "full-scan", Message: "full scan of table Albums; consider an index on the filtered columns"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Lint(default rules and hash-join) mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(want[:1], Lint(qp, hashJoin)); diff != "" {
+		t.Errorf("Lint(hash-join) mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, want := want[1].String(), "warning: node 1: full-scan: full scan of table Albums; consider an index on the filtered columns"; got != want {
+		t.Errorf("Advisory.String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package spannerplan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderMermaid returns the visible operator tree of qp as a Mermaid "flowchart TD" for
+// Markdown that renders Mermaid. Each operator is a node with the ID "n" followed by its
+// PlanNode index and its [NodeTitle] with opts as its label, and each visible child link is a
+// solid "-->" arrow from the parent to the child, labeled with its
+// [QueryPlan.LinkTypeInParent] in brackets when it has one. With [ShowScalarLinks], links to
+// hidden scalar nodes are dotted "-.->" arrows to rounded nodes.
+//
+// Labels are quoted, and the characters Mermaid treats specially, such as the angle brackets
+// of "<Row>", are written as Mermaid entity codes like "#lt;". RenderMermaid returns an error
+// when the visible child links form a cycle.
+func RenderMermaid(qp *QueryPlan, opts ...Option) (string, error) {
+	nodes, edges, err := planGraph(qp, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	for _, node := range nodes {
+		if node.scalar {
+			fmt.Fprintf(&sb, "  n%d(%s)\n", node.index, mermaidQuote(node.label))
+		} else {
+			fmt.Fprintf(&sb, "  n%d[%s]\n", node.index, mermaidQuote(node.label))
+		}
+	}
+	for _, edge := range edges {
+		arrow := "-->"
+		if edge.scalar {
+			arrow = "-.->"
+		}
+		if edge.linkType != "" {
+			fmt.Fprintf(&sb, "  n%d %s|%s| n%d\n", edge.from, arrow, mermaidQuote("["+edge.linkType+"]"), edge.to)
+		} else {
+			fmt.Fprintf(&sb, "  n%d %s n%d\n", edge.from, arrow, edge.to)
+		}
+	}
+	return sb.String(), nil
+}
+
+// mermaidEscaper replaces the characters that end or reinterpret a quoted Mermaid label with
+// entity codes. A literal "#" is escaped because it starts an entity code, while "&" needs no
+// escaping. The replacer makes a single pass, so the codes it writes are not escaped again.
+var mermaidEscaper = strings.NewReplacer(
+	"#", "#35;",
+	`"`, "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+	"(", "#40;",
+	")", "#41;",
+	"[", "#91;",
+	"]", "#93;",
+	"{", "#123;",
+	"}", "#125;",
+	"|", "#124;",
+	"\n", " ",
+)
+
+// mermaidQuote returns s as a quoted Mermaid label.
+func mermaidQuote(s string) string {
+	return `"` + mermaidEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,79 @@
+package spannerplan
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestRenderMermaid(t *testing.T) {
+	qp, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Cross Apply", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 1},
+			{ChildIndex: 2, Type: "Map"},
+		}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"execution_method": structpb.NewStringValue("Row"),
+		}}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan", Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"scan_target": structpb.NewStringValue(`Singers "S"`),
+			"scan_type":   structpb.NewStringValue("TableScan"),
+		}}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 3},
+			{ChildIndex: 4, Type: "Condition"},
+		}},
+		{Index: 3, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Unit Relation"},
+		{Index: 4, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{Description: "($x > 1) OR #y"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "operators",
+			opts: []Option{WithExecutionMethodFormat(ExecutionMethodFormatAngle), WithTargetMetadataFormat(TargetMetadataFormatOn)},
+			want: `flowchart TD
+  n0["Cross Apply #lt;Row#gt;"]
+  n1["Table Scan on Singers #quot;S#quot;"]
+  n2["Filter"]
+  n3["Unit Relation"]
+  n0 -->|"#91;Input#93;"| n1
+  n0 -->|"#91;Map#93;"| n2
+  n2 --> n3
+`,
+		},
+		{
+			name: "scalar links",
+			opts: []Option{ShowScalarLinks()},
+			want: `flowchart TD
+  n0["Cross Apply #40;execution_method: Row#41;"]
+  n1["Table Scan #40;Table: Singers #quot;S#quot;#41;"]
+  n2["Filter"]
+  n3["Unit Relation"]
+  n4("#40;$x #gt; 1#41; OR #35;y")
+  n0 -->|"#91;Input#93;"| n1
+  n0 -->|"#91;Map#93;"| n2
+  n2 --> n3
+  n2 -.->|"#91;Condition#93;"| n4
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderMermaid(qp, tt.opts...)
+			if err != nil {
+				t.Fatalf("RenderMermaid() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("RenderMermaid() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package spannerplan
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MetadataField is a metadata key of a PlanNode and its value as text.
+type MetadataField struct {
+	Key   string
+	Value string
+}
+
+// metadataKeyOrder lists the metadata keys that [NodeMetadataSorted] returns first, in the order
+// they appear in [NodeTitle]: the operator name parts, the target, and the execution method.
+var metadataKeyOrder = []string{"call_type", "iterator_type", "scan_type", "scan_target", "distribution_table", "table", "execution_method"}
+
+// NodeMetadataSorted returns every metadata field of node in a deterministic order, for renderers
+// that lay out metadata themselves. call_type, iterator_type, scan_type, scan_target,
+// distribution_table, table and execution_method come first in that order, and the remaining keys
+// follow sorted by key. No key is skipped or reformatted the way [NodeTitle] does.
+//
+// String values are returned as is, numbers in their shortest decimal form, booleans as "true"
+// or "false", and lists, structs and nulls as JSON. It returns nil when node has no metadata.
+func NodeMetadataSorted(node *sppb.PlanNode) []MetadataField {
+	fields := node.GetMetadata().GetFields()
+	if len(fields) == 0 {
+		return nil
+	}
+
+	result := make([]MetadataField, 0, len(fields))
+	for _, key := range metadataKeyOrder {
+		if v, ok := fields[key]; ok {
+			result = append(result, MetadataField{Key: key, Value: metadataValueString(v)})
+		}
+	}
+	known := len(result)
+	for key, v := range fields {
+		if !slices.Contains(metadataKeyOrder, key) {
+			result = append(result, MetadataField{Key: key, Value: metadataValueString(v)})
+		}
+	}
+	slices.SortFunc(result[known:], func(a, b MetadataField) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return result
+}
+
+func metadataValueString(v *structpb.Value) string {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return kind.StringValue
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(kind.NumberValue, 'f', -1, 64)
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(kind.BoolValue)
+	}
+	b, err := json.Marshal(v.AsInterface())
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
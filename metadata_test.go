@@ -0,0 +1,45 @@
+package spannerplan
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNodeMetadataSorted(t *testing.T) {
+	metadata, err := structpb.NewStruct(map[string]any{
+		"split_ranges_aligned": "false",
+		"execution_method":     "Row",
+		"scan_target":          "AlbumsByAlbumTitle",
+		"Full scan":            "true",
+		"scan_type":            "IndexScan",
+		"seekable_key_size":    0,
+		"flags":                []any{"a", true},
+		"enabled":              true,
+	})
+	if err != nil {
+		t.Fatalf("structpb.NewStruct() error = %v", err)
+	}
+
+	want := []MetadataField{
+		{Key: "scan_type", Value: "IndexScan"},
+		{Key: "scan_target", Value: "AlbumsByAlbumTitle"},
+		{Key: "execution_method", Value: "Row"},
+		{Key: "Full scan", Value: "true"},
+		{Key: "enabled", Value: "true"},
+		{Key: "flags", Value: `["a",true]`},
+		{Key: "seekable_key_size", Value: "0"},
+		{Key: "split_ranges_aligned", Value: "false"},
+	}
+	for range 3 {
+		if diff := cmp.Diff(want, NodeMetadataSorted(&sppb.PlanNode{Metadata: metadata})); diff != "" {
+			t.Fatalf("NodeMetadataSorted() mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	if got := NodeMetadataSorted(&sppb.PlanNode{}); got != nil {
+		t.Errorf("NodeMetadataSorted(no metadata) = %v, want nil", got)
+	}
+}
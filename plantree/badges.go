@@ -0,0 +1,73 @@
+package plantree
+
+import (
+	"strings"
+
+	"github.com/apstndb/go-tabwrap"
+	"github.com/samber/lo"
+
+	"github.com/apstndb/spannerplan/stats"
+)
+
+// WithStatBadges appends a badge of key execution statistics to the first line of each row
+// with stats, such as "[33r 1x 1.92ms]" for the rows returned, the number of executions, and
+// the latency. Each statistic is right-aligned to the widest value in the plan, and badges
+// start at the same column, so they form a gutter to the right of the tree.
+//
+// With [WithWrapWidth], node titles are wrapped to the width left over by the badge, so lines
+// stay within the wrap width and a badge is never split. With [WithLatencyBar], the bar comes
+// first in the gutter.
+func WithStatBadges() Option {
+	return func(o *options) {
+		o.statBadges = true
+	}
+}
+
+// badgeFields returns the statistics shown in the badge of s, or nil when s has none of them.
+func badgeFields(s stats.ExecutionStats) []string {
+	fields := []string{
+		lo.Ternary(s.Rows.Total != "", s.Rows.Total+"r", ""),
+		lo.Ternary(s.ExecutionSummary.NumExecutions != "", s.ExecutionSummary.NumExecutions+"x", ""),
+		s.Latency.ShortDuration(),
+	}
+	if strings.Join(fields, "") == "" {
+		return nil
+	}
+	return fields
+}
+
+// statBadges formats the badges of nodes, indexed like nodes, with every field padded to the
+// widest value of its position. Nodes without statistics get "". It also returns the display
+// width shared by every badge, or 0 when no node has a badge.
+func statBadges(nodes []*renderedNode, cond *tabwrap.Condition) ([]string, int) {
+	fieldsByNode := make([][]string, len(nodes))
+	var widths []int
+	for i, node := range nodes {
+		fields := badgeFields(node.ExecutionStats)
+		fieldsByNode[i] = fields
+		if widths == nil && fields != nil {
+			widths = make([]int, len(fields))
+		}
+		for j, field := range fields {
+			widths[j] = max(widths[j], cond.StringWidth(field))
+		}
+	}
+	if widths == nil {
+		return make([]string, len(nodes)), 0
+	}
+
+	badges := make([]string, len(nodes))
+	var width int
+	for i, fields := range fieldsByNode {
+		if fields == nil {
+			continue
+		}
+		padded := make([]string, len(fields))
+		for j, field := range fields {
+			padded[j] = cond.FillLeft(field, widths[j])
+		}
+		badges[i] = "[" + strings.Join(padded, " ") + "]"
+		width = cond.StringWidth(badges[i])
+	}
+	return badges, width
+}
@@ -0,0 +1,101 @@
+package plantree
+
+import (
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func newBadgeTestPlan(t *testing.T, hasStats bool) *spannerplan.QueryPlan {
+	t.Helper()
+
+	withStats := func(node *sppb.PlanNode, rows, executions, latency string) *sppb.PlanNode {
+		if !hasStats {
+			return node
+		}
+		s, err := structpb.NewStruct(map[string]any{
+			"rows":              map[string]any{"total": rows, "unit": "rows"},
+			"latency":           map[string]any{"total": latency, "unit": "msecs"},
+			"execution_summary": map[string]any{"num_executions": executions},
+		})
+		if err != nil {
+			t.Fatalf("structpb.NewStruct() error = %v", err)
+		}
+		node.ExecutionStats = s
+		return node
+	}
+	qp, err := spannerplan.New([]*sppb.PlanNode{
+		withStats(&sppb.PlanNode{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Serialize Result", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}}}, "120", "1", "12.5"),
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Create Batch", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 2}}},
+		withStats(&sppb.PlanNode{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Table Scan Over A Long Table Name"}, "7", "12", "0.9"),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return qp
+}
+
+func TestWithStatBadges(t *testing.T) {
+	qp := newBadgeTestPlan(t, true)
+
+	rows, err := ProcessPlan(qp, WithStatBadges())
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	want := []string{
+		"Serialize Result                        [120r  1x 12.5ms]",
+		"+- Create Batch",
+		"   +- Table Scan Over A Long Table Name [  7r 12x  0.9ms]",
+	}
+	if diff := cmp.Diff(want, rowTexts(rows)); diff != "" {
+		t.Errorf("ProcessPlan(WithStatBadges) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithStatBadgesWrapsAroundBadges(t *testing.T) {
+	qp := newBadgeTestPlan(t, true)
+
+	const wrapWidth = 36
+	rows, err := ProcessPlan(qp, WithStatBadges(), WithWrapWidth(wrapWidth))
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	want := []string{
+		"Serialize Result   [120r  1x 12.5ms]",
+		"+- Create Batch",
+		"   +- Table Scan O [  7r 12x  0.9ms]\n      ver A Long T\n      able Name",
+	}
+	if diff := cmp.Diff(want, rowTexts(rows)); diff != "" {
+		t.Errorf("ProcessPlan(WithStatBadges, WithWrapWidth) mismatch (-want +got):\n%s", diff)
+	}
+	for _, text := range rowTexts(rows) {
+		for _, line := range strings.Split(text, "\n") {
+			if len(line) > wrapWidth {
+				t.Errorf("line %q is wider than %d", line, wrapWidth)
+			}
+		}
+	}
+}
+
+func TestWithStatBadgesWithoutStats(t *testing.T) {
+	qp := newBadgeTestPlan(t, false)
+
+	for _, opts := range [][]Option{nil, {WithWrapWidth(20)}} {
+		want, err := ProcessPlan(qp, opts...)
+		if err != nil {
+			t.Fatalf("ProcessPlan() error = %v", err)
+		}
+		got, err := ProcessPlan(qp, append(opts, WithStatBadges())...)
+		if err != nil {
+			t.Fatalf("ProcessPlan(WithStatBadges) error = %v", err)
+		}
+		if diff := cmp.Diff(rowTexts(want), rowTexts(got)); diff != "" {
+			t.Errorf("ProcessPlan(WithStatBadges) changed a plan without stats (-want +got):\n%s", diff)
+		}
+	}
+}
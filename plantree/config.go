@@ -0,0 +1,128 @@
+package plantree
+
+import (
+	"fmt"
+
+	"github.com/apstndb/spannerplan"
+)
+
+// Config is a serialization-friendly form of the [ProcessPlan] options.
+//
+// A single JSON or YAML object decoded into Config describes a whole rendering,
+// which suits config files and WebAssembly embeddings. Go callers can append explicit
+// options after [Config.Options]; later options override earlier ones.
+type Config struct {
+	// RenderConfig configures node titles. It is a named field rather than an embedded one
+	// so its JSON encoding stays nested under "renderConfig".
+	RenderConfig spannerplan.RenderConfig `json:"renderConfig"`
+
+	// WrapWidth sets the maximum total rendered line width. See [WithWrapWidth].
+	WrapWidth int `json:"wrapWidth,omitempty"`
+
+	// HangingIndent enables [WithHangingIndent].
+	HangingIndent bool `json:"hangingIndent,omitempty"`
+
+	// MaxDepth hides nodes deeper than the given depth. See [WithMaxDepth].
+	// Nil keeps the whole tree.
+	MaxDepth *int `json:"maxDepth,omitempty"`
+
+	// HiddenStats enables [WithHiddenStats].
+	HiddenStats bool `json:"hiddenStats,omitempty"`
+
+	// UnicodeEdges enables [WithUnicodeEdges].
+	UnicodeEdges bool `json:"unicodeEdges,omitempty"`
+
+	// DedupeSubtrees enables [WithDedupeSubtrees].
+	DedupeSubtrees bool `json:"dedupeSubtrees,omitempty"`
+
+	// DisplayIDOffset shifts displayed row IDs. See [WithDisplayIDOffset].
+	DisplayIDOffset int32 `json:"displayIDOffset,omitempty"`
+
+	// NewlineMode sets how embedded line breaks are rendered. See [WithNewlineMode].
+	// Empty keeps [NewlinePreserve].
+	NewlineMode NewlineMode `json:"newlineMode,omitempty"`
+
+	// StatBadges enables [WithStatBadges].
+	StatBadges bool `json:"statBadges,omitempty"`
+
+	// LatencyBarWidth enables [WithLatencyBar] with this many cells when non-zero.
+	LatencyBarWidth int `json:"latencyBarWidth,omitempty"`
+
+	// DisallowUnknownStats enables [DisallowUnknownStats].
+	DisallowUnknownStats bool `json:"disallowUnknownStats,omitempty"`
+
+	// Filter keeps only the rows selected by a named preset, such as "critical-path". See
+	// [WithFilterPreset]. Empty keeps every row.
+	Filter FilterPreset `json:"filter,omitempty"`
+}
+
+// Validate reports an error when c contains values [ProcessPlan] would reject.
+func (c Config) Validate() error {
+	if err := c.RenderConfig.Validate(); err != nil {
+		return err
+	}
+	if c.WrapWidth < 0 {
+		return fmt.Errorf("wrap width cannot be negative: %d", c.WrapWidth)
+	}
+	if c.MaxDepth != nil && *c.MaxDepth < 0 {
+		return fmt.Errorf("max depth cannot be negative: %d", *c.MaxDepth)
+	}
+	if c.DisplayIDOffset < 0 {
+		return fmt.Errorf("display ID offset cannot be negative: %d", c.DisplayIDOffset)
+	}
+	if c.LatencyBarWidth < 0 {
+		return fmt.Errorf("latency bar width cannot be negative: %d", c.LatencyBarWidth)
+	}
+	if err := validateFilterPreset(c.Filter); err != nil {
+		return err
+	}
+	return validateNewlineMode(c.NewlineMode)
+}
+
+// Options returns the [ProcessPlan] options equivalent to c.
+func (c Config) Options() []Option {
+	renderConfig := c.RenderConfig
+	renderConfig.Compact = false
+
+	opts := []Option{WithQueryPlanOptions(renderConfig.Options()...)}
+	if c.RenderConfig.Compact {
+		opts = append(opts, EnableCompact())
+	}
+	if c.WrapWidth != 0 {
+		opts = append(opts, WithWrapWidth(c.WrapWidth))
+	}
+	if c.HangingIndent {
+		opts = append(opts, WithHangingIndent())
+	}
+	if c.MaxDepth != nil {
+		opts = append(opts, WithMaxDepth(*c.MaxDepth))
+	}
+	if c.HiddenStats {
+		opts = append(opts, WithHiddenStats())
+	}
+	if c.UnicodeEdges {
+		opts = append(opts, WithUnicodeEdges())
+	}
+	if c.DedupeSubtrees {
+		opts = append(opts, WithDedupeSubtrees())
+	}
+	if c.DisplayIDOffset != 0 {
+		opts = append(opts, WithDisplayIDOffset(c.DisplayIDOffset))
+	}
+	if c.NewlineMode != "" {
+		opts = append(opts, WithNewlineMode(c.NewlineMode))
+	}
+	if c.StatBadges {
+		opts = append(opts, WithStatBadges())
+	}
+	if c.LatencyBarWidth != 0 {
+		opts = append(opts, WithLatencyBar(c.LatencyBarWidth))
+	}
+	if c.DisallowUnknownStats {
+		opts = append(opts, DisallowUnknownStats())
+	}
+	if c.Filter != "" {
+		opts = append(opts, WithFilterPreset(c.Filter))
+	}
+	return opts
+}
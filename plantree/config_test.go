@@ -0,0 +1,130 @@
+package plantree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func rowTexts(rows []RowWithPredicates) []string {
+	texts := make([]string, 0, len(rows))
+	for _, row := range rows {
+		texts = append(texts, row.Text())
+	}
+	return texts
+}
+
+func TestConfig_JSONRoundTrip(t *testing.T) {
+	const input = `{"renderConfig":{"executionMethodFormat":"ANGLE","targetMetadataFormat":"ON","knownFlagFormat":"LABEL","compact":true},"wrapWidth":60,"hangingIndent":true,"maxDepth":0,"unicodeEdges":true,"dedupeSubtrees":true,"newlineMode":"escape","filter":"critical-path"}`
+
+	var cfg Config
+	if err := json.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if cfg.MaxDepth == nil || *cfg.MaxDepth != 0 {
+		t.Fatalf("MaxDepth = %v, want pointer to 0", cfg.MaxDepth)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(b) != input {
+		t.Fatalf("json.Marshal() = %s, want %s", b, input)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	negative := -1
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "negative wrap width", cfg: Config{WrapWidth: -1}},
+		{name: "negative max depth", cfg: Config{MaxDepth: &negative}},
+		{name: "negative display ID offset", cfg: Config{DisplayIDOffset: -1}},
+		{name: "negative latency bar width", cfg: Config{LatencyBarWidth: -1}},
+		{name: "unknown newline mode", cfg: Config{NewlineMode: "squash"}},
+		{name: "unknown filter preset", cfg: Config{Filter: "slow"}},
+		{name: "invalid render config", cfg: Config{RenderConfig: spannerplan.RenderConfig{KnownFlagFormat: 9}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); err == nil {
+				t.Fatal("Validate() error = nil, want non-nil")
+			}
+		})
+	}
+}
+
+func TestConfig_OptionsMatchExplicitOptions(t *testing.T) {
+	qp := decodeDCAPlan(t)
+	maxDepth := 2
+	cfg := Config{
+		RenderConfig: spannerplan.RenderConfig{
+			ExecutionMethodFormat: spannerplan.ExecutionMethodFormatAngle,
+			TargetMetadataFormat:  spannerplan.TargetMetadataFormatOn,
+			KnownFlagFormat:       spannerplan.KnownFlagFormatLabel,
+		},
+		MaxDepth:     &maxDepth,
+		UnicodeEdges: true,
+	}
+
+	got, err := ProcessPlan(qp, cfg.Options()...)
+	if err != nil {
+		t.Fatalf("ProcessPlan(cfg.Options()) error = %v", err)
+	}
+	want := []string{
+		"Distributed Union on AlbumsByAlbumTitle <Row>",
+		"└─ Distributed Cross Apply <Row>",
+		"   ├─ [Input] Create Batch <Row> (4 hidden)",
+		"   └─ [Map] Serialize Result <Row> (6 hidden)",
+	}
+	if diff := cmp.Diff(want, rowTexts(got)); diff != "" {
+		t.Fatalf("ProcessPlan(cfg.Options()) mismatch (-want +got):\n%s", diff)
+	}
+
+	explicit, err := ProcessPlan(qp, append(currentOptions(), WithMaxDepth(2), WithUnicodeEdges())...)
+	if err != nil {
+		t.Fatalf("ProcessPlan(explicit) error = %v", err)
+	}
+	if diff := cmp.Diff(rowTexts(explicit), rowTexts(got)); diff != "" {
+		t.Fatalf("Config.Options() differ from explicit options (-explicit +config):\n%s", diff)
+	}
+
+	overridden, err := ProcessPlan(qp, append(cfg.Options(), WithMaxDepth(0))...)
+	if err != nil {
+		t.Fatalf("ProcessPlan(cfg.Options(), WithMaxDepth(0)) error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"Distributed Union on AlbumsByAlbumTitle <Row> (13 hidden)"}, rowTexts(overridden)); diff != "" {
+		t.Fatalf("explicit option should override config (-want +got):\n%s", diff)
+	}
+}
+
+func TestProcessPlan_NegativeMaxDepthErrors(t *testing.T) {
+	if _, err := ProcessPlan(decodeDCAPlan(t), WithMaxDepth(-1)); err == nil {
+		t.Fatal("ProcessPlan(WithMaxDepth(-1)) error = nil, want non-nil")
+	}
+}
+
+func TestConfig_Filter(t *testing.T) {
+	qp := decodeDCAPlan(t)
+
+	got, err := ProcessPlan(qp, Config{Filter: FilterLint}.Options()...)
+	if err != nil {
+		t.Fatalf("ProcessPlan(cfg.Options()) error = %v", err)
+	}
+	want, err := ProcessPlan(qp, append(Config{}.Options(), WithFilterPreset(FilterLint))...)
+	if err != nil {
+		t.Fatalf("ProcessPlan(WithFilterPreset) error = %v", err)
+	}
+	if diff := cmp.Diff(rowTexts(want), rowTexts(got)); diff != "" {
+		t.Errorf("Config.Filter differs from WithFilterPreset (-explicit +config):\n%s", diff)
+	}
+}
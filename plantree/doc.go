@@ -31,6 +31,35 @@ at the time of this change uses ProcessPlan and Text/FormatID only, not TreePart
 Breaking changes in this package are called out in the release / PR description when
 they affect exported options or types.
 
+# Config
+
+[Config] bundles the [ProcessPlan] options, including a nested
+[spannerplan.RenderConfig] for node titles, into one JSON-friendly value.
+[Config.Options] returns ordinary options, so explicit options appended after
+them still take effect. Row filters are named presets such as "critical-path",
+as with [WithFilterPreset], so that the whole rendering, filter included, stays
+one JSON object.
+
+# Repeated subtrees
+
+[WithDedupeSubtrees] shortens plans with structurally identical repeated subtrees by
+rendering later occurrences as one "(see node N)" row. It is off by default because it
+drops rows. References are display-only: row IDs stay the original PlanNode IDs, and
+each reference row keeps its own execution statistics.
+
+# Row transforms
+
+[WithRowTransform] hooks into [ProcessPlan] after each row is rendered, which is a way to
+prototype annotations or filters without a dedicated option. Transforms see rows in preorder
+and can drop them with [DroppedRowID], but tree prefixes are already drawn by then, so keep
+or drop whole subtrees to avoid children pointing at a missing parent.
+
+# Embedded newlines
+
+Some plans carry multi-line predicate descriptions or metadata values. By default their extra
+lines become extra visual lines of the row; [WithNewlineMode] can instead escape them or join
+them with spaces so each row and predicate stays on one line.
+
 # Structural signatures
 
 [StructuralSignature] returns a deterministic, versioned canonical string for
@@ -0,0 +1,69 @@
+package plantree
+
+import (
+	"fmt"
+
+	"github.com/apstndb/spannerplan"
+)
+
+// FilterPreset names a row filter of [WithFilterPreset] that selects operators from the plan
+// itself, so that a [Config] can filter rows without computing a change set first.
+type FilterPreset string
+
+const (
+	// FilterCriticalPath selects the operators of [spannerplan.QueryPlan.CriticalPath]. It needs
+	// latency statistics, so [ProcessPlan] returns an error for PLAN-only input.
+	FilterCriticalPath FilterPreset = "critical-path"
+	// FilterUnbatchedApplies selects the Apply operators of
+	// [spannerplan.QueryPlan.UnbatchedApplies].
+	FilterUnbatchedApplies FilterPreset = "unbatched-applies"
+	// FilterLint selects the operators with an advisory of [spannerplan.Lint] with its default
+	// rules.
+	FilterLint FilterPreset = "lint"
+)
+
+// WithFilterPreset keeps the rows of the operators preset selects and the rows on the paths
+// from the root to them, as [WithChangeSet] does with the selected operators as the changed
+// set, so [RowWithPredicates.Changed] marks them. It replaces an earlier WithChangeSet, and a
+// later WithChangeSet replaces it. An empty preset disables the option, and an unknown one
+// makes [ProcessPlan] return an error.
+func WithFilterPreset(preset FilterPreset) Option {
+	return func(o *options) {
+		o.filterPreset = preset
+		o.changeSet = nil
+	}
+}
+
+func validateFilterPreset(preset FilterPreset) error {
+	switch preset {
+	case "", FilterCriticalPath, FilterUnbatchedApplies, FilterLint:
+		return nil
+	default:
+		return fmt.Errorf("unknown filter preset: %q", preset)
+	}
+}
+
+// filterPresetNodes returns the operators of qp that preset, which must be valid and non-empty,
+// selects.
+func filterPresetNodes(qp *spannerplan.QueryPlan, preset FilterPreset) (map[int32]bool, error) {
+	var ids []int32
+	switch preset {
+	case FilterCriticalPath:
+		path, err := qp.CriticalPath()
+		if err != nil {
+			return nil, fmt.Errorf("filter preset %s: %w", preset, err)
+		}
+		ids = path
+	case FilterUnbatchedApplies:
+		ids = qp.UnbatchedApplies()
+	case FilterLint:
+		for _, advisory := range spannerplan.Lint(qp) {
+			ids = append(ids, advisory.NodeID)
+		}
+	}
+	selected := make(map[int32]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	return selected, nil
+}
@@ -0,0 +1,93 @@
+package plantree
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func TestWithFilterPreset(t *testing.T) {
+	qp := decodeDCAPlan(t)
+
+	tests := []struct {
+		name   string
+		preset FilterPreset
+		want   []string
+	}{
+		{
+			name:   "critical path",
+			preset: FilterCriticalPath,
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row>",
+				"+- Distributed Cross Apply <Row> (5 hidden)",
+				"   +- [Map] Serialize Result <Row>",
+				"      +- Cross Apply <Row> (2 hidden)",
+				"         +- [Map] Local Distributed Union <Row>",
+				"            +- Filter Scan <Row> (seekable_key_size: 0)",
+				"               +- Table Scan on Albums <Row> (scan_method: Row)",
+			},
+		},
+		{
+			name:   "lint keeps the ancestors of the findings",
+			preset: FilterLint,
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row>",
+				"+- Distributed Cross Apply <Row> (5 hidden)",
+				"   +- [Map] Serialize Result <Row>",
+				"      +- Cross Apply <Row> (2 hidden)",
+				"         +- [Map] Local Distributed Union <Row>",
+				"            +- Filter Scan <Row> (seekable_key_size: 0) (1 hidden)",
+			},
+		},
+		{
+			name:   "no unbatched apply keeps only the root",
+			preset: FilterUnbatchedApplies,
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row> (13 hidden)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(qp, append(currentOptions(), WithFilterPreset(tt.preset))...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rowTexts(rows)); diff != "" {
+				t.Errorf("ProcessPlan(WithFilterPreset(%s)) mismatch (-want +got):\n%s", tt.preset, diff)
+			}
+		})
+	}
+
+	t.Run("later option wins", func(t *testing.T) {
+		rows, err := ProcessPlan(qp, append(currentOptions(), WithFilterPreset(FilterCriticalPath), WithChangeSet(map[int32]bool{}))...)
+		if err != nil {
+			t.Fatalf("ProcessPlan() error = %v", err)
+		}
+		if len(rows) != 1 {
+			t.Errorf("ProcessPlan(WithFilterPreset, WithChangeSet) = %q, want only the root", rowTexts(rows))
+		}
+		rows, err = ProcessPlan(qp, append(currentOptions(), WithChangeSet(map[int32]bool{}), WithFilterPreset(""))...)
+		if err != nil {
+			t.Fatalf("ProcessPlan() error = %v", err)
+		}
+		if len(rows) != 14 {
+			t.Errorf("ProcessPlan(WithChangeSet, WithFilterPreset(\"\")) has %d rows, want all 14", len(rows))
+		}
+	})
+
+	if _, err := ProcessPlan(qp, WithFilterPreset("slow")); err == nil {
+		t.Error("ProcessPlan(unknown preset) error = nil, want non-nil")
+	}
+
+	plan, err := spannerplan.New([]*sppb.PlanNode{{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := ProcessPlan(plan, WithFilterPreset(FilterCriticalPath)); err == nil {
+		t.Error("ProcessPlan(critical path without stats) error = nil, want non-nil")
+	}
+}
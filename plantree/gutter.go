@@ -0,0 +1,81 @@
+package plantree
+
+import (
+	"strings"
+
+	"github.com/apstndb/go-tabwrap"
+	"github.com/samber/lo"
+
+	"github.com/apstndb/spannerplan/treerender"
+)
+
+// gutterCells returns the gutter text of each of nodes, indexed like nodes, built from the
+// enabled gutter columns: the latency bar and the stat badge. Within a row that has any cell,
+// an empty cell is padded to the width of its column, so every column starts at the same
+// offset. It also returns the display width shared by every non-empty gutter text, or 0 and
+// nil when no node has a cell.
+func gutterCells(nodes []*renderedNode, o *options) ([]string, int) {
+	var columns [][]string
+	if o.latencyBarWidth != nil {
+		columns = append(columns, latencyBars(nodes, *o.latencyBarWidth))
+	}
+	if o.statBadges {
+		badges, _ := statBadges(nodes, o.wrapper)
+		columns = append(columns, badges)
+	}
+
+	widths := make([]int, len(columns))
+	for j, column := range columns {
+		for _, cell := range column {
+			widths[j] = max(widths[j], o.wrapper.StringWidth(cell))
+		}
+	}
+
+	var cells []string
+	var width int
+	for i := range nodes {
+		var parts []string
+		var hasCell bool
+		for j, column := range columns {
+			if widths[j] == 0 {
+				continue
+			}
+			hasCell = hasCell || column[i] != ""
+			parts = append(parts, o.wrapper.FillRight(column[i], widths[j]))
+		}
+		if !hasCell {
+			continue
+		}
+		if cells == nil {
+			cells = make([]string, len(nodes))
+		}
+		cells[i] = strings.TrimRight(strings.Join(parts, " "), " ")
+		width = max(width, o.wrapper.StringWidth(cells[i]))
+	}
+	return cells, width
+}
+
+// appendGutter appends cells[i] to the first line of rows[i], padding the lines so that every
+// cell starts one column after the widest first line that carries one.
+func appendGutter(rows []treerender.Row, cells []string, cond *tabwrap.Condition) {
+	firstLineWidth := func(row treerender.Row) int {
+		treePart, _, _ := strings.Cut(row.TreePart, "\n")
+		nodeText, _, _ := strings.Cut(row.NodeText, "\n")
+		return cond.StringWidth(treePart + nodeText)
+	}
+
+	gutter := 0
+	for i, row := range rows {
+		if cells[i] != "" {
+			gutter = max(gutter, firstLineWidth(row))
+		}
+	}
+	for i, row := range rows {
+		if cells[i] == "" {
+			continue
+		}
+		padding := strings.Repeat(" ", gutter-firstLineWidth(row)+1)
+		first, rest, hasRest := strings.Cut(row.NodeText, "\n")
+		rows[i].NodeText = first + padding + cells[i] + lo.Ternary(hasRest, "\n"+rest, "")
+	}
+}
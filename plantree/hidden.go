@@ -0,0 +1,82 @@
+package plantree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithHiddenStats adds the aggregated execution statistics of the hidden descendants to the
+// "(N hidden)" suffix of [WithMaxDepth] and [WithChangeSet], such as
+// "(5 hidden: 126 rows, 1.15ms)", so collapsing a subtree does not hide its cost. Rows are the
+// rows returned by every hidden operator added together. Latency is the latency of the hidden
+// subtrees, counted like [WithLatencyBar] counts children: the latency of each hidden child, or
+// of its nearest descendants with a latency when it has none. A statistic no hidden operator
+// has is omitted, and without either the suffix is unchanged.
+func WithHiddenStats() Option {
+	return func(o *options) {
+		o.hiddenStats = true
+	}
+}
+
+// hiddenSummary accumulates the subtrees dropped from a node for its "(N hidden)" suffix.
+type hiddenSummary struct {
+	count      int
+	rows       float64
+	hasRows    bool
+	latency    time.Duration
+	hasLatency bool
+}
+
+// add counts the subtree rooted at n. It must run before the subtree is pruned.
+func (s *hiddenSummary) add(n *renderedNode) {
+	if hasSubtreeLatency(n) {
+		s.latency += subtreeLatency(n)
+		s.hasLatency = true
+	}
+	for _, node := range collectPreorder(n) {
+		s.count++
+		if rows, err := node.ExecutionStats.Rows.Float64(); err == nil {
+			s.rows += rows
+			s.hasRows = true
+		}
+	}
+}
+
+// merge adds the subtrees counted by other.
+func (s *hiddenSummary) merge(other hiddenSummary) {
+	s.count += other.count
+	s.rows += other.rows
+	s.hasRows = s.hasRows || other.hasRows
+	s.latency += other.latency
+	s.hasLatency = s.hasLatency || other.hasLatency
+}
+
+// suffix returns the text appended to the node the subtrees were dropped from, or "" when none were.
+func (s *hiddenSummary) suffix(sep string, withStats bool) string {
+	if s.count == 0 {
+		return ""
+	}
+	var fields []string
+	if withStats && s.hasRows {
+		fields = append(fields, strconv.FormatFloat(s.rows, 'f', -1, 64)+" rows")
+	}
+	if withStats && s.hasLatency {
+		fields = append(fields, s.latency.String())
+	}
+	if len(fields) == 0 {
+		return fmt.Sprintf("%s(%d hidden)", sep, s.count)
+	}
+	return fmt.Sprintf("%s(%d hidden: %s)", sep, s.count, strings.Join(fields, ", "))
+}
+
+// hasSubtreeLatency reports whether n or any of its descendants has a latency statistic.
+func hasSubtreeLatency(n *renderedNode) bool {
+	for _, node := range collectPreorder(n) {
+		if _, err := node.ExecutionStats.Latency.Duration(); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package plantree
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithHiddenStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		hasStats bool
+		opts     []Option
+		want     []string
+	}{
+		{
+			name:     "max depth",
+			hasStats: true,
+			opts:     []Option{WithMaxDepth(0), WithHiddenStats()},
+			want:     []string{"Serialize Result (2 hidden: 7 rows, 900µs)"},
+		},
+		{
+			name:     "change set",
+			hasStats: true,
+			opts:     []Option{WithChangeSet(map[int32]bool{}), WithHiddenStats()},
+			want:     []string{"Serialize Result (2 hidden: 7 rows, 900µs)"},
+		},
+		{
+			name:     "compact",
+			hasStats: true,
+			opts:     []Option{WithMaxDepth(0), WithHiddenStats(), EnableCompact()},
+			want:     []string{"Serialize Result(2 hidden: 7 rows, 900µs)"},
+		},
+		{
+			name:     "without stats",
+			hasStats: false,
+			opts:     []Option{WithMaxDepth(1), WithHiddenStats()},
+			want:     []string{"Serialize Result", "+- Create Batch (1 hidden)"},
+		},
+		{
+			name:     "disabled",
+			hasStats: true,
+			opts:     []Option{WithMaxDepth(0)},
+			want:     []string{"Serialize Result (2 hidden)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(newBadgeTestPlan(t, tt.hasStats), tt.opts...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rowTexts(rows)); diff != "" {
+				t.Errorf("ProcessPlan() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
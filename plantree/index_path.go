@@ -0,0 +1,24 @@
+package plantree
+
+import "strconv"
+
+// WithIndexPaths sets [RowWithPredicates.IndexPath] to the path of each row from the root,
+// for cross-referencing operators with external tools. Spanner defines no path scheme of its
+// own, so the path is "0" for the root followed by the position of each child link in the
+// ChildLinks of its parent, joined with ".": "0.1.0" is the first child of the second child of
+// the root. Positions count every raw child link, including scalar and hidden ones, so a path
+// depends only on the plan and not on other options, and each occurrence of a node reachable
+// through several parents gets its own path.
+func WithIndexPaths() Option {
+	return func(o *options) {
+		o.indexPaths = true
+	}
+}
+
+// setIndexPaths sets the index path of n to path and those of its descendants below it.
+func setIndexPaths(n *renderedNode, path string) {
+	n.IndexPath = path
+	for _, child := range n.Children {
+		setIndexPaths(child, path+"."+strconv.Itoa(child.childLinkIndex))
+	}
+}
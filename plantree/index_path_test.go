@@ -0,0 +1,84 @@
+package plantree
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func rowIndexPaths(rows []RowWithPredicates) map[int32]string {
+	paths := make(map[int32]string, len(rows))
+	for _, row := range rows {
+		paths[row.ID] = row.IndexPath
+	}
+	return paths
+}
+
+func TestWithIndexPaths(t *testing.T) {
+	qp := decodeDCAPlan(t)
+
+	rows, err := ProcessPlan(qp, append(currentOptions(), WithIndexPaths())...)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	var got []string
+	for _, row := range rows {
+		got = append(got, row.IndexPath)
+	}
+	want := []string{
+		"0", "0.0", "0.0.0", "0.0.0.0", "0.0.0.0.0", "0.0.0.0.0.0", "0.0.0.0.0.0.0",
+		"0.0.1", "0.0.1.0", "0.0.1.0.0", "0.0.1.0.0.0", "0.0.1.0.1", "0.0.1.0.1.0", "0.0.1.0.1.0.0",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("IndexPath mismatch (-want +got):\n%s", diff)
+	}
+
+	// Paths do not depend on the other options.
+	reshaped, err := ProcessPlan(qp, WithIndexPaths(), EnableCompact(), WithWrapWidth(20), WithMaxDepth(3), WithTraversalOrder(PostOrder))
+	if err != nil {
+		t.Fatalf("ProcessPlan(reshaped) error = %v", err)
+	}
+	all := rowIndexPaths(rows)
+	for id, path := range rowIndexPaths(reshaped) {
+		if path != all[id] {
+			t.Errorf("node %d IndexPath = %q with other options, want %q", id, path, all[id])
+		}
+	}
+
+	unset, err := ProcessPlan(qp, currentOptions()...)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	if got := unset[1].IndexPath; got != "" {
+		t.Errorf("IndexPath without WithIndexPaths = %q, want empty", got)
+	}
+}
+
+func TestWithIndexPathsCountsRawChildLinks(t *testing.T) {
+	qp, err := spannerplan.New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 1, Type: "Condition"},
+			{ChildIndex: 2},
+			{ChildIndex: 2},
+		}},
+		{Index: 1, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{Description: "true"}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	rows, err := ProcessPlan(qp, WithIndexPaths())
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	var got []string
+	for _, row := range rows {
+		got = append(got, row.IndexPath)
+	}
+	if diff := cmp.Diff([]string{"0", "0.1", "0.2"}, got); diff != "" {
+		t.Errorf("IndexPath mismatch (-want +got):\n%s", diff)
+	}
+}
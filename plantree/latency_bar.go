@@ -0,0 +1,74 @@
+package plantree
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// WithLatencyBar appends a bar of width cells such as "[▇▇▁▁▁]" to the first line of each row
+// with a latency statistic, showing the share of the operator's self latency relative to the
+// largest self latency in the plan. Self latency is the latency of the operator minus the
+// latencies of its children, or of their nearest descendants with a latency when a child has
+// none, and is floored at zero because parallel children can add up to
+// more than their parent. The bar is part of the node text, so it survives exports that keep
+// only the Operator column.
+//
+// Bars are aligned in a gutter like [WithStatBadges], before the badge when both are enabled,
+// and node titles are wrapped around them with [WithWrapWidth]. A width below 1 makes
+// [ProcessPlan] return an error.
+func WithLatencyBar(width int) Option {
+	return func(o *options) {
+		o.latencyBarWidth = &width
+	}
+}
+
+// setSelfLatencies sets the self latency of n and its descendants. It runs before the tree is
+// pruned, so hidden children still count.
+func setSelfLatencies(n *renderedNode) {
+	if latency, err := n.ExecutionStats.Latency.Duration(); err == nil {
+		for _, child := range n.Children {
+			latency -= subtreeLatency(child)
+		}
+		n.selfLatency = max(latency, 0)
+		n.hasSelfLatency = true
+	}
+	for _, child := range n.Children {
+		setSelfLatencies(child)
+	}
+}
+
+// subtreeLatency returns the latency of n, or, when n has no latency statistic, the sum of the
+// latencies of its nearest descendants that have one.
+func subtreeLatency(n *renderedNode) time.Duration {
+	if latency, err := n.ExecutionStats.Latency.Duration(); err == nil {
+		return latency
+	}
+	var sum time.Duration
+	for _, child := range n.Children {
+		sum += subtreeLatency(child)
+	}
+	return sum
+}
+
+// latencyBars formats the latency bars of nodes, indexed like nodes. Nodes without a latency
+// statistic get "".
+func latencyBars(nodes []*renderedNode, width int) []string {
+	var maxLatency time.Duration
+	for _, node := range nodes {
+		maxLatency = max(maxLatency, node.selfLatency)
+	}
+
+	bars := make([]string, len(nodes))
+	for i, node := range nodes {
+		if !node.hasSelfLatency {
+			continue
+		}
+		var filled int
+		if maxLatency > 0 {
+			filled = int(math.Round(float64(node.selfLatency) / float64(maxLatency) * float64(width)))
+		}
+		bars[i] = "[" + strings.Repeat("▇", filled) + strings.Repeat("▁", width-filled) + "]"
+	}
+	return bars
+}
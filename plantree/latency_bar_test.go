@@ -0,0 +1,119 @@
+package plantree
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func TestWithLatencyBar(t *testing.T) {
+	qp := newBadgeTestPlan(t, true)
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "bar",
+			opts: []Option{WithLatencyBar(5)},
+			want: []string{
+				"Serialize Result                        [▇▇▇▇▇]",
+				"+- Create Batch",
+				"   +- Table Scan Over A Long Table Name [▁▁▁▁▁]",
+			},
+		},
+		{
+			name: "bar and badge",
+			opts: []Option{WithLatencyBar(3), WithStatBadges()},
+			want: []string{
+				"Serialize Result                        [▇▇▇] [120r  1x 12.5ms]",
+				"+- Create Batch",
+				"   +- Table Scan Over A Long Table Name [▁▁▁] [  7r 12x  0.9ms]",
+			},
+		},
+		{
+			name: "wrapped",
+			opts: []Option{WithLatencyBar(4), WithWrapWidth(28)},
+			want: []string{
+				"Serialize Result      [▇▇▇▇]",
+				"+- Create Batch",
+				"   +- Table Scan Over [▁▁▁▁]\n       A Long Table N\n      ame",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(qp, tt.opts...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rowTexts(rows)); diff != "" {
+				t.Errorf("ProcessPlan() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithLatencyBarSelfLatency(t *testing.T) {
+	withLatency := func(node *sppb.PlanNode, latency string) *sppb.PlanNode {
+		s, err := structpb.NewStruct(map[string]any{
+			"latency": map[string]any{"total": latency, "unit": "msecs"},
+		})
+		if err != nil {
+			t.Fatalf("structpb.NewStruct() error = %v", err)
+		}
+		node.ExecutionStats = s
+		return node
+	}
+	qp, err := spannerplan.New([]*sppb.PlanNode{
+		withLatency(&sppb.PlanNode{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Union All", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}, {ChildIndex: 2}}}, "10"),
+		withLatency(&sppb.PlanNode{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan A"}, "4"),
+		withLatency(&sppb.PlanNode{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan B"}, "7"),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// The children add up to more than their parent, so the parent has no self latency.
+	rows, err := ProcessPlan(qp, WithLatencyBar(7))
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	want := []string{
+		"Union All [▁▁▁▁▁▁▁]",
+		"+- Scan A [▇▇▇▇▁▁▁]",
+		"+- Scan B [▇▇▇▇▇▇▇]",
+	}
+	if diff := cmp.Diff(want, rowTexts(rows)); diff != "" {
+		t.Errorf("ProcessPlan(WithLatencyBar) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithLatencyBarWithoutStats(t *testing.T) {
+	qp := newBadgeTestPlan(t, false)
+
+	want, err := ProcessPlan(qp)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	got, err := ProcessPlan(qp, WithLatencyBar(5))
+	if err != nil {
+		t.Fatalf("ProcessPlan(WithLatencyBar) error = %v", err)
+	}
+	if diff := cmp.Diff(rowTexts(want), rowTexts(got)); diff != "" {
+		t.Errorf("ProcessPlan(WithLatencyBar) changed a plan without stats (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithLatencyBarInvalidWidth(t *testing.T) {
+	qp := newBadgeTestPlan(t, true)
+
+	if _, err := ProcessPlan(qp, WithLatencyBar(0)); err == nil {
+		t.Error("ProcessPlan(WithLatencyBar(0)) error = nil, want non-nil")
+	}
+}
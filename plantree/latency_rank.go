@@ -0,0 +1,38 @@
+package plantree
+
+import (
+	"cmp"
+	"slices"
+)
+
+// WithLatencyRank sets [RowWithPredicates.LatencyRank] to the rank of each row by self latency
+// among all operators of the plan, with 1 for the slowest, so a custom column can point at
+// hotspots with "#{{.LatencyRank}}". Self latency is computed as for [WithLatencyBar], and
+// operators hidden by other options still count. Rows with equal self latency share a rank,
+// and the next rank skips the tied ones, as in 1, 2, 2, 4. Rows without a latency statistic
+// get 0.
+func WithLatencyRank() Option {
+	return func(o *options) {
+		o.latencyRank = true
+	}
+}
+
+// setLatencyRanks ranks n and its descendants by self latency. The self latencies must be set.
+func setLatencyRanks(n *renderedNode) {
+	var ranked []*renderedNode
+	for _, node := range collectPreorder(n) {
+		if node.hasSelfLatency {
+			ranked = append(ranked, node)
+		}
+	}
+	slices.SortStableFunc(ranked, func(a, b *renderedNode) int {
+		return cmp.Compare(b.selfLatency, a.selfLatency)
+	})
+	for i, node := range ranked {
+		if i > 0 && node.selfLatency == ranked[i-1].selfLatency {
+			node.LatencyRank = ranked[i-1].LatencyRank
+			continue
+		}
+		node.LatencyRank = i + 1
+	}
+}
@@ -0,0 +1,65 @@
+package plantree
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apstndb/spannerplan"
+)
+
+func TestWithLatencyRank(t *testing.T) {
+	latency := func(index int32, total string, children ...int32) *sppb.PlanNode {
+		node := &sppb.PlanNode{Index: index, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Op"}
+		for _, child := range children {
+			node.ChildLinks = append(node.ChildLinks, &sppb.PlanNode_ChildLink{ChildIndex: child})
+		}
+		if total != "" {
+			s, err := structpb.NewStruct(map[string]any{"latency": map[string]any{"total": total, "unit": "msecs"}})
+			if err != nil {
+				t.Fatalf("structpb.NewStruct() error = %v", err)
+			}
+			node.ExecutionStats = s
+		}
+		return node
+	}
+	tied, err := spannerplan.New([]*sppb.PlanNode{
+		latency(0, "8", 1, 2, 3),
+		latency(1, "3"),
+		latency(2, "3"),
+		latency(3, "1"),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		qp   *spannerplan.QueryPlan
+		opts []Option
+		want []int
+	}{
+		{name: "self latency", qp: newBadgeTestPlan(t, true), opts: []Option{WithLatencyRank()}, want: []int{1, 0, 2}},
+		{name: "ties share a rank", qp: tied, opts: []Option{WithLatencyRank()}, want: []int{3, 1, 1, 3}},
+		{name: "hidden rows count", qp: tied, opts: []Option{WithLatencyRank(), WithMaxDepth(0)}, want: []int{3}},
+		{name: "without stats", qp: newBadgeTestPlan(t, false), opts: []Option{WithLatencyRank()}, want: []int{0, 0, 0}},
+		{name: "disabled", qp: newBadgeTestPlan(t, true), want: []int{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(tt.qp, tt.opts...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			var got []int
+			for _, row := range rows {
+				got = append(got, row.LatencyRank)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("LatencyRank mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package plantree
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NewlineMode controls how [ProcessPlan] renders line breaks embedded in plan text, such as
+// multi-line predicate descriptions or metadata values.
+type NewlineMode string
+
+const (
+	// NewlinePreserve keeps embedded line breaks. Each extra line of a node title becomes its own
+	// visual line aligned under the tree prefix. It is the default.
+	NewlinePreserve NewlineMode = "preserve"
+	// NewlineEscape replaces line breaks, tabs and NUL bytes with the escapes \n, \r, \t and \x00,
+	// so every row and predicate stays on one line and the original text can be recovered.
+	NewlineEscape NewlineMode = "escape"
+	// NewlineJoin collapses each line break and the whitespace around it into a single space.
+	NewlineJoin NewlineMode = "join"
+)
+
+// ParseNewlineMode parses the string form of a [NewlineMode], ignoring case.
+func ParseNewlineMode(s string) (NewlineMode, error) {
+	switch m := NewlineMode(strings.ToLower(s)); m {
+	case NewlinePreserve, NewlineEscape, NewlineJoin:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown newline mode: %q. Must be one of preserve, escape, join", s)
+	}
+}
+
+// WithNewlineMode sets how embedded line breaks in node titles and in the descriptions of
+// [RowWithPredicates.Predicates], PredicateLinks and ScalarChildLinks are rendered.
+// An unknown mode makes [ProcessPlan] return an error.
+func WithNewlineMode(mode NewlineMode) Option {
+	return func(o *options) {
+		o.newlineMode = mode
+	}
+}
+
+var (
+	newlineEscaper = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "\x00", `\x00`)
+	newlineJoinRe  = regexp.MustCompile(`[ \t]*(\r\n|\n|\r)\s*`)
+)
+
+func validateNewlineMode(mode NewlineMode) error {
+	switch mode {
+	case "", NewlinePreserve, NewlineEscape, NewlineJoin:
+		return nil
+	default:
+		return fmt.Errorf("unknown newline mode: %q", mode)
+	}
+}
+
+// apply rewrites s according to mode, which must be valid.
+func (mode NewlineMode) apply(s string) string {
+	switch mode {
+	case NewlineEscape:
+		return newlineEscaper.Replace(s)
+	case NewlineJoin:
+		return newlineJoinRe.ReplaceAllString(s, " ")
+	default:
+		return s
+	}
+}
@@ -0,0 +1,106 @@
+package plantree
+
+import (
+	_ "embed"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apstndb/spannerplan"
+)
+
+//go:embed testdata/multiline/filter.yaml
+var multilineFilterYAML []byte
+
+func decodeMultilinePlan(t *testing.T) *spannerplan.QueryPlan {
+	t.Helper()
+
+	stats, _, err := spannerplan.ExtractQueryPlan(multilineFilterYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := spannerplan.New(stats.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return qp
+}
+
+func TestWithNewlineMode(t *testing.T) {
+	qp := decodeMultilinePlan(t)
+
+	tests := []struct {
+		name           string
+		opts           []Option
+		wantRootText   string
+		wantPredicates []string
+	}{
+		{
+			name:           "default preserves newlines",
+			wantRootText:   "Filter (note: first line\nsecond\tline\x00end)",
+			wantPredicates: []string{"Condition: ($SingerId > 1)\n  AND\t($FirstName IS NOT NULL)"},
+		},
+		{
+			name:           "escape",
+			opts:           []Option{WithNewlineMode(NewlineEscape)},
+			wantRootText:   `Filter (note: first line\nsecond\tline\x00end)`,
+			wantPredicates: []string{`Condition: ($SingerId > 1)\n  AND\t($FirstName IS NOT NULL)`},
+		},
+		{
+			name:           "join",
+			opts:           []Option{WithNewlineMode(NewlineJoin)},
+			wantRootText:   "Filter (note: first line second\tline\x00end)",
+			wantPredicates: []string{"Condition: ($SingerId > 1) AND\t($FirstName IS NOT NULL)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(qp, tt.opts...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if got := rows[0].NodeText; got != tt.wantRootText {
+				t.Errorf("root NodeText = %q, want %q", got, tt.wantRootText)
+			}
+			if diff := cmp.Diff(tt.wantPredicates, rows[0].Predicates); diff != "" {
+				t.Errorf("root Predicates mismatch (-want +got):\n%s", diff)
+			}
+			if got, want := rows[0].PredicateLinks[0].Description, strings.TrimPrefix(tt.wantPredicates[0], "Condition: "); got != want {
+				t.Errorf("root PredicateLinks[0].Description = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if _, err := ProcessPlan(qp, WithNewlineMode("squash")); err == nil {
+		t.Fatal("ProcessPlan(WithNewlineMode(squash)) error = nil, want non-nil")
+	}
+}
+
+func TestProcessPlanWrapsTabsAndNewlines(t *testing.T) {
+	qp := decodeMultilinePlan(t)
+
+	for _, mode := range []NewlineMode{NewlinePreserve, NewlineEscape, NewlineJoin} {
+		for _, width := range []int{0, 8, 12, 40} {
+			rows, err := ProcessPlan(qp, WithNewlineMode(mode), WithWrapWidth(width))
+			if err != nil {
+				t.Fatalf("ProcessPlan(%s, width=%d) error = %v", mode, width, err)
+			}
+			if len(rows) != 2 {
+				t.Fatalf("ProcessPlan(%s, width=%d) returned %d rows, want 2", mode, width, len(rows))
+			}
+			if mode == NewlineEscape && width == 0 && strings.Contains(rows[0].NodeText, "\n") {
+				t.Errorf("ProcessPlan(escape) root NodeText = %q, want a single line", rows[0].NodeText)
+			}
+		}
+	}
+}
+
+func TestParseNewlineMode(t *testing.T) {
+	if got, err := ParseNewlineMode("Escape"); err != nil || got != NewlineEscape {
+		t.Errorf("ParseNewlineMode(Escape) = (%q, %v), want (%q, nil)", got, err, NewlineEscape)
+	}
+	if _, err := ParseNewlineMode("squash"); err == nil {
+		t.Error("ParseNewlineMode(squash) error = nil, want non-nil")
+	}
+}
@@ -0,0 +1,59 @@
+package plantree
+
+import "fmt"
+
+// TraversalOrder controls the order in which [ProcessPlan] returns rows.
+type TraversalOrder string
+
+const (
+	// PreOrder returns each row before the rows of its children, as the tree is rendered. It is
+	// the default.
+	PreOrder TraversalOrder = "pre-order"
+	// PostOrder returns each row after the rows of its children, so subtree rollups can be
+	// computed in a single pass.
+	PostOrder TraversalOrder = "post-order"
+)
+
+// WithTraversalOrder sets the order of the rows returned by [ProcessPlan]. The rows themselves,
+// including their tree prefixes, are rendered in pre-order and do not depend on the order.
+// An unknown order makes ProcessPlan return an error.
+func WithTraversalOrder(order TraversalOrder) Option {
+	return func(o *options) {
+		o.traversalOrder = order
+	}
+}
+
+func validateTraversalOrder(order TraversalOrder) error {
+	switch order {
+	case "", PreOrder, PostOrder:
+		return nil
+	default:
+		return fmt.Errorf("unknown traversal order: %q", order)
+	}
+}
+
+// rowOrder returns the positions of nodes, the pre-order traversal of root, in the order
+// requested by order, which must be valid.
+func rowOrder(root *renderedNode, nodes []*renderedNode, order TraversalOrder) []int {
+	positions := make([]int, 0, len(nodes))
+	if order != PostOrder || root == nil {
+		for i := range nodes {
+			positions = append(positions, i)
+		}
+		return positions
+	}
+
+	// Children follow their parent in pre-order, so the position of a node is the position of
+	// its parent plus one plus the sizes of the subtrees of its preceding siblings.
+	var walk func(n *renderedNode, position int) int
+	walk = func(n *renderedNode, position int) int {
+		next := position + 1
+		for _, child := range n.Children {
+			next = walk(child, next)
+		}
+		positions = append(positions, position)
+		return next
+	}
+	walk(root, 0)
+	return positions
+}
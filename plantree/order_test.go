@@ -0,0 +1,60 @@
+package plantree
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithTraversalOrder(t *testing.T) {
+	qp := decodeDCAPlan(t)
+
+	preOrder, err := ProcessPlan(qp)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		order TraversalOrder
+		want  []int32
+	}{
+		{name: "default", want: rowIDs(preOrder)},
+		{name: "pre-order", order: PreOrder, want: rowIDs(preOrder)},
+		{name: "post-order", order: PostOrder, want: []int32{6, 5, 4, 3, 2, 25, 24, 31, 30, 29, 23, 22, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(qp, WithTraversalOrder(tt.order))
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rowIDs(rows)); diff != "" {
+				t.Errorf("row IDs mismatch (-want +got):\n%s", diff)
+			}
+
+			// Rows are only reordered, so each keeps the tree prefix of its pre-order rendering.
+			texts := make(map[int32]string, len(preOrder))
+			for _, row := range preOrder {
+				texts[row.ID] = row.Text()
+			}
+			for _, row := range rows {
+				if row.Text() != texts[row.ID] {
+					t.Errorf("row %d text = %q, want %q", row.ID, row.Text(), texts[row.ID])
+				}
+			}
+		})
+	}
+
+	if _, err := ProcessPlan(qp, WithTraversalOrder("in-order")); err == nil {
+		t.Error("ProcessPlan(unknown order) error = nil, want non-nil")
+	}
+}
+
+func rowIDs(rows []RowWithPredicates) []int32 {
+	ids := make([]int32, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return ids
+}
@@ -1,10 +1,14 @@
 package plantree
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
 	"github.com/apstndb/go-tabwrap"
@@ -19,15 +23,15 @@ var defaultWrapCondition = newDefaultWrapCondition()
 
 const (
 	// MaxPlantreeDepth counts the root as depth zero. This conservative
-	// first-alpha renderer budget bounds recursive tree construction, row
-	// collection, and rendering passes even when a plan contains a deep DAG.
+	// first-alpha renderer budget bounds tree construction and the recursive
+	// row collection and rendering passes even when a plan contains a deep DAG.
 	// It may be raised non-breakingly when real capture evidence requires it.
-	MaxPlantreeDepth = 256
+	MaxPlantreeDepth = spannerplan.MaxTraversalDepth
 	// MaxPlantreeOccurrences bounds visible node occurrences, rather than
 	// unique PlanNode indexes, because a DAG can expand exponentially when it
 	// is rendered as a tree. This conservative first-alpha renderer budget may
 	// be raised non-breakingly when real capture evidence requires it.
-	MaxPlantreeOccurrences = 4096
+	MaxPlantreeOccurrences = spannerplan.MaxTraversalOccurrences
 )
 
 // ErrTraversalLimitExceeded identifies a plan whose visible rendered tree
@@ -107,10 +111,39 @@ type RowWithPredicates struct {
 	DisplayName string
 	// Predicates contains filter predicate text associated with this row.
 	Predicates []string
+	// PredicateLinks contains the structured form of Predicates, in the same order.
+	PredicateLinks []ScalarChildLink
 	// ExecutionStats contains execution statistics associated with this row.
 	ExecutionStats stats.ExecutionStats
+	// StatsMap contains the raw execution statistics of this row as strings keyed by their Spanner
+	// names, as returned by [stats.ExtractStrings]. Templates can read statistics that
+	// ExecutionStats does not model, such as {{index .StatsMap "cpu_time"}}. It is nil without stats.
+	StatsMap map[string]string
+	// EstimatedRows is the optimizer's estimated row count from the estimated_rows metadata,
+	// or empty when the plan does not carry estimates.
+	EstimatedRows string
 	// ScalarChildLinks contains this row's scalar child links in original PlanNode.ChildLinks order.
 	ScalarChildLinks []ScalarChildLink
+	// DisplayIDOffset is added to ID when the row is displayed. It is set by [WithDisplayIDOffset];
+	// use [RowWithPredicates.DisplayID] rather than reading it directly.
+	DisplayIDOffset int32
+	// Changed reports whether the PlanNode of this row is marked in the [WithChangeSet] set or
+	// selected by [WithFilterPreset].
+	// Rows kept only because they lead to a changed row have Changed false, so renderers can dim them.
+	Changed bool
+	// LatencyRank is the rank of this row by self latency, 1 for the slowest. It is set by
+	// [WithLatencyRank], and 0 for rows without a latency statistic.
+	LatencyRank int
+	// RootLatency is the latency statistic of the root operator of the plan. It is set by
+	// [WithRootLatency], and empty otherwise.
+	RootLatency stats.ExecutionStatsValue
+	// IndexPath locates this row by the positions of the child links from the root, such as
+	// "0.1.0". It is set by [WithIndexPaths], and empty otherwise.
+	IndexPath string
+	// Node is the raw PlanNode for this row. It is populated only when [IncludePlanNode] is set.
+	// It points into the plan passed to [ProcessPlan], so mutating it also mutates that plan;
+	// use proto.Clone before modifying it.
+	Node *sppb.PlanNode
 }
 
 // ScalarChildLink is a scalar child link attached to a rendered plan row.
@@ -138,9 +171,24 @@ type renderedNode struct {
 	NodeText           string
 	DisplayName        string
 	Predicates         []string
+	PredicateLinks     []ScalarChildLink
 	ExecutionStats     stats.ExecutionStats
+	StatsMap           map[string]string
+	EstimatedRows      string
 	ScalarChildLinks   []ScalarChildLink
+	Changed            bool
+	LatencyRank        int
+	IndexPath          string
+	Node               *sppb.PlanNode
 	Children           []*renderedNode
+	// selfLatency is set with hasSelfLatency only for [WithLatencyBar] and [WithLatencyRank].
+	selfLatency    time.Duration
+	hasSelfLatency bool
+	// childLinkIndex is the position of the link to this node in the ChildLinks of its parent,
+	// or -1 for the root.
+	childLinkIndex int
+	// localSignature is the structural signature of this node alone, set only for [WithDedupeSubtrees].
+	localSignature string
 }
 
 // Text returns the full rendered row text, with the tree prefix prepended to each node text line.
@@ -159,17 +207,59 @@ func (r RowWithPredicates) TreePartLines() []string {
 	return treerender.Row{TreePart: r.TreePartString()}.TreePartLines()
 }
 
+// DisplayID returns the ID shown to readers: ID shifted by [WithDisplayIDOffset], if any.
+// Use ID, not DisplayID, to look up PlanNodes.
+func (r RowWithPredicates) DisplayID() int32 {
+	return r.ID + r.DisplayIDOffset
+}
+
 // FormatID returns the display ID, prefixed with "*" when the row has predicates.
 func (r RowWithPredicates) FormatID() string {
-	return lo.Ternary(len(r.Predicates) != 0, "*", "") + strconv.Itoa(int(r.ID))
+	return lo.Ternary(len(r.Predicates) != 0, "*", "") + strconv.Itoa(int(r.DisplayID()))
+}
+
+// RowsEstimateRatio returns actual rows divided by estimated rows, for spotting cardinality misestimates.
+// It reports false when either count is missing or the estimate is zero.
+func (r RowWithPredicates) RowsEstimateRatio() (float64, bool) {
+	if r.EstimatedRows == "" {
+		return 0, false
+	}
+	estimated, err := strconv.ParseFloat(r.EstimatedRows, 64)
+	if err != nil || estimated == 0 {
+		return 0, false
+	}
+	actual, err := r.ExecutionStats.Rows.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return actual / estimated, true
 }
 
 type options struct {
 	disallowUnknownStats bool
+	statsErrorHandler    func(node *sppb.PlanNode, err error)
+	includePlanNode      bool
 	queryplanOptions     []spannerplan.Option
 	style                treerender.Style
 	compact              bool
 	hangingIndent        bool
+	unicodeEdges         bool
+	noInputSynthesis     bool
+	maxDepth             *int
+	hiddenStats          bool
+	dedupeSubtrees       bool
+	displayIDOffset      int32
+	rowTransforms        []func(RowWithPredicates) RowWithPredicates
+	newlineMode          NewlineMode
+	traversalOrder       TraversalOrder
+	changeSet            map[int32]bool
+	filterPreset         FilterPreset
+	annotations          map[int32]string
+	statBadges           bool
+	latencyBarWidth      *int
+	latencyRank          bool
+	rootLatency          bool
+	indexPaths           bool
 	wrapWidth            *int
 	wrapper              *tabwrap.Condition
 }
@@ -184,6 +274,26 @@ func DisallowUnknownStats() Option {
 	}
 }
 
+// WithStatsErrorHandler makes [ProcessPlan] continue when the execution stats of a node cannot
+// be extracted, such as when they are malformed or, with [DisallowUnknownStats], contain an
+// unknown key. The row of the node gets an empty [RowWithPredicates.ExecutionStats], while
+// [RowWithPredicates.StatsMap] keeps the raw values, and handler is called with the node and the
+// error. Without it, the first such error fails ProcessPlan.
+func WithStatsErrorHandler(handler func(node *sppb.PlanNode, err error)) Option {
+	return func(o *options) {
+		o.statsErrorHandler = handler
+	}
+}
+
+// IncludePlanNode makes [ProcessPlan] populate [RowWithPredicates.Node] with the raw PlanNode,
+// so callers and custom templates can read metadata that rows do not otherwise surface.
+// It is opt-in because the field shares memory with the input plan.
+func IncludePlanNode() Option {
+	return func(o *options) {
+		o.includePlanNode = true
+	}
+}
+
 // WithQueryPlanOptions forwards node-title formatting options to the underlying query plan renderer.
 func WithQueryPlanOptions(opts ...spannerplan.Option) Option {
 	return func(o *options) {
@@ -218,6 +328,109 @@ func WithHangingIndent() Option {
 	}
 }
 
+// WithUnicodeEdges draws tree edges with Unicode box-drawing characters instead of ASCII.
+// It combines with [EnableCompact] regardless of option order.
+func WithUnicodeEdges() Option {
+	return func(o *options) {
+		o.unicodeEdges = true
+	}
+}
+
+// WithApplyInputSynthesis sets whether rows label the untyped first child of an Apply operator
+// as "[Input]", as [spannerplan.QueryPlan.LinkTypeInParent] does to match the Spanner operator
+// documentation. It is enabled by default; disabling it shows only the link types present in
+// the plan, for comparison with the raw plan or with tools that do not synthesize Input.
+func WithApplyInputSynthesis(enabled bool) Option {
+	return func(o *options) {
+		o.noInputSynthesis = !enabled
+	}
+}
+
+// WithMaxDepth hides nodes deeper than depth, counting the root as depth zero.
+// A node at the depth limit whose descendants are hidden gets a "(N hidden)" suffix,
+// where N counts the hidden node occurrences. Negative values make [ProcessPlan] return an error.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		o.maxDepth = &depth
+	}
+}
+
+// WithDedupeSubtrees renders each later occurrence of a subtree that is structurally identical
+// to an earlier one as a single row with a "(see node N)" suffix, where N is the ID of the
+// first occurrence in preorder. Repeated subtrees are compared with the same fields as
+// [StructuralSignature], so execution statistics never prevent a match.
+//
+// References are display-only: the reference row keeps its own ID and its own execution
+// statistics, while the rows of its hidden descendants are omitted. Leaf nodes are never replaced.
+func WithDedupeSubtrees() Option {
+	return func(o *options) {
+		o.dedupeSubtrees = true
+	}
+}
+
+// WithDisplayIDOffset shifts the IDs shown for rows by n, for readers who cross-reference
+// documentation that numbers operators from 1. It changes only presentation: [RowWithPredicates.ID]
+// and every link stay PlanNode indexes, while [RowWithPredicates.FormatID],
+// [RowWithPredicates.DisplayID] and the "(see node N)" references of [WithDedupeSubtrees] use the
+// shifted value. Negative values make [ProcessPlan] return an error.
+func WithDisplayIDOffset(n int32) Option {
+	return func(o *options) {
+		o.displayIDOffset = n
+	}
+}
+
+// WithOneBasedIDs is shorthand for WithDisplayIDOffset(1).
+func WithOneBasedIDs() Option {
+	return WithDisplayIDOffset(1)
+}
+
+// WithChangeSet renders a review view of the nodes whose PlanNode indexes are true in changed,
+// such as the result of comparing two plans. Only changed rows and the rows on the paths from
+// the root to them are kept, so each change is shown in its context. A kept row whose unchanged
+// descendants are omitted gets a "(N hidden)" suffix, as with [WithMaxDepth], and
+// [RowWithPredicates.Changed] tells changed rows from their ancestors. The root row is always
+// kept, even when nothing changed. A nil map disables the option. It replaces an earlier
+// [WithFilterPreset], and a later one replaces it.
+func WithChangeSet(changed map[int32]bool) Option {
+	return func(o *options) {
+		o.changeSet = changed
+		o.filterPreset = ""
+	}
+}
+
+// WithAnnotations adds external text, such as review comments or estimates from another tool,
+// to the rows of the nodes whose PlanNode indexes are keys of annotations. Each annotation is
+// rendered on its own line below the node title as "-- text", or "--text" with [EnableCompact],
+// and goes through [WithNewlineMode] and [WithWrapWidth] like the title. Nodes without a key, or
+// with an empty annotation, render as usual. A nil map disables the option.
+func WithAnnotations(annotations map[int32]string) Option {
+	return func(o *options) {
+		o.annotations = annotations
+	}
+}
+
+// DroppedRowID is the ID a [WithRowTransform] function sets on its result to drop the row.
+// PlanNode indexes are never negative, so it cannot collide with a real row.
+const DroppedRowID int32 = -1
+
+// WithRowTransform calls transform on each row after it is rendered and before it is collected,
+// so callers can rewrite text, annotate rows, or drop them by returning a row whose ID is
+// [DroppedRowID]. Multiple transforms run in option order, and a dropped row is not passed
+// to later transforms.
+//
+// Transforms run after the tree layout is fixed, so they cannot reorder rows and do not
+// redraw tree prefixes: dropping a row whose descendants are kept leaves those descendants
+// with edges to a missing parent. Drop whole subtrees, or prefer [WithMaxDepth] or
+// [WithDedupeSubtrees], to keep the tree intact. A transformed row must keep the same number
+// of lines in NodeText and TreePart, or [ProcessPlan] returns an error.
+func WithRowTransform(transform func(RowWithPredicates) RowWithPredicates) Option {
+	return func(o *options) {
+		if transform != nil {
+			o.rowTransforms = append(o.rowTransforms, transform)
+		}
+	}
+}
+
 // ProcessPlan converts a query plan into rendered tree rows with predicate and execution metadata.
 func ProcessPlan(qp *spannerplan.QueryPlan, opts ...Option) (rows []RowWithPredicates, err error) {
 	o := options{
@@ -236,7 +449,35 @@ func ProcessPlan(qp *spannerplan.QueryPlan, opts ...Option) (rows []RowWithPredi
 	if o.wrapWidth != nil && *o.wrapWidth < 0 {
 		return nil, fmt.Errorf("wrap width cannot be negative: %d", *o.wrapWidth)
 	}
-	root, err := buildRenderedTree(qp, nil, -1, &o, make(map[int32]struct{}), &traversalState{})
+	if o.maxDepth != nil && *o.maxDepth < 0 {
+		return nil, fmt.Errorf("max depth cannot be negative: %d", *o.maxDepth)
+	}
+	if o.displayIDOffset < 0 {
+		return nil, fmt.Errorf("display ID offset cannot be negative: %d", o.displayIDOffset)
+	}
+	if o.latencyBarWidth != nil && *o.latencyBarWidth < 1 {
+		return nil, fmt.Errorf("latency bar width must be positive: %d", *o.latencyBarWidth)
+	}
+	if err := validateNewlineMode(o.newlineMode); err != nil {
+		return nil, err
+	}
+	if err := validateTraversalOrder(o.traversalOrder); err != nil {
+		return nil, err
+	}
+	if err := validateFilterPreset(o.filterPreset); err != nil {
+		return nil, err
+	}
+	if o.filterPreset != "" {
+		selected, err := filterPresetNodes(qp, o.filterPreset)
+		if err != nil {
+			return nil, err
+		}
+		o.changeSet = selected
+	}
+	if o.unicodeEdges {
+		o.style = lo.Ternary(o.compact, treerender.CompactUnicodeStyle(), treerender.UnicodeStyle())
+	}
+	root, err := buildRenderedTree(qp, &o, make(map[int32]struct{}), &traversalState{})
 	if err != nil {
 		if errors.Is(err, ErrTraversalLimitExceeded) {
 			return nil, err
@@ -246,11 +487,42 @@ func ProcessPlan(qp *spannerplan.QueryPlan, opts ...Option) (rows []RowWithPredi
 	if root == nil {
 		return nil, nil
 	}
+	if o.latencyBarWidth != nil || o.latencyRank {
+		setSelfLatencies(root)
+	}
+	if o.latencyRank {
+		setLatencyRanks(root)
+	}
+	if o.indexPaths {
+		setIndexPaths(root, "0")
+	}
+	var rootLatency stats.ExecutionStatsValue
+	if o.rootLatency {
+		rootLatency = root.ExecutionStats.Latency
+	}
+	if o.changeSet != nil {
+		pruneToChangeSet(root, o.changeSet, lo.Ternary(!o.compact, " ", ""), o.hiddenStats)
+	}
+	if o.dedupeSubtrees {
+		dedupeSubtrees(root, lo.Ternary(!o.compact, " ", ""), o.displayIDOffset)
+	}
+	if o.maxDepth != nil {
+		collapseBelowDepth(root, *o.maxDepth, lo.Ternary(!o.compact, " ", ""), o.hiddenStats)
+	}
 
 	wrapWidth := 0
 	if o.wrapWidth != nil {
 		wrapWidth = *o.wrapWidth
 	}
+	nodes := collectPreorder(root)
+	var gutter []string
+	if o.statBadges || o.latencyBarWidth != nil {
+		var gutterWidth int
+		gutter, gutterWidth = gutterCells(nodes, &o)
+		if wrapWidth > 0 && gutterWidth > 0 {
+			wrapWidth = max(1, wrapWidth-gutterWidth-1)
+		}
+	}
 	renderRows, err := treerender.RenderTreeWithOptions(root, o.style,
 		func(n *renderedNode) string { return n.NodeText },
 		func(n *renderedNode) []*renderedNode { return n.Children },
@@ -264,50 +536,94 @@ func ProcessPlan(qp *spannerplan.QueryPlan, opts ...Option) (rows []RowWithPredi
 	if err != nil {
 		return nil, fmt.Errorf("failed to render tree rows: %w", err)
 	}
-	nodes := collectPreorder(root)
 	if len(renderRows) != len(nodes) {
 		return nil, fmt.Errorf("unexpected rendered row count: got=%d want=%d", len(renderRows), len(nodes))
 	}
+	if gutter != nil {
+		appendGutter(renderRows, gutter, o.wrapper)
+	}
 
 	result := make([]RowWithPredicates, 0, len(nodes))
-	for i, node := range nodes {
+	for _, i := range rowOrder(root, nodes, o.traversalOrder) {
+		node := nodes[i]
 		row := renderRows[i]
-		gotLines := strings.Count(row.NodeText, "\n") + 1
-		if wantTreeLines := strings.Count(row.TreePart, "\n") + 1; gotLines != wantTreeLines {
-			return nil, fmt.Errorf("unexpected rendered row line count for node %d: tree=%d node=%d", node.ID, wantTreeLines, gotLines)
+		if err := checkRowLines(node.ID, row.TreePart, row.NodeText); err != nil {
+			return nil, fmt.Errorf("unexpected rendered row line count: %w", err)
 		}
-		result = append(result, RowWithPredicates{
+		resultRow := RowWithPredicates{
 			ID:               node.ID,
 			DisplayName:      node.DisplayName,
 			Predicates:       node.Predicates,
+			PredicateLinks:   node.PredicateLinks,
 			ScalarChildLinks: node.ScalarChildLinks,
 			TreePart:         row.TreePart,
 			NodeText:         row.NodeText,
 			ExecutionStats:   node.ExecutionStats,
-		})
+			StatsMap:         node.StatsMap,
+			EstimatedRows:    node.EstimatedRows,
+			DisplayIDOffset:  o.displayIDOffset,
+			Changed:          node.Changed,
+			LatencyRank:      node.LatencyRank,
+			RootLatency:      rootLatency,
+			IndexPath:        node.IndexPath,
+			Node:             node.Node,
+		}
+		if len(o.rowTransforms) > 0 {
+			var keep bool
+			if resultRow, keep = applyRowTransforms(resultRow, o.rowTransforms); !keep {
+				continue
+			}
+			if err := checkRowLines(node.ID, resultRow.TreePart, resultRow.NodeText); err != nil {
+				return nil, fmt.Errorf("row transform changed line count: %w", err)
+			}
+		}
+		result = append(result, resultRow)
 	}
 
 	return result, nil
 }
 
-func buildRenderedTree(
+// applyRowTransforms runs transforms in order and reports false once one of them drops the row.
+func applyRowTransforms(row RowWithPredicates, transforms []func(RowWithPredicates) RowWithPredicates) (RowWithPredicates, bool) {
+	for _, transform := range transforms {
+		row = transform(row)
+		if row.ID == DroppedRowID {
+			return RowWithPredicates{}, false
+		}
+	}
+	return row, true
+}
+
+// checkRowLines returns an error when treePart and nodeText of the row for node id span different numbers of lines.
+func checkRowLines(id int32, treePart, nodeText string) error {
+	gotLines := strings.Count(nodeText, "\n") + 1
+	if wantTreeLines := strings.Count(treePart, "\n") + 1; gotLines != wantTreeLines {
+		return fmt.Errorf("node %d: tree=%d node=%d", id, wantTreeLines, gotLines)
+	}
+	return nil
+}
+
+// renderTreeNode renders the node at childLinkIndex of the ChildLinks of parent, or the root when
+// parent is nil, without its children, and adds it to ancestors. It returns the rendered node and
+// its PlanNode, or nils when the link is not visible.
+func renderTreeNode(
 	qp *spannerplan.QueryPlan,
 	parent *sppb.PlanNode,
 	childLinkIndex int,
 	opts *options,
 	ancestors map[int32]struct{},
 	state *traversalState,
-) (*renderedNode, error) {
+) (*renderedNode, *sppb.PlanNode, error) {
 	var link *sppb.PlanNode_ChildLink
 	if parent != nil {
 		childLinks := parent.GetChildLinks()
 		if childLinkIndex < 0 || childLinkIndex >= len(childLinks) {
-			return nil, fmt.Errorf("child link index out of range: parent node %d childLinks[%d]", parent.GetIndex(), childLinkIndex)
+			return nil, nil, fmt.Errorf("child link index out of range: parent node %d childLinks[%d]", parent.GetIndex(), childLinkIndex)
 		}
 		link = childLinks[childLinkIndex]
 	}
 	if !qp.IsVisible(link) {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	sep := lo.Ternary(!opts.compact, " ", "")
@@ -316,17 +632,17 @@ func buildRenderedTree(
 	if node == nil {
 		// spannerplan.New rejects nil nodes and out-of-range child links; keep
 		// this guard so ProcessPlan still fails cleanly if that invariant changes.
-		return nil, fmt.Errorf("plan node not found for link: %v", link)
+		return nil, nil, fmt.Errorf("plan node not found for link: %v", link)
 	}
 	if node.GetIndex() < 0 {
-		return nil, fmt.Errorf("plan node index cannot be negative: %d", node.GetIndex())
+		return nil, nil, fmt.Errorf("plan node index cannot be negative: %d", node.GetIndex())
 	}
 	if _, ok := ancestors[node.GetIndex()]; ok {
-		return nil, fmt.Errorf("cycle detected at PlanNode index %d", node.GetIndex())
+		return nil, nil, fmt.Errorf("cycle detected at PlanNode index %d", node.GetIndex())
 	}
 	depth := len(ancestors)
 	if depth > MaxPlantreeDepth {
-		return nil, &TraversalLimitError{
+		return nil, nil, &TraversalLimitError{
 			Kind:      TraversalLimitDepth,
 			Limit:     MaxPlantreeDepth,
 			Observed:  depth,
@@ -334,7 +650,7 @@ func buildRenderedTree(
 		}
 	}
 	if state.occurrences >= MaxPlantreeOccurrences {
-		return nil, &TraversalLimitError{
+		return nil, nil, &TraversalLimitError{
 			Kind:      TraversalLimitOccurrences,
 			Limit:     MaxPlantreeOccurrences,
 			Observed:  state.occurrences + 1,
@@ -343,20 +659,33 @@ func buildRenderedTree(
 	}
 	state.occurrences++
 	ancestors[node.GetIndex()] = struct{}{}
-	defer delete(ancestors, node.GetIndex())
 	linkType := qp.LinkTypeInParent(parent, childLinkIndex)
+	if opts.noInputSynthesis {
+		linkType = link.GetType()
+	}
 	continuationAnchor := lo.Ternary(linkType != "", "["+linkType+"]"+sep, "")
-	nodeText := continuationAnchor + spannerplan.NodeTitle(node, opts.queryplanOptions...)
+	nodeText := continuationAnchor + opts.newlineMode.apply(spannerplan.NodeTitle(node, opts.queryplanOptions...))
+	if annotation := opts.annotations[node.GetIndex()]; annotation != "" {
+		nodeText += "\n--" + sep + opts.newlineMode.apply(annotation)
+	}
 
 	var predicates []string
+	var predicateLinks []ScalarChildLink
 	for _, cl := range node.GetChildLinks() {
 		if !qp.IsPredicate(cl) {
 			continue
 		}
 
-		predicates = append(predicates, fmt.Sprintf("%s: %s",
-			cl.GetType(),
-			qp.GetNodeByChildLink(cl).GetShortRepresentation().GetDescription()))
+		child := qp.GetNodeByChildLink(cl)
+		description := opts.newlineMode.apply(child.GetShortRepresentation().GetDescription())
+		predicates = append(predicates, fmt.Sprintf("%s: %s", cl.GetType(), description))
+		predicateLinks = append(predicateLinks, ScalarChildLink{
+			Type:        cl.GetType(),
+			Variable:    cl.GetVariable(),
+			Description: description,
+			DisplayName: child.GetDisplayName(),
+			ChildIndex:  child.GetIndex(),
+		})
 	}
 
 	resolvedChildLinks := lo.Map(node.GetChildLinks(), func(item *sppb.PlanNode_ChildLink, _ int) *spannerplan.ResolvedChildLink {
@@ -371,7 +700,7 @@ func buildRenderedTree(
 		return ScalarChildLink{
 			Type:        item.ChildLink.GetType(),
 			Variable:    item.ChildLink.GetVariable(),
-			Description: item.Child.GetShortRepresentation().GetDescription(),
+			Description: opts.newlineMode.apply(item.Child.GetShortRepresentation().GetDescription()),
 			DisplayName: item.Child.GetDisplayName(),
 			ChildIndex:  item.Child.GetIndex(),
 		}
@@ -379,8 +708,13 @@ func buildRenderedTree(
 
 	executionStats, err := stats.Extract(node, opts.disallowUnknownStats)
 	if err != nil {
-		return nil, err
+		if opts.statsErrorHandler == nil {
+			return nil, nil, err
+		}
+		opts.statsErrorHandler(node, err)
+		executionStats = &stats.ExecutionStats{}
 	}
+	estimatedRows, _ := spannerplan.EstimatedRows(node)
 
 	rendered := &renderedNode{
 		ID:                 node.GetIndex(),
@@ -388,26 +722,83 @@ func buildRenderedTree(
 		NodeText:           nodeText,
 		DisplayName:        node.GetDisplayName(),
 		Predicates:         predicates,
+		PredicateLinks:     predicateLinks,
 		ExecutionStats:     *executionStats,
+		StatsMap:           stats.ExtractStrings(node),
+		EstimatedRows:      estimatedRows,
 		ScalarChildLinks:   renderedScalarChildLinks,
+		childLinkIndex:     childLinkIndex,
+	}
+	if opts.includePlanNode {
+		rendered.Node = node
+	}
+	if opts.dedupeSubtrees {
+		rendered.localSignature, err = localSignature(qp, node)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return rendered, node, nil
+}
+
+// buildFrame is a node of buildRenderedTree whose children are being built.
+type buildFrame struct {
+	node     *sppb.PlanNode
+	rendered *renderedNode
+	// next is the position in the ChildLinks of node of the next child to build.
+	next int
+}
+
+// buildRenderedTree builds the rendered tree below the root of qp in pre-order. It keeps the
+// nodes being built on an explicit stack rather than recursing per child link, so the depth of a
+// plan never grows the goroutine stack; MaxPlantreeDepth still bounds the depth of the tree.
+func buildRenderedTree(qp *spannerplan.QueryPlan, opts *options, ancestors map[int32]struct{}, state *traversalState) (*renderedNode, error) {
+	root, rootNode, err := renderTreeNode(qp, nil, -1, opts, ancestors, state)
+	if err != nil || root == nil {
+		return nil, err
 	}
 
-	for childIndex, child := range node.GetChildLinks() {
-		if !qp.IsVisible(child) {
+	stack := []buildFrame{{node: rootNode, rendered: root}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		childLinks := top.node.GetChildLinks()
+		for top.next < len(childLinks) && !qp.IsVisible(childLinks[top.next]) {
+			top.next++
+		}
+		if top.next == len(childLinks) {
+			delete(ancestors, top.node.GetIndex())
+			stack = stack[:len(stack)-1]
 			continue
 		}
-		renderedChild, err := buildRenderedTree(qp, node, childIndex, opts, ancestors, state)
+
+		childIndex := top.next
+		top.next++
+		child, childNode, err := renderTreeNode(qp, top.node, childIndex, opts, ancestors, state)
 		if err != nil {
-			if errors.Is(err, ErrTraversalLimitExceeded) {
-				return nil, err
-			}
-			return nil, fmt.Errorf("buildRenderedTree failed on child link %v: %w", child, err)
+			return nil, wrapBuildError(stack, err)
 		}
-		if renderedChild != nil {
-			rendered.Children = append(rendered.Children, renderedChild)
+		if child == nil {
+			continue
 		}
+		top.rendered.Children = append(top.rendered.Children, child)
+		stack = append(stack, buildFrame{node: childNode, rendered: child})
 	}
-	return rendered, nil
+	return root, nil
+}
+
+// wrapBuildError wraps err, returned for the child being built below the top of stack, with the
+// child link being built at each level from the top down, as one recursive call per child link
+// would. Traversal limit errors are returned unwrapped.
+func wrapBuildError(stack []buildFrame, err error) error {
+	if errors.Is(err, ErrTraversalLimitExceeded) {
+		return err
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		frame := stack[i]
+		err = fmt.Errorf("buildRenderedTree failed on child link %v: %w", frame.node.GetChildLinks()[frame.next-1], err)
+	}
+	return err
 }
 
 func mapHangingIndent(enabled bool) treerender.ContinuationIndent {
@@ -417,6 +808,86 @@ func mapHangingIndent(enabled bool) treerender.ContinuationIndent {
 	return treerender.ContinuationIndentTree
 }
 
+// pruneToChangeSet marks the changed nodes of the subtree rooted at n, drops the subtrees that
+// contain none of them, and notes on each kept node how many descendants were dropped.
+// It reports whether the subtree contains a changed node.
+func pruneToChangeSet(n *renderedNode, changed map[int32]bool, sep string, withStats bool) bool {
+	n.Changed = changed[n.ID]
+	kept := n.Children[:0]
+	var hidden hiddenSummary
+	for _, child := range n.Children {
+		var summary hiddenSummary
+		summary.add(child)
+		if pruneToChangeSet(child, changed, sep, withStats) {
+			kept = append(kept, child)
+			continue
+		}
+		hidden.merge(summary)
+	}
+	n.Children = kept
+	n.NodeText += hidden.suffix(sep, withStats)
+	return n.Changed || len(kept) > 0
+}
+
+// collapseBelowDepth drops the descendants of nodes at maxDepth and notes how many were hidden.
+func collapseBelowDepth(n *renderedNode, maxDepth int, sep string, withStats bool) {
+	if maxDepth > 0 {
+		for _, child := range n.Children {
+			collapseBelowDepth(child, maxDepth-1, sep, withStats)
+		}
+		return
+	}
+	var hidden hiddenSummary
+	for _, child := range n.Children {
+		hidden.add(child)
+	}
+	n.Children = nil
+	n.NodeText += hidden.suffix(sep, withStats)
+}
+
+// dedupeSubtrees replaces every subtree whose fingerprint matches an earlier subtree in preorder
+// with a reference to the earlier subtree's root.
+func dedupeSubtrees(root *renderedNode, sep string, idOffset int32) {
+	fingerprints := make(map[*renderedNode]string)
+	subtreeFingerprint(root, fingerprints)
+
+	firstIDs := make(map[string]int32)
+	var walk func(*renderedNode)
+	walk = func(n *renderedNode) {
+		if len(n.Children) == 0 {
+			return
+		}
+		fingerprint := fingerprints[n]
+		if id, ok := firstIDs[fingerprint]; ok {
+			n.Children = nil
+			n.NodeText += fmt.Sprintf("%s(see node %d)", sep, id)
+			return
+		}
+		firstIDs[fingerprint] = n.ID + idOffset
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+}
+
+// subtreeFingerprint hashes the local signatures of n and its descendants, together with the
+// link types of the descendants. The link type of n itself is excluded, so the same subtree
+// matches under different parents.
+func subtreeFingerprint(n *renderedNode, fingerprints map[*renderedNode]string) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, n.localSignature)
+	for _, child := range n.Children {
+		var b strings.Builder
+		appendSignatureString(&b, child.ContinuationAnchor)
+		appendSignatureString(&b, subtreeFingerprint(child, fingerprints))
+		_, _ = io.WriteString(h, b.String())
+	}
+	fingerprint := hex.EncodeToString(h.Sum(nil))
+	fingerprints[n] = fingerprint
+	return fingerprint
+}
+
 func collectPreorder(root *renderedNode) []*renderedNode {
 	var nodes []*renderedNode
 	var walk func(*renderedNode)
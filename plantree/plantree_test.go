@@ -444,6 +444,71 @@ func TestProcessPlan_TraversalLimits(t *testing.T) {
 	}
 }
 
+// chainPlanNodes returns n relational nodes named displayName, each the only child of the one before.
+func chainPlanNodes(n int, displayName string) []*sppb.PlanNode {
+	nodes := make([]*sppb.PlanNode, n)
+	for i := range nodes {
+		nodes[i] = &sppb.PlanNode{Index: int32(i), DisplayName: displayName, Kind: sppb.PlanNode_RELATIONAL}
+		if i+1 < n {
+			nodes[i].ChildLinks = []*sppb.PlanNode_ChildLink{{ChildIndex: int32(i + 1)}}
+		}
+	}
+	return nodes
+}
+
+func TestProcessPlan_DeepPlan(t *testing.T) {
+	// A generated plan far deeper than the depth budget fails with the budget error rather than
+	// growing the stack per level.
+	qp, err := spannerplan.New(chainPlanNodes(50_000, "Distributed Union"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, err = ProcessPlan(qp)
+	var limitErr *TraversalLimitError
+	if !errors.As(err, &limitErr) || limitErr.Kind != TraversalLimitDepth || limitErr.NodeIndex != MaxPlantreeDepth+1 {
+		t.Fatalf("ProcessPlan() error = %v, want the depth budget error at PlanNode index %d", err, MaxPlantreeDepth+1)
+	}
+
+	// A plan at the depth budget renders every node in pre-order.
+	qp, err = spannerplan.New(chainPlanNodes(MaxPlantreeDepth+1, "Distributed Union"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	rows, err := ProcessPlan(qp)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	if len(rows) != MaxPlantreeDepth+1 {
+		t.Fatalf("len(ProcessPlan()) = %d, want %d", len(rows), MaxPlantreeDepth+1)
+	}
+	for i, row := range rows {
+		if row.ID != int32(i) {
+			t.Fatalf("ProcessPlan()[%d].ID = %d, want %d", i, row.ID, i)
+		}
+	}
+}
+
+func TestProcessPlan_WrapsNestedBuildErrors(t *testing.T) {
+	nodes := chainPlanNodes(3, "Node")
+	nodes[2].ExecutionStats = &structpb.Struct{Fields: map[string]*structpb.Value{
+		"rows": structpb.NewStringValue("not a struct"),
+	}}
+	qp, err := spannerplan.New(nodes)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, err = ProcessPlan(qp)
+	if err == nil {
+		t.Fatal("ProcessPlan() error = nil, want the stats error of node 2")
+	}
+	// Each level above the failing node wraps the error with the child link it was building.
+	const want = "failed to build rendered tree: buildRenderedTree failed on child link child_index:1: buildRenderedTree failed on child link child_index:2: "
+	if got := err.Error(); !strings.HasPrefix(got, want) {
+		t.Fatalf("ProcessPlan() error = %q, want prefix %q", got, want)
+	}
+}
+
 func TestProcessPlan_FullTextSearchPredicates(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -737,3 +802,539 @@ func TestProcessPlan_HangingIndentKeepsChildGuide(t *testing.T) {
 		t.Fatalf("row 1 mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestProcessPlan_IncludePlanNode(t *testing.T) {
+	qp := decodeDCAPlan(t)
+
+	rows, err := ProcessPlan(qp)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	for _, row := range rows {
+		if row.Node != nil {
+			t.Fatalf("row %d Node = %v, want nil without IncludePlanNode", row.ID, row.Node)
+		}
+	}
+
+	rows, err = ProcessPlan(qp, IncludePlanNode())
+	if err != nil {
+		t.Fatalf("ProcessPlan(IncludePlanNode) error = %v", err)
+	}
+	for _, row := range rows {
+		if row.Node != qp.GetNodeByIndex(row.ID) {
+			t.Fatalf("row %d Node = %p, want plan node %p", row.ID, row.Node, qp.GetNodeByIndex(row.ID))
+		}
+	}
+}
+
+func TestProcessPlan_WithStatsErrorHandler(t *testing.T) {
+	malformed, err := structpb.NewStruct(map[string]any{
+		"rows":    "33",
+		"latency": map[string]any{"total": "1.5", "unit": "msecs"},
+	})
+	if err != nil {
+		t.Fatalf("structpb.NewStruct() error = %v", err)
+	}
+	qp, err := spannerplan.New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Serialize Result", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan", ExecutionStats: malformed},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := ProcessPlan(qp); err == nil {
+		t.Fatal("ProcessPlan(malformed stats) error = nil, want non-nil")
+	}
+
+	var failed []int32
+	rows, err := ProcessPlan(qp, WithStatsErrorHandler(func(node *sppb.PlanNode, err error) {
+		if err == nil {
+			t.Errorf("handler called with a nil error for node %d", node.GetIndex())
+		}
+		failed = append(failed, node.GetIndex())
+	}))
+	if err != nil {
+		t.Fatalf("ProcessPlan(WithStatsErrorHandler) error = %v", err)
+	}
+	if diff := cmp.Diff([]int32{1}, failed); diff != "" {
+		t.Errorf("failed nodes mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"Serialize Result", "+- Scan"}, rowTexts(rows)); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+	if got := rows[1].ExecutionStats.Latency.Total; got != "" {
+		t.Errorf("row 1 latency = %q, want empty stats", got)
+	}
+	if got := rows[1].StatsMap["rows"]; got != "33" {
+		t.Errorf("row 1 StatsMap[rows] = %q, want %q", got, "33")
+	}
+}
+
+func TestProcessPlan_EstimatedRows(t *testing.T) {
+	rowsStats := func(total string) *structpb.Struct {
+		return &structpb.Struct{Fields: map[string]*structpb.Value{
+			"rows": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"total": structpb.NewStringValue(total),
+			}}),
+		}}
+	}
+	qp, err := spannerplan.New([]*sppb.PlanNode{
+		{
+			Index:          0,
+			DisplayName:    "Serialize Result",
+			Kind:           sppb.PlanNode_RELATIONAL,
+			ChildLinks:     []*sppb.PlanNode_ChildLink{{ChildIndex: 1}, {ChildIndex: 2}},
+			Metadata:       &structpb.Struct{Fields: map[string]*structpb.Value{"estimated_rows": structpb.NewStringValue("4")}},
+			ExecutionStats: rowsStats("100"),
+		},
+		{
+			Index:          1,
+			DisplayName:    "Scan",
+			Kind:           sppb.PlanNode_RELATIONAL,
+			Metadata:       &structpb.Struct{Fields: map[string]*structpb.Value{"estimated_rows": structpb.NewNumberValue(50)}},
+			ExecutionStats: rowsStats("25"),
+		},
+		{
+			Index:          2,
+			DisplayName:    "Scan",
+			Kind:           sppb.PlanNode_RELATIONAL,
+			ExecutionStats: rowsStats("75"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rows, err := ProcessPlan(qp)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+
+	tests := []struct {
+		id            int32
+		wantEstimated string
+		wantRatio     float64
+		wantOK        bool
+	}{
+		{id: 0, wantEstimated: "4", wantRatio: 25, wantOK: true},
+		{id: 1, wantEstimated: "50", wantRatio: 0.5, wantOK: true},
+		{id: 2},
+	}
+	for _, tt := range tests {
+		row := rowByID(t, rows, tt.id)
+		if row.EstimatedRows != tt.wantEstimated {
+			t.Errorf("row %d EstimatedRows = %q, want %q", tt.id, row.EstimatedRows, tt.wantEstimated)
+		}
+		if ratio, ok := row.RowsEstimateRatio(); ratio != tt.wantRatio || ok != tt.wantOK {
+			t.Errorf("row %d RowsEstimateRatio() = (%v, %v), want (%v, %v)", tt.id, ratio, ok, tt.wantRatio, tt.wantOK)
+		}
+	}
+}
+
+func TestProcessPlan_DedupeSubtrees(t *testing.T) {
+	metadata := func(name string) *structpb.Struct {
+		return &structpb.Struct{Fields: map[string]*structpb.Value{"source": structpb.NewStringValue(name)}}
+	}
+	latency := func(total string) *structpb.Struct {
+		return &structpb.Struct{Fields: map[string]*structpb.Value{
+			"latency": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"total": structpb.NewStringValue(total),
+				"unit":  structpb.NewStringValue("msecs"),
+			}}),
+		}}
+	}
+	qp, err := spannerplan.New([]*sppb.PlanNode{
+		{Index: 0, DisplayName: "Union All", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}, {ChildIndex: 3}, {ChildIndex: 5}, {ChildIndex: 7}}},
+		{Index: 1, DisplayName: "Filter", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 2}}, ExecutionStats: latency("1")},
+		{Index: 2, DisplayName: "Scan", Kind: sppb.PlanNode_RELATIONAL, Metadata: metadata("Singers")},
+		{Index: 3, DisplayName: "Filter", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 4}}, ExecutionStats: latency("2")},
+		{Index: 4, DisplayName: "Scan", Kind: sppb.PlanNode_RELATIONAL, Metadata: metadata("Singers")},
+		{Index: 5, DisplayName: "Filter", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 6}}},
+		{Index: 6, DisplayName: "Scan", Kind: sppb.PlanNode_RELATIONAL, Metadata: metadata("Albums")},
+		{Index: 7, DisplayName: "Scan", Kind: sppb.PlanNode_RELATIONAL, Metadata: metadata("Singers")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rows, err := ProcessPlan(qp, WithDedupeSubtrees())
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	want := []string{
+		"Union All",
+		"+- Filter",
+		"|  +- Scan (source: Singers)",
+		"+- Filter (see node 1)",
+		"+- Filter",
+		"|  +- Scan (source: Albums)",
+		"+- Scan (source: Singers)",
+	}
+	if diff := cmp.Diff(want, rowTexts(rows)); diff != "" {
+		t.Fatalf("ProcessPlan(WithDedupeSubtrees()) mismatch (-want +got):\n%s", diff)
+	}
+	if got := rowByID(t, rows, 3).ExecutionStats.Latency.Total; got != "2" {
+		t.Errorf("reference row latency = %q, want its own value %q", got, "2")
+	}
+
+	rows, err = ProcessPlan(qp)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	if len(rows) != 8 {
+		t.Errorf("ProcessPlan() without WithDedupeSubtrees returned %d rows, want 8", len(rows))
+	}
+}
+
+func TestProcessPlan_WithRowTransform(t *testing.T) {
+	qp, err := spannerplan.New([]*sppb.PlanNode{
+		{Index: 0, DisplayName: "Union All", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}, {ChildIndex: 3}}},
+		{Index: 1, DisplayName: "Filter", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 2}}},
+		{Index: 2, DisplayName: "Scan", Kind: sppb.PlanNode_RELATIONAL},
+		{Index: 3, DisplayName: "Scan", Kind: sppb.PlanNode_RELATIONAL},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	annotate := func(row RowWithPredicates) RowWithPredicates {
+		if row.DisplayName == "Scan" {
+			row.NodeText += " [leaf]"
+		}
+		return row
+	}
+	dropFilterSubtree := func(row RowWithPredicates) RowWithPredicates {
+		if row.ID == 1 || row.ID == 2 {
+			row.ID = DroppedRowID
+		}
+		return row
+	}
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "identity by default",
+			want: []string{"Union All", "+- Filter", "|  +- Scan", "+- Scan"},
+		},
+		{
+			name: "nil transform is ignored",
+			opts: []Option{WithRowTransform(nil)},
+			want: []string{"Union All", "+- Filter", "|  +- Scan", "+- Scan"},
+		},
+		{
+			name: "annotate",
+			opts: []Option{WithRowTransform(annotate)},
+			want: []string{"Union All", "+- Filter", "|  +- Scan [leaf]", "+- Scan [leaf]"},
+		},
+		{
+			name: "drop subtree before later transforms",
+			opts: []Option{WithRowTransform(dropFilterSubtree), WithRowTransform(annotate)},
+			want: []string{"Union All", "+- Scan [leaf]"},
+		},
+		{
+			name: "line count mismatch",
+			opts: []Option{WithRowTransform(func(row RowWithPredicates) RowWithPredicates {
+				row.NodeText += "\nmore"
+				return row
+			})},
+			wantErr: "row transform changed line count: node 0: tree=1 node=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(qp, tt.opts...)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ProcessPlan() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rowTexts(rows)); diff != "" {
+				t.Fatalf("ProcessPlan() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProcessPlan_DisplayIDOffset(t *testing.T) {
+	qp := decodeDCAPlan(t)
+	rows, err := ProcessPlan(qp, append(currentOptions(), WithOneBasedIDs())...)
+	if err != nil {
+		t.Fatalf("ProcessPlan(WithOneBasedIDs()) error = %v", err)
+	}
+	row := rowByID(t, rows, 1)
+	if got, want := row.FormatID(), "*2"; got != want {
+		t.Errorf("FormatID() = %q, want %q", got, want)
+	}
+	if got, want := row.DisplayID(), int32(2); got != want {
+		t.Errorf("DisplayID() = %d, want %d", got, want)
+	}
+	if got := rows[0].FormatID(); got != "*1" {
+		t.Errorf("root FormatID() = %q, want %q", got, "*1")
+	}
+
+	dedupePlan, err := spannerplan.New([]*sppb.PlanNode{
+		{Index: 0, DisplayName: "Union All", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}, {ChildIndex: 3}}},
+		{Index: 1, DisplayName: "Filter", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 2}}},
+		{Index: 2, DisplayName: "Scan", Kind: sppb.PlanNode_RELATIONAL},
+		{Index: 3, DisplayName: "Filter", Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 4}}},
+		{Index: 4, DisplayName: "Scan", Kind: sppb.PlanNode_RELATIONAL},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	rows, err = ProcessPlan(dedupePlan, WithDedupeSubtrees(), WithDisplayIDOffset(10))
+	if err != nil {
+		t.Fatalf("ProcessPlan(WithDisplayIDOffset(10)) error = %v", err)
+	}
+	if got, want := rows[len(rows)-1].Text(), "+- Filter (see node 11)"; got != want {
+		t.Errorf("reference row = %q, want %q", got, want)
+	}
+
+	if _, err := ProcessPlan(qp, WithDisplayIDOffset(-1)); err == nil {
+		t.Error("ProcessPlan(WithDisplayIDOffset(-1)) error = nil, want non-nil")
+	}
+}
+
+func TestVisibleNodesMatchesProcessPlan(t *testing.T) {
+	qp := decodeDCAPlan(t)
+
+	rows, err := ProcessPlan(qp)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+
+	type visited struct {
+		ID    int32
+		Depth int
+	}
+	var want []visited
+	for _, row := range rows {
+		// The default ASCII style draws three prefix characters per level, such as "+- ".
+		want = append(want, visited{ID: row.ID, Depth: len(row.TreePartLines()[0]) / 3})
+	}
+	var got []visited
+	for depth, node := range qp.VisibleNodes() {
+		got = append(got, visited{ID: node.GetIndex(), Depth: depth})
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("VisibleNodes() mismatch with ProcessPlan rows (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithChangeSet(t *testing.T) {
+	qp := decodeDCAPlan(t)
+
+	tests := []struct {
+		name        string
+		changed     map[int32]bool
+		want        []string
+		wantChanged []int32
+	}{
+		{
+			name:    "changed leaf keeps its ancestors",
+			changed: map[int32]bool{6: true},
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row>",
+				"+- Distributed Cross Apply <Row> (7 hidden)",
+				"   +- [Input] Create Batch <Row>",
+				"      +- Local Distributed Union <Row>",
+				"         +- Compute Struct <Row>",
+				"            +- Filter Scan <Row> (seekable_key_size: 1)",
+				"               +- Index Scan on AlbumsByAlbumTitle <Row> (scan_method: Row)",
+			},
+			wantChanged: []int32{6},
+		},
+		{
+			name:    "changed inner node hides its unchanged subtree",
+			changed: map[int32]bool{23: true, 5: false},
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row>",
+				"+- Distributed Cross Apply <Row> (5 hidden)",
+				"   +- [Map] Serialize Result <Row>",
+				"      +- Cross Apply <Row> (5 hidden)",
+			},
+			wantChanged: []int32{23},
+		},
+		{
+			name:    "nothing changed keeps only the root",
+			changed: map[int32]bool{},
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row> (13 hidden)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(qp, append(currentOptions(), WithChangeSet(tt.changed))...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rowTexts(rows)); diff != "" {
+				t.Errorf("ProcessPlan(WithChangeSet) mismatch (-want +got):\n%s", diff)
+			}
+			var gotChanged []int32
+			for _, row := range rows {
+				if row.Changed {
+					gotChanged = append(gotChanged, row.ID)
+				}
+			}
+			if diff := cmp.Diff(tt.wantChanged, gotChanged); diff != "" {
+				t.Errorf("changed rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	rows, err := ProcessPlan(qp, WithChangeSet(nil))
+	if err != nil {
+		t.Fatalf("ProcessPlan(WithChangeSet(nil)) error = %v", err)
+	}
+	if len(rows) != 14 {
+		t.Errorf("ProcessPlan(WithChangeSet(nil)) returned %d rows, want 14", len(rows))
+	}
+}
+
+func TestProcessPlanVisibilityOverride(t *testing.T) {
+	qp, err := spannerplan.New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 1},
+			{ChildIndex: 2, Type: "Condition"},
+		}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan"},
+		{Index: 2, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function", ShortRepresentation: &sppb.PlanNode_ShortRepresentation{Description: "TRUE"}},
+	}, spannerplan.WithVisibilityOverride(func(_ *spannerplan.QueryPlan, link *sppb.PlanNode_ChildLink) (bool, bool) {
+		return link.GetType() == "Condition", true
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rows, err := ProcessPlan(qp)
+	if err != nil {
+		t.Fatalf("ProcessPlan() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"Filter", "+- [Condition] Function"}, rowTexts(rows)); diff != "" {
+		t.Errorf("ProcessPlan() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithAnnotations(t *testing.T) {
+	qp := newBadgeTestPlan(t, false)
+	annotations := map[int32]string{
+		0: "reviewed",
+		2: "consider an index on this table",
+		5: "not in the plan",
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "annotations",
+			opts: []Option{WithAnnotations(annotations)},
+			want: []string{
+				"Serialize Result\n-- reviewed",
+				"+- Create Batch",
+				"   +- Table Scan Over A Long Table Name\n      -- consider an index on this table",
+			},
+		},
+		{
+			name: "compact",
+			opts: []Option{WithAnnotations(annotations), EnableCompact()},
+			want: []string{
+				"Serialize Result\n--reviewed",
+				"+Create Batch",
+				" +Table Scan Over A Long Table Name\n  --consider an index on this table",
+			},
+		},
+		{
+			name: "wrapped",
+			opts: []Option{WithAnnotations(annotations), WithWrapWidth(24)},
+			want: []string{
+				"Serialize Result\n-- reviewed",
+				"+- Create Batch",
+				"   +- Table Scan Over A\n      Long Table Name\n      -- consider an ind\n      ex on this table",
+			},
+		},
+		{
+			name: "escaped newlines",
+			opts: []Option{WithAnnotations(map[int32]string{1: "line 1\nline 2"}), WithNewlineMode(NewlineEscape)},
+			want: []string{
+				"Serialize Result",
+				"+- Create Batch\n   -- line 1\\nline 2",
+				"   +- Table Scan Over A Long Table Name",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(qp, tt.opts...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rowTexts(rows)); diff != "" {
+				t.Errorf("ProcessPlan() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithApplyInputSynthesis(t *testing.T) {
+	qp := decodeDCAPlan(t)
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row>",
+				"+- Distributed Cross Apply <Row>",
+				"   +- [Input] Create Batch <Row> (4 hidden)",
+				"   +- [Map] Serialize Result <Row> (6 hidden)",
+			},
+		},
+		{
+			name: "disabled",
+			opts: []Option{WithApplyInputSynthesis(false)},
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row>",
+				"+- Distributed Cross Apply <Row>",
+				"   +- Create Batch <Row> (4 hidden)",
+				"   +- [Map] Serialize Result <Row> (6 hidden)",
+			},
+		},
+		{
+			name: "re-enabled",
+			opts: []Option{WithApplyInputSynthesis(false), WithApplyInputSynthesis(true)},
+			want: []string{
+				"Distributed Union on AlbumsByAlbumTitle <Row>",
+				"+- Distributed Cross Apply <Row>",
+				"   +- [Input] Create Batch <Row> (4 hidden)",
+				"   +- [Map] Serialize Result <Row> (6 hidden)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(qp, append(currentOptions(), append(tt.opts, WithMaxDepth(2))...)...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rowTexts(rows)); diff != "" {
+				t.Errorf("ProcessPlan(WithApplyInputSynthesis) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package plantree
+
+// WithRootLatency sets [RowWithPredicates.RootLatency] of every row to the latency statistic of
+// the root operator, so that a custom column can relate an operator to the whole query, as in
+// "{{pctOfRoot .ExecutionStats.Latency .RootLatency}}". The root counts even when other options hide it.
+func WithRootLatency() Option {
+	return func(o *options) {
+		o.rootLatency = true
+	}
+}
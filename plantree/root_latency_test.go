@@ -0,0 +1,36 @@
+package plantree
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithRootLatency(t *testing.T) {
+	tests := []struct {
+		name     string
+		hasStats bool
+		opts     []Option
+		want     []string
+	}{
+		{name: "every row", hasStats: true, opts: []Option{WithRootLatency()}, want: []string{"12.5", "12.5", "12.5"}},
+		{name: "hidden rows", hasStats: true, opts: []Option{WithRootLatency(), WithMaxDepth(0)}, want: []string{"12.5"}},
+		{name: "without stats", opts: []Option{WithRootLatency()}, want: []string{"", "", ""}},
+		{name: "disabled", hasStats: true, want: []string{"", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ProcessPlan(newBadgeTestPlan(t, tt.hasStats), tt.opts...)
+			if err != nil {
+				t.Fatalf("ProcessPlan() error = %v", err)
+			}
+			var got []string
+			for _, row := range rows {
+				got = append(got, row.RootLatency.Total)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("RootLatency mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -129,15 +129,11 @@ func writeSignatureNode(
 	b.WriteByte(' ')
 	appendSignatureString(b, linkType)
 	b.WriteByte(' ')
-	appendSignatureStrings(b, signatureOperator(node))
-	b.WriteByte(' ')
-	metadata, err := signatureMetadata(node)
+	local, err := localSignature(qp, node)
 	if err != nil {
-		return fmt.Errorf("plan node %d metadata: %w", node.GetIndex(), err)
+		return err
 	}
-	appendSignatureFields(b, metadata)
-	b.WriteByte(' ')
-	appendSignatureFields(b, signaturePredicates(qp, node))
+	b.WriteString(local)
 	b.WriteByte('\n')
 
 	for idx, child := range node.GetChildLinks() {
@@ -154,6 +150,21 @@ func writeSignatureNode(
 	return nil
 }
 
+// localSignature encodes the fields of node that [StructuralSignature] compares, without its children.
+func localSignature(qp *spannerplan.QueryPlan, node *sppb.PlanNode) (string, error) {
+	metadata, err := signatureMetadata(node)
+	if err != nil {
+		return "", fmt.Errorf("plan node %d metadata: %w", node.GetIndex(), err)
+	}
+	var b strings.Builder
+	appendSignatureStrings(&b, signatureOperator(node))
+	b.WriteByte(' ')
+	appendSignatureFields(&b, metadata)
+	b.WriteByte(' ')
+	appendSignatureFields(&b, signaturePredicates(qp, node))
+	return b.String(), nil
+}
+
 func signatureOperator(node *sppb.PlanNode) []string {
 	return []string{node.GetDisplayName()}
 }
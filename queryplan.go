@@ -5,16 +5,19 @@ import (
 	"fmt"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
 	"github.com/samber/lo"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type QueryPlan struct {
-	planNodes      []*sppb.PlanNode
-	parentMap      map[int32]int32
-	parentLinksMap map[int32][]ResolvedParentLink
+	planNodes          []*sppb.PlanNode
+	parentMap          map[int32]int32
+	parentLinksMap     map[int32][]ResolvedParentLink
+	visibilityOverride func(*QueryPlan, *sppb.PlanNode_ChildLink) (visible, ok bool)
 }
 
 // ErrInvalidPlan is the stable sentinel identifying any plan-validation
@@ -95,7 +98,11 @@ func newValidationError(kind error, nodeIndex, childIndex int, err error) *Valid
 //
 // On validation failure it returns a *ValidationError that wraps ErrInvalidPlan
 // and a category sentinel; see ValidationError.
-func New(planNodes []*sppb.PlanNode) (*QueryPlan, error) {
+//
+// Only options that change the structure of the plan, such as
+// WithVisibilityOverride, take effect here; title formatting options are
+// ignored and should be passed to NodeTitle instead.
+func New(planNodes []*sppb.PlanNode, opts ...Option) (*QueryPlan, error) {
 	if len(planNodes) == 0 {
 		return nil, newValidationError(ErrEmptyPlanNodes, -1, -1, ErrEmptyPlanNodes)
 	}
@@ -132,10 +139,16 @@ func New(planNodes []*sppb.PlanNode) (*QueryPlan, error) {
 		}
 	}
 
+	var o option
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	return &QueryPlan{
-		planNodes:      planNodes,
-		parentMap:      parentMap,
-		parentLinksMap: parentLinksMap,
+		planNodes:          planNodes,
+		parentMap:          parentMap,
+		parentLinksMap:     parentLinksMap,
+		visibilityOverride: o.visibilityOverride,
 	}, nil
 }
 
@@ -177,8 +190,14 @@ func (qp *QueryPlan) GetNodeByIndex(id int32) *sppb.PlanNode {
 // IsVisible reports whether a child link should be rendered as part of the
 // operator tree. Scalar PlanNodes are hidden unless the child link type is
 // "Scalar", which represents scalar subquery-like operator subtrees.
-// A nil link represents the root node.
+// A nil link represents the root node, which is always decided by these rules.
+// An override set by WithVisibilityOverride is consulted first.
 func (qp *QueryPlan) IsVisible(link *sppb.PlanNode_ChildLink) bool {
+	if link != nil && qp.visibilityOverride != nil {
+		if visible, ok := qp.visibilityOverride(qp, link); ok {
+			return visible
+		}
+	}
 	return qp.GetNodeByChildLink(link).GetKind() == sppb.PlanNode_RELATIONAL || link.GetType() == "Scalar"
 }
 
@@ -193,6 +212,24 @@ func (qp *QueryPlan) VisibleChildLinks(node *sppb.PlanNode) []*sppb.PlanNode_Chi
 	return links
 }
 
+// Subqueries returns the short representation subqueries of node: a mapping from the subquery
+// variable names its description references, such as sq_1 for $sq_1, to the PlanNode indexes
+// of the scalar subqueries that define them. Entries whose index is not a node of qp are
+// dropped. It returns nil for nodes without subqueries.
+func (qp *QueryPlan) Subqueries(node *sppb.PlanNode) map[string]int32 {
+	var result map[string]int32
+	for name, index := range node.GetShortRepresentation().GetSubqueries() {
+		if index < 0 || int(index) >= len(qp.planNodes) {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]int32)
+		}
+		result[name] = index
+	}
+	return result
+}
+
 // GetNodeByChildLink returns PlanNode indicated by `link`.
 // If `link` is nil, return the root node.
 func (qp *QueryPlan) GetNodeByChildLink(link *sppb.PlanNode_ChildLink) *sppb.PlanNode {
@@ -229,6 +266,11 @@ type option struct {
 	compact               bool
 	inlineStatsFunc       func(*sppb.PlanNode) []string
 	hideMetadata          bool
+	typeMetadata          bool
+	scanEstimates         bool
+	scalarLinks           bool
+	dataFlow              bool
+	visibilityOverride    func(*QueryPlan, *sppb.PlanNode_ChildLink) (bool, bool)
 }
 
 type Option func(o *option)
@@ -255,6 +297,19 @@ func ParseExecutionMethodFormat(s string) (ExecutionMethodFormat, error) {
 	}
 }
 
+// String returns the name of f accepted by [ParseExecutionMethodFormat], "RAW" or "ANGLE", or
+// "ExecutionMethodFormat(N)" for an undefined value.
+func (f ExecutionMethodFormat) String() string {
+	switch f {
+	case ExecutionMethodFormatRaw:
+		return "RAW"
+	case ExecutionMethodFormatAngle:
+		return "ANGLE"
+	default:
+		return fmt.Sprintf("ExecutionMethodFormat(%d)", int64(f))
+	}
+}
+
 // TargetMetadataFormat controls how to render target metadata.
 // target metadata are scan_target, distribution_table, and table.
 type TargetMetadataFormat int64
@@ -279,6 +334,19 @@ func ParseTargetMetadataFormat(s string) (TargetMetadataFormat, error) {
 	}
 }
 
+// String returns the name of f accepted by [ParseTargetMetadataFormat], "RAW" or "ON", or
+// "TargetMetadataFormat(N)" for an undefined value.
+func (f TargetMetadataFormat) String() string {
+	switch f {
+	case TargetMetadataFormatRaw:
+		return "RAW"
+	case TargetMetadataFormatOn:
+		return "ON"
+	default:
+		return fmt.Sprintf("TargetMetadataFormat(%d)", int64(f))
+	}
+}
+
 type KnownFlagFormat int64
 
 const (
@@ -301,6 +369,19 @@ func ParseKnownFlagFormat(s string) (KnownFlagFormat, error) {
 	}
 }
 
+// String returns the name of f accepted by [ParseKnownFlagFormat], "RAW" or "LABEL", or
+// "KnownFlagFormat(N)" for an undefined value.
+func (f KnownFlagFormat) String() string {
+	switch f {
+	case KnownFlagFormatRaw:
+		return "RAW"
+	case KnownFlagFormatLabel:
+		return "LABEL"
+	default:
+		return fmt.Sprintf("KnownFlagFormat(%d)", int64(f))
+	}
+}
+
 func WithExecutionMethodFormat(fmt ExecutionMethodFormat) Option {
 	return func(o *option) {
 		o.executionMethodFormat = fmt
@@ -339,23 +420,85 @@ func HideMetadata() Option {
 	}
 }
 
+// ShowTypeMetadata also renders call_type and iterator_type as metadata fields, such as
+// `Local Distributed Union (call_type: Local)`, in addition to merging them into the operator.
+// A value that the display name already contains is not repeated. [HideMetadata] takes precedence.
+func ShowTypeMetadata() Option {
+	return func(o *option) {
+		o.typeMetadata = true
+	}
+}
+
+// ShowScanEstimates renders the estimated_rows metadata of a scan as the first metadata field,
+// such as `Index Scan on AlbumsByAlbumTitle (~7 rows)`, instead of as an estimated_rows field.
+// It applies to nodes with a scan_target rendered by [TargetMetadataFormatOn] and an estimate,
+// and [HideMetadata] takes precedence.
+func ShowScanEstimates() Option {
+	return func(o *option) {
+		o.scanEstimates = true
+	}
+}
+
+// WithVisibilityOverride overrides [QueryPlan.IsVisible] for the plans built by [New] with it,
+// and so every traversal of visible nodes, such as [QueryPlan.VisibleChildLinks] and the
+// plantree renderer. f receives each child link and returns the visibility and whether it made
+// a decision; when the second value is false, the default rules apply. It can hide a noisy link
+// type or reveal scalar subtrees that are normally hidden. The root node is always visible.
+//
+// Only [New] uses this option; [NodeTitle] ignores it.
+func WithVisibilityOverride(f func(qp *QueryPlan, link *sppb.PlanNode_ChildLink) (visible, ok bool)) Option {
+	return func(o *option) {
+		o.visibilityOverride = f
+	}
+}
+
+// EstimatedRows returns the estimated_rows metadata of node, the optimizer's row estimate, as a
+// string such as "7" or "1.5". The metadata is usually a string, and a number is formatted in
+// the shortest form. It reports false when node has no non-empty estimated_rows of either kind.
+func EstimatedRows(node *sppb.PlanNode) (string, bool) {
+	var rows string
+	switch v := node.GetMetadata().GetFields()["estimated_rows"].GetKind().(type) {
+	case *structpb.Value_StringValue:
+		rows = v.StringValue
+	case *structpb.Value_NumberValue:
+		rows = strconv.FormatFloat(v.NumberValue, 'f', -1, 64)
+	}
+	return rows, rows != ""
+}
+
 var (
 	knownBooleanFlagKeys = []string{"Full scan", "split_ranges_aligned"}
 	targetMetadataKeys   = []string{"scan_target", "distribution_table", "table"}
 )
 
-func NodeTitle(node *sppb.PlanNode, opts ...Option) string {
+// DistributionTable returns the distribution_table metadata of a distributed operator such as
+// Distributed Union, the table whose splits the operator distributes work over. It is distinct
+// from the scan target of the operator's children and does not depend on [TargetMetadataFormat].
+// It reports false when node has no non-empty distribution_table.
+func DistributionTable(node *sppb.PlanNode) (string, bool) {
+	table := node.GetMetadata().GetFields()["distribution_table"].GetStringValue()
+	return table, table != ""
+}
+
+// OperatorName returns the operator portion of [NodeTitle]: call_type, iterator_type, scan_type
+// without its "Scan" suffix, and the display name, followed by "on <target>" when opts select
+// [TargetMetadataFormatOn]. It omits the execution method and the parenthesized metadata, which
+// makes it a concise label for grouping operators or for tree nodes in graphical renderers.
+func OperatorName(node *sppb.PlanNode, opts ...Option) string {
+	return operatorName(node, newOption(opts))
+}
+
+func newOption(opts []Option) option {
 	var o option
 	for _, opt := range opts {
 		opt(&o)
 	}
+	return o
+}
 
-	sep := lo.Ternary(!o.compact, " ", "")
-
+func operatorName(node *sppb.PlanNode, o option) string {
 	metadataFields := node.GetMetadata().GetFields()
 
-	executionMethod := metadataFields["execution_method"].GetStringValue()
-
 	var target string
 	for _, k := range targetMetadataKeys {
 		if v := metadataFields[k].GetStringValue(); v != "" {
@@ -364,7 +507,7 @@ func NodeTitle(node *sppb.PlanNode, opts ...Option) string {
 		}
 	}
 
-	operator := joinIfNotEmpty(" ",
+	return joinIfNotEmpty(" ",
 		metadataFields["call_type"].GetStringValue(),
 		metadataFields["iterator_type"].GetStringValue(),
 		strings.TrimSuffix(metadataFields["scan_type"].GetStringValue(), "Scan"),
@@ -372,6 +515,18 @@ func NodeTitle(node *sppb.PlanNode, opts ...Option) string {
 		lo.Ternary(o.targetMetadataFormat == TargetMetadataFormatOn && len(target) > 0,
 			"on "+target, ""),
 	)
+}
+
+func NodeTitle(node *sppb.PlanNode, opts ...Option) string {
+	o := newOption(opts)
+
+	sep := lo.Ternary(!o.compact, " ", "")
+
+	metadataFields := node.GetMetadata().GetFields()
+
+	executionMethod := metadataFields["execution_method"].GetStringValue()
+
+	operator := operatorName(node, o)
 
 	executionMethodPart := lo.Ternary(
 		o.executionMethodFormat == ExecutionMethodFormatAngle && len(executionMethod) > 0,
@@ -381,14 +536,21 @@ func NodeTitle(node *sppb.PlanNode, opts ...Option) string {
 
 	var labels []string
 	var fields []string
+	estimate := scanEstimate(node, o, sep)
 	if !o.hideMetadata {
 		for k, v := range metadataFields {
+			if k == "estimated_rows" && estimate != "" {
+				continue
+			}
 			if o.targetMetadataFormat != TargetMetadataFormatRaw && slices.Contains(targetMetadataKeys, k) {
 				continue
 			}
 
 			switch k {
 			case "call_type", "iterator_type": // Skip because it is displayed in node title
+				if o.typeMetadata && !strings.Contains(node.GetDisplayName(), v.GetStringValue()) {
+					fields = append(fields, fmt.Sprintf("%s:%s%s", k, sep, v.GetStringValue()))
+				}
 				continue
 			case "scan_type": // Skip because it is combined with scan_target
 				continue
@@ -431,7 +593,27 @@ func NodeTitle(node *sppb.PlanNode, opts ...Option) string {
 	sort.Strings(labels)
 	sort.Strings(fields)
 
-	return joinIfNotEmpty(sep, operator, executionMethodPart, encloseIfNotEmpty("(", strings.Join(slices.Concat(labels, fields, inlineStats), ","+sep), ")"))
+	var estimates []string
+	if estimate != "" && !o.hideMetadata {
+		estimates = []string{estimate}
+	}
+
+	return joinIfNotEmpty(sep, operator, executionMethodPart, encloseIfNotEmpty("(", strings.Join(slices.Concat(estimates, labels, fields, inlineStats), ","+sep), ")"))
+}
+
+// scanEstimate returns the [ShowScanEstimates] field of node, such as "~7 rows", or "" when it
+// does not apply.
+func scanEstimate(node *sppb.PlanNode, o option, sep string) string {
+	metadataFields := node.GetMetadata().GetFields()
+	if !o.scanEstimates || o.targetMetadataFormat != TargetMetadataFormatOn || metadataFields["scan_target"].GetStringValue() == "" {
+		return ""
+	}
+
+	rows, ok := EstimatedRows(node)
+	if !ok {
+		return ""
+	}
+	return "~" + rows + sep + lo.Ternary(rows == "1", "row", "rows")
 }
 
 func encloseIfNotEmpty(open, input, close string) string {
@@ -463,6 +645,9 @@ type ResolvedChildLink struct {
 	Child     *sppb.PlanNode
 }
 
+// HasStats reports whether the first node, which is the root of the plan, has ExecutionStats.
+// It is the fast path used to tell PROFILE output from PLAN output. See [HasAnyStats] and
+// [HasAllStats] for checks over every node.
 func HasStats(nodes []*sppb.PlanNode) bool {
 	// hasStats returns true only if the first node has ExecutionStats.
 	if len(nodes) == 0 {
@@ -472,6 +657,28 @@ func HasStats(nodes []*sppb.PlanNode) bool {
 	return nodes[0].ExecutionStats != nil
 }
 
+// HasAnyStats reports whether any node has ExecutionStats. Unlike [HasStats], it also detects
+// partial plans whose root has no statistics.
+func HasAnyStats(nodes []*sppb.PlanNode) bool {
+	return slices.ContainsFunc(nodes, func(node *sppb.PlanNode) bool {
+		return node.GetExecutionStats() != nil
+	})
+}
+
+// HasAllStats reports whether every node has ExecutionStats. It returns false for an empty slice.
+//
+// PROFILE output from Spanner leaves scalar nodes, and some relational nodes such as Create Batch,
+// without statistics, so this is false for typical profiles. It is meant for tooling that needs
+// to know a plan is fully annotated, such as converters that attach statistics to every node.
+func HasAllStats(nodes []*sppb.PlanNode) bool {
+	if len(nodes) == 0 {
+		return false
+	}
+	return !slices.ContainsFunc(nodes, func(node *sppb.PlanNode) bool {
+		return node.GetExecutionStats() == nil
+	})
+}
+
 // LinkTypeInParent returns the type for one child-link occurrence in parent.
 //
 // rawChildLinkIndex is the position in parent.ChildLinks, not the child
@@ -1,7 +1,11 @@
 package spannerplan
 
 import (
+	_ "embed"
 	"errors"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
@@ -212,6 +216,56 @@ func TestHasStats(t *testing.T) {
 	}
 }
 
+func TestHasAnyStatsAndHasAllStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []*sppb.PlanNode
+		wantAny bool
+		wantAll bool
+	}{
+		{
+			"all nodes have stats",
+			[]*sppb.PlanNode{{ExecutionStats: &structpb.Struct{}}, {Index: 1, ExecutionStats: &structpb.Struct{}}},
+			true,
+			true,
+		},
+		{
+			"only root has stats",
+			[]*sppb.PlanNode{{ExecutionStats: &structpb.Struct{}}, {Index: 1}},
+			true,
+			false,
+		},
+		{
+			"only non-root nodes have stats",
+			[]*sppb.PlanNode{{}, {Index: 1, ExecutionStats: &structpb.Struct{}}},
+			true,
+			false,
+		},
+		{
+			"no stats",
+			[]*sppb.PlanNode{{ExecutionStats: nil}, {Index: 1}},
+			false,
+			false,
+		},
+		{
+			"empty",
+			nil,
+			false,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasAnyStats(tt.input); got != tt.wantAny {
+				t.Errorf("HasAnyStats() = %v, want %v", got, tt.wantAny)
+			}
+			if got := HasAllStats(tt.input); got != tt.wantAll {
+				t.Errorf("HasAllStats() = %v, want %v", got, tt.wantAll)
+			}
+		})
+	}
+}
+
 func TestParentLinks(t *testing.T) {
 	firstLink := &sppb.PlanNode_ChildLink{ChildIndex: 2, Type: "Input"}
 	secondLink := &sppb.PlanNode_ChildLink{ChildIndex: 2, Type: "Scalar"}
@@ -452,3 +506,355 @@ func TestIsPredicate(t *testing.T) {
 		})
 	}
 }
+
+func TestDistributionTable(t *testing.T) {
+	tests := []struct {
+		name      string
+		node      *sppb.PlanNode
+		wantTable string
+		wantOK    bool
+	}{
+		{
+			name: "distributed union",
+			node: &sppb.PlanNode{
+				DisplayName: "Distributed Union",
+				Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"distribution_table":   structpb.NewStringValue("AlbumsByAlbumTitle"),
+					"split_ranges_aligned": structpb.NewStringValue("false"),
+				}},
+			},
+			wantTable: "AlbumsByAlbumTitle",
+			wantOK:    true,
+		},
+		{
+			name: "scan target is not a distribution table",
+			node: &sppb.PlanNode{
+				DisplayName: "Scan",
+				Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"scan_target": structpb.NewStringValue("Albums"),
+				}},
+			},
+		},
+		{
+			name: "nil node",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, ok := DistributionTable(tt.node)
+			if table != tt.wantTable || ok != tt.wantOK {
+				t.Fatalf("DistributionTable() = (%q, %v), want (%q, %v)", table, ok, tt.wantTable, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEstimatedRows(t *testing.T) {
+	withEstimate := func(v *structpb.Value) *sppb.PlanNode {
+		return &sppb.PlanNode{Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{"estimated_rows": v}}}
+	}
+	tests := []struct {
+		name     string
+		node     *sppb.PlanNode
+		wantRows string
+		wantOK   bool
+	}{
+		{name: "string", node: withEstimate(structpb.NewStringValue("7")), wantRows: "7", wantOK: true},
+		{name: "number", node: withEstimate(structpb.NewNumberValue(1.5)), wantRows: "1.5", wantOK: true},
+		{name: "whole number", node: withEstimate(structpb.NewNumberValue(1e6)), wantRows: "1000000", wantOK: true},
+		{name: "empty string", node: withEstimate(structpb.NewStringValue(""))},
+		{name: "other kind", node: withEstimate(structpb.NewBoolValue(true))},
+		{name: "nil node"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, ok := EstimatedRows(tt.node)
+			if rows != tt.wantRows || ok != tt.wantOK {
+				t.Fatalf("EstimatedRows() = (%q, %v), want (%q, %v)", rows, ok, tt.wantRows, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNodeTitleShowTypeMetadata(t *testing.T) {
+	localUnion := &sppb.PlanNode{
+		DisplayName: "Distributed Union",
+		Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"call_type": structpb.NewStringValue("Local"),
+		}},
+	}
+	streamAggregate := &sppb.PlanNode{
+		DisplayName: "Aggregate",
+		Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"iterator_type": structpb.NewStringValue("Stream"),
+			"call_type":     structpb.NewStringValue("Aggregate"),
+		}},
+	}
+
+	tests := []struct {
+		name string
+		node *sppb.PlanNode
+		opts []Option
+		want string
+	}{
+		{
+			name: "default merges call_type only",
+			node: localUnion,
+			want: "Local Distributed Union",
+		},
+		{
+			name: "call_type field",
+			node: localUnion,
+			opts: []Option{ShowTypeMetadata()},
+			want: "Local Distributed Union (call_type: Local)",
+		},
+		{
+			name: "compact",
+			node: localUnion,
+			opts: []Option{ShowTypeMetadata(), EnableCompact()},
+			want: "Local Distributed Union(call_type:Local)",
+		},
+		{
+			name: "value contained in display name is not repeated",
+			node: streamAggregate,
+			opts: []Option{ShowTypeMetadata()},
+			want: "Aggregate Stream Aggregate (iterator_type: Stream)",
+		},
+		{
+			name: "hide metadata takes precedence",
+			node: localUnion,
+			opts: []Option{ShowTypeMetadata(), HideMetadata()},
+			want: "Local Distributed Union",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NodeTitle(tt.node, tt.opts...); got != tt.want {
+				t.Fatalf("NodeTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeTitleShowScanEstimates(t *testing.T) {
+	scan := func(estimate *structpb.Value) *sppb.PlanNode {
+		fields := map[string]*structpb.Value{
+			"scan_type":        structpb.NewStringValue("IndexScan"),
+			"scan_target":      structpb.NewStringValue("AlbumsByAlbumTitle"),
+			"execution_method": structpb.NewStringValue("Row"),
+			"Full scan":        structpb.NewStringValue("true"),
+		}
+		if estimate != nil {
+			fields["estimated_rows"] = estimate
+		}
+		return &sppb.PlanNode{DisplayName: "Scan", Metadata: &structpb.Struct{Fields: fields}}
+	}
+	union := &sppb.PlanNode{
+		DisplayName: "Distributed Union",
+		Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"distribution_table": structpb.NewStringValue("Albums"),
+			"estimated_rows":     structpb.NewStringValue("7"),
+		}},
+	}
+	on := []Option{WithTargetMetadataFormat(TargetMetadataFormatOn), WithKnownFlagFormat(KnownFlagFormatLabel)}
+
+	tests := []struct {
+		name string
+		node *sppb.PlanNode
+		opts []Option
+		want string
+	}{
+		{
+			name: "default keeps the field",
+			node: scan(structpb.NewStringValue("7")),
+			opts: on,
+			want: "Index Scan on AlbumsByAlbumTitle (Full scan, estimated_rows: 7, execution_method: Row)",
+		},
+		{
+			name: "string estimate",
+			node: scan(structpb.NewStringValue("7")),
+			opts: append(on, ShowScanEstimates()),
+			want: "Index Scan on AlbumsByAlbumTitle (~7 rows, Full scan, execution_method: Row)",
+		},
+		{
+			name: "number estimate",
+			node: scan(structpb.NewNumberValue(1)),
+			opts: append(on, ShowScanEstimates(), WithExecutionMethodFormat(ExecutionMethodFormatAngle)),
+			want: "Index Scan on AlbumsByAlbumTitle <Row> (~1 row, Full scan)",
+		},
+		{
+			name: "compact",
+			node: scan(structpb.NewStringValue("7")),
+			opts: append(on, ShowScanEstimates(), EnableCompact()),
+			want: "Index Scan on AlbumsByAlbumTitle(~7rows,Full scan,execution_method:Row)",
+		},
+		{
+			name: "no estimate",
+			node: scan(nil),
+			opts: append(on, ShowScanEstimates()),
+			want: "Index Scan on AlbumsByAlbumTitle (Full scan, execution_method: Row)",
+		},
+		{
+			name: "not a scan",
+			node: union,
+			opts: append(on, ShowScanEstimates()),
+			want: "Distributed Union on Albums (estimated_rows: 7)",
+		},
+		{
+			name: "raw target metadata",
+			node: scan(structpb.NewStringValue("7")),
+			opts: []Option{ShowScanEstimates()},
+			want: "Index Scan (Full scan: true, Index: AlbumsByAlbumTitle, estimated_rows: 7, execution_method: Row)",
+		},
+		{
+			name: "hide metadata takes precedence",
+			node: scan(structpb.NewStringValue("7")),
+			opts: append(on, ShowScanEstimates(), HideMetadata()),
+			want: "Index Scan on AlbumsByAlbumTitle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NodeTitle(tt.node, tt.opts...); got != tt.want {
+				t.Fatalf("NodeTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperatorName(t *testing.T) {
+	scan := &sppb.PlanNode{
+		DisplayName: "Scan",
+		Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"execution_method": structpb.NewStringValue("Row"),
+			"scan_target":      structpb.NewStringValue("Singers"),
+			"scan_type":        structpb.NewStringValue("TableScan"),
+			"Full scan":        structpb.NewStringValue("true"),
+		}},
+	}
+	union := &sppb.PlanNode{
+		DisplayName: "Distributed Union",
+		Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"call_type":          structpb.NewStringValue("Local"),
+			"distribution_table": structpb.NewStringValue("Albums"),
+		}},
+	}
+
+	tests := []struct {
+		name string
+		node *sppb.PlanNode
+		opts []Option
+		want string
+	}{
+		{name: "scan", node: scan, want: "Table Scan"},
+		{name: "scan on target", node: scan, opts: []Option{WithTargetMetadataFormat(TargetMetadataFormatOn), WithExecutionMethodFormat(ExecutionMethodFormatAngle)}, want: "Table Scan on Singers"},
+		{name: "call type", node: union, want: "Local Distributed Union"},
+		{name: "call type on target", node: union, opts: []Option{WithTargetMetadataFormat(TargetMetadataFormatOn)}, want: "Local Distributed Union on Albums"},
+		{name: "no metadata", node: &sppb.PlanNode{DisplayName: "Serialize Result"}, want: "Serialize Result"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := OperatorName(tt.node, tt.opts...)
+			if got != tt.want {
+				t.Fatalf("OperatorName() = %q, want %q", got, tt.want)
+			}
+			if title := NodeTitle(tt.node, tt.opts...); !strings.HasPrefix(title, got) {
+				t.Errorf("NodeTitle() = %q, want prefix %q", title, got)
+			}
+		})
+	}
+}
+
+//go:embed testdata/scalar_subquery.yaml
+var scalarSubqueryYAML []byte
+
+func TestSubqueries(t *testing.T) {
+	stats, _, err := ExtractQueryPlan(scalarSubqueryYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(stats.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	condition := qp.GetNodeByIndex(4)
+	got := qp.Subqueries(condition)
+	if want := map[string]int32{"sq_1": 6}; !maps.Equal(got, want) {
+		t.Fatalf("Subqueries(condition) = %v, want %v", got, want)
+	}
+	if name := qp.GetNodeByIndex(got["sq_1"]).GetDisplayName(); name != "Scalar Subquery" {
+		t.Errorf("subquery node display name = %q, want %q", name, "Scalar Subquery")
+	}
+
+	for _, index := range []int32{2, 5} {
+		if got := qp.Subqueries(qp.GetNodeByIndex(index)); got != nil {
+			t.Errorf("Subqueries(node %d) = %v, want nil", index, got)
+		}
+	}
+	if got := qp.Subqueries(nil); got != nil {
+		t.Errorf("Subqueries(nil) = %v, want nil", got)
+	}
+
+	dangling := &sppb.PlanNode{ShortRepresentation: &sppb.PlanNode_ShortRepresentation{
+		Subqueries: map[string]int32{"sq_1": 6, "sq_2": 99, "sq_3": -1},
+	}}
+	if got, want := qp.Subqueries(dangling), map[string]int32{"sq_1": 6}; !maps.Equal(got, want) {
+		t.Errorf("Subqueries(dangling) = %v, want %v", got, want)
+	}
+}
+
+func TestWithVisibilityOverride(t *testing.T) {
+	planNodes := []*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Cross Apply", ChildLinks: []*sppb.PlanNode_ChildLink{
+			{ChildIndex: 1, Type: "Input"},
+			{ChildIndex: 2, Type: "Map"},
+			{ChildIndex: 3, Type: "Condition"},
+		}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan A"},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan B"},
+		{Index: 3, Kind: sppb.PlanNode_SCALAR, DisplayName: "Function"},
+	}
+	override := func(_ *QueryPlan, link *sppb.PlanNode_ChildLink) (bool, bool) {
+		switch link.GetType() {
+		case "Map":
+			return false, true
+		case "Condition":
+			return true, true
+		default:
+			return false, false
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []int32
+	}{
+		{name: "default", want: []int32{1, 2}},
+		{name: "override", opts: []Option{WithVisibilityOverride(override)}, want: []int32{1, 3}},
+		{name: "formatting options are ignored", opts: []Option{HideMetadata()}, want: []int32{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(planNodes, tt.opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var got []int32
+			for _, link := range qp.VisibleChildLinks(qp.GetNodeByIndex(0)) {
+				got = append(got, link.GetChildIndex())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("VisibleChildLinks() = %v, want %v", got, tt.want)
+			}
+			if !qp.IsVisible(nil) {
+				t.Error("IsVisible(nil) = false, want true")
+			}
+		})
+	}
+}
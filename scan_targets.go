@@ -0,0 +1,54 @@
+package spannerplan
+
+import (
+	"strings"
+)
+
+// ScanKind is what a scan operator reads, taken from its scan_type metadata.
+type ScanKind string
+
+const (
+	// ScanIndex is a scan of a secondary index, scan_type IndexScan.
+	ScanIndex ScanKind = "Index"
+	// ScanTable is a scan of a base table, scan_type TableScan.
+	ScanTable ScanKind = "Table"
+	// ScanBatch is a scan of the batch of rows an apply sends to its Map side, scan_type
+	// BatchScan, such as "$v2".
+	ScanBatch ScanKind = "Batch"
+)
+
+// ScanTarget is a table, index or batch read by a scan operator of the plan.
+type ScanTarget struct {
+	// NodeID is the PlanNode index of the scan.
+	NodeID int32
+	// Kind is the scan_type metadata without its "Scan" suffix, such as [ScanIndex]. A scan_type
+	// without a constant, such as a future one, is kept the same way.
+	Kind ScanKind
+	// Name is the scan_target metadata, such as "SongsBySongGenre".
+	Name string
+	// FullScan reports that the scan has the "Full scan" metadata set to "true".
+	FullScan bool
+	// ScanMethod is the scan_method metadata, such as "Row" or "Automatic", or "" without one.
+	ScanMethod string
+}
+
+// ScanTargets returns the scan targets of every operator with scan_type metadata, in ascending
+// PlanNode index order, or nil when the plan has none.
+func (qp *QueryPlan) ScanTargets() []ScanTarget {
+	var targets []ScanTarget
+	for _, node := range qp.planNodes {
+		fields := node.GetMetadata().GetFields()
+		scanType := fields["scan_type"].GetStringValue()
+		if scanType == "" {
+			continue
+		}
+		targets = append(targets, ScanTarget{
+			NodeID:     node.GetIndex(),
+			Kind:       ScanKind(strings.TrimSuffix(scanType, "Scan")),
+			Name:       fields["scan_target"].GetStringValue(),
+			FullScan:   fields["Full scan"].GetStringValue() == "true",
+			ScanMethod: fields["scan_method"].GetStringValue(),
+		})
+	}
+	return targets
+}
@@ -0,0 +1,55 @@
+package spannerplan
+
+import (
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanTargets(t *testing.T) {
+	dca, _, err := ExtractQueryPlan(dcaYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	profile, _, err := ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		planNodes []*sppb.PlanNode
+		want      []ScanTarget
+	}{
+		{
+			name:      "plan",
+			planNodes: dca.GetQueryPlan().GetPlanNodes(),
+			want: []ScanTarget{
+				{NodeID: 6, Kind: ScanIndex, Name: "AlbumsByAlbumTitle", ScanMethod: "Row"},
+				{NodeID: 25, Kind: ScanBatch, Name: "$v2", ScanMethod: "Row"},
+				{NodeID: 31, Kind: ScanTable, Name: "Albums", ScanMethod: "Row"},
+			},
+		},
+		{
+			name:      "profile with full scans",
+			planNodes: profile.GetQueryPlan().GetPlanNodes(),
+			want: []ScanTarget{
+				{NodeID: 5, Kind: ScanIndex, Name: "AlbumsByAlbumTitle", FullScan: true, ScanMethod: "Automatic"},
+				{NodeID: 13, Kind: ScanBatch, Name: "$v2", ScanMethod: "Row"},
+				{NodeID: 18, Kind: ScanIndex, Name: "SongsBySongGenre", FullScan: true, ScanMethod: "Row"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.planNodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, qp.ScanTargets()); diff != "" {
+				t.Errorf("ScanTargets() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package spannerplan
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+
+	"github.com/apstndb/spannerplan/stats"
+)
+
+// Span is one operator of a plan as a trace span, in a generic form callers can map to
+// OpenTelemetry, Jaeger or another tracing model.
+type Span struct {
+	// TraceID is the trace ID passed to [QueryPlan.ToSpans].
+	TraceID string
+	// SpanID is the PlanNode index plus one as 16 hex digits, such as "0000000000000001" for
+	// the root, since tracing systems reserve the all-zero ID.
+	SpanID string
+	// ParentSpanID is the SpanID of the parent operator, or "" for the root.
+	ParentSpanID string
+	// Name is the [NodeTitle] of the operator with [HideMetadata], such as "Distributed Union",
+	// which keeps span names few as tracing systems expect. Attributes hold the metadata.
+	Name string
+	// Start and Duration are the execution interval of the operator. See Synthesized.
+	Start    time.Time
+	Duration time.Duration
+	// Synthesized reports that the operator has no execution timestamps, so Start is the Start
+	// of its parent, or the Unix epoch for the root, and Duration is its latency statistic, or
+	// zero without one.
+	Synthesized bool
+	// Attributes holds the PlanNode index as "spanner.plan_node.index", the display name as
+	// "spanner.display_name", the link type from the parent as "spanner.link_type" when there is
+	// one, and each metadata field and raw execution statistic with the "spanner.metadata." and
+	// "spanner.stats." prefixes.
+	Attributes map[string]string
+}
+
+// ToSpans returns a span for each visible operator of the plan, in pre-order of the operator
+// tree, with traceID and with parent span IDs following the tree. A node reachable through
+// several parents becomes one span, under the first parent in pre-order, and a child link back
+// to a node on the current path is skipped.
+//
+// Spans take their interval from the execution_start_timestamp and execution_end_timestamp of
+// the execution summary. Spanner reports them for only some operators, and for none in a PLAN
+// capture, so the others get an interval synthesized from their latency (see
+// [Span.Synthesized]). ToSpans returns an error when execution statistics cannot be extracted
+// or a timestamp is invalid.
+func (qp *QueryPlan) ToSpans(traceID string) ([]Span, error) {
+	var spans []Span
+	visited := make(map[int32]bool)
+	var walk func(link *sppb.PlanNode_ChildLink, linkType string, parent *Span) error
+	walk = func(link *sppb.PlanNode_ChildLink, linkType string, parent *Span) error {
+		node := qp.GetNodeByChildLink(link)
+		if visited[node.GetIndex()] {
+			return nil
+		}
+		visited[node.GetIndex()] = true
+
+		span, err := newSpan(node, traceID, linkType, parent)
+		if err != nil {
+			return err
+		}
+		spans = append(spans, span)
+		for i, child := range node.GetChildLinks() {
+			if !qp.IsVisible(child) {
+				continue
+			}
+			if err := walk(child, qp.LinkTypeInParent(node, i), &span); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !qp.IsVisible(nil) {
+		return nil, nil
+	}
+	if err := walk(nil, "", nil); err != nil {
+		return nil, err
+	}
+	return spans, nil
+}
+
+// newSpan returns the span of node under parent, which is nil for the root.
+func newSpan(node *sppb.PlanNode, traceID, linkType string, parent *Span) (Span, error) {
+	executionStats, err := stats.Extract(node, false)
+	if err != nil {
+		return Span{}, fmt.Errorf("failed to extract execution stats of node %d: %w", node.GetIndex(), err)
+	}
+
+	span := Span{
+		TraceID: traceID,
+		SpanID:  fmt.Sprintf("%016x", int64(node.GetIndex())+1),
+		Name:    NodeTitle(node, HideMetadata()),
+		Attributes: map[string]string{
+			"spanner.plan_node.index": strconv.Itoa(int(node.GetIndex())),
+			"spanner.display_name":    node.GetDisplayName(),
+		},
+	}
+	if parent != nil {
+		span.ParentSpanID = parent.SpanID
+	}
+	if linkType != "" {
+		span.Attributes["spanner.link_type"] = linkType
+	}
+	for _, field := range NodeMetadataSorted(node) {
+		span.Attributes["spanner.metadata."+field.Key] = field.Value
+	}
+	for key, value := range stats.ExtractStrings(node) {
+		span.Attributes["spanner.stats."+key] = value
+	}
+
+	summary := executionStats.ExecutionSummary
+	if summary.ExecutionStartTimestamp != "" && summary.ExecutionEndTimestamp != "" {
+		start, err := summary.StartTime()
+		if err != nil {
+			return Span{}, fmt.Errorf("node %d: %w", node.GetIndex(), err)
+		}
+		end, err := summary.EndTime()
+		if err != nil {
+			return Span{}, fmt.Errorf("node %d: %w", node.GetIndex(), err)
+		}
+		span.Start, span.Duration = start, end.Sub(start)
+		return span, nil
+	}
+
+	span.Synthesized = true
+	span.Start = time.Unix(0, 0).UTC()
+	if parent != nil {
+		span.Start = parent.Start
+	}
+	if executionStats.Latency.Total != "" {
+		if span.Duration, err = executionStats.Latency.Duration(); err != nil {
+			return Span{}, fmt.Errorf("node %d: %w", node.GetIndex(), err)
+		}
+	}
+	return span, nil
+}
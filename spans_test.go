@@ -0,0 +1,94 @@
+package spannerplan
+
+import (
+	"testing"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToSpans(t *testing.T) {
+	profile, _, err := ExtractQueryPlan(dcaProfileYAML)
+	if err != nil {
+		t.Fatalf("ExtractQueryPlan() error = %v", err)
+	}
+	qp, err := New(profile.GetQueryPlan().GetPlanNodes())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	spans, err := qp.ToSpans("4bf92f3577b34da6a3ce929d0e0e4736")
+	if err != nil {
+		t.Fatalf("ToSpans() error = %v", err)
+	}
+	if len(spans) != 12 {
+		t.Fatalf("len(ToSpans()) = %d, want 12", len(spans))
+	}
+
+	type spanSummary struct {
+		SpanID, ParentSpanID, Name string
+		Start                      time.Time
+		Duration                   time.Duration
+		Synthesized                bool
+	}
+	summarize := func(s Span) spanSummary {
+		return spanSummary{s.SpanID, s.ParentSpanID, s.Name, s.Start, s.Duration, s.Synthesized}
+	}
+	crossApplyStart := time.Unix(1745245143, 426959000).UTC()
+	want := []spanSummary{
+		{"0000000000000001", "", "Distributed Union", time.Unix(1745245143, 426926000).UTC(), 1956 * time.Microsecond, false},
+		{"0000000000000002", "0000000000000001", "Distributed Cross Apply", crossApplyStart, 1917 * time.Microsecond, false},
+		{"0000000000000003", "0000000000000002", "Create Batch", crossApplyStart, 0, true},
+		{"0000000000000004", "0000000000000003", "Local Distributed Union", crossApplyStart, 950 * time.Microsecond, true},
+	}
+	var got []spanSummary
+	for _, span := range spans[:len(want)] {
+		got = append(got, summarize(span))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ToSpans() mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, span := range spans {
+		if span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("span %s TraceID = %q", span.SpanID, span.TraceID)
+		}
+	}
+	attrs := spans[2].Attributes
+	if attrs["spanner.plan_node.index"] != "2" || attrs["spanner.display_name"] != "Create Batch" || attrs["spanner.link_type"] != "Input" {
+		t.Errorf("spans[2].Attributes = %v, want index, display name and link type", attrs)
+	}
+	if got := spans[0].Attributes["spanner.metadata.distribution_table"]; got != "AlbumsByAlbumTitle" {
+		t.Errorf("spans[0] distribution_table attribute = %q, want %q", got, "AlbumsByAlbumTitle")
+	}
+	if got := spans[0].Attributes["spanner.stats.rows"]; got != "33 rows" {
+		t.Errorf("spans[0] rows attribute = %q, want %q", got, "33 rows")
+	}
+}
+
+func TestToSpansSharedChild(t *testing.T) {
+	qp, err := New([]*sppb.PlanNode{
+		{Index: 0, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Union All", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 1}, {ChildIndex: 2}}},
+		{Index: 1, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Filter", ChildLinks: []*sppb.PlanNode_ChildLink{{ChildIndex: 2}}},
+		{Index: 2, Kind: sppb.PlanNode_RELATIONAL, DisplayName: "Scan"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	spans, err := qp.ToSpans("trace")
+	if err != nil {
+		t.Fatalf("ToSpans() error = %v", err)
+	}
+	var got [][2]string
+	for _, span := range spans {
+		got = append(got, [2]string{span.SpanID, span.ParentSpanID})
+	}
+	want := [][2]string{{"0000000000000001", ""}, {"0000000000000002", "0000000000000001"}, {"0000000000000003", "0000000000000002"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ToSpans() span links mismatch (-want +got):\n%s", diff)
+	}
+	if !spans[0].Start.Equal(time.Unix(0, 0)) || spans[0].Duration != 0 || !spans[0].Synthesized {
+		t.Errorf("spans[0] = %+v, want a synthesized span at the Unix epoch", spans[0])
+	}
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// byteUnits maps the accepted unit suffixes of [ParseBytes] to their sizes. Decimal (SI) and
+// binary (IEC) units are distinct: "KB" is 1000 bytes and "KiB" is 1024 bytes.
+var byteUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"KB":  1e3,
+	"kB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// binaryByteUnits lists the units [FormatBytes] chooses from, in increasing size.
+var binaryByteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+// ParseBytes parses a byte quantity such as "1.2 MiB", "512KB" or "42". A number without a
+// unit is a count of bytes. Units are case-sensitive so that the SI units KB, MB, GB and TB
+// (powers of 1000) are never confused with the IEC units KiB, MiB, GiB and TiB (powers of
+// 1024); "kB" is accepted as an alias of "KB". Fractional results are rounded to the nearest byte.
+func ParseBytes(s string) (int64, error) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return 0, errors.New("empty byte quantity")
+	}
+	number := strings.TrimRightFunc(trimmed, func(r rune) bool {
+		return r == ' ' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+	})
+	unit := strings.TrimSpace(trimmed[len(number):])
+	size, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte quantity %q: unknown unit %q", s, unit)
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte quantity %q: %w", s, err)
+	}
+	if f < 0 || math.IsNaN(f) {
+		return 0, fmt.Errorf("invalid byte quantity %q: must not be negative", s)
+	}
+	n := math.Round(f * size)
+	if n >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid byte quantity %q: out of range", s)
+	}
+	return int64(n), nil
+}
+
+// FormatBytes formats n with the largest binary unit that keeps the value at least 1,
+// rounded to one decimal place, such as "512 B", "2 KiB" or "1.2 MiB".
+func FormatBytes(n int64) string {
+	if n < 0 {
+		return "-" + FormatBytes(-n)
+	}
+	v := float64(n)
+	unit := binaryByteUnits[0]
+	for _, next := range binaryByteUnits[1:] {
+		if v < 1024 {
+			break
+		}
+		v /= 1024
+		unit = next
+	}
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64) + " " + unit
+}
+
+// Bytes parses Total and Unit as a byte quantity with [ParseBytes]. An empty Unit means bytes.
+func (v ExecutionStatsValue) Bytes() (int64, error) {
+	if v.Total == "" {
+		return 0, errors.New("empty execution stats value")
+	}
+	return ParseBytes(v.Total + " " + v.Unit)
+}
+
+// DiskUsageBytes returns the "Disk Usage (KBytes)" statistic in bytes.
+func (s ExecutionStats) DiskUsageBytes() (int64, error) {
+	return kbytesValue(s.DiskUsageKBytes)
+}
+
+// PeakMemoryUsageBytes returns the "Peak Memory Usage (KBytes)" statistic in bytes.
+func (s ExecutionStats) PeakMemoryUsageBytes() (int64, error) {
+	return kbytesValue(s.PeakMemoryUsageKBytes)
+}
+
+// PeakBufferingMemoryUsageBytes returns the "Peak Buffering Memory Usage (KBytes)" statistic in bytes.
+func (s ExecutionStats) PeakBufferingMemoryUsageBytes() (int64, error) {
+	return kbytesValue(s.PeekBufferingMemoryUsageKBytes)
+}
+
+// kbytesValue converts a statistic whose name is suffixed with "(KBytes)" to bytes. Spanner does
+// not say which kilobyte it means, so a value without its own unit is read as KiB, the usual
+// convention for memory and disk accounting. An explicit unit takes precedence.
+func kbytesValue(v ExecutionStatsValue) (int64, error) {
+	if v.Unit == "" {
+		v.Unit = "KiB"
+	}
+	return v.Bytes()
+}
@@ -0,0 +1,90 @@
+package stats
+
+import "testing"
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "42", want: 42},
+		{input: "42 B", want: 42},
+		{input: "1 KB", want: 1000},
+		{input: "1kB", want: 1000},
+		{input: "1.5 MB", want: 1_500_000},
+		{input: "2 GB", want: 2_000_000_000},
+		{input: "1 TB", want: 1_000_000_000_000},
+		{input: "1 KiB", want: 1024},
+		{input: "1.2 MiB", want: 1_258_291},
+		{input: "2GiB", want: 2 << 30},
+		{input: "1 TiB", want: 1 << 40},
+		{input: " 0.5 KiB ", want: 512},
+		{input: "", wantErr: true},
+		{input: "MiB", wantErr: true},
+		{input: "1 mb", wantErr: true},
+		{input: "1 kib", wantErr: true},
+		{input: "1 KIB", wantErr: true},
+		{input: "1 PiB", wantErr: true},
+		{input: "-1 KiB", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "9000000 TiB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseBytes(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseBytes(%q) = %d, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBytes(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseBytes(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1 KiB"},
+		{1_258_291, "1.2 MiB"},
+		{3 << 30, "3 GiB"},
+		{-2048, "-2 KiB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.input); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestExecutionStats_ByteStats(t *testing.T) {
+	s := ExecutionStats{
+		DiskUsageKBytes:                ExecutionStatsValue{Total: "2"},
+		PeakMemoryUsageKBytes:          ExecutionStatsValue{Total: "1.5", Unit: "MB"},
+		PeekBufferingMemoryUsageKBytes: ExecutionStatsValue{},
+	}
+	if got, err := s.DiskUsageBytes(); err != nil || got != 2048 {
+		t.Errorf("DiskUsageBytes() = (%d, %v), want (2048, nil)", got, err)
+	}
+	if got, err := s.PeakMemoryUsageBytes(); err != nil || got != 1_500_000 {
+		t.Errorf("PeakMemoryUsageBytes() = (%d, %v), want (1500000, nil)", got, err)
+	}
+	if _, err := s.PeakBufferingMemoryUsageBytes(); err == nil {
+		t.Error("PeakBufferingMemoryUsageBytes() error = nil, want error for an absent statistic")
+	}
+	if got, err := (ExecutionStatsValue{Total: "64", Unit: "KiB"}).Bytes(); err != nil || got != 65536 {
+		t.Errorf("Bytes() = (%d, %v), want (65536, nil)", got, err)
+	}
+}
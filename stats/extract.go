@@ -3,8 +3,10 @@ package stats
 import (
 	"bytes"
 	"encoding/json"
+	"strconv"
 
 	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 func Extract(node *spannerpb.PlanNode, disallowUnknownFields bool) (*ExecutionStats, error) {
@@ -12,9 +14,68 @@ func Extract(node *spannerpb.PlanNode, disallowUnknownFields bool) (*ExecutionSt
 	if err := jsonRoundtrip(node.GetExecutionStats(), &executionStats, disallowUnknownFields); err != nil {
 		return nil, err
 	}
+	executionStats.Extra = extractExtra(node)
 	return &executionStats, nil
 }
 
+// extractExtra decodes the execution stats keys that have no ExecutionStats field.
+// Values that are not shaped like ExecutionStatsValue are skipped.
+func extractExtra(node *spannerpb.PlanNode) map[string]ExecutionStatsValue {
+	var extra map[string]ExecutionStatsValue
+	known := executionStatsFieldIndexes()
+	for key, value := range node.GetExecutionStats().GetFields() {
+		if _, ok := known[key]; ok {
+			continue
+		}
+		var v ExecutionStatsValue
+		if err := jsonRoundtrip(value, &v, false); err != nil {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]ExecutionStatsValue)
+		}
+		extra[key] = v
+	}
+	return extra
+}
+
+// ExtractStrings returns the execution stats of node as strings keyed by their Spanner names, without
+// interpreting them. A value with a total, such as {"total": "33", "unit": "rows"}, becomes its
+// total and unit ("33 rows"), strings and numbers are kept as written, and any other value is
+// encoded as JSON. It returns nil when the node has no execution stats.
+func ExtractStrings(node *spannerpb.PlanNode) map[string]string {
+	fields := node.GetExecutionStats().GetFields()
+	if len(fields) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(fields))
+	for key, value := range fields {
+		result[key] = statsValueString(value)
+	}
+	return result
+}
+
+func statsValueString(value *structpb.Value) string {
+	switch kind := value.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return kind.StringValue
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(kind.NumberValue, 'f', -1, 64)
+	case *structpb.Value_StructValue:
+		if total, ok := kind.StructValue.GetFields()["total"]; ok {
+			return ExecutionStatsValue{
+				Total: statsValueString(total),
+				Unit:  kind.StructValue.GetFields()["unit"].GetStringValue(),
+			}.String()
+		}
+	}
+	b, err := json.Marshal(value.AsInterface())
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 func jsonRoundtrip(input interface{}, output interface{}, disallowUnknownFields bool) error {
 	b, err := json.Marshal(input)
 	if err != nil {
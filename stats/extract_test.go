@@ -0,0 +1,83 @@
+package stats
+
+import (
+	_ "embed"
+	"testing"
+
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+//go:embed testdata/index_scan.json
+var indexScanJSON []byte
+
+func TestExtract_Extra(t *testing.T) {
+	var node spannerpb.PlanNode
+	if err := protojson.Unmarshal(indexScanJSON, &node); err != nil {
+		t.Fatalf("protojson.Unmarshal() error = %v", err)
+	}
+
+	got, err := Extract(&node, false)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	want := map[string]ExecutionStatsValue{"Rows Skipped": {Total: "2", Unit: "rows"}}
+	if diff := cmp.Diff(want, got.Extra); diff != "" {
+		t.Errorf("Extra mismatch (-want +got):\n%s", diff)
+	}
+	if v, ok := got.Value("Rows Skipped"); !ok || v.Total != "2" {
+		t.Errorf(`Value("Rows Skipped") = (%+v, %v), want total 2`, v, ok)
+	}
+	if v, ok := got.Value("scanned_rows"); !ok || v.Total != "63" {
+		t.Errorf(`Value("scanned_rows") = (%+v, %v), want total 63`, v, ok)
+	}
+	if _, ok := got.Value("deleted_rows"); ok {
+		t.Error(`Value("deleted_rows") reported true for an absent statistic`)
+	}
+
+	if _, err := Extract(&node, true); err == nil {
+		t.Error("Extract(disallowUnknownFields) error = nil, want non-nil")
+	}
+}
+
+func TestExtract_CpuTime(t *testing.T) {
+	var node spannerpb.PlanNode
+	if err := protojson.Unmarshal([]byte(`{"index": 1, "executionStats": {
+		"cpu_time": {"total": "0.18", "unit": "msecs"},
+		"latency": {"total": "0.84", "unit": "msecs"}
+	}}`), &node); err != nil {
+		t.Fatalf("protojson.Unmarshal() error = %v", err)
+	}
+
+	got, err := Extract(&node, true)
+	if err != nil {
+		t.Fatalf("Extract(disallowUnknownFields) error = %v", err)
+	}
+	if diff := cmp.Diff(ExecutionStatsValue{Total: "0.18", Unit: "msecs"}, got.CpuTime); diff != "" {
+		t.Errorf("CpuTime mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExtractStrings(t *testing.T) {
+	var node spannerpb.PlanNode
+	if err := protojson.Unmarshal(indexScanJSON, &node); err != nil {
+		t.Fatalf("protojson.Unmarshal() error = %v", err)
+	}
+
+	want := map[string]string{
+		"execution_summary": `{"num_executions":"7"}`,
+		"filtered_rows":     "30 rows",
+		"latency":           "0.84 msecs",
+		"rows":              "33 rows",
+		"scanned_rows":      "63 rows",
+		"Rows Skipped":      "2 rows",
+		"scan_mode":         "Row",
+	}
+	if diff := cmp.Diff(want, ExtractStrings(&node)); diff != "" {
+		t.Errorf("ExtractStrings() mismatch (-want +got):\n%s", diff)
+	}
+	if got := ExtractStrings(&spannerpb.PlanNode{}); got != nil {
+		t.Errorf("ExtractStrings(no stats) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// regressionMetric is a statistic compared by [ExecutionStats.RegressedAgainst].
+type regressionMetric struct {
+	name  string
+	value func(ExecutionStats) ExecutionStatsValue
+	// parse converts a value to a number comparable across units, and format renders it in reasons.
+	parse  func(ExecutionStatsValue) (float64, error)
+	format func(ExecutionStatsValue) string
+}
+
+func parseSeconds(v ExecutionStatsValue) (float64, error) {
+	d, err := v.Duration()
+	return d.Seconds(), err
+}
+
+var regressionMetrics = []regressionMetric{
+	{
+		name:   "latency",
+		value:  func(s ExecutionStats) ExecutionStatsValue { return s.Latency },
+		parse:  parseSeconds,
+		format: ExecutionStatsValue.ShortDuration,
+	},
+	{
+		name:   "cpu_time",
+		value:  func(s ExecutionStats) ExecutionStatsValue { return s.CpuTime },
+		parse:  parseSeconds,
+		format: ExecutionStatsValue.ShortDuration,
+	},
+	{
+		name:   "scanned_rows",
+		value:  func(s ExecutionStats) ExecutionStatsValue { return s.ScannedRows },
+		parse:  ExecutionStatsValue.Float64,
+		format: func(v ExecutionStatsValue) string { return v.Total },
+	},
+}
+
+// RegressedAgainst reports whether s regressed against baseline, the statistics of the same
+// operator in an earlier run, by more than pct percent, and describes each regressed statistic
+// with a reason such as "latency 1.2ms -> 3.6ms (+200%)".
+//
+// The statistics considered are latency, cpu_time and scanned_rows, in that order. Durations are
+// compared after converting their units, so "1.5 secs" regresses against "900 msecs". A statistic
+// absent or unparsable in either s or baseline is skipped, so an operator without a baseline never
+// regresses. A zero baseline regresses whenever s is above zero, because no percentage of zero
+// can be exceeded otherwise.
+func (s ExecutionStats) RegressedAgainst(baseline ExecutionStats, pct float64) (bool, []string) {
+	var reasons []string
+	for _, m := range regressionMetrics {
+		current, base := m.value(s), m.value(baseline)
+		if current.Total == "" || base.Total == "" {
+			continue
+		}
+		c, err := m.parse(current)
+		if err != nil {
+			continue
+		}
+		b, err := m.parse(base)
+		if err != nil {
+			continue
+		}
+		switch {
+		case b == 0 && c > 0:
+			reasons = append(reasons, fmt.Sprintf("%s %s -> %s (new)", m.name, m.format(base), m.format(current)))
+		case b > 0 && (c-b)/b*100 > pct:
+			increase := strconv.FormatFloat((c-b)/b*100, 'f', 0, 64)
+			reasons = append(reasons, fmt.Sprintf("%s %s -> %s (+%s%%)", m.name, m.format(base), m.format(current), increase))
+		}
+	}
+	return len(reasons) > 0, reasons
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecutionStats_RegressedAgainst(t *testing.T) {
+	baseline := ExecutionStats{
+		Latency:     ExecutionStatsValue{Total: "1.2", Unit: "msecs"},
+		CpuTime:     ExecutionStatsValue{Total: "900", Unit: "usecs"},
+		ScannedRows: ExecutionStatsValue{Total: "100", Unit: "rows"},
+	}
+	tests := []struct {
+		name     string
+		current  ExecutionStats
+		baseline ExecutionStats
+		pct      float64
+		want     []string
+	}{
+		{
+			name: "within threshold",
+			current: ExecutionStats{
+				Latency:     ExecutionStatsValue{Total: "1.3", Unit: "msecs"},
+				CpuTime:     ExecutionStatsValue{Total: "0.95", Unit: "msecs"},
+				ScannedRows: ExecutionStatsValue{Total: "110", Unit: "rows"},
+			},
+			baseline: baseline,
+			pct:      10,
+		},
+		{
+			name: "latency and scanned rows",
+			current: ExecutionStats{
+				Latency:     ExecutionStatsValue{Total: "3.6", Unit: "msecs"},
+				CpuTime:     ExecutionStatsValue{Total: "900", Unit: "usecs"},
+				ScannedRows: ExecutionStatsValue{Total: "150", Unit: "rows"},
+			},
+			baseline: baseline,
+			pct:      10,
+			want:     []string{"latency 1.2ms -> 3.6ms (+200%)", "scanned_rows 100 -> 150 (+50%)"},
+		},
+		{
+			name:     "units are converted",
+			current:  ExecutionStats{CpuTime: ExecutionStatsValue{Total: "1.5", Unit: "secs"}},
+			baseline: ExecutionStats{CpuTime: ExecutionStatsValue{Total: "900", Unit: "msecs"}},
+			pct:      50,
+			want:     []string{"cpu_time 900ms -> 1.5s (+67%)"},
+		},
+		{
+			name:     "absent baseline",
+			current:  baseline,
+			baseline: ExecutionStats{},
+		},
+		{
+			name:     "absent current",
+			current:  ExecutionStats{},
+			baseline: baseline,
+		},
+		{
+			name:     "zero baseline",
+			current:  ExecutionStats{ScannedRows: ExecutionStatsValue{Total: "5", Unit: "rows"}},
+			baseline: ExecutionStats{ScannedRows: ExecutionStatsValue{Total: "0", Unit: "rows"}},
+			pct:      1000,
+			want:     []string{"scanned_rows 0 -> 5 (new)"},
+		},
+		{
+			name:     "unparsable",
+			current:  ExecutionStats{Latency: ExecutionStatsValue{Total: "9", Unit: "ticks"}},
+			baseline: ExecutionStats{Latency: ExecutionStatsValue{Total: "1", Unit: "ticks"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regressed, reasons := tt.current.RegressedAgainst(tt.baseline, tt.pct)
+			if regressed != (len(tt.want) > 0) {
+				t.Errorf("RegressedAgainst() regressed = %v, want %v", regressed, len(tt.want) > 0)
+			}
+			if diff := cmp.Diff(tt.want, reasons); diff != "" {
+				t.Errorf("RegressedAgainst() reasons mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -2,7 +2,14 @@ package stats
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type ExecutionStatsHistogram struct {
@@ -28,6 +35,63 @@ func (v ExecutionStatsValue) String() string {
 	}
 }
 
+// Float64 parses Total as a number. It returns an error when Total is empty or not numeric.
+func (v ExecutionStatsValue) Float64() (float64, error) {
+	if v.Total == "" {
+		return 0, errors.New("empty execution stats value")
+	}
+	f, err := strconv.ParseFloat(v.Total, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid execution stats value %q: %w", v.Total, err)
+	}
+	return f, nil
+}
+
+// Duration parses Total as a duration in Unit. Spanner reports durations in "msecs", "secs", or "usecs".
+func (v ExecutionStatsValue) Duration() (time.Duration, error) {
+	f, err := v.Float64()
+	if err != nil {
+		return 0, err
+	}
+	var unit time.Duration
+	switch v.Unit {
+	case "secs":
+		unit = time.Second
+	case "msecs":
+		unit = time.Millisecond
+	case "usecs":
+		unit = time.Microsecond
+	default:
+		return 0, fmt.Errorf("unknown duration unit %q", v.Unit)
+	}
+	return time.Duration(math.Round(f * float64(unit))), nil
+}
+
+// PercentOf returns v as a percentage of total, such as 50 for "0.5 msecs" of "1 msecs". Both are
+// parsed with [ExecutionStatsValue.Duration], so their units may differ. It reports false when
+// either is empty or not a duration, or when total is zero.
+func (v ExecutionStatsValue) PercentOf(total ExecutionStatsValue) (float64, bool) {
+	d, err := v.Duration()
+	if err != nil {
+		return 0, false
+	}
+	t, err := total.Duration()
+	if err != nil || t == 0 {
+		return 0, false
+	}
+	return float64(d) / float64(t) * 100, true
+}
+
+// LatencyDuration returns the latency statistic as a duration. See [ExecutionStatsValue.Duration].
+func (s ExecutionStats) LatencyDuration() (time.Duration, error) {
+	return s.Latency.Duration()
+}
+
+// CpuTimeDuration returns the cpu_time statistic as a duration. See [ExecutionStatsValue.Duration].
+func (s ExecutionStats) CpuTimeDuration() (time.Duration, error) {
+	return s.CpuTime.Duration()
+}
+
 type ExecutionStatsSummary struct {
 	NumExecutions           string      `json:"num_executions"`
 	CheckpointTime          string      `json:"checkpoint_time"`
@@ -36,6 +100,18 @@ type ExecutionStatsSummary struct {
 	NumCheckPoints          json.Number `json:"num_checkpoints"`
 }
 
+// Executions parses NumExecutions. It returns an error when NumExecutions is empty or not an integer.
+func (s ExecutionStatsSummary) Executions() (int64, error) {
+	if s.NumExecutions == "" {
+		return 0, errors.New("empty num_executions")
+	}
+	n, err := strconv.ParseInt(s.NumExecutions, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid num_executions %q: %w", s.NumExecutions, err)
+	}
+	return n, nil
+}
+
 type ExecutionStats struct {
 	DiskUsageKBytes                ExecutionStatsValue   `json:"Disk Usage (KBytes)"`
 	DiskWriteLatencyMsecs          ExecutionStatsValue   `json:"Disk Write Latency (msecs)"`
@@ -52,4 +128,180 @@ type ExecutionStats struct {
 	ScannedRows                    ExecutionStatsValue   `json:"scanned_rows"`
 	ExecutionSummary               ExecutionStatsSummary `json:"execution_summary"`
 	NumberOfBatches                ExecutionStatsValue   `json:"Number of Batches"`
+
+	// Extra holds statistics without a dedicated field, keyed by their Spanner names.
+	// [Extract] fills it with every unrecognized key whose value has the shape of an
+	// [ExecutionStatsValue], so new statistics are readable before they get a field.
+	Extra map[string]ExecutionStatsValue `json:"-"`
+}
+
+// String returns a compact one-line summary such as "rows=33 scanned=63 filtered=30 exec=7 lat=0.84ms",
+// omitting absent statistics. Durations keep Spanner's precision and use a short unit suffix, so
+// "0.84 msecs" is written as "0.84ms". Statistics in [ExecutionStats.Extra] are not included.
+func (s ExecutionStats) String() string {
+	var parts []string
+	add := func(name, value string) {
+		if value != "" {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	add("rows", s.Rows.Total)
+	add("scanned", s.ScannedRows.Total)
+	add("filtered", s.FilteredRows.Total)
+	add("deleted", s.DeletedRows.Total)
+	add("exec", s.ExecutionSummary.NumExecutions)
+	add("lat", s.Latency.ShortDuration())
+	add("cpu", s.CpuTime.ShortDuration())
+	return strings.Join(parts, " ")
+}
+
+// shortDurationUnits maps Spanner duration units to the suffixes used by [ExecutionStats.String].
+var shortDurationUnits = map[string]string{
+	"secs":  "s",
+	"msecs": "ms",
+	"usecs": "us",
+}
+
+// ShortDuration returns Total with a short unit suffix, such as "1.92ms" for 1.92 msecs, or Total and Unit separated by a space
+// when the unit is not a known duration unit. It returns "" when Total is empty.
+func (v ExecutionStatsValue) ShortDuration() string {
+	if v.Total == "" {
+		return ""
+	}
+	if unit, ok := shortDurationUnits[v.Unit]; ok {
+		return v.Total + unit
+	}
+	return v.String()
+}
+
+// RowsReturned returns the rows the operator emitted to its parent. It reports false when
+// the operator has no rows statistic.
+func (s ExecutionStats) RowsReturned() (ExecutionStatsValue, bool) {
+	return s.Rows, s.Rows.Total != ""
+}
+
+// RowsProduced returns the rows the operator read or produced before discarding any, which
+// explains why an operator can return fewer rows than it processed. It is scanned_rows when
+// present, otherwise the sum of rows and filtered_rows. It reports false when the statistics
+// do not carry the distinction, which is the case for most operators.
+func (s ExecutionStats) RowsProduced() (ExecutionStatsValue, bool) {
+	if s.ScannedRows.Total != "" {
+		return s.ScannedRows, true
+	}
+	if s.FilteredRows.Total == "" {
+		return ExecutionStatsValue{}, false
+	}
+	returned, err := s.Rows.Float64()
+	if err != nil {
+		return ExecutionStatsValue{}, false
+	}
+	filtered, err := s.FilteredRows.Float64()
+	if err != nil {
+		return ExecutionStatsValue{}, false
+	}
+	return ExecutionStatsValue{
+		Unit:  s.Rows.Unit,
+		Total: strconv.FormatFloat(returned+filtered, 'f', -1, 64),
+	}, true
+}
+
+// LatencyPerExecution returns the latency of one execution of an operator that ran more than once,
+// whose latency statistic is the total of all its executions. It is the mean Spanner reports
+// with the latency when present, and otherwise the latency divided by num_executions, rounded to
+// hundredths like Spanner's own values. It reports false when the operator ran at most once, or
+// when NumExecutions or the latency is absent or not numeric.
+func (s ExecutionStats) LatencyPerExecution() (ExecutionStatsValue, bool) {
+	executions, err := strconv.ParseFloat(s.ExecutionSummary.NumExecutions, 64)
+	if err != nil || executions <= 1 {
+		return ExecutionStatsValue{}, false
+	}
+	if s.Latency.Mean != "" {
+		return ExecutionStatsValue{Unit: s.Latency.Unit, Total: s.Latency.Mean}, true
+	}
+	latency, err := s.Latency.Float64()
+	if err != nil {
+		return ExecutionStatsValue{}, false
+	}
+	return ExecutionStatsValue{
+		Unit:  s.Latency.Unit,
+		Total: strconv.FormatFloat(latency/executions, 'f', 2, 64),
+	}, true
+}
+
+// Value returns the statistic named by its Spanner key, such as "scanned_rows", looking in
+// [ExecutionStats.Extra] for keys without a dedicated field. It reports false when absent.
+func (s ExecutionStats) Value(name string) (ExecutionStatsValue, bool) {
+	if v, ok := s.Extra[name]; ok {
+		return v, true
+	}
+	field, ok := executionStatsFieldIndexes()[name]
+	if !ok {
+		return ExecutionStatsValue{}, false
+	}
+	v, ok := reflect.ValueOf(s).Field(field).Interface().(ExecutionStatsValue)
+	return v, ok && v.Total != ""
+}
+
+// executionStatsFieldIndexes maps the JSON names of [ExecutionStats] fields to their field indexes.
+var executionStatsFieldIndexes = sync.OnceValue(func() map[string]int {
+	t := reflect.TypeFor[ExecutionStats]()
+	indexes := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			indexes[name] = i
+		}
+	}
+	return indexes
+})
+
+// StartTime parses ExecutionStartTimestamp. It returns an error when the timestamp is absent or invalid.
+func (s ExecutionStatsSummary) StartTime() (time.Time, error) {
+	return parseTimestamp(s.ExecutionStartTimestamp)
+}
+
+// EndTime parses ExecutionEndTimestamp. It returns an error when the timestamp is absent or invalid.
+func (s ExecutionStatsSummary) EndTime() (time.Time, error) {
+	return parseTimestamp(s.ExecutionEndTimestamp)
+}
+
+// parseTimestamp parses Spanner's "seconds.fraction" Unix timestamp strings without float rounding.
+// Both parts must be ASCII digits, so signs and negative timestamps are rejected, and fraction
+// digits past nanoseconds are checked and then truncated.
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, errors.New("empty timestamp")
+	}
+	secStr, fracStr, hasFrac := strings.Cut(s, ".")
+	if !isDigits(secStr) || hasFrac && !isDigits(fracStr) {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: want digits as seconds.fraction", s)
+	}
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	var nsec int64
+	if fracStr != "" {
+		if len(fracStr) > 9 {
+			fracStr = fracStr[:9]
+		}
+		nsec, err = strconv.ParseInt(fracStr+strings.Repeat("0", 9-len(fracStr)), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", s, err)
+		}
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
 }
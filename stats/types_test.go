@@ -0,0 +1,231 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExecutionStatsValue_Duration(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   ExecutionStatsValue
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "msecs", value: ExecutionStatsValue{Total: "0.87", Unit: "msecs"}, want: 870 * time.Microsecond},
+		{name: "secs", value: ExecutionStatsValue{Total: "1.5", Unit: "secs"}, want: 1500 * time.Millisecond},
+		{name: "usecs", value: ExecutionStatsValue{Total: "12", Unit: "usecs"}, want: 12 * time.Microsecond},
+		{name: "empty", value: ExecutionStatsValue{Unit: "msecs"}, wantErr: true},
+		{name: "not numeric", value: ExecutionStatsValue{Total: "abc", Unit: "msecs"}, wantErr: true},
+		{name: "unknown unit", value: ExecutionStatsValue{Total: "1", Unit: "rows"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.value.Duration()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Duration() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Duration() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Duration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutionStatsValue_PercentOf(t *testing.T) {
+	root := ExecutionStatsValue{Total: "2", Unit: "msecs"}
+	tests := []struct {
+		name   string
+		value  ExecutionStatsValue
+		total  ExecutionStatsValue
+		want   float64
+		wantOK bool
+	}{
+		{name: "same unit", value: ExecutionStatsValue{Total: "0.5", Unit: "msecs"}, total: root, want: 25, wantOK: true},
+		{name: "different units", value: ExecutionStatsValue{Total: "500", Unit: "usecs"}, total: root, want: 25, wantOK: true},
+		{name: "empty value", value: ExecutionStatsValue{}, total: root},
+		{name: "empty total", value: root},
+		{name: "zero total", value: root, total: ExecutionStatsValue{Total: "0", Unit: "msecs"}},
+		{name: "not a duration", value: ExecutionStatsValue{Total: "3", Unit: "rows"}, total: root},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.value.PercentOf(tt.total)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("PercentOf() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExecutionStats_Durations(t *testing.T) {
+	s := ExecutionStats{
+		Latency: ExecutionStatsValue{Total: "1.92", Unit: "msecs"},
+		CpuTime: ExecutionStatsValue{Total: "0.5", Unit: "secs"},
+	}
+	if got, err := s.LatencyDuration(); err != nil || got != 1920*time.Microsecond {
+		t.Errorf("LatencyDuration() = (%v, %v), want (1.92ms, nil)", got, err)
+	}
+	if got, err := s.CpuTimeDuration(); err != nil || got != 500*time.Millisecond {
+		t.Errorf("CpuTimeDuration() = (%v, %v), want (500ms, nil)", got, err)
+	}
+	if _, err := (ExecutionStats{}).LatencyDuration(); err == nil {
+		t.Error("LatencyDuration() of empty stats error = nil, want non-nil")
+	}
+}
+
+func TestExecutionStatsSummary_Executions(t *testing.T) {
+	if got, err := (ExecutionStatsSummary{NumExecutions: "7"}).Executions(); err != nil || got != 7 {
+		t.Errorf("Executions() = (%d, %v), want (7, nil)", got, err)
+	}
+	for _, numExecutions := range []string{"", "1.5", "many"} {
+		if _, err := (ExecutionStatsSummary{NumExecutions: numExecutions}).Executions(); err == nil {
+			t.Errorf("Executions() of %q error = nil, want non-nil", numExecutions)
+		}
+	}
+}
+
+func TestExecutionStatsSummary_Timestamps(t *testing.T) {
+	summary := ExecutionStatsSummary{
+		ExecutionStartTimestamp: "1745245143.426926",
+		ExecutionEndTimestamp:   "1745245143",
+	}
+
+	start, err := summary.StartTime()
+	if err != nil {
+		t.Fatalf("StartTime() error = %v", err)
+	}
+	if want := time.Unix(1745245143, 426926000).UTC(); !start.Equal(want) {
+		t.Fatalf("StartTime() = %v, want %v", start, want)
+	}
+
+	end, err := summary.EndTime()
+	if err != nil {
+		t.Fatalf("EndTime() error = %v", err)
+	}
+	if want := time.Unix(1745245143, 0).UTC(); !end.Equal(want) {
+		t.Fatalf("EndTime() = %v, want %v", end, want)
+	}
+
+	// Fraction digits past nanoseconds are truncated.
+	if got, err := (ExecutionStatsSummary{ExecutionStartTimestamp: "1.1234567899"}).StartTime(); err != nil || got.Nanosecond() != 123456789 {
+		t.Fatalf("StartTime() with 10 fraction digits = %v, %v, want 123456789 nanoseconds", got, err)
+	}
+
+	for _, s := range []string{"", "abc", "1.x", "1.-5", "1.+5", "1.123456789x", "1.1234567890abc", "-1.5", "+1.5", "1.", ".5", "1.2.3"} {
+		if _, err := (ExecutionStatsSummary{ExecutionStartTimestamp: s}).StartTime(); err == nil {
+			t.Fatalf("StartTime() with %q error = nil, want non-nil", s)
+		}
+	}
+}
+
+func TestExecutionStats_RowsProduced(t *testing.T) {
+	rows := func(total string) ExecutionStatsValue { return ExecutionStatsValue{Total: total, Unit: "rows"} }
+	tests := []struct {
+		name         string
+		stats        ExecutionStats
+		wantReturned string
+		wantProduced string
+		wantOK       bool
+	}{
+		{name: "scanned rows", stats: ExecutionStats{Rows: rows("33"), ScannedRows: rows("63"), FilteredRows: rows("30")}, wantReturned: "33", wantProduced: "63", wantOK: true},
+		{name: "filtered rows only", stats: ExecutionStats{Rows: rows("33"), FilteredRows: rows("30")}, wantReturned: "33", wantProduced: "63", wantOK: true},
+		{name: "rows only", stats: ExecutionStats{Rows: rows("5")}, wantReturned: "5"},
+		{name: "no stats", stats: ExecutionStats{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			returned, ok := tt.stats.RowsReturned()
+			if returned.Total != tt.wantReturned || ok != (tt.wantReturned != "") {
+				t.Errorf("RowsReturned() = (%q, %v), want %q", returned.Total, ok, tt.wantReturned)
+			}
+			produced, ok := tt.stats.RowsProduced()
+			if produced.Total != tt.wantProduced || ok != tt.wantOK {
+				t.Errorf("RowsProduced() = (%q, %v), want (%q, %v)", produced.Total, ok, tt.wantProduced, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExecutionStats_LatencyPerExecution(t *testing.T) {
+	msecs := func(total, mean string) ExecutionStatsValue {
+		return ExecutionStatsValue{Total: total, Mean: mean, Unit: "msecs"}
+	}
+	executions := func(n string) ExecutionStatsSummary { return ExecutionStatsSummary{NumExecutions: n} }
+	tests := []struct {
+		name   string
+		stats  ExecutionStats
+		want   string
+		wantOK bool
+	}{
+		{name: "computed", stats: ExecutionStats{Latency: msecs("0.84", ""), ExecutionSummary: executions("7")}, want: "0.12", wantOK: true},
+		{name: "rounded", stats: ExecutionStats{Latency: msecs("1", ""), ExecutionSummary: executions("3")}, want: "0.33", wantOK: true},
+		{name: "reported mean", stats: ExecutionStats{Latency: msecs("0.84", "0.1"), ExecutionSummary: executions("7")}, want: "0.1", wantOK: true},
+		{name: "single execution", stats: ExecutionStats{Latency: msecs("0.84", ""), ExecutionSummary: executions("1")}},
+		{name: "zero executions", stats: ExecutionStats{Latency: msecs("0.84", ""), ExecutionSummary: executions("0")}},
+		{name: "no executions", stats: ExecutionStats{Latency: msecs("0.84", "")}},
+		{name: "no latency", stats: ExecutionStats{ExecutionSummary: executions("7")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.stats.LatencyPerExecution()
+			if got.Total != tt.want || ok != tt.wantOK {
+				t.Fatalf("LatencyPerExecution() = (%q, %v), want (%q, %v)", got.Total, ok, tt.want, tt.wantOK)
+			}
+			if ok && got.Unit != "msecs" {
+				t.Errorf("LatencyPerExecution().Unit = %q, want %q", got.Unit, "msecs")
+			}
+		})
+	}
+}
+
+func TestExecutionStats_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats ExecutionStats
+		want  string
+	}{
+		{
+			name: "scan",
+			stats: ExecutionStats{
+				Rows:             ExecutionStatsValue{Total: "33", Unit: "rows"},
+				ScannedRows:      ExecutionStatsValue{Total: "63", Unit: "rows"},
+				FilteredRows:     ExecutionStatsValue{Total: "30", Unit: "rows"},
+				ExecutionSummary: ExecutionStatsSummary{NumExecutions: "7"},
+				Latency:          ExecutionStatsValue{Total: "0.84", Unit: "msecs"},
+			},
+			want: "rows=33 scanned=63 filtered=30 exec=7 lat=0.84ms",
+		},
+		{
+			name: "cpu time in seconds",
+			stats: ExecutionStats{
+				Latency: ExecutionStatsValue{Total: "1.5", Unit: "secs"},
+				CpuTime: ExecutionStatsValue{Total: "12", Unit: "usecs"},
+			},
+			want: "lat=1.5s cpu=12us",
+		},
+		{
+			name:  "unknown unit",
+			stats: ExecutionStats{Latency: ExecutionStatsValue{Total: "3", Unit: "ticks"}},
+			want:  "lat=3 ticks",
+		},
+		{name: "empty", stats: ExecutionStats{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -118,6 +118,27 @@ func CompactStyle() Style {
 	}
 }
 
+// UnicodeStyle returns a tree style drawn with Unicode box-drawing characters.
+func UnicodeStyle() Style {
+	return Style{
+		EdgeLink:      "│",
+		EdgeMid:       "├─",
+		EdgeEnd:       "└─",
+		EdgeSeparator: " ",
+		IndentSize:    2,
+	}
+}
+
+// CompactUnicodeStyle returns the [CompactStyle] counterpart of [UnicodeStyle].
+func CompactUnicodeStyle() Style {
+	return Style{
+		EdgeLink:   "│",
+		EdgeMid:    "├",
+		EdgeEnd:    "└",
+		IndentSize: 0,
+	}
+}
+
 // styleWidths holds display widths and indent for a [Style], computed once per render.
 type styleWidths struct {
 	style      Style
@@ -377,6 +398,10 @@ func wrapChunks(text string, firstBudget, continuationBudget int, wrapCondition
 	lines := make([]string, 0, len(rawLines))
 	budget := firstBudget
 	for _, rawLine := range rawLines {
+		// Truncate expands tabs, so expand them up front to keep each chunk a prefix of rawLine.
+		if strings.Contains(rawLine, "\t") {
+			rawLine = wrapCondition.ExpandTab(rawLine)
+		}
 		if rawLine == "" {
 			lines = append(lines, "")
 			budget = continuationBudget
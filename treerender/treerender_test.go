@@ -92,6 +92,45 @@ func TestRender_CompactStyle(t *testing.T) {
 	}
 }
 
+func TestRender_UnicodeStyles(t *testing.T) {
+	tests := []struct {
+		name  string
+		style Style
+		want  []Row
+	}{
+		{
+			name:  "unicode",
+			style: UnicodeStyle(),
+			want: []Row{
+				{TreePart: "", NodeText: "root"},
+				{TreePart: "├─ \n│  ", NodeText: "left\ncont"},
+				{TreePart: "│  ├─ ", NodeText: "leaf-a"},
+				{TreePart: "│  └─ ", NodeText: "leaf-b"},
+				{TreePart: "└─ ", NodeText: "right"},
+			},
+		},
+		{
+			name:  "compact unicode",
+			style: CompactUnicodeStyle(),
+			want: []Row{
+				{TreePart: "", NodeText: "root"},
+				{TreePart: "├\n│", NodeText: "left\ncont"},
+				{TreePart: "│├", NodeText: "leaf-a"},
+				{TreePart: "│└", NodeText: "leaf-b"},
+				{TreePart: "└", NodeText: "right"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, Render(sampleTree(), tt.style)); diff != "" {
+				t.Fatalf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestRender_NegativeIndentDoesNotPanic(t *testing.T) {
 	style := DefaultStyle()
 	style.IndentSize = -1
@@ -325,6 +364,26 @@ func TestRenderTreeWithOptions_TinyBudgetKeepsUTF8Valid(t *testing.T) {
 	}
 }
 
+func TestRenderTreeWithOptions_WrapsTabs(t *testing.T) {
+	root := &Node{Text: "a\tb\nc\x00d"}
+
+	got, err := RenderTreeWithOptions(
+		root,
+		DefaultStyle(),
+		func(n *Node) string { return n.Text },
+		func(n *Node) []*Node { return n.Children },
+		RenderOptions[Node]{WrapWidth: 4},
+	)
+	if err != nil {
+		t.Fatalf("RenderTreeWithOptions() error = %v", err)
+	}
+
+	want := []Row{{TreePart: "\n\n", NodeText: "a\nb\nc\x00d"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("RenderTreeWithOptions() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestRenderTreeWithOptions_SkipsAnchorCallbackWhenUnused(t *testing.T) {
 	root := &Node{
 		Text: "root",
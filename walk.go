@@ -0,0 +1,88 @@
+package spannerplan
+
+import (
+	"errors"
+	"fmt"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MaxTraversalDepth is the depth, counting the root as zero, below which [QueryPlan.Walk]
+	// and [QueryPlan.VisibleNodes] stop. It is the renderer budget plantree.MaxPlantreeDepth.
+	MaxTraversalDepth = 256
+	// MaxTraversalOccurrences bounds the visible node occurrences that [QueryPlan.Walk] and
+	// [QueryPlan.VisibleNodes] visit, rather than unique PlanNode indexes, because a plan that
+	// shares children can expand exponentially into a tree. It is the renderer budget
+	// plantree.MaxPlantreeOccurrences.
+	MaxTraversalOccurrences = 4096
+)
+
+// ErrTraversalLimitExceeded is returned, wrapped, by [QueryPlan.Walk] for a plan whose visible
+// tree is deeper than [MaxTraversalDepth] or has more than [MaxTraversalOccurrences] node
+// occurrences.
+var ErrTraversalLimitExceeded = errors.New("spannerplan: traversal limit exceeded")
+
+// ErrStopWalk is returned by the function passed to [QueryPlan.Walk] to stop the traversal
+// without an error.
+var ErrStopWalk = errors.New("spannerplan: stop walk")
+
+// Walk calls fn for each visible node of the operator tree, in the pre-order of plantree.ProcessPlan
+// and [QueryPlan.VisibleNodes]: a node is visited before its children, which follow child link
+// order with the visibility of [QueryPlan.IsVisible]. depth counts the root as zero, and a node
+// reachable through several parents is visited once per occurrence.
+//
+// link is the child link from the parent, or nil for the root. Its Type is resolved as
+// [QueryPlan.LinkTypeInParent] does, so the untyped first child of an Apply has the type
+// "Input"; such a link is a copy, and every other link is the one in the plan.
+//
+// When fn returns an error, Walk stops and returns it, except that [ErrStopWalk] stops the
+// traversal and Walk returns nil. Like plantree.ProcessPlan, Walk returns an error when a child
+// link leads back to a node on the current path, and one wrapping [ErrTraversalLimitExceeded]
+// before it visits a node beyond [MaxTraversalDepth] or [MaxTraversalOccurrences].
+func (qp *QueryPlan) Walk(fn func(node *sppb.PlanNode, link *sppb.PlanNode_ChildLink, depth int) error) error {
+	if err := qp.walk(fn); err != nil && !errors.Is(err, ErrStopWalk) {
+		return err
+	}
+	return nil
+}
+
+// walk is the bounded traversal behind [QueryPlan.Walk] and [QueryPlan.VisibleNodes]. It returns
+// the error of fn as is, including [ErrStopWalk].
+func (qp *QueryPlan) walk(fn func(node *sppb.PlanNode, link *sppb.PlanNode_ChildLink, depth int) error) error {
+	ancestors := make(map[int32]struct{})
+	occurrences := 0
+	var walk func(node *sppb.PlanNode, link *sppb.PlanNode_ChildLink, depth int) error
+	walk = func(node *sppb.PlanNode, link *sppb.PlanNode_ChildLink, depth int) error {
+		if _, ok := ancestors[node.GetIndex()]; ok {
+			return fmt.Errorf("cycle detected at PlanNode index %d", node.GetIndex())
+		}
+		if depth > MaxTraversalDepth {
+			return fmt.Errorf("%w: depth exceeds the limit of %d at PlanNode index %d", ErrTraversalLimitExceeded, MaxTraversalDepth, node.GetIndex())
+		}
+		if occurrences >= MaxTraversalOccurrences {
+			return fmt.Errorf("%w: node occurrences exceed the limit of %d at PlanNode index %d", ErrTraversalLimitExceeded, MaxTraversalOccurrences, node.GetIndex())
+		}
+		occurrences++
+		if err := fn(node, link, depth); err != nil {
+			return err
+		}
+		ancestors[node.GetIndex()] = struct{}{}
+		defer delete(ancestors, node.GetIndex())
+		for i, child := range node.GetChildLinks() {
+			if !qp.IsVisible(child) {
+				continue
+			}
+			if linkType := qp.LinkTypeInParent(node, i); linkType != child.GetType() {
+				child = proto.Clone(child).(*sppb.PlanNode_ChildLink)
+				child.Type = linkType
+			}
+			if err := walk(qp.GetNodeByChildLink(child), child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(qp.GetNodeByChildLink(nil), nil, 0)
+}
@@ -0,0 +1,171 @@
+package spannerplan
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+func TestWalk(t *testing.T) {
+	relational := func(index int32, name string, children ...int32) *sppb.PlanNode {
+		node := &sppb.PlanNode{Index: index, Kind: sppb.PlanNode_RELATIONAL, DisplayName: name}
+		for _, child := range children {
+			node.ChildLinks = append(node.ChildLinks, &sppb.PlanNode_ChildLink{ChildIndex: child})
+		}
+		return node
+	}
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name string
+		// stopAt is the PlanNode index whose visit returns stopErr, or -1 to visit every node.
+		stopAt    int32
+		stopErr   error
+		nodes     []*sppb.PlanNode
+		wantIDs   []int32
+		wantDepth []int
+		wantLinks []string
+		wantErr   string
+	}{
+		{
+			name: "scalars are hidden unless linked as Scalar",
+			nodes: []*sppb.PlanNode{
+				{Index: 0, Kind: sppb.PlanNode_RELATIONAL, ChildLinks: []*sppb.PlanNode_ChildLink{
+					{ChildIndex: 1},
+					{ChildIndex: 3, Type: "Condition"},
+					{ChildIndex: 4, Type: "Scalar"},
+				}},
+				relational(1, "Scan", 2),
+				relational(2, "Scan"),
+				{Index: 3, Kind: sppb.PlanNode_SCALAR},
+				{Index: 4, Kind: sppb.PlanNode_SCALAR},
+			},
+			stopAt:    -1,
+			wantIDs:   []int32{0, 1, 2, 4},
+			wantDepth: []int{0, 1, 2, 1},
+			wantLinks: []string{"<root>", "", "", "Scalar"},
+		},
+		{
+			name:      "untyped first child of an Apply is Input",
+			nodes:     []*sppb.PlanNode{relational(0, "Cross Apply", 1, 2), relational(1, "Scan"), relational(2, "Scan")},
+			stopAt:    -1,
+			wantIDs:   []int32{0, 1, 2},
+			wantDepth: []int{0, 1, 1},
+			wantLinks: []string{"<root>", "Input", ""},
+		},
+		{
+			name:      "shared child is visited per occurrence",
+			nodes:     []*sppb.PlanNode{relational(0, "Union", 1, 2), relational(1, "Filter", 2), relational(2, "Scan")},
+			stopAt:    -1,
+			wantIDs:   []int32{0, 1, 2, 2},
+			wantDepth: []int{0, 1, 2, 1},
+			wantLinks: []string{"<root>", "", "", ""},
+		},
+		{
+			name:      "ErrStopWalk stops without an error",
+			nodes:     []*sppb.PlanNode{relational(0, "Union", 1, 2), relational(1, "Scan"), relational(2, "Scan")},
+			stopAt:    1,
+			stopErr:   ErrStopWalk,
+			wantIDs:   []int32{0, 1},
+			wantDepth: []int{0, 1},
+			wantLinks: []string{"<root>", ""},
+		},
+		{
+			name:      "other errors are returned",
+			nodes:     []*sppb.PlanNode{relational(0, "Union", 1, 2), relational(1, "Scan"), relational(2, "Scan")},
+			stopAt:    1,
+			stopErr:   errAbort,
+			wantIDs:   []int32{0, 1},
+			wantDepth: []int{0, 1},
+			wantLinks: []string{"<root>", ""},
+			wantErr:   "abort",
+		},
+		{
+			name:      "cycle",
+			nodes:     []*sppb.PlanNode{relational(0, "Filter", 1), relational(1, "Filter", 0)},
+			stopAt:    -1,
+			wantIDs:   []int32{0, 1},
+			wantDepth: []int{0, 1},
+			wantLinks: []string{"<root>", ""},
+			wantErr:   "cycle detected at PlanNode index 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qp, err := New(tt.nodes)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var ids []int32
+			var depths []int
+			var links []string
+			err = qp.Walk(func(node *sppb.PlanNode, link *sppb.PlanNode_ChildLink, depth int) error {
+				ids = append(ids, node.GetIndex())
+				depths = append(depths, depth)
+				if link == nil {
+					links = append(links, "<root>")
+				} else {
+					links = append(links, link.GetType())
+				}
+				if node.GetIndex() == tt.stopAt {
+					return tt.stopErr
+				}
+				return nil
+			})
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Walk() error = %v, want %q", err, tt.wantErr)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(depths, tt.wantDepth) || !slices.Equal(links, tt.wantLinks) {
+				t.Fatalf("Walk() = ids %v depths %v links %q, want ids %v depths %v links %q", ids, depths, links, tt.wantIDs, tt.wantDepth, tt.wantLinks)
+			}
+		})
+	}
+
+	t.Run("traversal limits", func(t *testing.T) {
+		chain := make([]*sppb.PlanNode, MaxTraversalDepth+2)
+		for i := range chain {
+			chain[i] = relational(int32(i), "Filter")
+			if i+1 < len(chain) {
+				chain[i].ChildLinks = []*sppb.PlanNode_ChildLink{{ChildIndex: int32(i + 1)}}
+			}
+		}
+		for name, nodes := range map[string][]*sppb.PlanNode{
+			"shared children": sharedChildChain(40),
+			"deep chain":      chain,
+		} {
+			qp, err := New(nodes)
+			if err != nil {
+				t.Fatalf("%s: New() error = %v", name, err)
+			}
+			var visited int
+			err = qp.Walk(func(*sppb.PlanNode, *sppb.PlanNode_ChildLink, int) error {
+				visited++
+				return nil
+			})
+			if !errors.Is(err, ErrTraversalLimitExceeded) {
+				t.Errorf("%s: Walk() error = %v, want %v", name, err, ErrTraversalLimitExceeded)
+			}
+			if visited > MaxTraversalOccurrences {
+				t.Errorf("%s: Walk() visited %d nodes, want at most %d", name, visited, MaxTraversalOccurrences)
+			}
+		}
+	})
+
+	t.Run("does not modify the plan", func(t *testing.T) {
+		nodes := []*sppb.PlanNode{relational(0, "Cross Apply", 1, 2), relational(1, "Scan"), relational(2, "Scan")}
+		qp, err := New(nodes)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if err := qp.Walk(func(*sppb.PlanNode, *sppb.PlanNode_ChildLink, int) error { return nil }); err != nil {
+			t.Fatalf("Walk() error = %v", err)
+		}
+		if got := nodes[0].GetChildLinks()[0].GetType(); got != "" {
+			t.Errorf("Walk() set the child link type to %q", got)
+		}
+	})
+}